@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/pkg/client"
+	"golang.org/x/term"
+)
+
+func runLogin(args []string) error {
+	flags := newFlagSet("login")
+	server := flags.String("server", os.Getenv("FILEFORT_SERVER"), "url of the file-fort server, $FILEFORT_SERVER by default")
+	email := flags.String("email", "", "email of the account")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	// A previous login supplies the defaults, so logging in again after the
+	// session expires only needs the password.
+	if previous, err := loadConfig(); err == nil {
+		if *server == "" {
+			*server = previous.Server
+		}
+		if *email == "" {
+			*email = previous.Email
+		}
+	}
+	if *server == "" {
+		return errors.New("-server is required")
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	if *email == "" {
+		value, err := prompt(stdin, "Email: ")
+		if err != nil {
+			return err
+		}
+		*email = value
+	}
+	// The password is read from $FILEFORT_PASSWORD or from stdin, where it
+	// can be piped in by scripts. When typed at a terminal, it is not echoed.
+	password := os.Getenv("FILEFORT_PASSWORD")
+	if password == "" {
+		value, err := promptPassword(stdin)
+		if err != nil {
+			return err
+		}
+		password = value
+	}
+
+	ctx := context.Background()
+	api, err := client.NewClient(*server)
+	if err != nil {
+		return err
+	}
+	login, err := api.Login(ctx, client.LoginRequest{Email: *email, Password: password})
+	if err != nil {
+		return err
+	}
+	api.SetAccessToken(login.AccessToken)
+	me, err := api.Me(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = saveConfig(config{Server: api.BaseUrl(), Email: me.Email, UserId: me.ID.String(), AccessToken: login.AccessToken})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Logged in to %s as %s\n", api.BaseUrl(), me.Email)
+	return nil
+}
+
+func prompt(stdin *bufio.Reader, label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	line, err := stdin.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", fmt.Errorf("error reading %s: %w", strings.ToLower(strings.TrimSuffix(label, ": ")), err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func promptPassword(stdin *bufio.Reader) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return prompt(stdin, "Password: ")
+	}
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("error reading password: %w", err)
+	}
+	return string(password), nil
+}
+
+func runList(args []string) error {
+	flags := newFlagSet("ls")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return errUsage
+	}
+	api, c, err := loggedInClient()
+	if err != nil {
+		return err
+	}
+
+	// The home folder shares its id with its owner.
+	folderId := c.UserId
+	if flags.NArg() == 1 {
+		folderId = flags.Arg(0)
+	}
+	id, err := parseId(folderId)
+	if err != nil {
+		return err
+	}
+	files, err := api.ListAllFiles(context.Background(), id, client.ListFilesOptions{Limit: 100})
+	if err != nil {
+		return err
+	}
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(out, "ID\tSIZE\tCREATED\tNAME")
+	for _, f := range files {
+		created := f.CreatedAt.Local().Format("2006-01-02 15:04")
+		fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", f.ID, formatSize(f.FileSize), created, f.FileName)
+	}
+	return out.Flush()
+}
+
+func runMkdir(args []string) error {
+	flags := newFlagSet("mkdir")
+	parent := flags.String("parent", "", "id of the folder to create it in; top level by default")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	api, _, err := loggedInClient()
+	if err != nil {
+		return err
+	}
+
+	request := client.CreateFolderRequest{FolderName: flags.Arg(0)}
+	if *parent != "" {
+		parentId, err := parseId(*parent)
+		if err != nil {
+			return err
+		}
+		request.ParentId = &parentId
+	}
+	created, err := api.CreateFolder(context.Background(), request)
+	if err != nil {
+		return err
+	}
+	fmt.Println(created.ID)
+	return nil
+}
+
+func runUpload(args []string) error {
+	flags := newFlagSet("upload")
+	folderId := flags.String("folder", "", "id of the folder to upload into; home by default")
+	resume := flags.Bool("resume", false, "skip files already in the folder with the same name and size; a file cut off part way is uploaded again from the start")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+	api, c, err := loggedInClient()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var target *uuid.UUID
+	if *folderId != "" {
+		id, err := parseId(*folderId)
+		if err != nil {
+			return err
+		}
+		target = &id
+	}
+
+	// Uploads are not resumable on the server, so -resume only skips the
+	// files that an earlier run finished, matched by name and size.
+	uploaded := map[string]bool{}
+	if *resume {
+		listed := c.UserId
+		if target != nil {
+			listed = target.String()
+		}
+		id, err := parseId(listed)
+		if err != nil {
+			return err
+		}
+		existing, err := api.ListAllFiles(ctx, id, client.ListFilesOptions{Limit: 100})
+		if err != nil {
+			return err
+		}
+		for _, f := range existing {
+			uploaded[uploadKey(f.FileName, f.FileSize)] = true
+		}
+	}
+
+	for _, path := range flags.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+		name := filepath.Base(path)
+		if uploaded[uploadKey(name, info.Size())] {
+			fmt.Fprintf(os.Stderr, "%s already uploaded, skipping\n", name)
+			continue
+		}
+
+		uploadedFile, err := uploadFile(ctx, api, path, target, info.Size())
+		if err != nil {
+			return fmt.Errorf("error uploading %s: %w", path, err)
+		}
+		fmt.Printf("%s\t%s\n", uploadedFile.ID, uploadedFile.FileName)
+	}
+	return nil
+}
+
+func uploadFile(ctx context.Context, api *client.Client, path string, folderId *uuid.UUID, size int64) (client.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return client.File{}, err
+	}
+	defer f.Close()
+
+	bar := newProgressBar(filepath.Base(path), 0, size)
+	defer bar.finish()
+	return api.UploadFile(ctx, client.UploadFileRequest{
+		FolderId: folderId,
+		FileName: filepath.Base(path),
+		Content:  &progressReader{f, bar.add},
+	})
+}
+
+func uploadKey(name string, size int64) string {
+	return fmt.Sprintf("%s\x00%d", name, size)
+}
+
+func runDownload(args []string) error {
+	flags := newFlagSet("download")
+	output := flags.String("o", "", "path to save the file to; its name in the current directory by default, - for stdout")
+	resume := flags.Bool("resume", false, "carry on from the end of a partially downloaded file")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	api, _, err := loggedInClient()
+	if err != nil {
+		return err
+	}
+	fileId, err := parseId(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	info, err := api.StatFile(ctx, fileId)
+	if err != nil {
+		return err
+	}
+	path := *output
+	if path == "" {
+		path = info.FileName
+	}
+
+	var out io.Writer = os.Stdout
+	var offset int64
+	if path != "-" {
+		flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if *resume {
+			flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+			if info, err := os.Stat(path); err == nil {
+				offset = info.Size()
+			}
+		}
+		if offset > 0 && offset >= info.Size {
+			fmt.Fprintf(os.Stderr, "%s already downloaded\n", path)
+			return nil
+		}
+		f, err := os.OpenFile(path, flag, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	download, err := api.DownloadFile(ctx, fileId, offset)
+	if err != nil {
+		return err
+	}
+	defer download.Body.Close()
+	if download.Offset != offset {
+		return fmt.Errorf("the server did not resume the download of %s, remove it and download it again", path)
+	}
+
+	bar := newProgressBar(info.FileName, offset, download.Size)
+	_, err = io.Copy(out, &progressReader{download.Body, bar.add})
+	bar.finish()
+	return err
+}
+
+func runShare(args []string) error {
+	flags := newFlagSet("share")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	api, _, err := loggedInClient()
+	if err != nil {
+		return err
+	}
+
+	fileId, err := parseId(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	downloadUrl, err := api.GetDownloadUrl(context.Background(), fileId)
+	if err != nil {
+		return err
+	}
+	fmt.Println(downloadUrl)
+	return nil
+}
+
+func runRemove(args []string) error {
+	flags := newFlagSet("rm")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+	api, _, err := loggedInClient()
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range flags.Args() {
+		fileId, err := parseId(arg)
+		if err != nil {
+			return err
+		}
+		if err := api.DeleteFile(context.Background(), fileId); err != nil {
+			return fmt.Errorf("error deleting %s: %w", arg, err)
+		}
+	}
+	return nil
+}
+
+func parseId(id string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%q is not a valid id", id)
+	}
+	return parsed, nil
+}
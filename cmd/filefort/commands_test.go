@@ -0,0 +1,173 @@
+package main
+
+import (
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+// newTestServer serves the routes the commands use for a single user, keeping
+// the names of the uploaded files.
+func newTestServer(t *testing.T, userId uuid.UUID, existing []map[string]interface{}) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	uploaded := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/login":
+			response.SuccessResponse(w, "user logged in successfully", map[string]interface{}{"access_token": "some-token"})
+		case "/users/me":
+			response.SuccessResponse(w, "user retrieved successfully", map[string]interface{}{
+				"id":    userId.String(),
+				"email": "will@gmail.com",
+			})
+		case "/folder/" + userId.String() + "/files":
+			response.SuccessResponse(w, "files retrieved successfully", map[string]interface{}{"files": existing})
+		case "/file":
+			reader, err := r.MultipartReader()
+			if err != nil {
+				response.ErrorResponse(w, "invalid form", http.StatusBadRequest)
+				return
+			}
+			name := readUploadedFileName(reader)
+			mu.Lock()
+			uploaded = append(uploaded, name)
+			mu.Unlock()
+			response.SuccessResponse(w, "file uploaded successfully", map[string]interface{}{
+				"id":        uuid.New().String(),
+				"file_name": name,
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, uploaded...)
+	}
+}
+
+func readUploadedFileName(reader *multipart.Reader) string {
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return ""
+		}
+		if part.FormName() == "file" {
+			io.Copy(io.Discard, part)
+			return part.FileName()
+		}
+	}
+}
+
+func TestLogin(t *testing.T) {
+	userId := uuid.New()
+	server, _ := newTestServer(t, userId, nil)
+
+	t.Run(`Given the password in $FILEFORT_PASSWORD, when the user logs in, then
+    the session should be saved to the config file.`,
+		func(t *testing.T) {
+			t.Setenv("FILEFORT_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+			t.Setenv("FILEFORT_PASSWORD", "passcode")
+
+			err := runLogin([]string{"-server", server.URL, "-email", "will@gmail.com"})
+			if err != nil {
+				t.Fatalf("error logging in: %v", err)
+			}
+
+			c, err := loadConfig()
+			if err != nil {
+				t.Fatalf("error loading config: %v", err)
+			}
+			if c.Server != server.URL || c.AccessToken != "some-token" || c.UserId != userId.String() {
+				t.Errorf("got config %+v", c)
+			}
+		},
+	)
+
+	t.Run(`Given a config written by an earlier login, when the user logs in without
+    -server, then the saved server should be used.`,
+		func(t *testing.T) {
+			t.Setenv("FILEFORT_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+			t.Setenv("FILEFORT_PASSWORD", "passcode")
+			err := saveConfig(config{Server: server.URL, Email: "will@gmail.com", AccessToken: "expired-token"})
+			if err != nil {
+				t.Fatalf("error saving config: %v", err)
+			}
+
+			if err := runLogin(nil); err != nil {
+				t.Fatalf("error logging in: %v", err)
+			}
+
+			c, err := loadConfig()
+			if err != nil {
+				t.Fatalf("error loading config: %v", err)
+			}
+			if c.AccessToken != "some-token" {
+				t.Errorf("expected the new access token, got %q", c.AccessToken)
+			}
+		},
+	)
+}
+
+func TestUpload(t *testing.T) {
+	userId := uuid.New()
+	server, uploaded := newTestServer(t, userId, []map[string]interface{}{
+		{"id": uuid.New().String(), "file_name": "done.txt", "file_size": 4},
+		{"id": uuid.New().String(), "file_name": "resized.txt", "file_size": 3},
+	})
+	t.Setenv("FILEFORT_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+	err := saveConfig(config{Server: server.URL, Email: "will@gmail.com", UserId: userId.String(), AccessToken: "some-token"})
+	if err != nil {
+		t.Fatalf("error saving config: %v", err)
+	}
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{"done.txt": "done", "resized.txt": "bigger", "new.txt": "new"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("error writing %s: %v", name, err)
+		}
+	}
+
+	t.Run(`Given files already in the folder, when they are uploaded with -resume,
+    then only the files that differ in name or size should be uploaded.`,
+		func(t *testing.T) {
+			err := runUpload([]string{
+				"-resume",
+				filepath.Join(dir, "done.txt"),
+				filepath.Join(dir, "resized.txt"),
+				filepath.Join(dir, "new.txt"),
+			})
+			if err != nil {
+				t.Fatalf("error uploading: %v", err)
+			}
+
+			got := uploaded()
+			if len(got) != 2 || got[0] != "resized.txt" || got[1] != "new.txt" {
+				t.Errorf("expected resized.txt and new.txt to be uploaded, got %v", got)
+			}
+		},
+	)
+}
+
+func TestParseId(t *testing.T) {
+	t.Run(`Given an argument that is not a uuid, when it is parsed, then the error
+    should name the argument.`,
+		func(t *testing.T) {
+			_, err := parseId("report.pdf")
+			if err == nil || err.Error() != `"report.pdf" is not a valid id` {
+				t.Errorf("got error %v", err)
+			}
+		},
+	)
+}
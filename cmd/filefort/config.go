@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// config is what login saves for the other commands: the server to talk to
+// and the session to talk to it with.
+type config struct {
+	Server      string `json:"server"`
+	Email       string `json:"email"`
+	UserId      string `json:"user_id"`
+	AccessToken string `json:"access_token"`
+}
+
+var errNotLoggedIn = errors.New("not logged in, run `filefort login` first")
+
+// configPath is $FILEFORT_CONFIG, or filefort/config.json in the user's
+// config directory.
+func configPath() (string, error) {
+	if path := os.Getenv("FILEFORT_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error finding the config directory: %w", err)
+	}
+	return filepath.Join(dir, "filefort", "config.json"), nil
+}
+
+func loadConfig() (config, error) {
+	path, err := configPath()
+	if err != nil {
+		return config{}, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config{}, errNotLoggedIn
+	}
+	if err != nil {
+		return config{}, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	var c config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return config{}, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	if c.Server == "" || c.AccessToken == "" {
+		return config{}, errNotLoggedIn
+	}
+	return c, nil
+}
+
+// saveConfig writes the config readable only by the user, since it holds an
+// access token.
+func saveConfig(c config) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating %s: %w", filepath.Dir(path), err)
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
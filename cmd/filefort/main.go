@@ -0,0 +1,121 @@
+// Command filefort is a command-line client for a file-fort server.
+//
+//	filefort login -server https://files.example.com -email me@example.com
+//	filefort ls
+//	filefort upload -folder <folder-id> report.pdf
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/olad5/file-fort/pkg/client"
+)
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(args []string) error
+}
+
+var commands []command
+
+// The table is filled in init, as the commands look their usage up in it.
+func init() {
+	commands = []command{
+		{"login", "login [-server url] [-email email]", "log in and save the session", runLogin},
+		{"ls", "ls [folder-id]", "list the files in a folder, home by default", runList},
+		{"mkdir", "mkdir [-parent folder-id] name", "create a folder", runMkdir},
+		{"upload", "upload [-folder folder-id] [-resume] file...", "upload files", runUpload},
+		{"download", "download [-o path] [-resume] file-id", "download a file", runDownload},
+		{"share", "share file-id", "print a link the file can be downloaded from", runShare},
+		{"rm", "rm file-id...", "delete files", runRemove},
+		{"sync", "sync [-folder folder-id] [-dry-run | -watch interval] dir", "mirror a directory with a folder, both ways", runSync},
+	}
+}
+
+// errUsage is returned by commands called with the wrong arguments, after
+// their usage has been printed.
+var errUsage = errors.New("usage")
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	name, args := flag.Arg(0), flag.Args()[1:]
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		err := c.run(args)
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		if errors.Is(err, client.ErrUnauthorized) && name != "login" {
+			err = errors.New("session expired or invalid, run `filefort login` again")
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "filefort "+name+": "+err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "filefort: unknown command %q\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: filefort <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run `filefort <command> -h` for the arguments of a command.")
+}
+
+// newFlagSet returns the flags of a command, with a usage line taken from
+// the command table.
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		for _, c := range commands {
+			if c.name == name {
+				fmt.Fprintln(flags.Output(), "usage: filefort "+c.usage)
+			}
+		}
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	// The flag package has already printed what went wrong.
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+// loggedInClient returns a client for the saved session.
+func loggedInClient() (*client.Client, config, error) {
+	c, err := loadConfig()
+	if err != nil {
+		return nil, config{}, err
+	}
+	api, err := client.NewClient(c.Server, client.WithAccessToken(c.AccessToken))
+	if err != nil {
+		return nil, config{}, err
+	}
+	return api, c, nil
+}
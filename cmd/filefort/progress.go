@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// progressReader reports the bytes read through it.
+type progressReader struct {
+	reader   io.Reader
+	progress func(n int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.reader.Read(b)
+	if n > 0 && p.progress != nil {
+		p.progress(int64(n))
+	}
+	return n, err
+}
+
+// progressBar draws the progress of a transfer on one line of stderr. It is
+// only drawn when stderr is a terminal, so that scripts see clean output.
+type progressBar struct {
+	name    string
+	total   int64
+	done    int64
+	enabled bool
+	drawn   time.Time
+}
+
+func newProgressBar(name string, done, total int64) *progressBar {
+	enabled := false
+	if info, err := os.Stderr.Stat(); err == nil {
+		enabled = info.Mode()&os.ModeCharDevice != 0
+	}
+	return &progressBar{name: name, total: total, done: done, enabled: enabled}
+}
+
+func (p *progressBar) add(n int64) {
+	p.done += n
+	if p.enabled && time.Since(p.drawn) > 100*time.Millisecond {
+		p.draw()
+	}
+}
+
+func (p *progressBar) draw() {
+	p.drawn = time.Now()
+	percent := 100
+	if p.total > 0 {
+		percent = int(p.done * 100 / p.total)
+	}
+	fmt.Fprintf(os.Stderr, "\r%s  %s / %s  %3d%%", p.name, formatSize(p.done), formatSize(p.total), percent)
+}
+
+// finish draws the final state and ends the line.
+func (p *progressBar) finish() {
+	if p.enabled {
+		p.draw()
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,602 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/pkg/client"
+)
+
+// syncStateName is the file a synced directory keeps its state in. Files
+// whose name starts with syncPrefix are never synced.
+const (
+	syncPrefix    = ".filefort-"
+	syncStateName = syncPrefix + "sync.json"
+)
+
+// syncState is what a directory and its folder looked like the last time they
+// were in sync. A file that differs from its entry on one side only has
+// changed on that side, and the change is copied to the other.
+type syncState struct {
+	FolderId string               `json:"folder_id"`
+	Cursor   string               `json:"cursor"`
+	Files    map[string]syncEntry `json:"files"`
+}
+
+type syncEntry struct {
+	FileId   uuid.UUID `json:"file_id"`
+	Checksum string    `json:"checksum"`
+	Size     int64     `json:"size"`
+	// ModTime is the modification time of the local file and UpdatedAt the
+	// updated_at of the remote one.
+	ModTime   time.Time `json:"mod_time"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type localFile struct {
+	size     int64
+	modTime  time.Time
+	checksum string
+}
+
+type syncer struct {
+	ctx    context.Context
+	api    *client.Client
+	dir    string
+	rootId uuid.UUID
+	dryRun bool
+	state  syncState
+
+	local   map[string]*localFile
+	remote  map[string]client.File
+	folders map[string]uuid.UUID
+}
+
+func runSync(args []string) error {
+	flags := newFlagSet("sync")
+	folder := flags.String("folder", "", "id of the folder to sync with; home by default, or the folder the directory was last synced with")
+	dryRun := flags.Bool("dry-run", false, "print what would be done without doing it")
+	watch := flags.Duration("watch", 0, "keep syncing until interrupted: remote changes are synced as they are made, local ones at this interval")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *watch < 0 || (*watch > 0 && *dryRun) {
+		flags.Usage()
+		return errUsage
+	}
+	api, c, err := loggedInClient()
+	if err != nil {
+		return err
+	}
+
+	dir := flags.Arg(0)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	state, err := loadSyncState(dir)
+	if err != nil {
+		return err
+	}
+
+	folderId := *folder
+	switch {
+	case folderId == "" && state.FolderId != "":
+		folderId = state.FolderId
+	case folderId == "":
+		folderId = c.UserId
+	case state.FolderId != "" && state.FolderId != folderId:
+		return fmt.Errorf("%s is synced with folder %s, remove %s to sync it with another", dir, state.FolderId, syncStateName)
+	}
+	rootId, err := parseId(folderId)
+	if err != nil {
+		return err
+	}
+	state.FolderId = rootId.String()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	s := &syncer{ctx: ctx, api: api, dir: dir, rootId: rootId, dryRun: *dryRun, state: state}
+	if *watch == 0 {
+		return s.run()
+	}
+	return s.watch(*watch)
+}
+
+// watch syncs, then long-polls the change feed for remote changes, syncing
+// again as soon as there are some or once interval has passed without any,
+// which is when local changes are picked up. Errors are reported and retried
+// on the next pass, so a server that is briefly down does not stop it.
+func (s *syncer) watch(interval time.Duration) error {
+	for {
+		if err := s.run(); err != nil {
+			if s.ctx.Err() != nil {
+				return nil
+			}
+			fmt.Fprintln(os.Stderr, "filefort:", err)
+		}
+
+		_, err := s.api.WaitForChanges(s.ctx, s.state.Cursor, 1, interval)
+		if s.ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "filefort:", err)
+			select {
+			case <-s.ctx.Done():
+				return nil
+			case <-time.After(interval):
+			}
+		}
+	}
+}
+
+func (s *syncer) run() error {
+	s.folders = nil
+	if err := s.listRemote(); err != nil {
+		return err
+	}
+	if err := s.listLocal(); err != nil {
+		return err
+	}
+
+	paths := map[string]bool{}
+	for p := range s.state.Files {
+		paths[p] = true
+	}
+	for p := range s.local {
+		paths[p] = true
+	}
+	for p := range s.remote {
+		paths[p] = true
+	}
+	sorted := []string{}
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	files := map[string]syncEntry{}
+	for _, p := range sorted {
+		if err := s.syncFile(p, files); err != nil {
+			return fmt.Errorf("error syncing %s: %w", p, err)
+		}
+	}
+	if s.dryRun {
+		return nil
+	}
+	s.state.Files = files
+	return saveSyncState(s.dir, s.state)
+}
+
+// listRemote asks the change feed whether anything changed since the last
+// sync. When nothing did, the folder is still as the state recorded it and
+// the tree does not have to be listed.
+func (s *syncer) listRemote() error {
+	if s.state.Cursor != "" {
+		page, err := s.api.GetChanges(s.ctx, s.state.Cursor, 1)
+		if err != nil && !errors.Is(err, client.ErrBadRequest) {
+			return err
+		}
+		if err == nil && len(page.Changes) == 0 {
+			s.remote = map[string]client.File{}
+			for p, entry := range s.state.Files {
+				s.remote[p] = client.File{ID: entry.FileId, Checksum: entry.Checksum, FileSize: entry.Size, UpdatedAt: entry.UpdatedAt}
+			}
+			return nil
+		}
+	}
+	return s.listTree()
+}
+
+func (s *syncer) listTree() error {
+	tree, err := s.api.GetFolderTree(s.ctx, s.rootId)
+	if err != nil {
+		return err
+	}
+	s.state.Cursor = tree.Cursor
+
+	s.folders = map[string]uuid.UUID{"": s.rootId}
+	for _, folder := range tree.Folders {
+		if _, ok := s.folders[folder.Path]; !ok && syncable(folder.Path) {
+			s.folders[folder.Path] = folder.ID
+		}
+	}
+
+	s.remote = map[string]client.File{}
+	for _, file := range tree.Files {
+		if !syncable(file.Path) {
+			fmt.Fprintf(os.Stderr, "skipping %s, its path cannot be synced\n", file.Path)
+			continue
+		}
+		// Names are not unique within a folder, so only the newest of the
+		// files with a path is synced.
+		if existing, ok := s.remote[file.Path]; ok {
+			older := file.File
+			if existing.UpdatedAt.Before(file.UpdatedAt) {
+				older, s.remote[file.Path] = existing, file.File
+			}
+			fmt.Fprintf(os.Stderr, "skipping an older copy of %s (%s)\n", file.Path, older.ID)
+			continue
+		}
+		s.remote[file.Path] = file.File
+	}
+	return nil
+}
+
+// syncable reports whether a remote path can be written below the synced
+// directory.
+func syncable(p string) bool {
+	return p != "" && filepath.IsLocal(filepath.FromSlash(p)) && !strings.HasPrefix(path.Base(p), syncPrefix)
+}
+
+func (s *syncer) listLocal() error {
+	s.local = map[string]*localFile{}
+	return filepath.WalkDir(s.dir, func(fullPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), syncPrefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.dir, fullPath)
+		if err != nil {
+			return err
+		}
+		s.local[filepath.ToSlash(rel)] = &localFile{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+}
+
+// syncFile brings one path in line on both sides and records the result in
+// files. Deleting a file on one side deletes it on the other, unless the
+// other side changed it, in which case the changed file is kept. When both
+// sides changed a file differently, both copies are kept: the local one
+// under a new name.
+func (s *syncer) syncFile(p string, files map[string]syncEntry) error {
+	base, hasBase := s.state.Files[p]
+	local := s.local[p]
+	remote, hasRemote := s.remote[p]
+
+	localChanged, err := s.localChanged(p, local, base, hasBase)
+	if err != nil {
+		return err
+	}
+	remoteChanged := remoteChanged(remote, hasRemote, base, hasBase)
+
+	switch {
+	case !localChanged && !remoteChanged:
+		if local != nil && hasRemote {
+			return s.record(p, remote, files)
+		}
+		return nil
+
+	case localChanged && !remoteChanged:
+		if local == nil {
+			if hasRemote {
+				return s.deleteRemote(p, remote)
+			}
+			return nil
+		}
+		return s.upload(p, remote, hasRemote, files)
+
+	case !localChanged && remoteChanged:
+		if !hasRemote {
+			if local != nil {
+				return s.deleteLocal(p)
+			}
+			return nil
+		}
+		return s.download(p, remote, files)
+	}
+
+	switch {
+	case local == nil && !hasRemote:
+		return nil
+	case local == nil:
+		return s.download(p, remote, files)
+	case !hasRemote:
+		return s.upload(p, remote, false, files)
+	}
+	checksum, err := s.checksum(p)
+	if err != nil {
+		return err
+	}
+	if checksum == remote.Checksum {
+		return s.record(p, remote, files)
+	}
+	return s.conflict(p, remote, files)
+}
+
+func (s *syncer) localChanged(p string, local *localFile, base syncEntry, hasBase bool) (bool, error) {
+	if !hasBase || local == nil {
+		return hasBase != (local != nil), nil
+	}
+	if local.size == base.Size && local.modTime.Equal(base.ModTime) {
+		return false, nil
+	}
+	checksum, err := s.checksum(p)
+	if err != nil {
+		return false, err
+	}
+	return checksum != base.Checksum, nil
+}
+
+// remoteChanged compares a remote file against the state. Content never
+// changes once uploaded, so a file with a new id or updated_at but the same
+// checksum has only been renamed or relabelled.
+func remoteChanged(remote client.File, hasRemote bool, base syncEntry, hasBase bool) bool {
+	if !hasBase || !hasRemote {
+		return hasBase != hasRemote
+	}
+	if remote.ID == base.FileId && remote.UpdatedAt.Equal(base.UpdatedAt) {
+		return false
+	}
+	return remote.Checksum == "" || remote.Checksum != base.Checksum
+}
+
+func (s *syncer) checksum(p string) (string, error) {
+	local := s.local[p]
+	if local.checksum != "" {
+		return local.checksum, nil
+	}
+	f, err := os.Open(s.localPath(p))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	local.checksum = hex.EncodeToString(hash.Sum(nil))
+	return local.checksum, nil
+}
+
+func (s *syncer) localPath(p string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(p))
+}
+
+// upload uploads the local file, then deletes the remote file it replaces.
+func (s *syncer) upload(p string, replaced client.File, replaces bool, files map[string]syncEntry) error {
+	fmt.Println("upload  ", p)
+	if s.dryRun {
+		return nil
+	}
+	folderId, err := s.ensureFolder(path.Dir(p))
+	if err != nil {
+		return err
+	}
+	checksum, err := s.checksum(p)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(s.localPath(p))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	uploaded, err := s.api.UploadFile(s.ctx, client.UploadFileRequest{FolderId: &folderId, FileName: path.Base(p), Content: f})
+	if err != nil {
+		return err
+	}
+	if uploaded.Checksum != "" && uploaded.Checksum != checksum {
+		return fmt.Errorf("checksum of the uploaded file does not match, it changed while it was uploaded")
+	}
+
+	if replaces {
+		if err := s.api.DeleteFile(s.ctx, replaced.ID); err != nil && !errors.Is(err, client.ErrNotFound) {
+			return err
+		}
+	}
+	return s.record(p, uploaded, files)
+}
+
+// download writes the remote file next to its destination and renames it into
+// place once its checksum has been verified, so that an interrupted download
+// never leaves a partial file behind.
+func (s *syncer) download(p string, remote client.File, files map[string]syncEntry) error {
+	fmt.Println("download", p)
+	if s.dryRun {
+		return nil
+	}
+	destination := s.localPath(p)
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return err
+	}
+
+	download, err := s.api.DownloadFile(s.ctx, remote.ID, 0)
+	if err != nil {
+		return err
+	}
+	defer download.Body.Close()
+
+	temp, err := os.CreateTemp(filepath.Dir(destination), syncPrefix+"download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(temp, hash), download.Body)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if remote.Checksum != "" && checksum != remote.Checksum {
+		return fmt.Errorf("checksum of the downloaded file does not match")
+	}
+	if err := os.Rename(temp.Name(), destination); err != nil {
+		return err
+	}
+
+	s.local[p] = &localFile{checksum: checksum}
+	return s.record(p, remote, files)
+}
+
+// conflict keeps both copies of a file changed on both sides: the local copy
+// is renamed and uploaded next to the remote one, which is downloaded in its
+// place.
+func (s *syncer) conflict(p string, remote client.File, files map[string]syncEntry) error {
+	conflictPath := s.conflictPath(p)
+	fmt.Printf("conflict %s, keeping the local copy as %s\n", p, conflictPath)
+	if s.dryRun {
+		return nil
+	}
+	if err := os.Rename(s.localPath(p), s.localPath(conflictPath)); err != nil {
+		return err
+	}
+	s.local[conflictPath] = s.local[p]
+	delete(s.local, p)
+
+	if err := s.upload(conflictPath, client.File{}, false, files); err != nil {
+		return err
+	}
+	return s.download(p, remote, files)
+}
+
+func (s *syncer) conflictPath(p string) string {
+	ext := path.Ext(p)
+	stem := strings.TrimSuffix(p, ext)
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "local"
+	}
+	suffix := fmt.Sprintf(" (conflict %s %s)", host, time.Now().Format("2006-01-02 150405"))
+	for i := 1; ; i++ {
+		candidate := stem + suffix + ext
+		if i > 1 {
+			candidate = fmt.Sprintf("%s%s %d%s", stem, suffix, i, ext)
+		}
+		_, inLocal := s.local[candidate]
+		_, inRemote := s.remote[candidate]
+		if _, err := os.Lstat(s.localPath(candidate)); !inLocal && !inRemote && errors.Is(err, fs.ErrNotExist) {
+			return candidate
+		}
+	}
+}
+
+func (s *syncer) deleteLocal(p string) error {
+	fmt.Println("delete  ", p, "(local)")
+	if s.dryRun {
+		return nil
+	}
+	if err := os.Remove(s.localPath(p)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *syncer) deleteRemote(p string, remote client.File) error {
+	fmt.Println("delete  ", p, "(remote)")
+	if s.dryRun {
+		return nil
+	}
+	if err := s.api.DeleteFile(s.ctx, remote.ID); err != nil && !errors.Is(err, client.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// ensureFolder returns the id of the remote folder at dir, creating it and
+// the folders above it as needed.
+func (s *syncer) ensureFolder(dir string) (uuid.UUID, error) {
+	if dir == "." {
+		dir = ""
+	}
+	if s.folders == nil {
+		// The remote side was unchanged, so the tree was not listed.
+		if err := s.listTree(); err != nil {
+			return uuid.Nil, err
+		}
+	}
+	if id, ok := s.folders[dir]; ok {
+		return id, nil
+	}
+
+	parentId, err := s.ensureFolder(path.Dir(dir))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	folder, err := s.api.CreateFolder(s.ctx, client.CreateFolderRequest{FolderName: path.Base(dir), ParentId: &parentId})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	s.folders[dir] = folder.ID
+	return folder.ID, nil
+}
+
+// record saves the state of a path both sides agree on.
+func (s *syncer) record(p string, remote client.File, files map[string]syncEntry) error {
+	info, err := os.Stat(s.localPath(p))
+	if err != nil {
+		return err
+	}
+	checksum := remote.Checksum
+	if checksum == "" {
+		if checksum, err = s.checksum(p); err != nil {
+			return err
+		}
+	}
+	files[p] = syncEntry{
+		FileId:    remote.ID,
+		Checksum:  checksum,
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+		UpdatedAt: remote.UpdatedAt,
+	}
+	return nil
+}
+
+func loadSyncState(dir string) (syncState, error) {
+	state := syncState{Files: map[string]syncEntry{}}
+	data, err := os.ReadFile(filepath.Join(dir, syncStateName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return syncState{}, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return syncState{}, fmt.Errorf("error parsing %s: %w", syncStateName, err)
+	}
+	if state.Files == nil {
+		state.Files = map[string]syncEntry{}
+	}
+	return state, nil
+}
+
+// saveSyncState writes the state next to it and renames it into place, so
+// that an interrupted write never loses the previous state.
+func saveSyncState(dir string, state syncState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp := filepath.Join(dir, syncPrefix+"sync.json.tmp")
+	if err := os.WriteFile(temp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(temp, filepath.Join(dir, syncStateName))
+}
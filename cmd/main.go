@@ -2,27 +2,57 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/olad5/file-fort/config/data"
+	"google.golang.org/grpc"
 
 	"github.com/olad5/file-fort/config"
 	"github.com/olad5/file-fort/internal/app/router"
+	accessKeyHandlers "github.com/olad5/file-fort/internal/handlers/accesskeys"
+	activityHandlers "github.com/olad5/file-fort/internal/handlers/activity"
+	auditHandlers "github.com/olad5/file-fort/internal/handlers/audit"
 	fileHandlers "github.com/olad5/file-fort/internal/handlers/files"
+	graphqlHandlers "github.com/olad5/file-fort/internal/handlers/graphql"
+	grpcHandlers "github.com/olad5/file-fort/internal/handlers/grpc"
 	healthHandlers "github.com/olad5/file-fort/internal/handlers/health"
+	notificationHandlers "github.com/olad5/file-fort/internal/handlers/notifications"
+	openapiHandlers "github.com/olad5/file-fort/internal/handlers/openapi"
+	s3Handlers "github.com/olad5/file-fort/internal/handlers/s3"
+	searchHandlers "github.com/olad5/file-fort/internal/handlers/search"
 	userHandlers "github.com/olad5/file-fort/internal/handlers/users"
+	webdavHandlers "github.com/olad5/file-fort/internal/handlers/webdav"
+	webhookHandlers "github.com/olad5/file-fort/internal/handlers/webhooks"
+	"github.com/olad5/file-fort/internal/infra"
 	"github.com/olad5/file-fort/internal/infra/aws"
+	"github.com/olad5/file-fort/internal/infra/memory"
 	"github.com/olad5/file-fort/internal/infra/postgres"
 	"github.com/olad5/file-fort/internal/infra/redis"
+	"github.com/olad5/file-fort/internal/services/audit"
 	"github.com/olad5/file-fort/internal/services/auth"
+	"github.com/olad5/file-fort/internal/services/events"
+	"github.com/olad5/file-fort/internal/services/extraction"
+	"github.com/olad5/file-fort/internal/services/reconciler"
+	"github.com/olad5/file-fort/internal/services/thumbnails"
+	"github.com/olad5/file-fort/internal/services/webhooks"
+	accessKeyServices "github.com/olad5/file-fort/internal/usecases/accesskeys"
+	activityServices "github.com/olad5/file-fort/internal/usecases/activity"
+	auditServices "github.com/olad5/file-fort/internal/usecases/audit"
 	fileServices "github.com/olad5/file-fort/internal/usecases/files"
+	notificationServices "github.com/olad5/file-fort/internal/usecases/notifications"
+	searchServices "github.com/olad5/file-fort/internal/usecases/search"
 	"github.com/olad5/file-fort/internal/usecases/users"
+	webhookServices "github.com/olad5/file-fort/internal/usecases/webhooks"
 )
 
 func main() {
@@ -38,6 +68,13 @@ func main() {
 
 	defer postgresConnection.Close()
 
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		if err := runReconcile(ctx, configurations, postgresConnection, os.Args[2:]); err != nil {
+			log.Fatal("Error reconciling file store: ", err)
+		}
+		return
+	}
+
 	userRepo, err := postgres.NewPostgresUserRepo(ctx, postgresConnection)
 	if err != nil {
 		log.Fatal("Error Initializing User Repo", err)
@@ -53,7 +90,22 @@ func main() {
 		log.Fatal("Error Initializing Auth Service", err)
 	}
 
-	userService, err := users.NewUserService(userRepo, authService)
+	quotaRepo, err := postgres.NewPostgresQuotaRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Quota Repo", err)
+	}
+
+	auditRepo, err := postgres.NewPostgresAuditRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Audit Repo", err)
+	}
+
+	auditLogger, err := audit.NewRepositoryAuditLogger(ctx, auditRepo)
+	if err != nil {
+		log.Fatal("Error Initializing Audit Logger", err)
+	}
+
+	userService, err := users.NewUserService(userRepo, quotaRepo, authService, auditLogger)
 	if err != nil {
 		log.Fatal("Error Initializing UserService")
 	}
@@ -78,7 +130,53 @@ func main() {
 		log.Fatal("Error Initializing AWS File store", err)
 	}
 
-	filesService, err := fileServices.NewFileService(fileRepo, folderRepo, fileStore)
+	webhookRepo, err := postgres.NewPostgresWebhookRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Webhook Repo", err)
+	}
+
+	transactor, err := postgres.NewPostgresTransactor(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Transactor", err)
+	}
+
+	outboxRepo, err := postgres.NewPostgresOutboxRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Outbox Repo", err)
+	}
+
+	inboxRepo, err := postgres.NewPostgresInboxRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Inbox Repo", err)
+	}
+
+	thumbnailRepo, err := postgres.NewPostgresThumbnailRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Thumbnail Repo", err)
+	}
+
+	activityRepo, err := postgres.NewPostgresActivityRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Activity Repo", err)
+	}
+
+	changeRepo, err := postgres.NewPostgresChangeRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Change Repo", err)
+	}
+
+	var notificationBroker infra.NotificationBroker
+	switch configurations.EventBus {
+	case "redis":
+		notificationBroker, err = redis.NewRedisNotificationBroker(ctx, redisCache.Client, redis.NotificationChannel)
+		if err != nil {
+			log.Fatal("Error Initializing Redis Notification Broker", err)
+		}
+	default:
+		notificationBroker = memory.NewMemoryNotificationBroker()
+	}
+
+	filesService, err := fileServices.NewFileService(fileRepo, folderRepo, quotaRepo, fileStore, transactor, auditLogger, outboxRepo, thumbnailRepo, activityRepo, changeRepo, notificationBroker)
 	if err != nil {
 		log.Fatal("Error Initializing UserService")
 	}
@@ -93,7 +191,188 @@ func main() {
 		log.Fatal("failed to create the healthHandler: ", err)
 	}
 
-	appRouter := router.NewHttpRouter(*userHandler, *fileHandler, *healthHandler, authService)
+	auditService, err := auditServices.NewAuditService(auditRepo)
+	if err != nil {
+		log.Fatal("Error Initializing AuditService")
+	}
+
+	auditHandler, err := auditHandlers.NewAuditHandler(*auditService)
+	if err != nil {
+		log.Fatal("failed to create the auditHandler: ", err)
+	}
+
+	allowPrivateWebhookHosts := configurations.WebhookAllowPrivateHosts == "true"
+	webhookService, err := webhookServices.NewWebhookService(webhookRepo, allowPrivateWebhookHosts)
+	if err != nil {
+		log.Fatal("Error Initializing WebhookService")
+	}
+
+	webhookHandler, err := webhookHandlers.NewWebhookHandler(*webhookService)
+	if err != nil {
+		log.Fatal("failed to create the webhookHandler: ", err)
+	}
+
+	searchRepo, err := postgres.NewPostgresSearchRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Search Repo", err)
+	}
+
+	searchService, err := searchServices.NewSearchService(searchRepo, folderRepo)
+	if err != nil {
+		log.Fatal("Error Initializing SearchService")
+	}
+
+	searchHandler, err := searchHandlers.NewSearchHandler(*searchService)
+	if err != nil {
+		log.Fatal("failed to create the searchHandler: ", err)
+	}
+
+	starRepo, err := postgres.NewPostgresStarRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Star Repo", err)
+	}
+
+	activityService, err := activityServices.NewActivityService(starRepo, activityRepo, fileRepo, folderRepo)
+	if err != nil {
+		log.Fatal("Error Initializing ActivityService")
+	}
+
+	activityHandler, err := activityHandlers.NewActivityHandler(*activityService)
+	if err != nil {
+		log.Fatal("failed to create the activityHandler: ", err)
+	}
+
+	webdavHandler, err := webdavHandlers.NewWebDAVHandler(*filesService)
+	if err != nil {
+		log.Fatal("failed to create the webdavHandler: ", err)
+	}
+
+	accessKeyRepo, err := postgres.NewPostgresAccessKeyRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Access Key Repo", err)
+	}
+
+	accessKeyService, err := accessKeyServices.NewAccessKeyService(accessKeyRepo, userRepo, auditLogger)
+	if err != nil {
+		log.Fatal("Error Initializing AccessKeyService")
+	}
+
+	accessKeyHandler, err := accessKeyHandlers.NewAccessKeyHandler(*accessKeyService)
+	if err != nil {
+		log.Fatal("failed to create the accessKeyHandler: ", err)
+	}
+
+	s3Handler, err := s3Handlers.NewS3Handler(*filesService, *accessKeyService)
+	if err != nil {
+		log.Fatal("failed to create the s3Handler: ", err)
+	}
+
+	notificationService, err := notificationServices.NewNotificationService(notificationBroker)
+	if err != nil {
+		log.Fatal("Error Initializing NotificationService")
+	}
+
+	notificationHandler, err := notificationHandlers.NewNotificationHandler(*notificationService)
+	if err != nil {
+		log.Fatal("failed to create the notificationHandler: ", err)
+	}
+
+	graphqlHandler, err := graphqlHandlers.NewGraphQLHandler(*filesService, *userService)
+	if err != nil {
+		log.Fatal("failed to create the graphqlHandler: ", err)
+	}
+
+	openapiHandler, err := openapiHandlers.NewOpenAPIHandler(ctx)
+	if err != nil {
+		log.Fatal("failed to create the openapiHandler: ", err)
+	}
+
+	grpcHandler, err := grpcHandlers.NewGRPCHandler(*filesService, *userService)
+	if err != nil {
+		log.Fatal("failed to create the grpcHandler: ", err)
+	}
+
+	appRouter := router.NewHttpRouter(*userHandler, *fileHandler, *healthHandler, *auditHandler, *webhookHandler, *searchHandler, *activityHandler, *webdavHandler, *accessKeyHandler, *s3Handler, *notificationHandler, *graphqlHandler, *openapiHandler, authService, userService)
+
+	webhookWorker, err := webhooks.NewDeliveryWorker(ctx, webhookRepo, allowPrivateWebhookHosts)
+	if err != nil {
+		log.Fatal("Error Initializing Webhook Delivery Worker", err)
+	}
+
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+
+	var eventBus infra.EventBus
+	switch configurations.EventBus {
+	case "redis":
+		eventBus, err = redis.NewRedisEventBus(ctx, redisCache.Client, redis.EventStream)
+		if err != nil {
+			log.Fatal("Error Initializing Redis Event Bus", err)
+		}
+	default:
+		eventBus = memory.NewMemoryEventBus()
+	}
+
+	webhookDispatcher, err := webhooks.NewRepositoryDispatcher(ctx, webhookRepo)
+	if err != nil {
+		log.Fatal("Error Initializing Webhook Dispatcher", err)
+	}
+
+	err = eventBus.Subscribe(workerCtx, "webhooks", events.IdempotentInTransaction("webhooks", inboxRepo, transactor, webhookDispatcher.HandleEvent))
+	if err != nil {
+		log.Fatal("Error Subscribing Webhook Dispatcher", err)
+	}
+
+	fileContentRepo, err := postgres.NewPostgresFileContentRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing File Content Repo", err)
+	}
+
+	contentIndexer, err := extraction.NewContentIndexer(ctx, fileRepo, fileStore, fileContentRepo)
+	if err != nil {
+		log.Fatal("Error Initializing Content Indexer", err)
+	}
+
+	err = eventBus.Subscribe(workerCtx, "content-indexer", events.Idempotent("content-indexer", inboxRepo, contentIndexer.HandleEvent))
+	if err != nil {
+		log.Fatal("Error Subscribing Content Indexer", err)
+	}
+
+	thumbnailGenerator, err := thumbnails.NewGenerator(ctx, fileRepo, thumbnailRepo, fileStore)
+	if err != nil {
+		log.Fatal("Error Initializing Thumbnail Generator", err)
+	}
+
+	err = eventBus.Subscribe(workerCtx, "thumbnails", events.Idempotent("thumbnails", inboxRepo, thumbnailGenerator.HandleEvent))
+	if err != nil {
+		log.Fatal("Error Subscribing Thumbnail Generator", err)
+	}
+
+	err = eventBus.Subscribe(workerCtx, "notifications", events.Idempotent("notifications", inboxRepo, notificationBroker.Notify))
+	if err != nil {
+		log.Fatal("Error Subscribing Notification Broker", err)
+	}
+
+	outboxRelay, err := events.NewOutboxRelay(ctx, outboxRepo, eventBus)
+	if err != nil {
+		log.Fatal("Error Initializing Outbox Relay", err)
+	}
+
+	go outboxRelay.Run(workerCtx)
+	go webhookWorker.Run(workerCtx)
+
+	if configurations.ReconcileInterval != "" {
+		interval, err := time.ParseDuration(configurations.ReconcileInterval)
+		if err != nil {
+			log.Fatal("Error parsing RECONCILE_INTERVAL", err)
+		}
+
+		fileReconciler, err := reconciler.NewReconciler(ctx, fileRepo, quotaRepo, outboxRepo, changeRepo, fileStore, transactor)
+		if err != nil {
+			log.Fatal("Error Initializing Reconciler", err)
+		}
+		go fileReconciler.Run(workerCtx, interval, reconciler.Options{MarkMissing: true})
+	}
 
 	server := &http.Server{Addr: ":" + port, Handler: appRouter}
 	go func() {
@@ -102,10 +381,27 @@ func main() {
 			fmt.Printf("HTTP server ListenAndServe: %v", err)
 		}
 	}()
+
+	var grpcServer *grpc.Server
+	if configurations.GrpcPort != "" {
+		grpcListener, err := net.Listen("tcp", ":"+configurations.GrpcPort)
+		if err != nil {
+			log.Fatal("Error listening on GRPC_PORT", err)
+		}
+		grpcServer = router.NewGrpcServer(*grpcHandler, authService)
+		go func() {
+			fmt.Println("gRPC server is running....")
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				fmt.Printf("gRPC server Serve: %v", err)
+			}
+		}()
+	}
+
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 
 	<-signals
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -113,6 +409,100 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		fmt.Printf("Server forced to shutdown: %v", err)
 	}
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcServer.Stop()
+		}
+	}
 
 	fmt.Println("Server exiting gracefully")
 }
+
+func runReconcile(ctx context.Context, configurations *config.Configurations, postgresConnection *sqlx.DB, args []string) error {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	deleteOrphans := flags.Bool("delete-orphans", false, "delete objects in the file store that no file references")
+	markMissing := flags.Bool("mark-missing", false, "mark files whose object is missing from the file store")
+	deleteMissing := flags.Bool("delete-missing", false, "delete files whose object is missing from the file store")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	fileRepo, err := postgres.NewPostgresFileRepo(ctx, postgresConnection)
+	if err != nil {
+		return err
+	}
+	quotaRepo, err := postgres.NewPostgresQuotaRepo(ctx, postgresConnection)
+	if err != nil {
+		return err
+	}
+	outboxRepo, err := postgres.NewPostgresOutboxRepo(ctx, postgresConnection)
+	if err != nil {
+		return err
+	}
+	changeRepo, err := postgres.NewPostgresChangeRepo(ctx, postgresConnection)
+	if err != nil {
+		return err
+	}
+	transactor, err := postgres.NewPostgresTransactor(ctx, postgresConnection)
+	if err != nil {
+		return err
+	}
+	fileStore, err := aws.NewAwsFileStore(ctx, configurations)
+	if err != nil {
+		return err
+	}
+
+	fileReconciler, err := reconciler.NewReconciler(ctx, fileRepo, quotaRepo, outboxRepo, changeRepo, fileStore, transactor)
+	if err != nil {
+		return err
+	}
+
+	report, err := fileReconciler.Reconcile(ctx, reconciler.Options{
+		DeleteOrphans: *deleteOrphans,
+		MarkMissing:   *markMissing,
+		DeleteMissing: *deleteMissing,
+	})
+	if err != nil {
+		return err
+	}
+
+	orphans := []map[string]interface{}{}
+	for _, object := range report.Orphans {
+		orphans = append(orphans, map[string]interface{}{
+			"key":           object.Key,
+			"size":          object.Size,
+			"last_modified": object.LastModified,
+		})
+	}
+	missing := []map[string]interface{}{}
+	for _, file := range report.Missing {
+		missing = append(missing, map[string]interface{}{
+			"id":             file.ID,
+			"file_name":      file.FileName,
+			"file_store_key": file.FileStoreKey,
+			"owner_id":       file.OwnerId,
+		})
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"started_at":       report.StartedAt,
+		"finished_at":      report.FinishedAt,
+		"objects_scanned":  report.ObjectsScanned,
+		"files_scanned":    report.FilesScanned,
+		"orphans":          orphans,
+		"missing":          missing,
+		"deleted_orphans":  report.DeletedOrphans,
+		"marked_missing":   report.MarkedMissing,
+		"deleted_missing":  report.DeletedMissing,
+		"restored_missing": report.RestoredMissing,
+	})
+}
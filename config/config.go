@@ -8,15 +8,19 @@ import (
 )
 
 type Configurations struct {
-	DatabaseUrl  string
-	Port         string
-	JwtSecretKey string
-	CacheAddress string
-	AwsEndpoint  string
-	AwsRegion    string
-	AwsS3Bucket  string
-	AwsSecretKey string
-	AwsAccessKey string
+	DatabaseUrl              string
+	Port                     string
+	GrpcPort                 string
+	JwtSecretKey             string
+	CacheAddress             string
+	AwsEndpoint              string
+	AwsRegion                string
+	AwsS3Bucket              string
+	AwsSecretKey             string
+	AwsAccessKey             string
+	EventBus                 string
+	ReconcileInterval        string
+	WebhookAllowPrivateHosts string
 }
 
 func GetConfig(filepath string) *Configurations {
@@ -26,15 +30,19 @@ func GetConfig(filepath string) *Configurations {
 	}
 
 	configurations := Configurations{
-		DatabaseUrl:  os.Getenv("DATABASE_URL"),
-		Port:         os.Getenv("PORT"),
-		JwtSecretKey: os.Getenv("SECRET_KEY"),
-		CacheAddress: os.Getenv("REDIS_URL"),
-		AwsEndpoint:  os.Getenv("AWS_ENDPOINT"),
-		AwsS3Bucket:  os.Getenv("AWS_S3_BUCKET"),
-		AwsRegion:    os.Getenv("AWS_REGION"),
-		AwsSecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
-		AwsAccessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+		DatabaseUrl:              os.Getenv("DATABASE_URL"),
+		Port:                     os.Getenv("PORT"),
+		GrpcPort:                 os.Getenv("GRPC_PORT"),
+		JwtSecretKey:             os.Getenv("SECRET_KEY"),
+		CacheAddress:             os.Getenv("REDIS_URL"),
+		AwsEndpoint:              os.Getenv("AWS_ENDPOINT"),
+		AwsS3Bucket:              os.Getenv("AWS_S3_BUCKET"),
+		AwsRegion:                os.Getenv("AWS_REGION"),
+		AwsSecretKey:             os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		AwsAccessKey:             os.Getenv("AWS_ACCESS_KEY_ID"),
+		EventBus:                 os.Getenv("EVENT_BUS"),
+		ReconcileInterval:        os.Getenv("RECONCILE_INTERVAL"),
+		WebhookAllowPrivateHosts: os.Getenv("WEBHOOK_ALLOW_PRIVATE_HOSTS"),
 	}
 
 	return &configurations
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"github.com/olad5/file-fort/internal/handlers/auth"
+	grpcHandlers "github.com/olad5/file-fort/internal/handlers/grpc"
+	authService "github.com/olad5/file-fort/internal/services/auth"
+	filefortv1 "github.com/olad5/file-fort/pkg/proto/filefort/v1"
+	"google.golang.org/grpc"
+)
+
+// publicGrpcMethods are the gRPC methods that can be called without a token.
+var publicGrpcMethods = []string{
+	filefortv1.UserService_CreateUser_FullMethodName,
+	filefortv1.UserService_Login_FullMethodName,
+}
+
+func NewGrpcServer(grpcHandler grpcHandlers.GRPCHandler, authService authService.AuthService) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(auth.EnsureAuthenticatedUnary(authService, publicGrpcMethods...)),
+		grpc.StreamInterceptor(auth.EnsureAuthenticatedStream(authService, publicGrpcMethods...)),
+	)
+	grpcHandler.Register(server)
+	return server
+}
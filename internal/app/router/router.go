@@ -4,23 +4,41 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5/middleware"
+	accessKeyHandlers "github.com/olad5/file-fort/internal/handlers/accesskeys"
+	activityHandlers "github.com/olad5/file-fort/internal/handlers/activity"
+	auditHandlers "github.com/olad5/file-fort/internal/handlers/audit"
 	"github.com/olad5/file-fort/internal/handlers/auth"
 	fileHandlers "github.com/olad5/file-fort/internal/handlers/files"
+	graphqlHandlers "github.com/olad5/file-fort/internal/handlers/graphql"
 	healthHandlers "github.com/olad5/file-fort/internal/handlers/health"
+	notificationHandlers "github.com/olad5/file-fort/internal/handlers/notifications"
+	openapiHandlers "github.com/olad5/file-fort/internal/handlers/openapi"
+	s3Handlers "github.com/olad5/file-fort/internal/handlers/s3"
+	searchHandlers "github.com/olad5/file-fort/internal/handlers/search"
 	userHandlers "github.com/olad5/file-fort/internal/handlers/users"
+	webdavHandlers "github.com/olad5/file-fort/internal/handlers/webdav"
+	webhookHandlers "github.com/olad5/file-fort/internal/handlers/webhooks"
 	authService "github.com/olad5/file-fort/internal/services/auth"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func NewHttpRouter(userHandler userHandlers.UserHandler, fileHandler fileHandlers.FileHandler, healthcheckHandler healthHandlers.HealthHandler, authService authService.AuthService) http.Handler {
+func NewHttpRouter(userHandler userHandlers.UserHandler, fileHandler fileHandlers.FileHandler, healthcheckHandler healthHandlers.HealthHandler, auditHandler auditHandlers.AuditHandler, webhookHandler webhookHandlers.WebhookHandler, searchHandler searchHandlers.SearchHandler, activityHandler activityHandlers.ActivityHandler, webdavHandler webdavHandlers.WebDAVHandler, accessKeyHandler accessKeyHandlers.AccessKeyHandler, s3Handler s3Handlers.S3Handler, notificationHandler notificationHandlers.NotificationHandler, graphqlHandler graphqlHandlers.GraphQLHandler, openapiHandler openapiHandlers.OpenAPIHandler, authService authService.AuthService, passwordAuthenticator auth.PasswordAuthenticator) http.Handler {
+	for _, method := range webdavHandlers.Methods {
+		chi.RegisterMethod(method)
+	}
+
 	router := chi.NewRouter()
+	router.Use(auditHandlers.CaptureRequestInfo)
 
 	router.Group(func(r chi.Router) {
 		r.Use(
 			middleware.AllowContentType("application/json"),
 			middleware.SetHeader("Content-Type", "application/json"),
 		)
+		r.Use(openapiHandler.ValidateRequest)
+
+		r.Get("/openapi.json", openapiHandler.Spec)
 		r.Post("/users/login", userHandler.Login)
 		r.Post("/users", userHandler.Register)
 		r.Get("/health", healthcheckHandler.Healthcheck)
@@ -34,11 +52,53 @@ func NewHttpRouter(userHandler userHandlers.UserHandler, fileHandler fileHandler
 			middleware.SetHeader("Content-Type", "application/json"),
 		)
 		r.Use(auth.EnsureAuthenticated(authService))
+		r.Use(openapiHandler.ValidateRequest)
 
 		r.Get("/users/me", userHandler.GetLoggedInUser)
+		r.Get("/users/me/usage", userHandler.GetStorageUsage)
+		r.Get("/users/me/starred", activityHandler.GetStarred)
+		r.Get("/users/me/recent", activityHandler.GetRecent)
+		r.Put("/file/{id}/star", activityHandler.StarFile)
+		r.Delete("/file/{id}/star", activityHandler.UnstarFile)
+		r.Put("/folder/{id}/star", activityHandler.StarFolder)
+		r.Delete("/folder/{id}/star", activityHandler.UnstarFolder)
 		r.Get("/file/{id}", fileHandler.Download)
+		r.Delete("/file/{id}", fileHandler.Delete)
+		r.Post("/file/{id}/copy", fileHandler.Copy)
+		r.Get("/file/{id}/labels", fileHandler.GetFileLabels)
+		r.Put("/file/{id}/labels", fileHandler.UpdateFileLabels)
+		r.Patch("/file/{id}/labels", fileHandler.UpdateFileLabels)
+		r.Post("/files/batch", fileHandler.Batch)
+		r.Get("/file/{id}/thumbnail", fileHandler.Thumbnail)
+		r.Get("/file/{id}/content", fileHandler.Content)
+		r.Head("/file/{id}/content", fileHandler.Content)
 		r.Post("/folder", fileHandler.CreateFolder)
 		r.Get("/folder/{id}/files", fileHandler.GetFilesByFolderId)
+		r.Get("/folder/{id}/tree", fileHandler.GetFolderTree)
+		r.Get("/folder/{id}/archive", fileHandler.Archive)
+		r.Get("/folder/{id}/labels", fileHandler.GetFolderLabels)
+		r.Put("/folder/{id}/labels", fileHandler.UpdateFolderLabels)
+		r.Patch("/folder/{id}/labels", fileHandler.UpdateFolderLabels)
+		r.Get("/changes", fileHandler.GetChanges)
+		r.Post("/graphql", graphqlHandler.Query)
+		r.Get("/search", searchHandler.Search)
+		r.Post("/webhooks", webhookHandler.CreateWebhook)
+		r.Get("/webhooks", webhookHandler.GetWebhooks)
+		r.Delete("/webhooks/{id}", webhookHandler.DeleteWebhook)
+		r.Get("/webhooks/{id}/deliveries", webhookHandler.GetDeliveries)
+		r.Post("/webhooks/{id}/deliveries/{deliveryId}/redeliver", webhookHandler.Redeliver)
+		r.Post("/users/me/access-keys", accessKeyHandler.CreateAccessKey)
+		r.Get("/users/me/access-keys", accessKeyHandler.GetAccessKeys)
+		r.Delete("/users/me/access-keys/{id}", accessKeyHandler.DeleteAccessKey)
+	})
+
+	// -------------------------------------------------------------------------
+
+	router.Group(func(r chi.Router) {
+		r.Use(auth.EnsureAuthenticated(authService))
+		r.Use(openapiHandler.ValidateRequest)
+
+		r.Get("/events", notificationHandler.Stream)
 	})
 
 	// -------------------------------------------------------------------------
@@ -46,12 +106,28 @@ func NewHttpRouter(userHandler userHandlers.UserHandler, fileHandler fileHandler
 	router.Group(func(r chi.Router) {
 		r.Use(middleware.AllowContentType("multipart/form-data"))
 		r.Use(auth.EnsureAuthenticated(authService))
+		r.Use(openapiHandler.ValidateRequest)
 
 		r.Post("/file", fileHandler.Upload)
 	})
 
 	// -------------------------------------------------------------------------
 
+	router.Group(func(r chi.Router) {
+		r.Use(auth.EnsureBasicAuthenticated(authService, passwordAuthenticator, "file-fort"))
+
+		r.Handle(webdavHandlers.Prefix, webdavHandler)
+		r.Handle(webdavHandlers.Prefix+"/*", webdavHandler)
+	})
+
+	// -------------------------------------------------------------------------
+
+	// The S3 gateway authenticates requests itself, from their signatures.
+	router.Handle(s3Handlers.Prefix, s3Handler)
+	router.Handle(s3Handlers.Prefix+"/*", s3Handler)
+
+	// -------------------------------------------------------------------------
+
 	router.Group(func(r chi.Router) {
 		r.Use(
 			middleware.AllowContentType("application/json"),
@@ -59,8 +135,12 @@ func NewHttpRouter(userHandler userHandlers.UserHandler, fileHandler fileHandler
 		)
 		r.Use(auth.EnsureAuthenticated(authService))
 		r.Use(auth.AdminGuard(authService))
+		r.Use(openapiHandler.ValidateRequest)
 
 		r.Post("/file/{id}/mark-unsafe", fileHandler.MarkFileAsUnSafe)
+		r.Put("/users/{id}/quota", userHandler.SetUserQuota)
+		r.Put("/roles/{role}/quota", userHandler.SetRoleQuota)
+		r.Get("/audit-events", auditHandler.GetAuditEvents)
 	})
 
 	return router
@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccessKey is a key pair that S3 clients sign requests with. The secret is
+// kept, rather than a hash of it, since checking a signature needs it.
+type AccessKey struct {
+	ID        string
+	UserId    uuid.UUID
+	SecretKey string
+	CreatedAt time.Time
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Star is a file or folder a user has starred. Exactly one of File and
+// Folder is set.
+type Star struct {
+	ID        uuid.UUID
+	UserId    uuid.UUID
+	File      *File
+	Folder    *Folder
+	CreatedAt time.Time
+}
+
+type ActivityType string
+
+const (
+	ActivityUploaded   ActivityType = "uploaded"
+	ActivityDownloaded ActivityType = "downloaded"
+)
+
+// RecentFile is a file along with the last thing a user did with it.
+type RecentFile struct {
+	File       File
+	Activity   ActivityType
+	OccurredAt time.Time
+}
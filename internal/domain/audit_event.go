@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditAction string
+
+const (
+	AuditActionLoginSucceeded        AuditAction = "user.login_succeeded"
+	AuditActionLoginFailed           AuditAction = "user.login_failed"
+	AuditActionUserRegistered        AuditAction = "user.registered"
+	AuditActionFileUploaded          AuditAction = "file.uploaded"
+	AuditActionFileDownloadUrlIssued AuditAction = "file.download_url_issued"
+	AuditActionFileDownloaded        AuditAction = "file.downloaded"
+	AuditActionFileMarkedUnsafe      AuditAction = "file.marked_unsafe"
+	AuditActionFileDeleted           AuditAction = "file.deleted"
+	AuditActionFileMoved             AuditAction = "file.moved"
+	AuditActionFileCopied            AuditAction = "file.copied"
+	AuditActionFileLabelsUpdated     AuditAction = "file.labels_updated"
+	AuditActionFolderCreated         AuditAction = "folder.created"
+	AuditActionFolderMoved           AuditAction = "folder.moved"
+	AuditActionFolderDeleted         AuditAction = "folder.deleted"
+	AuditActionFolderDownloaded      AuditAction = "folder.downloaded"
+	AuditActionFolderLabelsUpdated   AuditAction = "folder.labels_updated"
+	AuditActionAccessKeyCreated      AuditAction = "access_key.created"
+	AuditActionAccessKeyDeleted      AuditAction = "access_key.deleted"
+)
+
+type AuditTargetType string
+
+const (
+	AuditTargetUser      AuditTargetType = "user"
+	AuditTargetFile      AuditTargetType = "file"
+	AuditTargetFolder    AuditTargetType = "folder"
+	AuditTargetAccessKey AuditTargetType = "access_key"
+)
+
+type AuditEvent struct {
+	ID         uuid.UUID
+	ActorId    *uuid.UUID
+	ActorEmail string
+	Action     AuditAction
+	TargetType AuditTargetType
+	TargetId   string
+	IpAddress  string
+	UserAgent  string
+	CreatedAt  time.Time
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeMoved   ChangeType = "moved"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+type ChangeTarget string
+
+const (
+	ChangeTargetFile   ChangeTarget = "file"
+	ChangeTargetFolder ChangeTarget = "folder"
+)
+
+// Change is an entry in an owner's change feed. Sequences are per owner and
+// increase with every change, so a client that remembers the last one it saw
+// can ask for everything after it.
+type Change struct {
+	Sequence   int64
+	OwnerId    uuid.UUID
+	Type       ChangeType
+	TargetType ChangeTarget
+	TargetId   uuid.UUID
+	// Data is the state of the target once the change was made.
+	Data       map[string]interface{}
+	OccurredAt time.Time
+}
+
+func NewChange(changeType ChangeType, targetType ChangeTarget, targetId, ownerId uuid.UUID, data map[string]interface{}) Change {
+	return Change{
+		OwnerId:    ownerId,
+		Type:       changeType,
+		TargetType: targetType,
+		TargetId:   targetId,
+		Data:       data,
+		OccurredAt: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+const (
+	EventFileUploaded     EventType = "file.uploaded"
+	EventFileMarkedUnsafe EventType = "file.marked_unsafe"
+	EventFileDeleted      EventType = "file.deleted"
+	EventFileMoved        EventType = "file.moved"
+	EventFileCopied       EventType = "file.copied"
+	EventFolderCreated    EventType = "folder.created"
+	EventFolderMoved      EventType = "folder.moved"
+	EventFolderDeleted    EventType = "folder.deleted"
+)
+
+var EventTypes = []EventType{
+	EventFileUploaded,
+	EventFileMarkedUnsafe,
+	EventFileDeleted,
+	EventFileMoved,
+	EventFileCopied,
+	EventFolderCreated,
+	EventFolderMoved,
+	EventFolderDeleted,
+}
+
+func (e EventType) IsValid() bool {
+	for _, eventType := range EventTypes {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type Event struct {
+	ID         uuid.UUID
+	Type       EventType
+	OwnerId    uuid.UUID
+	Data       map[string]interface{}
+	OccurredAt time.Time
+}
+
+func NewEvent(eventType EventType, ownerId uuid.UUID, data map[string]interface{}) Event {
+	return Event{
+		ID:         uuid.New(),
+		Type:       eventType,
+		OwnerId:    ownerId,
+		Data:       data,
+		OccurredAt: time.Now().UTC(),
+	}
+}
@@ -13,7 +13,14 @@ type File struct {
 	FolderId     uuid.UUID
 	FileStoreKey string
 	FileSize     int64
-	IsUnsafe     bool
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ContentType  string
+	// Checksum is the hex encoded SHA-256 of the content, or empty for files
+	// uploaded before checksums were recorded.
+	Checksum  string
+	Tags      []string
+	Metadata  map[string]string
+	IsUnsafe  bool
+	IsMissing bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
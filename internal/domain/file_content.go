@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileContent is the plain text extracted from a file for content search.
+type FileContent struct {
+	FileId    uuid.UUID
+	Content   string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
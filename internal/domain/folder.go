@@ -10,6 +10,9 @@ type Folder struct {
 	ID         uuid.UUID
 	FolderName string
 	OwnerId    uuid.UUID
+	ParentId   *uuid.UUID
+	Tags       []string
+	Metadata   map[string]string
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	MaxTags                = 20
+	MaxTagLength           = 50
+	MaxMetadataEntries     = 32
+	MaxMetadataKeyLength   = 64
+	MaxMetadataValueLength = 1024
+)
+
+var ErrTooManyTags = fmt.Errorf("at most %d tags are allowed", MaxTags)
+
+// NormalizeTag trims and lowercases a tag, so that tags differing only in
+// case or surrounding space are the same tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// IsValidTag reports whether a normalized tag is short enough and made up of
+// letters, digits, spaces and the characters - _ . :
+func IsValidTag(tag string) bool {
+	if tag == "" || len(tag) > MaxTagLength {
+		return false
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(" -_.:", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsValidMetadataKey reports whether key is short enough and made up of
+// ASCII letters, digits and the characters - _ .
+func IsValidMetadataKey(key string) bool {
+	if key == "" || len(key) > MaxMetadataKeyLength {
+		return false
+	}
+	for _, r := range key {
+		if r > unicode.MaxASCII || (!unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.", r)) {
+			return false
+		}
+	}
+	return true
+}
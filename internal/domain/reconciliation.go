@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+type StoredObject struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	ETag         string
+}
+
+type ReconciliationReport struct {
+	StartedAt       time.Time
+	FinishedAt      time.Time
+	ObjectsScanned  int
+	FilesScanned    int
+	Orphans         []StoredObject
+	Missing         []File
+	DeletedOrphans  int
+	MarkedMissing   int
+	DeletedMissing  int
+	RestoredMissing int
+}
@@ -0,0 +1,26 @@
+package domain
+
+type SearchResultType string
+
+const (
+	SearchResultFile   SearchResultType = "file"
+	SearchResultFolder SearchResultType = "folder"
+)
+
+// Matching terms in a SearchResult snippet are wrapped in HighlightStart and
+// HighlightEnd. Extracted content never contains either, since control
+// characters are stripped during extraction.
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// SearchResult is either a File or a Folder, depending on Type. Snippet is
+// an excerpt of the file's content when the content matched.
+type SearchResult struct {
+	Type    SearchResultType
+	File    *File
+	Folder  *Folder
+	Rank    float64
+	Snippet string
+}
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+)
+
+type StorageUsage struct {
+	UserId     uuid.UUID
+	UsedBytes  int64
+	QuotaBytes *int64
+}
+
+func (s StorageUsage) CanStore(size int64) bool {
+	if s.QuotaBytes == nil {
+		return true
+	}
+	return s.UsedBytes+size <= *s.QuotaBytes
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ThumbnailSize string
+
+const (
+	ThumbnailSmall  ThumbnailSize = "small"
+	ThumbnailMedium ThumbnailSize = "medium"
+	ThumbnailLarge  ThumbnailSize = "large"
+)
+
+var ThumbnailSizes = []ThumbnailSize{ThumbnailSmall, ThumbnailMedium, ThumbnailLarge}
+
+func (s ThumbnailSize) IsValid() bool {
+	for _, size := range ThumbnailSizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxDimension is the length, in pixels, of the longer side of a thumbnail.
+func (s ThumbnailSize) MaxDimension() int {
+	switch s {
+	case ThumbnailSmall:
+		return 128
+	case ThumbnailLarge:
+		return 1024
+	default:
+		return 384
+	}
+}
+
+type Thumbnail struct {
+	FileId       uuid.UUID
+	Size         ThumbnailSize
+	FileStoreKey string
+	Width        int
+	Height       int
+	CreatedAt    time.Time
+}
+
+const thumbnailKeyPrefix = "thumbnails/"
+
+// ThumbnailKey is where the thumbnail of a file is kept in the file store.
+// Keys are derived from the file id so that thumbnails can be found and
+// cleaned up from the file alone.
+func ThumbnailKey(fileId uuid.UUID, size ThumbnailSize) string {
+	return thumbnailKeyPrefix + fileId.String() + "/" + string(size) + ".jpg"
+}
+
+// ThumbnailFileId returns the id of the file a thumbnail key belongs to.
+func ThumbnailFileId(key string) (uuid.UUID, bool) {
+	if !strings.HasPrefix(key, thumbnailKeyPrefix) {
+		return uuid.Nil, false
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(key, thumbnailKeyPrefix), "/")
+	fileId, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return fileId, true
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Webhook struct {
+	ID        uuid.UUID
+	OwnerId   uuid.UUID
+	Url       string
+	Secret    string
+	Events    []EventType
+	IsActive  bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (w Webhook) Subscribes(eventType EventType) bool {
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+type WebhookDelivery struct {
+	ID               uuid.UUID
+	WebhookId        uuid.UUID
+	EventId          uuid.UUID
+	EventType        EventType
+	Payload          []byte
+	Status           DeliveryStatus
+	Attempts         int
+	NextAttemptAt    time.Time
+	LastResponseCode int
+	LastError        string
+	DeliveredAt      *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/olad5/file-fort/internal/usecases/accesskeys"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func (ah AccessKeyHandler) CreateAccessKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	newAccessKey, err := ah.accessKeyService.CreateAccessKey(ctx)
+	if err != nil {
+		switch {
+		case errors.Is(err, accesskeys.ErrTooManyAccessKeys):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	data := ToResponseAccessKey(newAccessKey)
+	data["secret_access_key"] = newAccessKey.SecretKey
+	response.SuccessResponse(w, "access key created successfully", data)
+}
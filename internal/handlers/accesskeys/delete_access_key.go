@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/olad5/file-fort/internal/infra"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func (ah AccessKeyHandler) DeleteAccessKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	accessKeyId := chi.URLParam(r, "id")
+	if accessKeyId == "" {
+		response.ErrorResponse(w, "access key id required", http.StatusBadRequest)
+		return
+	}
+
+	err := ah.accessKeyService.DeleteAccessKey(ctx, accessKeyId)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrAccessKeyNotFound):
+			response.ErrorResponse(w, "access key does not exist", http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrUserNotAuthorized):
+			response.ErrorResponse(w, "unauthorized to manage this access key", http.StatusForbidden)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "access key deleted successfully",
+		map[string]interface{}{
+			"access_key_id": accessKeyId,
+		})
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func (ah AccessKeyHandler) GetAccessKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	accessKeys, err := ah.accessKeyService.GetAccessKeys(ctx)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+		return
+	}
+
+	results := []map[string]interface{}{}
+	for _, accessKey := range accessKeys {
+		results = append(results, ToResponseAccessKey(accessKey))
+	}
+
+	response.SuccessResponse(w, "access keys retrieved successfully",
+		map[string]interface{}{
+			"access_keys": results,
+		})
+}
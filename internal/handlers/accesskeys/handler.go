@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/olad5/file-fort/internal/usecases/accesskeys"
+)
+
+type AccessKeyHandler struct {
+	accessKeyService accesskeys.AccessKeyService
+}
+
+func NewAccessKeyHandler(accessKeyService accesskeys.AccessKeyService) (*AccessKeyHandler, error) {
+	if accessKeyService == (accesskeys.AccessKeyService{}) {
+		return nil, errors.New("access key service cannot be empty")
+	}
+
+	return &AccessKeyHandler{accessKeyService}, nil
+}
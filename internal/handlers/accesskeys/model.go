@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"github.com/olad5/file-fort/internal/domain"
+)
+
+func ToResponseAccessKey(accessKey domain.AccessKey) map[string]interface{} {
+	return map[string]interface{}{
+		"access_key_id": accessKey.ID,
+		"user_id":       accessKey.UserId,
+		"created_at":    accessKey.CreatedAt,
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	fileHandlers "github.com/olad5/file-fort/internal/handlers/files"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+const (
+	defaultRowsPerPage = 20
+	maxRowsPerPage     = 50
+)
+
+func (a ActivityHandler) GetRecent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	pageNumber, rowsPerPage, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	recentFiles, total, err := a.activityService.GetRecentFiles(ctx, pageNumber, rowsPerPage)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+		return
+	}
+
+	results := []map[string]interface{}{}
+	for _, recentFile := range recentFiles {
+		body := fileHandlers.ToResponseFile(recentFile.File)
+		body["activity"] = recentFile.Activity
+		body["occurred_at"] = recentFile.OccurredAt
+		results = append(results, body)
+	}
+
+	response.SuccessResponse(w, "recent files retrieved successfully",
+		map[string]interface{}{
+			"recent":        results,
+			"total":         total,
+			"page":          pageNumber,
+			"rows_per_page": rowsPerPage,
+		})
+}
+
+func parsePage(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	query := r.URL.Query()
+	pageNumber, rowsPerPage := 1, defaultRowsPerPage
+	if pageQuery := query.Get("page"); pageQuery != "" {
+		page, err := strconv.Atoi(pageQuery)
+		if err != nil {
+			response.ErrorResponse(w, "page must be a number", http.StatusBadRequest)
+			return 0, 0, false
+		}
+		pageNumber = page
+	}
+	if rowQuery := query.Get("rows"); rowQuery != "" {
+		rows, err := strconv.Atoi(rowQuery)
+		if err != nil {
+			response.ErrorResponse(w, "rows must be a number", http.StatusBadRequest)
+			return 0, 0, false
+		}
+		rowsPerPage = rows
+	}
+	if pageNumber < 1 {
+		pageNumber = 1
+	}
+	if rowsPerPage < 1 || rowsPerPage > maxRowsPerPage {
+		rowsPerPage = defaultRowsPerPage
+	}
+	return pageNumber, rowsPerPage, true
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/olad5/file-fort/internal/domain"
+	fileHandlers "github.com/olad5/file-fort/internal/handlers/files"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func (a ActivityHandler) GetStarred(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	pageNumber, rowsPerPage, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	stars, total, err := a.activityService.GetStarred(ctx, pageNumber, rowsPerPage)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+		return
+	}
+
+	results := []map[string]interface{}{}
+	for _, star := range stars {
+		results = append(results, toResponseStar(star))
+	}
+
+	response.SuccessResponse(w, "starred items retrieved successfully",
+		map[string]interface{}{
+			"starred":       results,
+			"total":         total,
+			"page":          pageNumber,
+			"rows_per_page": rowsPerPage,
+		})
+}
+
+func toResponseStar(star domain.Star) map[string]interface{} {
+	var body map[string]interface{}
+	if star.Folder != nil {
+		body = fileHandlers.ToResponseFolder(*star.Folder)
+		body["type"] = "folder"
+	} else {
+		body = fileHandlers.ToResponseFile(*star.File)
+		body["type"] = "file"
+	}
+	body["starred_at"] = star.CreatedAt
+	return body
+}
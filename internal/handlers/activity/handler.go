@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/olad5/file-fort/internal/usecases/activity"
+)
+
+type ActivityHandler struct {
+	activityService activity.ActivityService
+}
+
+func NewActivityHandler(activityService activity.ActivityService) (*ActivityHandler, error) {
+	if activityService == (activity.ActivityService{}) {
+		return nil, errors.New("activity service cannot be empty")
+	}
+
+	return &ActivityHandler{activityService}, nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/infra"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func (a ActivityHandler) StarFile(w http.ResponseWriter, r *http.Request) {
+	a.updateStar(w, r, "file", a.activityService.StarFile, "file starred successfully")
+}
+
+func (a ActivityHandler) UnstarFile(w http.ResponseWriter, r *http.Request) {
+	a.updateStar(w, r, "file", a.activityService.UnstarFile, "file unstarred successfully")
+}
+
+func (a ActivityHandler) StarFolder(w http.ResponseWriter, r *http.Request) {
+	a.updateStar(w, r, "folder", a.activityService.StarFolder, "folder starred successfully")
+}
+
+func (a ActivityHandler) UnstarFolder(w http.ResponseWriter, r *http.Request) {
+	a.updateStar(w, r, "folder", a.activityService.UnstarFolder, "folder unstarred successfully")
+}
+
+func (a ActivityHandler) updateStar(w http.ResponseWriter, r *http.Request, targetType string, update func(context.Context, uuid.UUID) error, message string) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		response.ErrorResponse(w, targetType+" id required", http.StatusBadRequest)
+		return
+	}
+
+	targetId, err := uuid.Parse(id)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = update(ctx, targetId)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrFileNotFound), errors.Is(err, infra.ErrFolderNotFound):
+			response.ErrorResponse(w, targetType+" does not exist", http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrUserNotAuthorized):
+			response.ErrorResponse(w, "unauthorized to star this "+targetType, http.StatusForbidden)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, message,
+		map[string]interface{}{
+			targetType + "_id": targetId,
+		})
+}
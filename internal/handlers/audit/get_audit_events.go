@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/usecases/audit"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+const (
+	formatJson   = "json"
+	formatCsv    = "csv"
+	formatNdjson = "ndjson"
+)
+
+func (a AuditHandler) GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	var filter infra.AuditEventFilter
+
+	if actorQuery := query.Get("actor_id"); actorQuery != "" {
+		actorId, err := uuid.Parse(actorQuery)
+		if err != nil {
+			response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.ActorId = &actorId
+	}
+	filter.TargetType = query.Get("target_type")
+	filter.TargetId = query.Get("target_id")
+
+	if fromQuery := query.Get("from"); fromQuery != "" {
+		from, err := time.Parse(time.RFC3339, fromQuery)
+		if err != nil {
+			response.ErrorResponse(w, "from must be an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		filter.From = &from
+	}
+	if toQuery := query.Get("to"); toQuery != "" {
+		to, err := time.Parse(time.RFC3339, toQuery)
+		if err != nil {
+			response.ErrorResponse(w, "to must be an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		filter.To = &to
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = formatJson
+	}
+	if format != formatJson && format != formatCsv && format != formatNdjson {
+		response.ErrorResponse(w, "format must be one of json, csv or ndjson", http.StatusBadRequest)
+		return
+	}
+
+	pageNumber, rowsPerPage := 1, 50
+	if format == formatJson {
+		if pageQuery := query.Get("page"); pageQuery != "" {
+			page, err := strconv.Atoi(pageQuery)
+			if err != nil {
+				response.ErrorResponse(w, "page must be a number", http.StatusBadRequest)
+				return
+			}
+			pageNumber = page
+		}
+		if rowQuery := query.Get("rows"); rowQuery != "" {
+			rows, err := strconv.Atoi(rowQuery)
+			if err != nil {
+				response.ErrorResponse(w, "rows must be a number", http.StatusBadRequest)
+				return
+			}
+			rowsPerPage = rows
+		}
+
+		if pageNumber < 1 {
+			pageNumber = 1
+		}
+		if rowsPerPage < 1 || rowsPerPage > 100 {
+			rowsPerPage = 50
+		}
+		filter.Limit = rowsPerPage
+		filter.Offset = (pageNumber - 1) * rowsPerPage
+	}
+
+	events, err := a.auditService.GetAuditEvents(ctx, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, audit.ErrInvalidTimeRange):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	switch format {
+	case formatCsv:
+		writeCsv(w, events)
+	case formatNdjson:
+		writeNdjson(w, events)
+	default:
+		results := []map[string]interface{}{}
+		for _, event := range events {
+			results = append(results, toResponseAuditEvent(event))
+		}
+
+		response.SuccessResponse(w, "audit events retrieved successfully",
+			map[string]interface{}{
+				"events":        results,
+				"page":          pageNumber,
+				"rows_per_page": rowsPerPage,
+			})
+	}
+}
+
+func writeCsv(w http.ResponseWriter, events []domain.AuditEvent) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit_events.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	records := [][]string{{"id", "actor_id", "actor_email", "action", "target_type", "target_id", "ip_address", "user_agent", "created_at"}}
+	for _, event := range events {
+		actorId := ""
+		if event.ActorId != nil {
+			actorId = event.ActorId.String()
+		}
+		records = append(records, []string{
+			event.ID.String(),
+			actorId,
+			event.ActorEmail,
+			string(event.Action),
+			string(event.TargetType),
+			event.TargetId,
+			event.IpAddress,
+			event.UserAgent,
+			event.CreatedAt.Format(time.RFC3339Nano),
+		})
+	}
+	if err := writer.WriteAll(records); err != nil {
+		log.Printf("Error sending response: %v", err)
+	}
+}
+
+func writeNdjson(w http.ResponseWriter, events []domain.AuditEvent) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit_events.ndjson"`)
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	for _, event := range events {
+		if err := encoder.Encode(toResponseAuditEvent(event)); err != nil {
+			log.Printf("Error sending response: %v", err)
+			return
+		}
+	}
+}
+
+func toResponseAuditEvent(event domain.AuditEvent) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          event.ID,
+		"actor_id":    event.ActorId,
+		"actor_email": event.ActorEmail,
+		"action":      event.Action,
+		"target_type": event.TargetType,
+		"target_id":   event.TargetId,
+		"ip_address":  event.IpAddress,
+		"user_agent":  event.UserAgent,
+		"created_at":  event.CreatedAt,
+	}
+}
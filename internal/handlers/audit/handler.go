@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/olad5/file-fort/internal/usecases/audit"
+)
+
+type AuditHandler struct {
+	auditService audit.AuditService
+}
+
+func NewAuditHandler(auditService audit.AuditService) (*AuditHandler, error) {
+	if auditService == (audit.AuditService{}) {
+		return nil, errors.New("audit service cannot be empty")
+	}
+
+	return &AuditHandler{auditService}, nil
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/olad5/file-fort/internal/services/audit"
+)
+
+func CaptureRequestInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ipAddress, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ipAddress = r.RemoteAddr
+		}
+
+		ctx := audit.Set(r.Context(), audit.RequestInfo{
+			IpAddress: ipAddress,
+			UserAgent: r.UserAgent(),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/services/auth"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+const (
+	// verifiedPasswordTTL is how long a checked email and password are
+	// trusted without checking them again. Clients such as WebDAV mounts send
+	// them with every request, and checking a password hash is slow.
+	verifiedPasswordTTL = time.Minute
+	// maxFailedLogins wrong passwords within failedLoginWindow lock the email
+	// out of Basic authentication until the window ends.
+	maxFailedLogins   = 10
+	failedLoginWindow = 15 * time.Minute
+	// sweepThreshold is how many entries either map holds before expired
+	// ones are removed.
+	sweepThreshold = 1024
+)
+
+var (
+	errNotAuthenticated    = errors.New(appErrors.ErrUnauthorized)
+	errTooManyFailedLogins = errors.New("too many failed login attempts, try again later")
+)
+
+// PasswordAuthenticator checks a user's email and password.
+type PasswordAuthenticator interface {
+	Authenticate(ctx context.Context, email, password string) (domain.User, error)
+}
+
+// EnsureBasicAuthenticated is EnsureAuthenticated for clients, such as
+// WebDAV mounts, that can only be configured with a username and password.
+// Besides a bearer token it accepts Basic credentials, where the password is
+// either the user's password or an access token from /users/login.
+func EnsureBasicAuthenticated(authService auth.AuthService, authenticator PasswordAuthenticator, realm string) func(next http.Handler) http.Handler {
+	passwords := newPasswordCache()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			jwtClaims, err := basicAuthClaims(r, authService, authenticator, passwords)
+			if errors.Is(err, errTooManyFailedLogins) {
+				w.Header().Set("Retry-After", strconv.Itoa(int(failedLoginWindow.Seconds())))
+				response.ErrorResponse(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+				response.ErrorResponse(w, appErrors.ErrUnauthorized, http.StatusUnauthorized)
+				return
+			}
+
+			ctx = auth.Set(ctx, jwtClaims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func basicAuthClaims(r *http.Request, authService auth.AuthService, authenticator PasswordAuthenticator, passwords *passwordCache) (auth.JWTClaims, error) {
+	ctx := r.Context()
+	authHeader := r.Header.Get("Authorization")
+
+	email, password, ok := r.BasicAuth()
+	if !ok {
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			return auth.JWTClaims{}, errNotAuthenticated
+		}
+		return tokenClaims(ctx, authService, authHeader)
+	}
+
+	if jwtClaims, err := tokenClaims(ctx, authService, "Bearer "+password); err == nil {
+		return jwtClaims, nil
+	}
+
+	if jwtClaims, ok := passwords.verified(email, password); ok {
+		return jwtClaims, nil
+	}
+	if passwords.lockedOut(email) {
+		return auth.JWTClaims{}, errTooManyFailedLogins
+	}
+	user, err := authenticator.Authenticate(ctx, email, password)
+	if err != nil {
+		passwords.failed(email)
+		return auth.JWTClaims{}, err
+	}
+	jwtClaims := auth.JWTClaims{ID: user.ID, Role: user.Role, Email: user.Email}
+	passwords.succeeded(email, password, jwtClaims)
+	return jwtClaims, nil
+}
+
+func tokenClaims(ctx context.Context, authService auth.AuthService, authHeader string) (auth.JWTClaims, error) {
+	jwtClaims, err := authService.DecodeJWT(ctx, authHeader)
+	if err != nil {
+		return auth.JWTClaims{}, err
+	}
+	if isUserLoggedIn := authService.IsUserLoggedIn(ctx, authHeader, jwtClaims.ID.String()); !isUserLoggedIn {
+		return auth.JWTClaims{}, errNotAuthenticated
+	}
+	return jwtClaims, nil
+}
+
+// passwordCache remembers recently checked Basic credentials and recent
+// failures for each email. It is held in memory, so each server instance
+// counts failures on its own.
+type passwordCache struct {
+	mu sync.Mutex
+	// byCredentials is keyed by a hash of the email and password, so that
+	// passwords are not kept in memory.
+	byCredentials map[[sha256.Size]byte]verifiedPassword
+	failures      map[string]failedLogins
+}
+
+type verifiedPassword struct {
+	claims    auth.JWTClaims
+	expiresAt time.Time
+}
+
+type failedLogins struct {
+	count     int
+	expiresAt time.Time
+}
+
+func newPasswordCache() *passwordCache {
+	return &passwordCache{
+		byCredentials: map[[sha256.Size]byte]verifiedPassword{},
+		failures:      map[string]failedLogins{},
+	}
+}
+
+func credentialsKey(email, password string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(strings.ToLower(email) + "\x00" + password))
+}
+
+func (p *passwordCache) verified(email, password string) (auth.JWTClaims, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.byCredentials[credentialsKey(email, password)]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return auth.JWTClaims{}, false
+	}
+	return entry.claims, true
+}
+
+func (p *passwordCache) lockedOut(email string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.failures[strings.ToLower(email)]
+	return ok && entry.count >= maxFailedLogins && time.Now().Before(entry.expiresAt)
+}
+
+func (p *passwordCache) failed(email string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	p.sweep(now)
+	email = strings.ToLower(email)
+	entry := p.failures[email]
+	if now.After(entry.expiresAt) {
+		entry = failedLogins{expiresAt: now.Add(failedLoginWindow)}
+	}
+	entry.count++
+	p.failures[email] = entry
+}
+
+func (p *passwordCache) succeeded(email, password string, claims auth.JWTClaims) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	p.sweep(now)
+	delete(p.failures, strings.ToLower(email))
+	p.byCredentials[credentialsKey(email, password)] = verifiedPassword{claims, now.Add(verifiedPasswordTTL)}
+}
+
+// sweep removes expired entries once either map has grown past
+// sweepThreshold.
+func (p *passwordCache) sweep(now time.Time) {
+	if len(p.byCredentials) >= sweepThreshold {
+		for key, entry := range p.byCredentials {
+			if now.After(entry.expiresAt) {
+				delete(p.byCredentials, key)
+			}
+		}
+	}
+	if len(p.failures) >= sweepThreshold {
+		for email, entry := range p.failures {
+			if now.After(entry.expiresAt) {
+				delete(p.failures, email)
+			}
+		}
+	}
+}
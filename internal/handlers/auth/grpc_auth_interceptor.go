@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/olad5/file-fort/internal/services/auth"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// EnsureAuthenticatedUnary is EnsureAuthenticated for unary gRPC calls, which
+// send the token as the authorization metadata. The methods in publicMethods,
+// given by their full names, are let through without one.
+func EnsureAuthenticatedUnary(authService auth.AuthService, publicMethods ...string) grpc.UnaryServerInterceptor {
+	public := toMethodSet(publicMethods)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if public[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticateMetadata(ctx, authService)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// EnsureAuthenticatedStream is EnsureAuthenticatedUnary for streaming gRPC
+// calls.
+func EnsureAuthenticatedStream(authService auth.AuthService, publicMethods ...string) grpc.StreamServerInterceptor {
+	public := toMethodSet(publicMethods)
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if public[info.FullMethod] {
+			return handler(srv, stream)
+		}
+
+		ctx, err := authenticateMetadata(stream.Context(), authService)
+		if err != nil {
+			return err
+		}
+		return handler(srv, authenticatedStream{stream, ctx})
+	}
+}
+
+func authenticateMetadata(ctx context.Context, authService auth.AuthService) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, appErrors.ErrUnauthorized)
+	}
+	authHeader := values[0]
+
+	jwtClaims, err := authService.DecodeJWT(ctx, authHeader)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, appErrors.ErrUnauthorized)
+	}
+
+	userId := jwtClaims.ID.String()
+	if isUserLoggedIn := authService.IsUserLoggedIn(ctx, authHeader, userId); !isUserLoggedIn {
+		return nil, status.Error(codes.Unauthenticated, appErrors.ErrUnauthorized)
+	}
+
+	return auth.Set(ctx, jwtClaims), nil
+}
+
+func toMethodSet(methods []string) map[string]bool {
+	set := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		set[method] = true
+	}
+	return set
+}
+
+// authenticatedStream is a stream whose context carries the caller's claims.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/infra"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+// Archive streams a folder and everything below it as a zip. Files are read
+// from the file store one at a time as the archive is written, so an error
+// part way through can only be logged and leaves the client with a truncated
+// archive.
+func (f FileHandler) Archive(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		response.ErrorResponse(w, "folder id required", http.StatusBadRequest)
+		return
+	}
+
+	folderId, err := uuid.Parse(id)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	folder, entries, err := f.fileService.GetFolderArchive(ctx, folderId)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrFolderNotFound):
+			response.ErrorResponse(w, "folder does not exist", http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrUserNotAuthorized):
+			response.ErrorResponse(w, "unauthorized to view this folder", http.StatusForbidden)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	disposition := "attachment"
+	if formatted := mime.FormatMediaType(disposition, map[string]string{"filename": folder.FolderName + ".zip"}); formatted != "" {
+		disposition = formatted
+	}
+	header := w.Header()
+	header.Set("Content-Type", "application/zip")
+	header.Set("Content-Disposition", disposition)
+	header.Set("Cache-Control", "private")
+	w.WriteHeader(http.StatusOK)
+	if err := f.fileService.WriteArchive(ctx, entries, w); err != nil {
+		log.Printf("Error streaming archive of folder %s: %v", folder.ID, err)
+	}
+}
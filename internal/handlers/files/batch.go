@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/usecases/files"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+// Batch runs a list of file operations. The request only fails as a whole
+// when it is malformed; otherwise each operation gets its own result, with
+// the status code the matching single file endpoint would have returned.
+func (f FileHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	type operationDTO struct {
+		Op       string   `json:"op"`
+		FileId   string   `json:"file_id"`
+		FolderId string   `json:"folder_id"`
+		Tags     []string `json:"tags"`
+	}
+	type requestDTO struct {
+		Operations []operationDTO `json:"operations"`
+	}
+
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+
+	operations := []files.BatchOperation{}
+	for i, element := range request.Operations {
+		if element.Op == "" {
+			response.ErrorResponse(w, fmt.Sprintf("operations[%d]: op required", i), http.StatusBadRequest)
+			return
+		}
+		fileId, err := uuid.Parse(element.FileId)
+		if err != nil {
+			response.ErrorResponse(w, fmt.Sprintf("operations[%d]: %s", i, appErrors.ErrInvalidID), http.StatusBadRequest)
+			return
+		}
+		operation := files.BatchOperation{Type: files.BatchOperationType(element.Op), FileId: fileId, Tags: element.Tags}
+		if element.FolderId != "" {
+			folderId, err := uuid.Parse(element.FolderId)
+			if err != nil {
+				response.ErrorResponse(w, fmt.Sprintf("operations[%d]: %s", i, appErrors.ErrInvalidID), http.StatusBadRequest)
+				return
+			}
+			operation.FolderId = &folderId
+		}
+		operations = append(operations, operation)
+	}
+
+	results, err := f.fileService.ExecuteBatch(ctx, operations)
+	if err != nil {
+		switch {
+		case errors.Is(err, files.ErrEmptyBatch),
+			errors.Is(err, files.ErrTooManyOperations):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	succeeded := 0
+	responseResults := []map[string]interface{}{}
+	for i, result := range results {
+		status, message := batchResultStatus(result.Err)
+		if result.Err == nil {
+			succeeded++
+		}
+		body := map[string]interface{}{
+			"index":   i,
+			"op":      result.Operation.Type,
+			"file_id": result.Operation.FileId,
+			"status":  status,
+			"message": message,
+		}
+		if result.File != nil {
+			body["file"] = ToResponseFile(*result.File)
+		}
+		responseResults = append(responseResults, body)
+	}
+
+	response.SuccessResponse(w, "batch processed",
+		map[string]interface{}{
+			"results":   responseResults,
+			"succeeded": succeeded,
+			"failed":    len(results) - succeeded,
+		})
+}
+
+func batchResultStatus(err error) (int, string) {
+	switch {
+	case err == nil:
+		return http.StatusOK, "ok"
+	case errors.Is(err, files.ErrUnsupportedOperation),
+		errors.Is(err, files.ErrTargetFolderRequired),
+		errors.Is(err, files.ErrDuplicateFile),
+		errors.Is(err, files.ErrAlreadyInFolder),
+		errors.Is(err, files.ErrTagsRequired),
+		errors.Is(err, files.ErrInvalidTag),
+		errors.Is(err, domain.ErrTooManyTags):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, infra.ErrFileNotFound):
+		return http.StatusNotFound, "file does not exist"
+	case errors.Is(err, infra.ErrFolderNotFound):
+		return http.StatusNotFound, "folder does not exist"
+	case errors.Is(err, infra.ErrUserNotAuthorized):
+		return http.StatusForbidden, "unauthorized to modify this file or folder"
+	case errors.Is(err, infra.ErrQuotaExceeded):
+		return http.StatusRequestEntityTooLarge, "storage quota exceeded"
+	default:
+		return http.StatusInternalServerError, appErrors.ErrSomethingWentWrong
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/usecases/files"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+const (
+	defaultChangeLimit = 100
+	maxChangeLimit     = 1000
+	// maxChangeWait stays below the idle timeouts of common proxies, so a
+	// long-poll is answered before the connection is dropped.
+	maxChangeWait = 55 * time.Second
+)
+
+// GetChanges lists the caller's changes after the cursor of a previous
+// response, or of a folder tree. With wait, the number of seconds to
+// long-poll for, a request with no changes to return is held open until
+// there are some or the wait is over.
+func (f FileHandler) GetChanges(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	limit := defaultChangeLimit
+	if limitQuery := query.Get("limit"); limitQuery != "" {
+		var err error
+		limit, err = strconv.Atoi(limitQuery)
+		if err != nil {
+			response.ErrorResponse(w, "limit must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+	if limit < 1 {
+		limit = defaultChangeLimit
+	} else if limit > maxChangeLimit {
+		limit = maxChangeLimit
+	}
+
+	var wait time.Duration
+	if waitQuery := query.Get("wait"); waitQuery != "" {
+		seconds, err := strconv.Atoi(waitQuery)
+		if err != nil || seconds < 0 {
+			response.ErrorResponse(w, "wait must be a number of seconds", http.StatusBadRequest)
+			return
+		}
+		wait = time.Duration(seconds) * time.Second
+		if wait > maxChangeWait {
+			wait = maxChangeWait
+		}
+	}
+
+	page, err := f.fileService.WaitForChanges(ctx, query.Get("cursor"), limit, wait)
+	if err != nil {
+		switch {
+		case errors.Is(err, files.ErrInvalidCursor):
+			response.ErrorResponse(w, "invalid cursor", http.StatusBadRequest)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	changes := []map[string]interface{}{}
+	for _, change := range page.Changes {
+		changes = append(changes, ToResponseChange(change))
+	}
+	response.SuccessResponse(w, "changes retrieved successfully",
+		map[string]interface{}{
+			"changes":  changes,
+			"cursor":   page.Cursor,
+			"has_more": page.HasMore,
+		})
+}
+
+// GetFolderTree lists everything below a folder, with the cursor of the
+// change feed to follow it with.
+func (f FileHandler) GetFolderTree(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		response.ErrorResponse(w, "folder id required", http.StatusBadRequest)
+		return
+	}
+
+	folderId, err := uuid.Parse(id)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tree, err := f.fileService.GetFolderTree(ctx, folderId)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrFolderNotFound):
+			response.ErrorResponse(w, "folder does not exist", http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrUserNotAuthorized):
+			response.ErrorResponse(w, "unauthorized to view this folder", http.StatusForbidden)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	folders := []map[string]interface{}{}
+	for _, treeFolder := range tree.Folders {
+		folder := ToResponseFolder(treeFolder.Folder)
+		folder["path"] = treeFolder.Path
+		folders = append(folders, folder)
+	}
+	treeFiles := []map[string]interface{}{}
+	for _, treeFile := range tree.Files {
+		file := ToResponseFile(treeFile.File)
+		file["path"] = treeFile.Path
+		treeFiles = append(treeFiles, file)
+	}
+	response.SuccessResponse(w, "folder tree retrieved successfully",
+		map[string]interface{}{
+			"folder":  ToResponseFolder(tree.Folder),
+			"folders": folders,
+			"files":   treeFiles,
+			"cursor":  tree.Cursor,
+		})
+}
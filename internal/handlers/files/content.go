@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+// Content streams a file through the server, for clients that cannot reach
+// the file store directly. A single byte range may be requested; requests for
+// several ranges are answered with the whole file.
+func (f FileHandler) Content(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		response.ErrorResponse(w, "file id required", http.StatusBadRequest)
+		return
+	}
+
+	fileId, err := uuid.Parse(id)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	file, object, err := f.fileService.StatFile(ctx, fileId)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrFileNotFound):
+			response.ErrorResponse(w, "file does not exist", http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrUserNotAuthorized):
+			response.ErrorResponse(w, "unauthorized to view this file", http.StatusForbidden)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	header := w.Header()
+	header.Set("Accept-Ranges", "bytes")
+	header.Set("Cache-Control", "private")
+	header.Set("Last-Modified", object.LastModified.UTC().Format(http.TimeFormat))
+	if object.ETag != "" {
+		header.Set("ETag", object.ETag)
+	}
+
+	if object.ETag != "" && etagMatches(r.Header.Get("If-None-Match"), object.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	offset, length, status := int64(0), object.Size, http.StatusOK
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" && ifRangeMatches(r.Header.Get("If-Range"), object) {
+		requested, ok, err := parseRange(rangeHeader, object.Size)
+		if err != nil {
+			header.Set("Content-Range", "bytes */"+strconv.FormatInt(object.Size, 10))
+			response.ErrorResponse(w, "requested range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		if ok {
+			offset, length, status = requested.start, requested.length, http.StatusPartialContent
+			header.Set("Content-Range", requested.contentRange(object.Size))
+		}
+	}
+
+	var body io.ReadCloser = http.NoBody
+	if length > 0 && r.Method != http.MethodHead {
+		body, err = f.fileService.ReadFile(ctx, file, offset, length)
+		if err != nil {
+			header.Del("Content-Range")
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+	defer body.Close()
+
+	header.Set("Content-Type", contentTypeOf(file))
+	header.Set("Content-Disposition", contentDisposition(file, r.URL.Query().Get("inline") == "true"))
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Length", strconv.FormatInt(length, 10))
+	w.WriteHeader(status)
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("Error streaming file %s: %v", file.ID, err)
+	}
+}
+
+func contentTypeOf(file domain.File) string {
+	if file.ContentType == "" {
+		return "application/octet-stream"
+	}
+	return file.ContentType
+}
+
+func contentDisposition(file domain.File, inline bool) string {
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
+	if formatted := mime.FormatMediaType(disposition, map[string]string{"filename": file.FileName}); formatted != "" {
+		return formatted
+	}
+	return disposition
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using
+// weak comparison as RFC 9110 requires for If-None-Match.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ifRangeMatches reports whether a Range header should be honoured, that is
+// when there is no If-Range header or it still identifies the stored object.
+func ifRangeMatches(header string, object domain.StoredObject) bool {
+	if header == "" {
+		return true
+	}
+	if strings.HasPrefix(header, `"`) {
+		return object.ETag != "" && header == object.ETag
+	}
+	return header == object.LastModified.UTC().Format(http.TimeFormat)
+}
+
+var errUnsatisfiableRange = errors.New("unsatisfiable range")
+
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+func (b byteRange) contentRange(size int64) string {
+	return "bytes " + strconv.FormatInt(b.start, 10) + "-" + strconv.FormatInt(b.start+b.length-1, 10) + "/" + strconv.FormatInt(size, 10)
+}
+
+// parseRange parses a single range of a Range header. It returns false when
+// the header should be ignored, because it is malformed or asks for several
+// ranges, and errUnsatisfiableRange when no byte of the range exists.
+func parseRange(header string, size int64) (byteRange, bool, error) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return byteRange{}, false, nil
+	}
+
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return byteRange{}, false, nil
+	}
+
+	if first == "" {
+		suffix, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || suffix < 0 {
+			return byteRange{}, false, nil
+		}
+		if suffix == 0 || size == 0 {
+			return byteRange{}, false, errUnsatisfiableRange
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return byteRange{start: size - suffix, length: suffix}, true, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return byteRange{}, false, nil
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return byteRange{}, false, nil
+		}
+		if end > size-1 {
+			end = size - 1
+		}
+	}
+	if start >= size {
+		return byteRange{}, false, errUnsatisfiableRange
+	}
+	return byteRange{start: start, length: end - start + 1}, true, nil
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/infra"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+// Copy copies a file within the file store. Without a folder_id the copy is
+// made in the same folder as the file.
+func (f FileHandler) Copy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		response.ErrorResponse(w, "file id required", http.StatusBadRequest)
+		return
+	}
+
+	fileId, err := uuid.Parse(id)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	type requestDTO struct {
+		FolderId string `json:"folder_id"`
+	}
+
+	var request requestDTO
+	if r.Body != nil {
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil && !errors.Is(err, io.EOF) {
+			response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+			return
+		}
+	}
+
+	var folderId *uuid.UUID
+	if request.FolderId != "" {
+		id, err := uuid.Parse(request.FolderId)
+		if err != nil {
+			response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+			return
+		}
+		folderId = &id
+	}
+
+	copiedFile, err := f.fileService.CopyFile(ctx, fileId, folderId)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrFileNotFound):
+			response.ErrorResponse(w, "file does not exist", http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrFolderNotFound):
+			response.ErrorResponse(w, "folder does not exist", http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrUserNotAuthorized):
+			response.ErrorResponse(w, "unauthorized to copy this file", http.StatusForbidden)
+			return
+		case errors.Is(err, infra.ErrQuotaExceeded):
+			response.ErrorResponse(w, "storage quota exceeded", http.StatusRequestEntityTooLarge)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "file copied successfully", ToResponseFile(copiedFile))
+}
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/infra"
 	appErrors "github.com/olad5/file-fort/pkg/errors"
 
 	response "github.com/olad5/file-fort/pkg/utils"
@@ -18,6 +21,7 @@ func (f FileHandler) CreateFolder(w http.ResponseWriter, r *http.Request) {
 	}
 	type requestDTO struct {
 		FolderName string `json:"folder_name"`
+		ParentId   string `json:"parent_id"`
 	}
 
 	var request requestDTO
@@ -31,21 +35,30 @@ func (f FileHandler) CreateFolder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newFolder, err := f.fileService.CreateFolder(ctx, request.FolderName)
+	var parentId *uuid.UUID
+	if request.ParentId != "" {
+		id, err := uuid.Parse(request.ParentId)
+		if err != nil {
+			response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+			return
+		}
+		parentId = &id
+	}
+
+	newFolder, err := f.fileService.CreateFolder(ctx, request.FolderName, parentId)
 	if err != nil {
 		switch {
+		case errors.Is(err, infra.ErrFolderNotFound):
+			response.ErrorResponse(w, "parent folder does not exist", http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrUserNotAuthorized):
+			response.ErrorResponse(w, "unauthorized to use this folder", http.StatusForbidden)
+			return
 		default:
 			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
 			return
 		}
 	}
 
-	response.SuccessResponse(w, "folder created successfully",
-		map[string]interface{}{
-			"id":          newFolder.ID,
-			"folder_name": newFolder.FolderName,
-			"owner_id":    newFolder.OwnerId,
-			"created_at":  newFolder.CreatedAt,
-			"updated_at":  newFolder.UpdatedAt,
-		})
+	response.SuccessResponse(w, "folder created successfully", ToResponseFolder(newFolder))
 }
@@ -7,12 +7,19 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
 	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/usecases/files"
 	appErrors "github.com/olad5/file-fort/pkg/errors"
 
 	response "github.com/olad5/file-fort/pkg/utils"
 )
 
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
 func (f FileHandler) GetFilesByFolderId(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	id := chi.URLParam(r, "id")
@@ -27,41 +34,59 @@ func (f FileHandler) GetFilesByFolderId(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	pageQuery := r.URL.Query().Get("page")
-	if pageQuery == "" {
-		response.ErrorResponse(w, "page required", http.StatusBadRequest)
+	query := r.URL.Query()
+
+	// page was replaced by cursor; the first page is still accepted so that
+	// older clients keep working.
+	if pageQuery := query.Get("page"); pageQuery != "" && pageQuery != "1" {
+		response.ErrorResponse(w, "page is no longer supported, use the next_cursor of the previous page", http.StatusBadRequest)
 		return
+	}
 
+	limit := defaultListLimit
+	limitQuery := query.Get("limit")
+	if limitQuery == "" {
+		limitQuery = query.Get("rows")
+	}
+	if limitQuery != "" {
+		limit, err = strconv.Atoi(limitQuery)
+		if err != nil {
+			response.ErrorResponse(w, "limit must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+	if limit < 1 {
+		limit = defaultListLimit
+	} else if limit > maxListLimit {
+		limit = maxListLimit
 	}
 
-	pageNumber, err := strconv.Atoi(pageQuery)
-	if err != nil {
-		response.ErrorResponse(w, "page required", http.StatusBadRequest)
-		return
+	sortBy := infra.SortByCreatedAt
+	if sortQuery := query.Get("sort"); sortQuery != "" {
+		sortBy = infra.FileSortField(sortQuery)
+		if !sortBy.IsValid() {
+			response.ErrorResponse(w, "sort must be one of name, size or created_at", http.StatusBadRequest)
+			return
+		}
 	}
-	rowQuery := r.URL.Query().Get("rows")
-	if pageQuery == "" {
-		response.ErrorResponse(w, "rows required", http.StatusBadRequest)
-		return
 
+	order := query.Get("order")
+	if order == "" {
+		order = "asc"
 	}
-	rowsPerPage, err := strconv.Atoi(rowQuery)
-	if err != nil {
-		response.ErrorResponse(w, "rows required", http.StatusBadRequest)
+	if order != "asc" && order != "desc" {
+		response.ErrorResponse(w, "order must be one of asc or desc", http.StatusBadRequest)
 		return
 	}
 
-	if pageNumber < 1 {
-		pageNumber = 1
-	}
-
-	if rowsPerPage < 1 {
-		rowsPerPage = 20
-	} else if rowsPerPage > 20 {
-		rowsPerPage = 20
+	listQuery := infra.FileListQuery{
+		SortBy:     sortBy,
+		Descending: order == "desc",
+		Tags:       query["tag"],
+		Limit:      limit,
 	}
 
-	files, err := f.fileService.GetFilesByFolderId(ctx, folderId, pageNumber, rowsPerPage)
+	page, err := f.fileService.GetFilesByFolderId(ctx, folderId, listQuery, query.Get("cursor"))
 	if err != nil {
 		switch {
 		case errors.Is(err, infra.ErrFolderNotFound):
@@ -70,24 +95,37 @@ func (f FileHandler) GetFilesByFolderId(w http.ResponseWriter, r *http.Request)
 		case errors.Is(err, infra.ErrUserNotAuthorized):
 			response.ErrorResponse(w, "unauthorized to view this folder", http.StatusForbidden)
 			return
+		case errors.Is(err, files.ErrInvalidCursor):
+			response.ErrorResponse(w, "invalid cursor", http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrTooManyTags):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
 		default:
 			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
 			return
 		}
 	}
 
-	var results []map[string]interface{}
-	for _, file := range files {
-		fileData := ToResponseFile(file)
-		results = append(results, fileData)
+	results := []map[string]interface{}{}
+	for _, file := range page.Files {
+		results = append(results, ToResponseFile(file))
+	}
+
+	var nextCursor interface{}
+	if page.NextCursor != "" {
+		nextCursor = page.NextCursor
 	}
 
 	response.SuccessResponse(w, "files retreived successfully",
 		map[string]interface{}{
-			"owner_id":      files[0].OwnerId,
-			"folder_id":     folderId,
-			"files":         results,
-			"page":          pageNumber,
-			"rows_per_page": rowsPerPage,
+			"owner_id":    page.Folder.OwnerId,
+			"folder_id":   folderId,
+			"files":       results,
+			"total":       page.Total,
+			"next_cursor": nextCursor,
+			"limit":       limit,
+			"sort":        sortBy,
+			"order":       order,
 		})
 }
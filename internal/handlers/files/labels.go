@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/usecases/files"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func (f FileHandler) GetFileLabels(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	fileId, ok := parseLabelsTargetId(w, r, "file id required")
+	if !ok {
+		return
+	}
+
+	file, err := f.fileService.GetFile(ctx, fileId)
+	if err != nil {
+		writeLabelsError(w, err, "file does not exist", "unauthorized to view this file")
+		return
+	}
+
+	response.SuccessResponse(w, "labels retrieved successfully", ToResponseFile(file))
+}
+
+// UpdateFileLabels replaces the tags and metadata of a file on PUT, and
+// changes them in place on PATCH.
+func (f FileHandler) UpdateFileLabels(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	fileId, ok := parseLabelsTargetId(w, r, "file id required")
+	if !ok {
+		return
+	}
+	update, ok := decodeLabelUpdate(w, r)
+	if !ok {
+		return
+	}
+
+	file, err := f.fileService.UpdateFileLabels(ctx, fileId, update)
+	if err != nil {
+		writeLabelsError(w, err, "file does not exist", "unauthorized to update this file")
+		return
+	}
+
+	response.SuccessResponse(w, "labels updated successfully", ToResponseFile(file))
+}
+
+func (f FileHandler) GetFolderLabels(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	folderId, ok := parseLabelsTargetId(w, r, "folder id required")
+	if !ok {
+		return
+	}
+
+	folder, err := f.fileService.GetFolder(ctx, folderId)
+	if err != nil {
+		writeLabelsError(w, err, "folder does not exist", "unauthorized to view this folder")
+		return
+	}
+
+	response.SuccessResponse(w, "labels retrieved successfully", ToResponseFolder(folder))
+}
+
+// UpdateFolderLabels replaces the tags and metadata of a folder on PUT, and
+// changes them in place on PATCH.
+func (f FileHandler) UpdateFolderLabels(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	folderId, ok := parseLabelsTargetId(w, r, "folder id required")
+	if !ok {
+		return
+	}
+	update, ok := decodeLabelUpdate(w, r)
+	if !ok {
+		return
+	}
+
+	folder, err := f.fileService.UpdateFolderLabels(ctx, folderId, update)
+	if err != nil {
+		writeLabelsError(w, err, "folder does not exist", "unauthorized to update this folder")
+		return
+	}
+
+	response.SuccessResponse(w, "labels updated successfully", ToResponseFolder(folder))
+}
+
+func parseLabelsTargetId(w http.ResponseWriter, r *http.Request, missingMessage string) (uuid.UUID, bool) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		response.ErrorResponse(w, missingMessage, http.StatusBadRequest)
+		return uuid.UUID{}, false
+	}
+
+	targetId, err := uuid.Parse(id)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return uuid.UUID{}, false
+	}
+	return targetId, true
+}
+
+// decodeLabelUpdate reads {"tags": [...], "metadata": {...}} on PUT, and
+// {"add_tags": [...], "remove_tags": [...], "metadata": {...}} on PATCH,
+// where a null metadata value removes the key.
+func decodeLabelUpdate(w http.ResponseWriter, r *http.Request) (files.LabelUpdate, bool) {
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return files.LabelUpdate{}, false
+	}
+	type requestDTO struct {
+		Tags       []string           `json:"tags"`
+		AddTags    []string           `json:"add_tags"`
+		RemoveTags []string           `json:"remove_tags"`
+		Metadata   map[string]*string `json:"metadata"`
+	}
+
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return files.LabelUpdate{}, false
+	}
+
+	if r.Method == http.MethodPut {
+		for _, value := range request.Metadata {
+			if value == nil {
+				response.ErrorResponse(w, "metadata values must be strings", http.StatusBadRequest)
+				return files.LabelUpdate{}, false
+			}
+		}
+		return files.LabelUpdate{
+			ReplaceTags:     true,
+			Tags:            request.Tags,
+			ReplaceMetadata: true,
+			Metadata:        request.Metadata,
+		}, true
+	}
+	return files.LabelUpdate{
+		Tags:       request.AddTags,
+		RemoveTags: request.RemoveTags,
+		Metadata:   request.Metadata,
+	}, true
+}
+
+func writeLabelsError(w http.ResponseWriter, err error, notFoundMessage, unauthorizedMessage string) {
+	switch {
+	case errors.Is(err, infra.ErrFileNotFound), errors.Is(err, infra.ErrFolderNotFound):
+		response.ErrorResponse(w, notFoundMessage, http.StatusNotFound)
+	case errors.Is(err, infra.ErrUserNotAuthorized):
+		response.ErrorResponse(w, unauthorizedMessage, http.StatusForbidden)
+	case errors.Is(err, files.ErrInvalidTag),
+		errors.Is(err, domain.ErrTooManyTags),
+		errors.Is(err, files.ErrInvalidMetadataKey),
+		errors.Is(err, files.ErrMetadataValueTooLong),
+		errors.Is(err, files.ErrTooManyMetadataEntries):
+		response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+	default:
+		response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+	}
+}
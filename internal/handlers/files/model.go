@@ -9,10 +9,52 @@ func ToResponseFile(file domain.File) map[string]interface{} {
 		"id":              file.ID,
 		"file_name":       file.FileName,
 		"file_size":       file.FileSize,
+		"content_type":    file.ContentType,
+		"checksum":        file.Checksum,
 		"file_store_link": file.FileStoreKey,
 		"owner_id":        file.OwnerId,
 		"folder_id":       file.FolderId,
+		"tags":            toResponseTags(file.Tags),
+		"metadata":        toResponseMetadata(file.Metadata),
 		"created_at":      file.CreatedAt,
 		"updated_at":      file.UpdatedAt,
 	}
 }
+
+func ToResponseFolder(folder domain.Folder) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          folder.ID,
+		"folder_name": folder.FolderName,
+		"owner_id":    folder.OwnerId,
+		"parent_id":   folder.ParentId,
+		"tags":        toResponseTags(folder.Tags),
+		"metadata":    toResponseMetadata(folder.Metadata),
+		"created_at":  folder.CreatedAt,
+		"updated_at":  folder.UpdatedAt,
+	}
+}
+
+func ToResponseChange(change domain.Change) map[string]interface{} {
+	return map[string]interface{}{
+		"sequence":    change.Sequence,
+		"type":        change.Type,
+		"target_type": change.TargetType,
+		"target_id":   change.TargetId,
+		"data":        change.Data,
+		"occurred_at": change.OccurredAt,
+	}
+}
+
+func toResponseTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+func toResponseMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return map[string]string{}
+	}
+	return metadata
+}
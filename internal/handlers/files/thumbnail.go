@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func (f FileHandler) Thumbnail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		response.ErrorResponse(w, "file id required", http.StatusBadRequest)
+		return
+	}
+
+	fileId, err := uuid.Parse(id)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	size := domain.ThumbnailMedium
+	if sizeQuery := r.URL.Query().Get("size"); sizeQuery != "" {
+		size = domain.ThumbnailSize(sizeQuery)
+		if !size.IsValid() {
+			response.ErrorResponse(w, "size must be one of small, medium or large", http.StatusBadRequest)
+			return
+		}
+	}
+
+	thumbnailUrl, err := f.fileService.GetThumbnailUrl(ctx, fileId, size)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrFileNotFound):
+			response.ErrorResponse(w, "file does not exist", http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrThumbnailNotFound):
+			response.ErrorResponse(w, "thumbnail is not available for this file", http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrUserNotAuthorized):
+			response.ErrorResponse(w, "unauthorized to view this file", http.StatusForbidden)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "thumbnail url generated successfully",
+		map[string]interface{}{
+			"thumbnail_url": thumbnailUrl,
+			"size":          size,
+		})
+}
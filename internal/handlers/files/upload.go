@@ -5,16 +5,15 @@ import (
 	"net/http"
 
 	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/usecases/files"
 	appErrors "github.com/olad5/file-fort/pkg/errors"
 
 	response "github.com/olad5/file-fort/pkg/utils"
 )
 
-const MAX_UPLOAD_SIZE = 1024 * 1024 * 200 // 1MB * 200
-
 func (f FileHandler) Upload(w http.ResponseWriter, r *http.Request) {
-	r.Body = http.MaxBytesReader(w, r.Body, MAX_UPLOAD_SIZE)
-	if err := r.ParseMultipartForm(MAX_UPLOAD_SIZE); err != nil {
+	r.Body = http.MaxBytesReader(w, r.Body, files.MaxUploadSize)
+	if err := r.ParseMultipartForm(files.MaxUploadSize); err != nil {
 		response.ErrorResponse(w, "The file you are trying to upload exceeds the maximum allowed size of 200MB.", http.StatusBadRequest)
 		return
 	}
@@ -34,6 +33,9 @@ func (f FileHandler) Upload(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, infra.ErrFolderNotFound):
 			response.ErrorResponse(w, "folder does not exist", http.StatusNotFound)
 			return
+		case errors.Is(err, infra.ErrQuotaExceeded):
+			response.ErrorResponse(w, "storage quota exceeded", http.StatusRequestEntityTooLarge)
+			return
 		default:
 			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
 			return
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	_ "embed"
+	"errors"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/olad5/file-fort/internal/usecases/files"
+	"github.com/olad5/file-fort/internal/usecases/users"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// maxDepth bounds how deeply a query can nest folders inside folders, each
+// level of which costs a round trip to the database.
+const maxDepth = 12
+
+// maxListedItems bounds how many folders and files a single query can list
+// across all of its pages, and defaultRows and maxRows bound each page.
+const (
+	maxListedItems = 5000
+	defaultRows    = 20
+	maxRows        = 100
+)
+
+type GraphQLHandler struct {
+	schema *graphql.Schema
+}
+
+func NewGraphQLHandler(fileService files.FileService, userService users.UserService) (*GraphQLHandler, error) {
+	if fileService == (files.FileService{}) {
+		return nil, errors.New("file service cannot be empty")
+	}
+	if userService == (users.UserService{}) {
+		return nil, errors.New("user service cannot be empty")
+	}
+
+	parsed, err := graphql.ParseSchema(schema, &resolver{&fileService, &userService}, graphql.MaxDepth(maxDepth))
+	if err != nil {
+		return nil, err
+	}
+	return &GraphQLHandler{parsed}, nil
+}
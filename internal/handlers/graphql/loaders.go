@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+)
+
+// batch loads the values of a group of keys with a single call, the first
+// time the value of any of them is asked for. Resolvers of a list share a
+// batch for each of their fields, so resolving a field across a list costs
+// one query instead of one per item.
+type batch[V any] struct {
+	keys   []uuid.UUID
+	load   func(ctx context.Context, keys []uuid.UUID) (map[uuid.UUID]V, error)
+	once   sync.Once
+	values map[uuid.UUID]V
+	err    error
+}
+
+func newBatch[V any](keys []uuid.UUID, load func(ctx context.Context, keys []uuid.UUID) (map[uuid.UUID]V, error)) *batch[V] {
+	return &batch[V]{keys: keys, load: load}
+}
+
+func (b *batch[V]) get(ctx context.Context, key uuid.UUID) (V, error) {
+	b.once.Do(func() {
+		if len(b.keys) == 0 {
+			b.values = map[uuid.UUID]V{}
+			return
+		}
+		b.values, b.err = b.load(ctx, b.keys)
+	})
+	return b.values[key], b.err
+}
+
+type requestCacheKey struct{}
+
+// requestCache holds what every resolver of a request may ask for. A query
+// only ever sees the caller's own files and folders, so every owner is the
+// caller and is loaded once.
+type requestCache struct {
+	once sync.Once
+	user domain.User
+	err  error
+
+	mu sync.Mutex
+	// listed is how many folders and files the request's lists may hold so
+	// far, counted before each page is loaded.
+	listed int
+}
+
+func withRequestCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestCacheKey{}, &requestCache{})
+}
+
+func (r *resolver) loggedInUser(ctx context.Context) (domain.User, error) {
+	cache, ok := ctx.Value(requestCacheKey{}).(*requestCache)
+	if !ok {
+		return r.userService.GetLoggedInUser(ctx)
+	}
+	cache.once.Do(func() {
+		cache.user, cache.err = r.userService.GetLoggedInUser(ctx)
+	})
+	return cache.user, cache.err
+}
+
+// errTooManyListed fails a query whose lists would hold more than
+// maxListedItems folders and files.
+var errTooManyListed = fmt.Errorf("query lists more than %d folders and files, ask for fewer rows or nest less deeply", maxListedItems)
+
+// reserveListed counts n more listed items against the request, failing once
+// the request goes over maxListedItems. Nested lists multiply, so this keeps
+// a query that is shallow enough for maxDepth from still loading most of the
+// caller's tree.
+func reserveListed(ctx context.Context, n int) error {
+	cache, ok := ctx.Value(requestCacheKey{}).(*requestCache)
+	if !ok {
+		return nil
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.listed+n > maxListedItems {
+		return errTooManyListed
+	}
+	cache.listed += n
+	return nil
+}
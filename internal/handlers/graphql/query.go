@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+// Query executes a GraphQL query or mutation. The response is the standard
+// GraphQL one, with data and errors, rather than the envelope of the REST
+// routes, so that GraphQL clients can read it.
+func (g GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	type requestDTO struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+	if request.Query == "" {
+		response.ErrorResponse(w, "query required", http.StatusBadRequest)
+		return
+	}
+
+	result := g.schema.Exec(withRequestCache(ctx), request.Query, request.OperationName, request.Variables)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,551 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/usecases/files"
+	"github.com/olad5/file-fort/internal/usecases/users"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+)
+
+// resolver resolves the fields of Query and Mutation.
+type resolver struct {
+	fileService *files.FileService
+	userService *users.UserService
+}
+
+func (r *resolver) Me(ctx context.Context) (*userResolver, error) {
+	user, err := r.loggedInUser(ctx)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return &userResolver{r, user}, nil
+}
+
+func (r *resolver) Folder(ctx context.Context, args struct{ ID graphql.ID }) (*folderResolver, error) {
+	folderId, err := parseId(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	return r.folder(ctx, folderId)
+}
+
+// folder resolves a single folder. The home folder has the id of its owner
+// and is created on first use.
+func (r *resolver) folder(ctx context.Context, folderId uuid.UUID) (*folderResolver, error) {
+	user, err := r.loggedInUser(ctx)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	var folder domain.Folder
+	if folderId == user.ID {
+		folder, err = r.fileService.GetHomeFolder(ctx)
+	} else {
+		folder, err = r.fileService.GetFolder(ctx, folderId)
+	}
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return r.newFolderResolvers([]domain.Folder{folder})[0], nil
+}
+
+func (r *resolver) File(ctx context.Context, args struct{ ID graphql.ID }) (*fileResolver, error) {
+	fileId, err := parseId(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	file, err := r.fileService.GetFile(ctx, fileId)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return &fileResolver{r: r, file: file}, nil
+}
+
+func (r *resolver) CreateFolder(ctx context.Context, args struct {
+	Name     string
+	ParentId *graphql.ID
+}) (*folderResolver, error) {
+	if args.Name == "" {
+		return nil, errors.New("name required")
+	}
+	parentId, err := parseOptionalId(args.ParentId)
+	if err != nil {
+		return nil, err
+	}
+
+	folder, err := r.fileService.CreateFolder(ctx, args.Name, parentId)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return r.newFolderResolvers([]domain.Folder{folder})[0], nil
+}
+
+func (r *resolver) MoveFile(ctx context.Context, args struct {
+	ID       graphql.ID
+	FolderId *graphql.ID
+	Name     *string
+}) (*fileResolver, error) {
+	fileId, err := parseId(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	folderId, err := parseOptionalId(args.FolderId)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := r.fileService.GetFile(ctx, fileId)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	if folderId == nil {
+		folderId = &file.FolderId
+	}
+	name := file.FileName
+	if args.Name != nil {
+		if *args.Name == "" {
+			return nil, errors.New("name cannot be empty")
+		}
+		name = *args.Name
+	}
+
+	moved, err := r.fileService.RenameFile(ctx, file.ID, *folderId, name)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return &fileResolver{r: r, file: moved}, nil
+}
+
+func (r *resolver) MoveFolder(ctx context.Context, args struct {
+	ID       graphql.ID
+	ParentId *graphql.ID
+	Name     *string
+}) (*folderResolver, error) {
+	folderId, err := parseId(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	parentId, err := parseOptionalId(args.ParentId)
+	if err != nil {
+		return nil, err
+	}
+
+	folder, err := r.fileService.GetFolder(ctx, folderId)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	if parentId == nil {
+		parentId = folder.ParentId
+	}
+	name := folder.FolderName
+	if args.Name != nil {
+		if *args.Name == "" {
+			return nil, errors.New("name cannot be empty")
+		}
+		name = *args.Name
+	}
+
+	moved, err := r.fileService.RenameFolder(ctx, folder.ID, parentId, name)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return r.newFolderResolvers([]domain.Folder{moved})[0], nil
+}
+
+func (r *resolver) CopyFile(ctx context.Context, args struct {
+	ID       graphql.ID
+	FolderId *graphql.ID
+}) (*fileResolver, error) {
+	fileId, err := parseId(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	folderId, err := parseOptionalId(args.FolderId)
+	if err != nil {
+		return nil, err
+	}
+
+	copied, err := r.fileService.CopyFile(ctx, fileId, folderId)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return &fileResolver{r: r, file: copied}, nil
+}
+
+func (r *resolver) DeleteFile(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	fileId, err := parseId(args.ID)
+	if err != nil {
+		return false, err
+	}
+	if err := r.fileService.DeleteFile(ctx, fileId); err != nil {
+		return false, toGraphQLError(err)
+	}
+	return true, nil
+}
+
+func (r *resolver) DeleteFolder(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	folderId, err := parseId(args.ID)
+	if err != nil {
+		return false, err
+	}
+	if err := r.fileService.DeleteFolder(ctx, folderId); err != nil {
+		return false, toGraphQLError(err)
+	}
+	return true, nil
+}
+
+type userResolver struct {
+	r    *resolver
+	user domain.User
+}
+
+func (u *userResolver) ID() graphql.ID    { return graphql.ID(u.user.ID.String()) }
+func (u *userResolver) Email() string     { return u.user.Email }
+func (u *userResolver) FirstName() string { return u.user.FirstName }
+func (u *userResolver) LastName() string  { return u.user.LastName }
+func (u *userResolver) Role() string      { return string(u.user.Role) }
+
+func (u *userResolver) StorageUsage(ctx context.Context) (*storageUsageResolver, error) {
+	usage, err := u.r.userService.GetStorageUsage(ctx)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return &storageUsageResolver{usage}, nil
+}
+
+func (u *userResolver) Home(ctx context.Context) (*folderResolver, error) {
+	return u.r.folder(ctx, u.user.ID)
+}
+
+type storageUsageResolver struct {
+	usage domain.StorageUsage
+}
+
+func (s *storageUsageResolver) UsedBytes() float64 { return float64(s.usage.UsedBytes) }
+
+func (s *storageUsageResolver) QuotaBytes() *float64 {
+	if s.usage.QuotaBytes == nil {
+		return nil
+	}
+	quota := float64(*s.usage.QuotaBytes)
+	return &quota
+}
+
+// folderBatches are shared by the resolvers of a list of folders. Subfolders
+// and files get a batch for each page asked for, since the same list can be
+// paged differently under different aliases.
+type folderBatches struct {
+	r       *resolver
+	ids     []uuid.UUID
+	byId    map[uuid.UUID]*folderResolver
+	parents *batch[*folderResolver]
+
+	mu         sync.Mutex
+	subfolders map[listPage]*batch[[]*folderResolver]
+	files      map[listPage]*batch[[]*fileResolver]
+}
+
+type folderResolver struct {
+	r       *resolver
+	folder  domain.Folder
+	batches *folderBatches
+}
+
+// newFolderResolvers resolves a list of folders. The folders and files inside
+// all of them are loaded together, and resolved as lists of their own, so a
+// query costs a round trip per level of nesting rather than one per folder.
+func (r *resolver) newFolderResolvers(folders []domain.Folder) []*folderResolver {
+	batches := &folderBatches{
+		r:          r,
+		byId:       map[uuid.UUID]*folderResolver{},
+		subfolders: map[listPage]*batch[[]*folderResolver]{},
+		files:      map[listPage]*batch[[]*fileResolver]{},
+	}
+	resolvers := []*folderResolver{}
+	parentIds := []uuid.UUID{}
+	for _, folder := range folders {
+		resolver := &folderResolver{r, folder, batches}
+		resolvers = append(resolvers, resolver)
+		batches.byId[folder.ID] = resolver
+		batches.ids = append(batches.ids, folder.ID)
+		if parentId := parentOf(folder); parentId != nil {
+			parentIds = append(parentIds, *parentId)
+		}
+	}
+
+	batches.parents = newBatch(parentIds, func(ctx context.Context, keys []uuid.UUID) (map[uuid.UUID]*folderResolver, error) {
+		parents, err := r.fileService.GetFolders(ctx, keys)
+		if err != nil {
+			return nil, err
+		}
+		list := []domain.Folder{}
+		for _, parent := range parents {
+			list = append(list, parent)
+		}
+		result := map[uuid.UUID]*folderResolver{}
+		for _, resolver := range r.newFolderResolvers(list) {
+			result[resolver.folder.ID] = resolver
+		}
+		return result, nil
+	})
+
+	return resolvers
+}
+
+func (b *folderBatches) subfoldersBatch(page listPage) *batch[[]*folderResolver] {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if existing, ok := b.subfolders[page]; ok {
+		return existing
+	}
+	b.subfolders[page] = newBatch(b.ids, func(ctx context.Context, keys []uuid.UUID) (map[uuid.UUID][]*folderResolver, error) {
+		if err := reserveListed(ctx, len(keys)*page.rows); err != nil {
+			return nil, err
+		}
+		subfolders, err := b.r.fileService.GetSubfolders(ctx, keys, page.number, page.rows)
+		if err != nil {
+			return nil, err
+		}
+		list := []domain.Folder{}
+		for _, key := range keys {
+			list = append(list, subfolders[key]...)
+		}
+		resolved := b.r.newFolderResolvers(list)
+		result := map[uuid.UUID][]*folderResolver{}
+		for _, key := range keys {
+			result[key], resolved = resolved[:len(subfolders[key])], resolved[len(subfolders[key]):]
+		}
+		return result, nil
+	})
+	return b.subfolders[page]
+}
+
+func (b *folderBatches) filesBatch(page listPage) *batch[[]*fileResolver] {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if existing, ok := b.files[page]; ok {
+		return existing
+	}
+	b.files[page] = newBatch(b.ids, func(ctx context.Context, keys []uuid.UUID) (map[uuid.UUID][]*fileResolver, error) {
+		if err := reserveListed(ctx, len(keys)*page.rows); err != nil {
+			return nil, err
+		}
+		filesByFolder, err := b.r.fileService.GetFilesInFolders(ctx, keys, page.number, page.rows)
+		if err != nil {
+			return nil, err
+		}
+		result := map[uuid.UUID][]*fileResolver{}
+		for folderId, folderFiles := range filesByFolder {
+			for _, file := range folderFiles {
+				result[folderId] = append(result[folderId], &fileResolver{r: b.r, file: file, folder: b.byId[folderId]})
+			}
+		}
+		return result, nil
+	})
+	return b.files[page]
+}
+
+// listPage is a page of the folders or files inside a folder.
+type listPage struct {
+	number int
+	rows   int
+}
+
+// pageArgs are the arguments of the paged fields of Folder.
+type pageArgs struct {
+	Page int32
+	Rows int32
+}
+
+func (a pageArgs) toListPage() (listPage, error) {
+	page := listPage{number: int(a.Page), rows: int(a.Rows)}
+	if page.number < 1 {
+		return listPage{}, errors.New("page must be at least 1")
+	}
+	if page.rows < 1 || page.rows > maxRows {
+		return listPage{}, fmt.Errorf("rows must be between 1 and %d", maxRows)
+	}
+	return page, nil
+}
+
+// parentOf returns the folder a folder is listed in. Top level folders are
+// listed in the home folder, which is listed in none.
+func parentOf(folder domain.Folder) *uuid.UUID {
+	switch {
+	case folder.ID == folder.OwnerId:
+		return nil
+	case folder.ParentId == nil:
+		return &folder.OwnerId
+	default:
+		return folder.ParentId
+	}
+}
+
+func (f *folderResolver) ID() graphql.ID { return graphql.ID(f.folder.ID.String()) }
+func (f *folderResolver) Name() string   { return f.folder.FolderName }
+func (f *folderResolver) Tags() []string { return toTags(f.folder.Tags) }
+func (f *folderResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: f.folder.CreatedAt}
+}
+func (f *folderResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: f.folder.UpdatedAt}
+}
+
+func (f *folderResolver) Metadata() []*metadataEntryResolver {
+	return toMetadataEntries(f.folder.Metadata)
+}
+
+func (f *folderResolver) Parent(ctx context.Context) (*folderResolver, error) {
+	parentId := parentOf(f.folder)
+	if parentId == nil {
+		return nil, nil
+	}
+	parent, err := f.batches.parents.get(ctx, *parentId)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return parent, nil
+}
+
+func (f *folderResolver) Owner(ctx context.Context) (*userResolver, error) {
+	return f.r.Me(ctx)
+}
+
+func (f *folderResolver) Folders(ctx context.Context, args pageArgs) ([]*folderResolver, error) {
+	page, err := args.toListPage()
+	if err != nil {
+		return nil, err
+	}
+	subfolders, err := f.batches.subfoldersBatch(page).get(ctx, f.folder.ID)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	if subfolders == nil {
+		return []*folderResolver{}, nil
+	}
+	return subfolders, nil
+}
+
+func (f *folderResolver) Files(ctx context.Context, args pageArgs) ([]*fileResolver, error) {
+	page, err := args.toListPage()
+	if err != nil {
+		return nil, err
+	}
+	folderFiles, err := f.batches.filesBatch(page).get(ctx, f.folder.ID)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	if folderFiles == nil {
+		return []*fileResolver{}, nil
+	}
+	return folderFiles, nil
+}
+
+type fileResolver struct {
+	r    *resolver
+	file domain.File
+	// folder is the resolver of the folder the file was listed in, if any.
+	folder *folderResolver
+}
+
+func (f *fileResolver) ID() graphql.ID      { return graphql.ID(f.file.ID.String()) }
+func (f *fileResolver) Name() string        { return f.file.FileName }
+func (f *fileResolver) ContentType() string { return f.file.ContentType }
+func (f *fileResolver) Size() float64       { return float64(f.file.FileSize) }
+func (f *fileResolver) Checksum() string    { return f.file.Checksum }
+func (f *fileResolver) Tags() []string      { return toTags(f.file.Tags) }
+func (f *fileResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: f.file.CreatedAt}
+}
+func (f *fileResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: f.file.UpdatedAt}
+}
+
+func (f *fileResolver) Metadata() []*metadataEntryResolver {
+	return toMetadataEntries(f.file.Metadata)
+}
+
+func (f *fileResolver) Folder(ctx context.Context) (*folderResolver, error) {
+	if f.folder != nil {
+		return f.folder, nil
+	}
+	return f.r.folder(ctx, f.file.FolderId)
+}
+
+func (f *fileResolver) Owner(ctx context.Context) (*userResolver, error) {
+	return f.r.Me(ctx)
+}
+
+type metadataEntryResolver struct {
+	key   string
+	value string
+}
+
+func (m *metadataEntryResolver) Key() string   { return m.key }
+func (m *metadataEntryResolver) Value() string { return m.value }
+
+func toTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+func toMetadataEntries(metadata map[string]string) []*metadataEntryResolver {
+	entries := []*metadataEntryResolver{}
+	for key, value := range metadata {
+		entries = append(entries, &metadataEntryResolver{key, value})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	return entries
+}
+
+func parseId(id graphql.ID) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(string(id))
+	if err != nil {
+		return uuid.Nil, appErrors.ErrInvalidID
+	}
+	return parsed, nil
+}
+
+func parseOptionalId(id *graphql.ID) (*uuid.UUID, error) {
+	if id == nil {
+		return nil, nil
+	}
+	parsed, err := parseId(*id)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// toGraphQLError turns the errors of the services into the messages the REST
+// routes answer with, and hides unexpected ones.
+func toGraphQLError(err error) error {
+	switch {
+	case errors.Is(err, infra.ErrFileNotFound):
+		return errors.New("file does not exist")
+	case errors.Is(err, infra.ErrFolderNotFound):
+		return errors.New("folder does not exist")
+	case errors.Is(err, infra.ErrUserNotAuthorized):
+		return errors.New("unauthorized to access this file or folder")
+	case errors.Is(err, infra.ErrQuotaExceeded):
+		return errors.New("storage quota exceeded")
+	case errors.Is(err, files.ErrHomeFolder),
+		errors.Is(err, files.ErrFolderCycle),
+		errors.Is(err, files.ErrFileNameTaken),
+		errors.Is(err, errTooManyListed):
+		return err
+	default:
+		return errors.New(appErrors.ErrSomethingWentWrong)
+	}
+}
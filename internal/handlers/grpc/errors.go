@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/usecases/files"
+	"github.com/olad5/file-fort/internal/usecases/users"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatusError turns the errors of the services into the messages the REST
+// routes answer with, under the matching status code, and hides unexpected
+// ones.
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, infra.ErrFileNotFound):
+		return status.Error(codes.NotFound, "file does not exist")
+	case errors.Is(err, infra.ErrFolderNotFound):
+		return status.Error(codes.NotFound, "folder does not exist")
+	case errors.Is(err, infra.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user does not exist")
+	case errors.Is(err, infra.ErrUserNotAuthorized):
+		return status.Error(codes.PermissionDenied, "unauthorized to access this file or folder")
+	case errors.Is(err, infra.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, "storage quota exceeded")
+	case errors.Is(err, users.ErrPasswordIncorrect):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, users.ErrUserAlreadyExists),
+		errors.Is(err, files.ErrFileNameTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, appErrors.ErrInvalidID),
+		errors.Is(err, files.ErrFileTooLarge),
+		errors.Is(err, files.ErrFileSizeMismatch):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, files.ErrHomeFolder),
+		errors.Is(err, files.ErrFolderCycle):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Internal, appErrors.ErrSomethingWentWrong)
+	}
+}
+
+func parseId(id string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, appErrors.ErrInvalidID.Error())
+	}
+	return parsed, nil
+}
+
+func parseOptionalId(id *string) (*uuid.UUID, error) {
+	if id == nil {
+		return nil, nil
+	}
+	parsed, err := parseId(*id)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/usecases/files"
+	filefortv1 "github.com/olad5/file-fort/pkg/proto/filefort/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// downloadChunkSize is the size of the chunks a file is streamed in, well
+// below the 4MB default limit of a gRPC message.
+const downloadChunkSize = 64 * 1024
+
+var (
+	errUploadTooShort = errors.New("upload ended before size bytes were sent")
+	errUploadTooLong  = errors.New("upload sent more than size bytes")
+	errUploadInfo     = errors.New("file info must only be sent first")
+)
+
+func (g GRPCHandler) UploadFile(stream filefortv1.FileService_UploadFileServer) error {
+	ctx := stream.Context()
+
+	request, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "file info required")
+	}
+	if err != nil {
+		return err
+	}
+	info := request.GetInfo()
+	if info == nil {
+		return status.Error(codes.InvalidArgument, "file info required")
+	}
+	if info.Name == "" {
+		return status.Error(codes.InvalidArgument, "name required")
+	}
+	if info.Size < 0 {
+		return status.Error(codes.InvalidArgument, "size cannot be negative")
+	}
+	if info.Size > files.MaxUploadSize {
+		return toStatusError(files.ErrFileTooLarge)
+	}
+
+	content := &uploadReader{stream: stream, remaining: info.Size}
+	file, err := g.fileService.SaveFile(ctx, info.FolderId, info.Name, info.Size, info.ContentType, content)
+	if err != nil {
+		switch {
+		case errors.Is(content.err, errUploadTooShort),
+			errors.Is(content.err, errUploadTooLong),
+			errors.Is(content.err, errUploadInfo):
+			return status.Error(codes.InvalidArgument, content.err.Error())
+		case content.err != nil && content.err != io.EOF:
+			return content.err
+		default:
+			return toStatusError(err)
+		}
+	}
+	return stream.SendAndClose(toProtoFile(file))
+}
+
+// uploadReader reads the content of an upload from the chunks of its stream,
+// and fails unless the stream holds exactly the declared size, so that the
+// quota checked against the size holds for what is stored.
+type uploadReader struct {
+	stream    filefortv1.FileService_UploadFileServer
+	remaining int64
+	chunk     []byte
+	err       error
+}
+
+func (u *uploadReader) Read(p []byte) (int, error) {
+	for len(u.chunk) == 0 {
+		if u.err != nil {
+			return 0, u.err
+		}
+		u.chunk, u.err = u.next()
+	}
+
+	n := copy(p, u.chunk)
+	u.chunk = u.chunk[n:]
+	u.remaining -= int64(n)
+	return n, nil
+}
+
+func (u *uploadReader) next() ([]byte, error) {
+	request, err := u.stream.Recv()
+	if err == io.EOF {
+		if u.remaining > 0 {
+			return nil, errUploadTooShort
+		}
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	if request.GetInfo() != nil {
+		return nil, errUploadInfo
+	}
+	chunk := request.GetChunk()
+	if int64(len(chunk)) > u.remaining {
+		return nil, errUploadTooLong
+	}
+	return chunk, nil
+}
+
+func (g GRPCHandler) DownloadFile(request *filefortv1.DownloadFileRequest, stream filefortv1.FileService_DownloadFileServer) error {
+	ctx := stream.Context()
+	fileId, err := parseId(request.Id)
+	if err != nil {
+		return err
+	}
+
+	file, object, err := g.fileService.StatFile(ctx, fileId)
+	if err != nil {
+		return toStatusError(err)
+	}
+	err = stream.Send(&filefortv1.DownloadFileResponse{
+		Data: &filefortv1.DownloadFileResponse_Info{Info: toProtoFile(file)},
+	})
+	if err != nil {
+		return err
+	}
+	if object.Size == 0 {
+		return nil
+	}
+
+	body, err := g.fileService.ReadFile(ctx, file, 0, object.Size)
+	if err != nil {
+		return toStatusError(err)
+	}
+	defer body.Close()
+
+	buffer := make([]byte, downloadChunkSize)
+	for {
+		n, err := io.ReadFull(body, buffer)
+		if n > 0 {
+			sendErr := stream.Send(&filefortv1.DownloadFileResponse{
+				Data: &filefortv1.DownloadFileResponse_Chunk{Chunk: buffer[:n]},
+			})
+			if sendErr != nil {
+				return sendErr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return toStatusError(err)
+		}
+	}
+}
+
+func (g GRPCHandler) GetFile(ctx context.Context, request *filefortv1.GetFileRequest) (*filefortv1.File, error) {
+	fileId, err := parseId(request.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := g.fileService.GetFile(ctx, fileId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoFile(file), nil
+}
+
+func (g GRPCHandler) MoveFile(ctx context.Context, request *filefortv1.MoveFileRequest) (*filefortv1.File, error) {
+	fileId, err := parseId(request.Id)
+	if err != nil {
+		return nil, err
+	}
+	folderId, err := parseOptionalId(request.FolderId)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := g.fileService.GetFile(ctx, fileId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	if folderId == nil {
+		folderId = &file.FolderId
+	}
+	name := file.FileName
+	if request.Name != nil {
+		if *request.Name == "" {
+			return nil, status.Error(codes.InvalidArgument, "name cannot be empty")
+		}
+		name = *request.Name
+	}
+
+	moved, err := g.fileService.RenameFile(ctx, file.ID, *folderId, name)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoFile(moved), nil
+}
+
+func (g GRPCHandler) CopyFile(ctx context.Context, request *filefortv1.CopyFileRequest) (*filefortv1.File, error) {
+	fileId, err := parseId(request.Id)
+	if err != nil {
+		return nil, err
+	}
+	folderId, err := parseOptionalId(request.FolderId)
+	if err != nil {
+		return nil, err
+	}
+
+	copied, err := g.fileService.CopyFile(ctx, fileId, folderId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoFile(copied), nil
+}
+
+func (g GRPCHandler) DeleteFile(ctx context.Context, request *filefortv1.DeleteFileRequest) (*filefortv1.DeleteFileResponse, error) {
+	fileId, err := parseId(request.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.fileService.DeleteFile(ctx, fileId); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &filefortv1.DeleteFileResponse{}, nil
+}
+
+func toProtoFile(file domain.File) *filefortv1.File {
+	return &filefortv1.File{
+		Id:          file.ID.String(),
+		Name:        file.FileName,
+		FolderId:    file.FolderId.String(),
+		OwnerId:     file.OwnerId.String(),
+		ContentType: file.ContentType,
+		Size:        file.FileSize,
+		Checksum:    file.Checksum,
+		Tags:        file.Tags,
+		Metadata:    file.Metadata,
+		CreatedAt:   timestamppb.New(file.CreatedAt),
+		UpdatedAt:   timestamppb.New(file.UpdatedAt),
+	}
+}
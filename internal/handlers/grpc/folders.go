@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/olad5/file-fort/internal/domain"
+	filefortv1 "github.com/olad5/file-fort/pkg/proto/filefort/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (g GRPCHandler) GetHomeFolder(ctx context.Context, request *filefortv1.GetHomeFolderRequest) (*filefortv1.Folder, error) {
+	folder, err := g.fileService.GetHomeFolder(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoFolder(folder), nil
+}
+
+func (g GRPCHandler) GetFolder(ctx context.Context, request *filefortv1.GetFolderRequest) (*filefortv1.Folder, error) {
+	folderId, err := parseId(request.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	folder, err := g.fileService.GetFolder(ctx, folderId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoFolder(folder), nil
+}
+
+func (g GRPCHandler) ListFolderContents(ctx context.Context, request *filefortv1.ListFolderContentsRequest) (*filefortv1.ListFolderContentsResponse, error) {
+	folderId, err := parseId(request.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	folders, files, err := g.fileService.GetFolderContents(ctx, folderId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &filefortv1.ListFolderContentsResponse{
+		Folders: make([]*filefortv1.Folder, 0, len(folders)),
+		Files:   make([]*filefortv1.File, 0, len(files)),
+	}
+	for _, folder := range folders {
+		response.Folders = append(response.Folders, toProtoFolder(folder))
+	}
+	for _, file := range files {
+		response.Files = append(response.Files, toProtoFile(file))
+	}
+	return response, nil
+}
+
+func (g GRPCHandler) CreateFolder(ctx context.Context, request *filefortv1.CreateFolderRequest) (*filefortv1.Folder, error) {
+	if request.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name required")
+	}
+	parentId, err := parseOptionalId(request.ParentId)
+	if err != nil {
+		return nil, err
+	}
+
+	folder, err := g.fileService.CreateFolder(ctx, request.Name, parentId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoFolder(folder), nil
+}
+
+func (g GRPCHandler) MoveFolder(ctx context.Context, request *filefortv1.MoveFolderRequest) (*filefortv1.Folder, error) {
+	folderId, err := parseId(request.Id)
+	if err != nil {
+		return nil, err
+	}
+	parentId, err := parseOptionalId(request.ParentId)
+	if err != nil {
+		return nil, err
+	}
+
+	folder, err := g.fileService.GetFolder(ctx, folderId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	if parentId == nil {
+		parentId = folder.ParentId
+	}
+	name := folder.FolderName
+	if request.Name != nil {
+		if *request.Name == "" {
+			return nil, status.Error(codes.InvalidArgument, "name cannot be empty")
+		}
+		name = *request.Name
+	}
+
+	moved, err := g.fileService.RenameFolder(ctx, folder.ID, parentId, name)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoFolder(moved), nil
+}
+
+func (g GRPCHandler) DeleteFolder(ctx context.Context, request *filefortv1.DeleteFolderRequest) (*filefortv1.DeleteFolderResponse, error) {
+	folderId, err := parseId(request.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.fileService.DeleteFolder(ctx, folderId); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &filefortv1.DeleteFolderResponse{}, nil
+}
+
+func toProtoFolder(folder domain.Folder) *filefortv1.Folder {
+	protoFolder := &filefortv1.Folder{
+		Id:        folder.ID.String(),
+		Name:      folder.FolderName,
+		OwnerId:   folder.OwnerId.String(),
+		Tags:      folder.Tags,
+		Metadata:  folder.Metadata,
+		CreatedAt: timestamppb.New(folder.CreatedAt),
+		UpdatedAt: timestamppb.New(folder.UpdatedAt),
+	}
+	if folder.ParentId != nil {
+		parentId := folder.ParentId.String()
+		protoFolder.ParentId = &parentId
+	}
+	return protoFolder
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/olad5/file-fort/internal/usecases/files"
+	"github.com/olad5/file-fort/internal/usecases/users"
+	filefortv1 "github.com/olad5/file-fort/pkg/proto/filefort/v1"
+	"google.golang.org/grpc"
+)
+
+// GRPCHandler serves the gRPC API, which covers the users, folders and files
+// of the REST routes for internal consumers.
+type GRPCHandler struct {
+	filefortv1.UnimplementedUserServiceServer
+	filefortv1.UnimplementedFolderServiceServer
+	filefortv1.UnimplementedFileServiceServer
+	fileService files.FileService
+	userService users.UserService
+}
+
+func NewGRPCHandler(fileService files.FileService, userService users.UserService) (*GRPCHandler, error) {
+	if fileService == (files.FileService{}) {
+		return nil, errors.New("file service cannot be empty")
+	}
+	if userService == (users.UserService{}) {
+		return nil, errors.New("user service cannot be empty")
+	}
+
+	return &GRPCHandler{fileService: fileService, userService: userService}, nil
+}
+
+// Register registers the services of the API on server.
+func (g GRPCHandler) Register(server grpc.ServiceRegistrar) {
+	filefortv1.RegisterUserServiceServer(server, g)
+	filefortv1.RegisterFolderServiceServer(server, g)
+	filefortv1.RegisterFileServiceServer(server, g)
+}
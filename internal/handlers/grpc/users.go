@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/olad5/file-fort/internal/domain"
+	filefortv1 "github.com/olad5/file-fort/pkg/proto/filefort/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (g GRPCHandler) CreateUser(ctx context.Context, request *filefortv1.CreateUserRequest) (*filefortv1.User, error) {
+	if request.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, "email required")
+	}
+	if request.Password == "" {
+		return nil, status.Error(codes.InvalidArgument, "password required")
+	}
+	if request.FirstName == "" {
+		return nil, status.Error(codes.InvalidArgument, "first_name required")
+	}
+	if request.LastName == "" {
+		return nil, status.Error(codes.InvalidArgument, "last_name required")
+	}
+
+	newUser, err := g.userService.CreateUser(ctx, request.FirstName, request.LastName, request.Email, request.Password)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoUser(newUser), nil
+}
+
+func (g GRPCHandler) Login(ctx context.Context, request *filefortv1.LoginRequest) (*filefortv1.LoginResponse, error) {
+	if request.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, "email required")
+	}
+	if request.Password == "" {
+		return nil, status.Error(codes.InvalidArgument, "password required")
+	}
+
+	accessToken, err := g.userService.LogUserIn(ctx, request.Email, request.Password)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &filefortv1.LoginResponse{AccessToken: accessToken}, nil
+}
+
+func (g GRPCHandler) GetLoggedInUser(ctx context.Context, request *filefortv1.GetLoggedInUserRequest) (*filefortv1.User, error) {
+	user, err := g.userService.GetLoggedInUser(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoUser(user), nil
+}
+
+func (g GRPCHandler) GetStorageUsage(ctx context.Context, request *filefortv1.GetStorageUsageRequest) (*filefortv1.StorageUsage, error) {
+	usage, err := g.userService.GetStorageUsage(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &filefortv1.StorageUsage{
+		UserId:     usage.UserId.String(),
+		UsedBytes:  usage.UsedBytes,
+		QuotaBytes: usage.QuotaBytes,
+	}, nil
+}
+
+func toProtoUser(user domain.User) *filefortv1.User {
+	return &filefortv1.User{
+		Id:        user.ID.String(),
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Role:      string(user.Role),
+	}
+}
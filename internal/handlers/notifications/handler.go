@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/olad5/file-fort/internal/usecases/notifications"
+)
+
+type NotificationHandler struct {
+	notificationService notifications.NotificationService
+}
+
+func NewNotificationHandler(notificationService notifications.NotificationService) (*NotificationHandler, error) {
+	if notificationService == (notifications.NotificationService{}) {
+		return nil, errors.New("notification service cannot be empty")
+	}
+
+	return &NotificationHandler{notificationService}, nil
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/olad5/file-fort/internal/domain"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+// heartbeatInterval keeps idle streams from being closed by proxies.
+const heartbeatInterval = 25 * time.Second
+
+// Stream sends the caller's file and folder events as Server-Sent Events
+// until they disconnect. Events are not replayed on reconnecting; a client
+// that needs every change catches up from the change feed.
+func (n NotificationHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	events, err := n.notificationService.Listen(ctx)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			body, err := json.Marshal(toResponseEvent(event))
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, body)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func toResponseEvent(event domain.Event) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          event.ID,
+		"type":        event.Type,
+		"data":        event.Data,
+		"occurred_at": event.OccurredAt,
+	}
+}
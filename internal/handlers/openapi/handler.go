@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/google/uuid"
+)
+
+// spec is the contract of the JSON API. Routes are added to it together with
+// their handlers, since requests are validated against it.
+//
+//go:embed openapi.json
+var spec []byte
+
+func init() {
+	openapi3.DefineStringFormatCallback("uuid", func(value string) error {
+		_, err := uuid.Parse(value)
+		return err
+	})
+}
+
+type OpenAPIHandler struct {
+	spec    []byte
+	router  routers.Router
+	options openapi3filter.Options
+}
+
+func NewOpenAPIHandler(ctx context.Context) (*OpenAPIHandler, error) {
+	loader := openapi3.NewLoader()
+	loader.Context = ctx
+
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(ctx); err != nil {
+		return nil, err
+	}
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	options := openapi3filter.Options{
+		MultiError: true,
+		// Authentication is left to the auth middleware, which runs first.
+		AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+		SkipSettingDefaults: true,
+	}
+	return &OpenAPIHandler{spec, router, options}, nil
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+)
+
+func (o OpenAPIHandler) Spec(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(o.spec); err != nil {
+		log.Printf("Error sending response: %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+// ValidateRequest checks the parameters and JSON body of a request against
+// the spec, and answers a request that does not match it with an error per
+// field. Requests to routes outside the spec are passed on untouched.
+func (o OpenAPIHandler) ValidateRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := o.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		options := o.options
+		// Only JSON bodies are validated, uploads are streamed to the file
+		// store rather than buffered here.
+		options.ExcludeRequestBody = !isJSONRequest(r)
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    &options,
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			response.ValidationErrorResponse(w, toFieldErrors(err))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isJSONRequest(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
+
+func toFieldErrors(err error) []response.FieldError {
+	if multiError, ok := err.(openapi3.MultiError); ok {
+		fieldErrors := []response.FieldError{}
+		for _, e := range multiError {
+			fieldErrors = append(fieldErrors, toFieldErrors(e)...)
+		}
+		return fieldErrors
+	}
+
+	var requestError *openapi3filter.RequestError
+	if !errors.As(err, &requestError) {
+		return []response.FieldError{{Message: "invalid request"}}
+	}
+
+	if parameter := requestError.Parameter; parameter != nil {
+		return parameterFieldErrors(parameter, requestError.Err)
+	}
+	return bodyFieldErrors(requestError.Err)
+}
+
+func parameterFieldErrors(parameter *openapi3.Parameter, err error) []response.FieldError {
+	var schema *openapi3.Schema
+	if parameter.Schema != nil {
+		schema = parameter.Schema.Value
+	}
+
+	toFieldError := func(message string) response.FieldError {
+		return response.FieldError{Field: parameter.Name, In: parameter.In, Message: message}
+	}
+
+	schemaErrors := flattenSchemaErrors(err)
+	if len(schemaErrors) > 0 {
+		fieldErrors := []response.FieldError{}
+		for _, schemaError := range schemaErrors {
+			fieldErrors = append(fieldErrors, toFieldError(schemaErrorMessage(parameter.Name, schemaError)))
+		}
+		return fieldErrors
+	}
+
+	var parseError *openapi3filter.ParseError
+	switch {
+	case errors.Is(err, openapi3filter.ErrInvalidRequired), errors.Is(err, openapi3filter.ErrInvalidEmptyValue):
+		return []response.FieldError{toFieldError(parameter.Name + " required")}
+	case errors.As(err, &parseError) && schema != nil:
+		if schema.Type.Is(openapi3.TypeArray) && schema.Items != nil {
+			schema = schema.Items.Value
+		}
+		return []response.FieldError{toFieldError(parameter.Name + " must be " + typeName(schema))}
+	default:
+		return []response.FieldError{toFieldError(parameter.Name + " is invalid")}
+	}
+}
+
+func bodyFieldErrors(err error) []response.FieldError {
+	schemaErrors := flattenSchemaErrors(err)
+	if len(schemaErrors) == 0 {
+		message := appErrors.ErrInvalidJson
+		if errors.Is(err, openapi3filter.ErrInvalidRequired) {
+			message = appErrors.ErrMissingBody
+		}
+		return []response.FieldError{{In: "body", Message: message}}
+	}
+
+	fieldErrors := []response.FieldError{}
+	for _, schemaError := range schemaErrors {
+		field := strings.Join(schemaError.JSONPointer(), ".")
+		message := appErrors.ErrInvalidJson
+		if field != "" {
+			message = schemaErrorMessage(field, schemaError)
+		}
+		fieldErrors = append(fieldErrors, response.FieldError{Field: field, In: "body", Message: message})
+	}
+	return fieldErrors
+}
+
+func flattenSchemaErrors(err error) []*openapi3.SchemaError {
+	if multiError, ok := err.(openapi3.MultiError); ok {
+		schemaErrors := []*openapi3.SchemaError{}
+		for _, e := range multiError {
+			schemaErrors = append(schemaErrors, flattenSchemaErrors(e)...)
+		}
+		return schemaErrors
+	}
+	if schemaError, ok := err.(*openapi3.SchemaError); ok {
+		return []*openapi3.SchemaError{schemaError}
+	}
+	return nil
+}
+
+// schemaErrorMessage words a failed schema check the way the handlers word
+// their own checks, such as "folder_name required" or "page must be a number".
+func schemaErrorMessage(field string, err *openapi3.SchemaError) string {
+	schema := err.Schema
+	if schema == nil {
+		return field + " is invalid"
+	}
+
+	switch err.SchemaField {
+	case "required":
+		return field + " required"
+	case "type":
+		return field + " must be " + typeName(schema)
+	case "nullable":
+		return field + " must not be null"
+	case "enum":
+		return field + " must be one of " + joinChoices(schema.Enum)
+	case "minimum":
+		if schema.Min != nil && *schema.Min == 0 {
+			return field + " must be a non-negative number"
+		}
+		return fmt.Sprintf("%s must be at least %v", field, *schema.Min)
+	case "maximum":
+		return fmt.Sprintf("%s must be at most %v", field, *schema.Max)
+	case "minLength":
+		if schema.MinLength == 1 {
+			return field + " required"
+		}
+		return fmt.Sprintf("%s must be at least %d characters", field, schema.MinLength)
+	case "maxLength":
+		return fmt.Sprintf("%s must be at most %d characters", field, *schema.MaxLength)
+	case "minItems":
+		if schema.MinItems == 1 {
+			return field + " required"
+		}
+		return fmt.Sprintf("%s must have at least %d items", field, schema.MinItems)
+	case "maxItems":
+		return fmt.Sprintf("%s must have at most %d items", field, *schema.MaxItems)
+	case "format":
+		switch schema.Format {
+		case "uuid":
+			return field + " is not in its proper form"
+		case "date-time":
+			return field + " must be an RFC3339 timestamp"
+		}
+	}
+	return field + " is invalid"
+}
+
+func typeName(schema *openapi3.Schema) string {
+	switch {
+	case schema.Type.Is(openapi3.TypeInteger), schema.Type.Is(openapi3.TypeNumber):
+		return "a number"
+	case schema.Type.Is(openapi3.TypeBoolean):
+		return "a boolean"
+	case schema.Type.Is(openapi3.TypeArray):
+		return "a list"
+	case schema.Type.Is(openapi3.TypeObject):
+		return "an object"
+	default:
+		return "a string"
+	}
+}
+
+// joinChoices lists the allowed values as the handlers do, "a, b or c".
+func joinChoices(values []interface{}) string {
+	choices := []string{}
+	for _, value := range values {
+		choices = append(choices, fmt.Sprint(value))
+	}
+	if len(choices) < 2 {
+		return strings.Join(choices, "")
+	}
+	return strings.Join(choices[:len(choices)-1], ", ") + " or " + choices[len(choices)-1]
+}
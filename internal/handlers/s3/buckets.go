@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olad5/file-fort/internal/services/auth"
+)
+
+const (
+	s3Namespace     = "http://s3.amazonaws.com/doc/2006-03-01/"
+	s3TimeFormat    = "2006-01-02T15:04:05.000Z"
+	defaultMaxKeys  = 1000
+	keyMarker       = "k:"
+	prefixMarker    = "p:"
+	urlEncodingType = "url"
+)
+
+type owner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+type bucket struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+type listBucketsResponse struct {
+	XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Owner   owner    `xml:"Owner"`
+	Buckets []bucket `xml:"Buckets>Bucket"`
+}
+
+type object struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type listObjectsV2Response struct {
+	XMLName               xml.Name       `xml:"ListBucketResult"`
+	Xmlns                 string         `xml:"xmlns,attr"`
+	Name                  string         `xml:"Name"`
+	Prefix                string         `xml:"Prefix"`
+	Delimiter             string         `xml:"Delimiter,omitempty"`
+	MaxKeys               int            `xml:"MaxKeys"`
+	KeyCount              int            `xml:"KeyCount"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string         `xml:"StartAfter,omitempty"`
+	EncodingType          string         `xml:"EncodingType,omitempty"`
+	Contents              []object       `xml:"Contents"`
+	CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+}
+
+type locationResponse struct {
+	XMLName xml.Name `xml:"LocationConstraint"`
+	Xmlns   string   `xml:"xmlns,attr"`
+}
+
+func (h S3Handler) listBuckets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jwtClaims, _ := auth.Get(ctx)
+
+	folders, err := h.fileService.GetBuckets(ctx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	buckets := []bucket{}
+	for _, folder := range folders {
+		buckets = append(buckets, bucket{Name: folder.FolderName, CreationDate: formatTime(folder.CreatedAt)})
+	}
+	writeXML(w, http.StatusOK, listBucketsResponse{
+		Xmlns:   s3Namespace,
+		Owner:   owner{ID: jwtClaims.ID.String(), DisplayName: jwtClaims.Email},
+		Buckets: buckets,
+	})
+}
+
+func (h S3Handler) headBucket(w http.ResponseWriter, r *http.Request, bucketName string) {
+	if _, err := h.fileService.GetBucket(r.Context(), bucketName); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// getBucketLocation is answered with the default region, which some clients
+// ask for before anything else. Signatures for any region are accepted.
+func (h S3Handler) getBucketLocation(w http.ResponseWriter, r *http.Request, bucketName string) {
+	if _, err := h.fileService.GetBucket(r.Context(), bucketName); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeXML(w, http.StatusOK, locationResponse{Xmlns: s3Namespace})
+}
+
+func (h S3Handler) listObjectsV2(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	encodingType := query.Get("encoding-type")
+	if encodingType != "" && encodingType != urlEncodingType {
+		writeError(w, r, errInvalidArgument)
+		return
+	}
+	maxKeys := defaultMaxKeys
+	if value := query.Get("max-keys"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			writeError(w, r, errInvalidArgument)
+			return
+		}
+		if parsed < maxKeys {
+			maxKeys = parsed
+		}
+	}
+
+	// Listing resumes after the last key or common prefix of the previous
+	// page. A common prefix stands for every key below it, so they are all
+	// skipped.
+	marker, markerIsPrefix := query.Get("start-after"), false
+	token := query.Get("continuation-token")
+	if query.Has("continuation-token") {
+		decoded, err := base64.RawURLEncoding.DecodeString(token)
+		switch {
+		case err == nil && strings.HasPrefix(string(decoded), keyMarker):
+			marker = strings.TrimPrefix(string(decoded), keyMarker)
+		case err == nil && strings.HasPrefix(string(decoded), prefixMarker):
+			marker, markerIsPrefix = strings.TrimPrefix(string(decoded), prefixMarker), true
+		default:
+			writeError(w, r, &s3Error{"InvalidArgument", "The continuation token provided is incorrect", http.StatusBadRequest})
+			return
+		}
+	}
+
+	folder, err := h.fileService.GetBucket(ctx, bucketName)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	objects, err := h.fileService.ListObjects(ctx, folder)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	encode := func(s string) string { return s }
+	if encodingType == urlEncodingType {
+		encode = url.QueryEscape
+	}
+	result := listObjectsV2Response{
+		Xmlns:             s3Namespace,
+		Name:              bucketName,
+		Prefix:            encode(prefix),
+		Delimiter:         encode(delimiter),
+		MaxKeys:           maxKeys,
+		ContinuationToken: token,
+		StartAfter:        encode(query.Get("start-after")),
+		EncodingType:      encodingType,
+		Contents:          []object{},
+		CommonPrefixes:    []commonPrefix{},
+	}
+
+	var last string
+	lastIsPrefix := false
+	for _, o := range objects {
+		if o.Key <= marker || (markerIsPrefix && strings.HasPrefix(o.Key, marker)) || !strings.HasPrefix(o.Key, prefix) {
+			continue
+		}
+
+		entry, isPrefix := o.Key, false
+		if delimiter != "" {
+			if i := strings.Index(o.Key[len(prefix):], delimiter); i >= 0 {
+				entry, isPrefix = o.Key[:len(prefix)+i+len(delimiter)], true
+			}
+		}
+		if isPrefix && lastIsPrefix && entry == last {
+			continue
+		}
+		if result.KeyCount == maxKeys {
+			result.IsTruncated = true
+			break
+		}
+
+		result.KeyCount++
+		last, lastIsPrefix = entry, isPrefix
+		if isPrefix {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: encode(entry)})
+			continue
+		}
+		result.Contents = append(result.Contents, object{
+			Key:          encode(o.Key),
+			LastModified: formatTime(o.File.CreatedAt),
+			ETag:         objectETag(o.File.ID.String()),
+			Size:         o.File.FileSize,
+			StorageClass: "STANDARD",
+		})
+	}
+	if result.IsTruncated {
+		next := keyMarker + last
+		if lastIsPrefix {
+			next = prefixMarker + last
+		}
+		result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(next))
+	}
+
+	writeXML(w, http.StatusOK, result)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(s3TimeFormat)
+}
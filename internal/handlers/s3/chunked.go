@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxChunkHeaderLength bounds the lines read while decoding aws-chunked
+// bodies, which carry a chunk size, a signature or a trailing checksum.
+const maxChunkHeaderLength = 4096
+
+var errMalformedChunk = errors.New("malformed aws-chunked body")
+
+// chunkSigner checks the signature of each chunk of a streaming upload, which
+// chains on from the signature of the chunk before it.
+type chunkSigner struct {
+	key      []byte
+	sig      signature
+	previous string
+}
+
+func (c *chunkSigner) verify(chunkHash []byte, chunkSignature string) bool {
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256-PAYLOAD",
+		c.sig.amzDate.Format(amzDateFormat),
+		c.sig.scope(),
+		c.previous,
+		emptyPayloadHash,
+		hex.EncodeToString(chunkHash),
+	}, "\n")
+	expected := hex.EncodeToString(hmacSHA256(c.key, stringToSign))
+	c.previous = chunkSignature
+	return hmac.Equal([]byte(expected), []byte(chunkSignature))
+}
+
+// chunkedReader decodes an aws-chunked body. Chunks are passed on as they
+// arrive, so when they are signed a bad signature fails the read that
+// reaches the end of the chunk. The chunks must add up to the decoded length
+// the request declared, and a chunk that goes past it fails before it is
+// read. Trailing checksums are skipped.
+type chunkedReader struct {
+	body      io.ReadCloser
+	reader    *bufio.Reader
+	signer    *chunkSigner
+	length    int64
+	decoded   int64
+	remaining int64
+	hash      hash.Hash
+	signature string
+	started   bool
+	done      bool
+}
+
+func newChunkedReader(body io.ReadCloser, length int64, signer *chunkSigner) *chunkedReader {
+	return &chunkedReader{body: body, reader: bufio.NewReaderSize(body, maxChunkHeaderLength), signer: signer, length: length, hash: sha256.New()}
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for c.remaining == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		if err := c.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.reader.Read(p)
+	c.hash.Write(p[:n])
+	c.remaining -= int64(n)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return n, io.ErrUnexpectedEOF
+		}
+		return n, err
+	}
+	if c.remaining == 0 {
+		if err := c.endChunk(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (c *chunkedReader) nextChunk() error {
+	if c.started {
+		if line, err := c.readLine(); err != nil || line != "" {
+			return errMalformedChunk
+		}
+	}
+	c.started = true
+
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	sizeField, extension, _ := strings.Cut(line, ";")
+	size, err := strconv.ParseInt(sizeField, 16, 64)
+	if err != nil || size < 0 {
+		return errMalformedChunk
+	}
+	c.signature = strings.TrimPrefix(extension, "chunk-signature=")
+	if c.signer != nil && c.signature == "" {
+		return errMalformedChunk
+	}
+	if size > c.length-c.decoded || (size == 0 && c.decoded != c.length) {
+		return errIncompleteBody
+	}
+	c.decoded += size
+	c.remaining = size
+	c.hash.Reset()
+
+	if size == 0 {
+		// The last chunk is empty, and is followed by any trailers and a
+		// blank line.
+		if err := c.endChunk(); err != nil {
+			return err
+		}
+		for {
+			line, err := c.readLine()
+			if err != nil {
+				return err
+			}
+			if line == "" {
+				break
+			}
+		}
+		c.done = true
+	}
+	return nil
+}
+
+func (c *chunkedReader) endChunk() error {
+	if c.signer != nil && !c.signer.verify(c.hash.Sum(nil), c.signature) {
+		return errSignatureDoesNotMatch
+	}
+	return nil
+}
+
+func (c *chunkedReader) readLine() (string, error) {
+	line, err := c.reader.ReadSlice('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return "", io.ErrUnexpectedEOF
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return "", errMalformedChunk
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+func (c *chunkedReader) Close() error {
+	return c.body.Close()
+}
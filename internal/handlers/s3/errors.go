@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/usecases/files"
+)
+
+// s3Error is an error as S3 reports it, with the code clients switch on.
+type s3Error struct {
+	code    string
+	message string
+	status  int
+}
+
+func (e *s3Error) Error() string {
+	return e.message
+}
+
+var (
+	errAccessDenied                      = &s3Error{"AccessDenied", "Access Denied", http.StatusForbidden}
+	errInvalidAccessKeyId                = &s3Error{"InvalidAccessKeyId", "The access key ID you provided does not exist in our records.", http.StatusForbidden}
+	errSignatureDoesNotMatch             = &s3Error{"SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.", http.StatusForbidden}
+	errRequestTimeTooSkewed              = &s3Error{"RequestTimeTooSkewed", "The difference between the request time and the server's time is too large.", http.StatusForbidden}
+	errExpiredPresignedRequest           = &s3Error{"AccessDenied", "Request has expired", http.StatusForbidden}
+	errAuthorizationMalformed            = &s3Error{"AuthorizationHeaderMalformed", "The authorization header is malformed.", http.StatusBadRequest}
+	errAuthorizationQueryParametersError = &s3Error{"AuthorizationQueryParametersError", "X-Amz-Expires must be between 0 and 604800 seconds.", http.StatusBadRequest}
+	errUnsupportedSignature              = &s3Error{"InvalidRequest", "Please use AWS4-HMAC-SHA256.", http.StatusBadRequest}
+	errMissingContentSHA256              = &s3Error{"InvalidRequest", "Missing required header for this request: x-amz-content-sha256", http.StatusBadRequest}
+	errContentSHA256Mismatch             = &s3Error{"XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed.", http.StatusBadRequest}
+	errMissingContentLength              = &s3Error{"MissingContentLength", "You must provide the Content-Length HTTP header.", http.StatusLengthRequired}
+	errInvalidArgument                   = &s3Error{"InvalidArgument", "Invalid Argument", http.StatusBadRequest}
+	errInvalidObjectKey                  = &s3Error{"InvalidArgument", files.ErrInvalidObjectKey.Error(), http.StatusBadRequest}
+	errInvalidRange                      = &s3Error{"InvalidRange", "The requested range is not satisfiable", http.StatusRequestedRangeNotSatisfiable}
+	errQuotaExceeded                     = &s3Error{"EntityTooLarge", "Your proposed upload exceeds your storage quota.", http.StatusBadRequest}
+	errEntityTooLarge                    = &s3Error{"EntityTooLarge", "Your proposed upload exceeds the maximum allowed object size.", http.StatusBadRequest}
+	errIncompleteBody                    = &s3Error{"IncompleteBody", "You did not provide the number of bytes specified by the Content-Length HTTP header.", http.StatusBadRequest}
+	errNoSuchBucket                      = &s3Error{"NoSuchBucket", "The specified bucket does not exist", http.StatusNotFound}
+	errNoSuchKey                         = &s3Error{"NoSuchKey", "The specified key does not exist.", http.StatusNotFound}
+	errMethodNotAllowed                  = &s3Error{"MethodNotAllowed", "The specified method is not allowed against this resource.", http.StatusMethodNotAllowed}
+	errNotImplemented                    = &s3Error{"NotImplemented", "A header or query you provided implies functionality that is not implemented.", http.StatusNotImplemented}
+	errInternalError                     = &s3Error{"InternalError", "We encountered an internal error. Please try again.", http.StatusInternalServerError}
+)
+
+type errorResponse struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string   `xml:"Code"`
+	Message  string   `xml:"Message"`
+	Resource string   `xml:"Resource"`
+}
+
+// toS3Error maps the errors of the files service onto S3 errors. Missing
+// folders are missing buckets, since the handlers look keys up in a bucket
+// the caller already knows exists.
+func toS3Error(err error) *s3Error {
+	var s3Err *s3Error
+	switch {
+	case errors.As(err, &s3Err):
+		return s3Err
+	case errors.Is(err, infra.ErrAccessKeyNotFound), errors.Is(err, infra.ErrUserNotFound):
+		return errInvalidAccessKeyId
+	case errors.Is(err, infra.ErrFileNotFound):
+		return errNoSuchKey
+	case errors.Is(err, infra.ErrFolderNotFound):
+		return errNoSuchBucket
+	case errors.Is(err, infra.ErrUserNotAuthorized):
+		return errAccessDenied
+	case errors.Is(err, infra.ErrQuotaExceeded):
+		return errQuotaExceeded
+	case errors.Is(err, files.ErrInvalidObjectKey):
+		return errInvalidObjectKey
+	case errors.Is(err, files.ErrFileTooLarge):
+		return errEntityTooLarge
+	case errors.Is(err, files.ErrFileSizeMismatch):
+		return errIncompleteBody
+	default:
+		return errInternalError
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	s3Err := toS3Error(err)
+	if s3Err == errInternalError {
+		log.Printf("Error handling S3 %s %s: %v", r.Method, r.URL.Path, err)
+	}
+
+	// Responses to HEAD requests have no body, so only the status is sent.
+	if r.Method == http.MethodHead {
+		w.WriteHeader(s3Err.status)
+		return
+	}
+	writeXML(w, s3Err.status, errorResponse{
+		Code:     s3Err.code,
+		Message:  s3Err.message,
+		Resource: r.URL.Path,
+	})
+}
+
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	body, err := xml.Marshal(v)
+	if err != nil {
+		log.Printf("Error encoding S3 response: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	w.Write(body)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/olad5/file-fort/internal/services/auth"
+	"github.com/olad5/file-fort/internal/usecases/accesskeys"
+	"github.com/olad5/file-fort/internal/usecases/files"
+)
+
+// Prefix is the path the S3 gateway is served under. Clients address it in
+// path style, with the bucket as the first element after the prefix.
+const Prefix = "/s3"
+
+// subresources are the query parameters that turn a request into one of the
+// S3 operations the gateway does not implement.
+var subresources = []string{
+	"acl", "attributes", "cors", "delete", "legal-hold", "lifecycle", "policy",
+	"restore", "retention", "select", "tagging", "torrent", "uploadId", "uploads",
+	"versionId", "versioning", "versions",
+}
+
+// S3Handler serves a small subset of the S3 API over each user's folders,
+// for tools that already speak S3. Requests are signed with SigV4 using an
+// access key from /users/me/access-keys, and act as the key's user, so every
+// operation goes through the files service like any other request.
+type S3Handler struct {
+	fileService      files.FileService
+	accessKeyService accesskeys.AccessKeyService
+}
+
+func NewS3Handler(fileService files.FileService, accessKeyService accesskeys.AccessKeyService) (*S3Handler, error) {
+	if fileService == (files.FileService{}) {
+		return nil, errors.New("file service cannot be empty")
+	}
+	if accessKeyService == (accesskeys.AccessKeyService{}) {
+		return nil, errors.New("access key service cannot be empty")
+	}
+
+	return &S3Handler{fileService, accessKeyService}, nil
+}
+
+func (h S3Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, err := verifyRequest(r, h.accessKeyService.GetKeyOwner, time.Now())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	ctx := auth.Set(r.Context(), auth.JWTClaims{ID: user.ID, Role: user.Role, Email: user.Email})
+	r = r.WithContext(ctx)
+
+	query := r.URL.Query()
+	for _, subresource := range subresources {
+		if query.Has(subresource) {
+			writeError(w, r, errNotImplemented)
+			return
+		}
+	}
+
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, Prefix), "/"), "/")
+	switch {
+	case bucketName == "" && r.Method == http.MethodGet:
+		h.listBuckets(w, r)
+	case bucketName == "":
+		writeError(w, r, errMethodNotAllowed)
+	case key == "" && r.Method == http.MethodHead:
+		h.headBucket(w, r, bucketName)
+	case key == "" && r.Method == http.MethodGet && query.Has("location"):
+		h.getBucketLocation(w, r, bucketName)
+	case key == "" && r.Method == http.MethodGet && query.Get("list-type") == "2":
+		h.listObjectsV2(w, r, bucketName)
+	case key == "":
+		writeError(w, r, errNotImplemented)
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		h.getObject(w, r, bucketName, key)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") == "":
+		h.putObject(w, r, bucketName, key)
+	case r.Method == http.MethodDelete:
+		h.deleteObject(w, r, bucketName, key)
+	default:
+		writeError(w, r, errNotImplemented)
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/olad5/file-fort/internal/usecases/files"
+)
+
+// objectETag is the ETag of a file, which changes whenever the content at a
+// key does, since writing a key replaces the file there with a new one. It is
+// not an MD5 of the content, and has dashes in it so that clients do not
+// mistake it for one.
+func objectETag(fileId string) string {
+	return `"` + fileId + `"`
+}
+
+func (h S3Handler) getObject(w http.ResponseWriter, r *http.Request, bucketName, key string) {
+	ctx := r.Context()
+
+	folder, err := h.fileService.GetBucket(ctx, bucketName)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	file, err := h.fileService.GetObject(ctx, folder, key)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	offset, length, partial, err := parseRange(r.Header.Get("Range"), file.FileSize)
+	if err != nil {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", file.FileSize))
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+	w.Header().Set("ETag", objectETag(file.ID.String()))
+	w.Header().Set("Last-Modified", file.CreatedAt.UTC().Format(http.TimeFormat))
+	w.Header().Set("Accept-Ranges", "bytes")
+	status := http.StatusOK
+	if partial {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, file.FileSize))
+		status = http.StatusPartialContent
+	}
+	if r.Method == http.MethodHead || length == 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	body, err := h.fileService.ReadFile(ctx, file, offset, length)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	defer body.Close()
+
+	w.WriteHeader(status)
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("Error streaming file %s over S3: %v", file.ID, err)
+	}
+}
+
+func (h S3Handler) putObject(w http.ResponseWriter, r *http.Request, bucketName, key string) {
+	ctx := r.Context()
+	if r.ContentLength < 0 {
+		writeError(w, r, errMissingContentLength)
+		return
+	}
+	if r.ContentLength > files.MaxUploadSize {
+		writeError(w, r, errEntityTooLarge)
+		return
+	}
+
+	folder, err := h.fileService.GetBucket(ctx, bucketName)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	file, err := h.fileService.PutObject(ctx, folder, key, r.ContentLength, r.Body)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", objectETag(file.ID.String()))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h S3Handler) deleteObject(w http.ResponseWriter, r *http.Request, bucketName, key string) {
+	ctx := r.Context()
+
+	folder, err := h.fileService.GetBucket(ctx, bucketName)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := h.fileService.DeleteObject(ctx, folder, key); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseRange parses a Range header holding a single byte range, and returns
+// the offset and length to read. Like S3, a header that cannot be parsed is
+// ignored and the whole object returned.
+func parseRange(header string, size int64) (int64, int64, bool, error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, size, false, nil
+	}
+	first, last, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, size, false, nil
+	}
+
+	var start, end int64
+	var err error
+	switch {
+	case first == "":
+		suffix, err := strconv.ParseInt(last, 10, 64)
+		if err != nil {
+			return 0, size, false, nil
+		}
+		if suffix == 0 {
+			return 0, 0, false, errInvalidRange
+		}
+		if suffix > size {
+			suffix = size
+		}
+		start, end = size-suffix, size-1
+	default:
+		start, err = strconv.ParseInt(first, 10, 64)
+		if err != nil {
+			return 0, size, false, nil
+		}
+		end = size - 1
+		if last != "" {
+			end, err = strconv.ParseInt(last, 10, 64)
+			if err != nil || end < start {
+				return 0, size, false, nil
+			}
+			if end > size-1 {
+				end = size - 1
+			}
+		}
+	}
+	if start >= size || size == 0 {
+		return 0, 0, false, errInvalidRange
+	}
+	return start, end - start + 1, true, nil
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olad5/file-fort/internal/domain"
+)
+
+const (
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	amzDateFormat    = "20060102T150405Z"
+	maxClockSkew     = 15 * time.Minute
+	maxPresignExpiry = 7 * 24 * time.Hour
+
+	unsignedPayload          = "UNSIGNED-PAYLOAD"
+	streamingSignedPayload   = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	streamingUnsignedPayload = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+	emptyPayloadHash         = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// signature holds the parts of a SigV4 signed request, whether the signature
+// came in the Authorization header or in a presigned URL.
+type signature struct {
+	accessKeyId   string
+	date          string
+	region        string
+	service       string
+	signedHeaders []string
+	signature     string
+	amzDate       time.Time
+	payloadHash   string
+	presigned     bool
+}
+
+func (s signature) scope() string {
+	return strings.Join([]string{s.date, s.region, s.service, "aws4_request"}, "/")
+}
+
+type keyLookup func(ctx context.Context, accessKeyId string) (domain.AccessKey, domain.User, error)
+
+// verifyRequest checks the SigV4 signature of r. On success the body of r is
+// replaced with one that checks the payload against the hash that was signed,
+// or decodes it when it was sent in aws-chunked encoding.
+func verifyRequest(r *http.Request, lookup keyLookup, now time.Time) (domain.User, error) {
+	sig, err := parseSignature(r)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	if sig.presigned {
+		if sig.amzDate.After(now.Add(maxClockSkew)) {
+			return domain.User{}, errRequestTimeTooSkewed
+		}
+		expires, err := strconv.Atoi(r.URL.Query().Get("X-Amz-Expires"))
+		if err != nil || expires < 0 || time.Duration(expires)*time.Second > maxPresignExpiry {
+			return domain.User{}, errAuthorizationQueryParametersError
+		}
+		if now.After(sig.amzDate.Add(time.Duration(expires) * time.Second)) {
+			return domain.User{}, errExpiredPresignedRequest
+		}
+	} else if sig.amzDate.Before(now.Add(-maxClockSkew)) || sig.amzDate.After(now.Add(maxClockSkew)) {
+		return domain.User{}, errRequestTimeTooSkewed
+	}
+
+	accessKey, user, err := lookup(r.Context(), sig.accessKeyId)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	signingKey := deriveSigningKey(accessKey.SecretKey, sig)
+	expected := hex.EncodeToString(hmacSHA256(signingKey, stringToSign(sig, canonicalRequest(r, sig))))
+	if !hmac.Equal([]byte(expected), []byte(sig.signature)) {
+		return domain.User{}, errSignatureDoesNotMatch
+	}
+
+	// The length of a streaming upload is that of its decoded chunks, which
+	// the chunked reader holds the body to.
+	if strings.HasPrefix(sig.payloadHash, "STREAMING-") {
+		decodedLength, err := strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64)
+		if err != nil || decodedLength < 0 {
+			return domain.User{}, errMissingContentLength
+		}
+		r.ContentLength = decodedLength
+	}
+
+	switch {
+	case sig.payloadHash == unsignedPayload:
+	case sig.payloadHash == streamingSignedPayload:
+		r.Body = newChunkedReader(r.Body, r.ContentLength, &chunkSigner{key: signingKey, sig: sig, previous: sig.signature})
+	case sig.payloadHash == streamingUnsignedPayload:
+		r.Body = newChunkedReader(r.Body, r.ContentLength, nil)
+	case isPayloadHash(sig.payloadHash):
+		r.Body = &hashingReader{body: r.Body, hash: sha256.New(), expected: sig.payloadHash}
+	default:
+		return domain.User{}, errNotImplemented
+	}
+	return user, nil
+}
+
+func parseSignature(r *http.Request) (signature, error) {
+	query := r.URL.Query()
+	var sig signature
+	var credential, signedHeaders, amzDate string
+
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		algorithm, fields, _ := strings.Cut(authorization, " ")
+		if algorithm != signingAlgorithm {
+			return signature{}, errUnsupportedSignature
+		}
+		for _, field := range strings.Split(fields, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+			switch name {
+			case "Credential":
+				credential = value
+			case "SignedHeaders":
+				signedHeaders = value
+			case "Signature":
+				sig.signature = value
+			}
+		}
+		amzDate = r.Header.Get("X-Amz-Date")
+		if amzDate == "" {
+			amzDate = r.Header.Get("Date")
+		}
+		sig.payloadHash = r.Header.Get("X-Amz-Content-Sha256")
+		if sig.payloadHash == "" {
+			return signature{}, errMissingContentSHA256
+		}
+	} else if query.Has("X-Amz-Signature") {
+		if query.Get("X-Amz-Algorithm") != signingAlgorithm {
+			return signature{}, errUnsupportedSignature
+		}
+		credential = query.Get("X-Amz-Credential")
+		signedHeaders = query.Get("X-Amz-SignedHeaders")
+		sig.signature = query.Get("X-Amz-Signature")
+		amzDate = query.Get("X-Amz-Date")
+		sig.payloadHash = unsignedPayload
+		sig.presigned = true
+	} else {
+		return signature{}, errAccessDenied
+	}
+
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[4] != "aws4_request" || signedHeaders == "" || sig.signature == "" {
+		return signature{}, errAuthorizationMalformed
+	}
+	sig.accessKeyId, sig.date, sig.region, sig.service = parts[0], parts[1], parts[2], parts[3]
+	if sig.service != "s3" {
+		return signature{}, errAuthorizationMalformed
+	}
+	sig.signedHeaders = strings.Split(signedHeaders, ";")
+	if !sort.StringsAreSorted(sig.signedHeaders) {
+		return signature{}, errAuthorizationMalformed
+	}
+	hasHost := false
+	for _, header := range sig.signedHeaders {
+		if header == "host" {
+			hasHost = true
+		}
+	}
+	if !hasHost {
+		return signature{}, errAuthorizationMalformed
+	}
+
+	parsedDate, err := time.Parse(amzDateFormat, amzDate)
+	if err != nil {
+		parsedDate, err = http.ParseTime(amzDate)
+		if err != nil {
+			return signature{}, errAuthorizationMalformed
+		}
+	}
+	if parsedDate.UTC().Format("20060102") != sig.date {
+		return signature{}, errAuthorizationMalformed
+	}
+	sig.amzDate = parsedDate.UTC()
+	return sig, nil
+}
+
+func canonicalRequest(r *http.Request, sig signature) string {
+	headers := []string{}
+	for _, name := range sig.signedHeaders {
+		var value string
+		switch name {
+		case "host":
+			value = r.Host
+		case "content-length":
+			value = strconv.FormatInt(r.ContentLength, 10)
+		default:
+			values := []string{}
+			for _, v := range r.Header.Values(name) {
+				values = append(values, strings.Join(strings.Fields(v), " "))
+			}
+			value = strings.Join(values, ",")
+		}
+		headers = append(headers, name+":"+value+"\n")
+	}
+
+	return strings.Join([]string{
+		r.Method,
+		escapePath(r.URL.Path),
+		canonicalQuery(r.URL.Query()),
+		strings.Join(headers, ""),
+		strings.Join(sig.signedHeaders, ";"),
+		sig.payloadHash,
+	}, "\n")
+}
+
+func canonicalQuery(query url.Values) string {
+	pairs := []string{}
+	for name, values := range query {
+		if name == "X-Amz-Signature" {
+			continue
+		}
+		for _, value := range values {
+			pairs = append(pairs, escape(name, true)+"="+escape(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+func stringToSign(sig signature, canonicalRequest string) string {
+	return strings.Join([]string{
+		signingAlgorithm,
+		sig.amzDate.Format(amzDateFormat),
+		sig.scope(),
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+}
+
+func deriveSigningKey(secretKey string, sig signature) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretKey), sig.date)
+	key = hmacSHA256(key, sig.region)
+	key = hmacSHA256(key, sig.service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func isPayloadHash(value string) bool {
+	decoded, err := hex.DecodeString(value)
+	return err == nil && len(decoded) == sha256.Size && value == strings.ToLower(value)
+}
+
+func escapePath(path string) string {
+	return escape(path, false)
+}
+
+// escape percent-encodes everything but the characters SigV4 leaves
+// unreserved, and slashes too unless encodeSlash is set.
+func escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// hashingReader fails the read that reaches the end of the body if the body
+// does not match the hash it was signed with.
+type hashingReader struct {
+	body     io.ReadCloser
+	hash     hash.Hash
+	expected string
+}
+
+func (h *hashingReader) Read(p []byte) (int, error) {
+	n, err := h.body.Read(p)
+	h.hash.Write(p[:n])
+	if errors.Is(err, io.EOF) && hex.EncodeToString(h.hash.Sum(nil)) != h.expected {
+		return n, errContentSHA256Mismatch
+	}
+	return n, err
+}
+
+func (h *hashingReader) Close() error {
+	return h.body.Close()
+}
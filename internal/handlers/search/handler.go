@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/olad5/file-fort/internal/usecases/search"
+)
+
+type SearchHandler struct {
+	searchService search.SearchService
+}
+
+func NewSearchHandler(searchService search.SearchService) (*SearchHandler, error) {
+	if searchService == (search.SearchService{}) {
+		return nil, errors.New("search service cannot be empty")
+	}
+
+	return &SearchHandler{searchService}, nil
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	fileHandlers "github.com/olad5/file-fort/internal/handlers/files"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/usecases/search"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func (s SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	searchQuery := infra.SearchQuery{
+		Text:        query.Get("q"),
+		Tags:        query["tag"],
+		ContentType: query.Get("mime"),
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **int64
+	}{{"min_size", &searchQuery.MinSize}, {"max_size", &searchQuery.MaxSize}} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || size < 0 {
+			response.ErrorResponse(w, param.name+" must be a non-negative number", http.StatusBadRequest)
+			return
+		}
+		*param.target = &size
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{{"from", &searchQuery.From}, {"to", &searchQuery.To}} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			response.ErrorResponse(w, param.name+" must be an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		*param.target = &parsed
+	}
+
+	if folderQuery := query.Get("folder_id"); folderQuery != "" {
+		folderId, err := uuid.Parse(folderQuery)
+		if err != nil {
+			response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+			return
+		}
+		searchQuery.FolderId = &folderId
+	}
+
+	pageNumber, rowsPerPage := 1, 20
+	if pageQuery := query.Get("page"); pageQuery != "" {
+		page, err := strconv.Atoi(pageQuery)
+		if err != nil {
+			response.ErrorResponse(w, "page must be a number", http.StatusBadRequest)
+			return
+		}
+		pageNumber = page
+	}
+	if rowQuery := query.Get("rows"); rowQuery != "" {
+		rows, err := strconv.Atoi(rowQuery)
+		if err != nil {
+			response.ErrorResponse(w, "rows must be a number", http.StatusBadRequest)
+			return
+		}
+		rowsPerPage = rows
+	}
+	if pageNumber < 1 {
+		pageNumber = 1
+	}
+	if rowsPerPage < 1 || rowsPerPage > 50 {
+		rowsPerPage = 20
+	}
+	searchQuery.Limit = rowsPerPage
+	searchQuery.Offset = (pageNumber - 1) * rowsPerPage
+
+	results, total, err := s.searchService.Search(ctx, searchQuery)
+	if err != nil {
+		switch {
+		case errors.Is(err, search.ErrEmptyQuery),
+			errors.Is(err, search.ErrQueryTooLong),
+			errors.Is(err, domain.ErrTooManyTags),
+			errors.Is(err, search.ErrInvalidSizeRange),
+			errors.Is(err, search.ErrInvalidTimeRange):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, infra.ErrFolderNotFound):
+			response.ErrorResponse(w, "folder does not exist", http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrUserNotAuthorized):
+			response.ErrorResponse(w, "unauthorized to view this folder", http.StatusForbidden)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	responseResults := []map[string]interface{}{}
+	for _, result := range results {
+		responseResults = append(responseResults, toResponseSearchResult(result))
+	}
+
+	response.SuccessResponse(w, "search results retrieved successfully",
+		map[string]interface{}{
+			"results":       responseResults,
+			"total":         total,
+			"page":          pageNumber,
+			"rows_per_page": rowsPerPage,
+		})
+}
+
+func toResponseSearchResult(result domain.SearchResult) map[string]interface{} {
+	var body map[string]interface{}
+	if result.Type == domain.SearchResultFolder {
+		body = fileHandlers.ToResponseFolder(*result.Folder)
+	} else {
+		body = fileHandlers.ToResponseFile(*result.File)
+	}
+	body["type"] = result.Type
+	body["rank"] = result.Rank
+	body["snippet"] = toHtmlSnippet(result.Snippet)
+	return body
+}
+
+// toHtmlSnippet escapes the snippet so that it is safe to render as HTML and
+// wraps each matching term in a mark element.
+func toHtmlSnippet(snippet string) string {
+	return strings.NewReplacer(
+		domain.HighlightStart, "<mark>",
+		domain.HighlightEnd, "</mark>",
+	).Replace(html.EscapeString(snippet))
+}
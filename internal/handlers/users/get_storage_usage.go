@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func (u UserHandler) GetStorageUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	usage, err := u.userService.GetStorageUsage(ctx)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "storage usage retrieved successfully", toResponseStorageUsage(usage))
+}
+
+func toResponseStorageUsage(usage domain.StorageUsage) map[string]interface{} {
+	var remainingBytes interface{}
+	if usage.QuotaBytes != nil {
+		remaining := *usage.QuotaBytes - usage.UsedBytes
+		if remaining < 0 {
+			remaining = 0
+		}
+		remainingBytes = remaining
+	}
+
+	return map[string]interface{}{
+		"user_id":         usage.UserId,
+		"used_bytes":      usage.UsedBytes,
+		"quota_bytes":     usage.QuotaBytes,
+		"remaining_bytes": remainingBytes,
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/usecases/users"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func (u UserHandler) SetUserQuota(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		response.ErrorResponse(w, "user id required", http.StatusBadRequest)
+		return
+	}
+
+	userId, err := uuid.Parse(id)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	type requestDTO struct {
+		QuotaBytes *int64 `json:"quota_bytes"`
+	}
+	var request requestDTO
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+
+	usage, err := u.userService.SetUserQuota(ctx, userId, request.QuotaBytes)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrUserNotFound):
+			response.ErrorResponse(w, "user does not exist", http.StatusNotFound)
+			return
+		case errors.Is(err, users.ErrInvalidQuota):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "user quota updated successfully", toResponseStorageUsage(usage))
+}
+
+func (u UserHandler) SetRoleQuota(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	role := chi.URLParam(r, "role")
+	if role == "" {
+		response.ErrorResponse(w, "role required", http.StatusBadRequest)
+		return
+	}
+
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	type requestDTO struct {
+		QuotaBytes *int64 `json:"quota_bytes"`
+	}
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+	if request.QuotaBytes == nil {
+		response.ErrorResponse(w, "quota_bytes required", http.StatusBadRequest)
+		return
+	}
+
+	err = u.userService.SetRoleQuota(ctx, domain.Role(role), *request.QuotaBytes)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrInvalidRole), errors.Is(err, users.ErrInvalidQuota):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "role quota updated successfully",
+		map[string]interface{}{
+			"role":        role,
+			"quota_bytes": *request.QuotaBytes,
+		})
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/usecases/files"
+	"golang.org/x/net/webdav"
+)
+
+var (
+	errIsDirectory  = errors.New("is a directory")
+	errNotDirectory = errors.New("not a directory")
+	errReadOnly     = errors.New("file is open for reading")
+	errWriteOnly    = errors.New("file is open for writing")
+)
+
+// fileInfo describes an entry. It implements the optional interfaces the
+// webdav package checks for, so that listing a folder never reads the
+// content of its files.
+type fileInfo struct {
+	name        string
+	size        int64
+	modTime     time.Time
+	isDir       bool
+	contentType string
+	etag        string
+}
+
+func (f fileInfo) Name() string       { return f.name }
+func (f fileInfo) Size() int64        { return f.size }
+func (f fileInfo) ModTime() time.Time { return f.modTime }
+func (f fileInfo) IsDir() bool        { return f.isDir }
+func (f fileInfo) Sys() interface{}   { return nil }
+
+func (f fileInfo) Mode() os.FileMode {
+	if f.isDir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+func (f fileInfo) ContentType(ctx context.Context) (string, error) {
+	if f.contentType == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return f.contentType, nil
+}
+
+func (f fileInfo) ETag(ctx context.Context) (string, error) {
+	if f.etag == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return f.etag, nil
+}
+
+// fileETag changes whenever the content of a path does, since writing a file
+// replaces it with a new one.
+func fileETag(file domain.File) string {
+	return `"` + file.ID.String() + `"`
+}
+
+// davFile is a file or folder opened for reading. File content is fetched
+// from the file store lazily, from the current offset, so seeking only costs
+// a new request when the file is read afterwards.
+type davFile struct {
+	ctx         context.Context
+	fileService *files.FileService
+	entry       entry
+	info        fileInfo
+	children    []os.FileInfo
+	listed      bool
+	offset      int64
+	body        io.ReadCloser
+}
+
+func (d *davFile) Read(p []byte) (int, error) {
+	if d.entry.file == nil {
+		return 0, errIsDirectory
+	}
+	if d.offset >= d.info.size {
+		return 0, io.EOF
+	}
+	if d.body == nil {
+		body, err := d.fileService.ReadFile(d.ctx, *d.entry.file, d.offset, d.info.size-d.offset)
+		if err != nil {
+			return 0, err
+		}
+		d.body = body
+	}
+	n, err := d.body.Read(p)
+	d.offset += int64(n)
+	return n, err
+}
+
+func (d *davFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += d.offset
+	case io.SeekEnd:
+		offset += d.info.size
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	if offset != d.offset && d.body != nil {
+		d.body.Close()
+		d.body = nil
+	}
+	d.offset = offset
+	return offset, nil
+}
+
+func (d *davFile) Readdir(count int) ([]os.FileInfo, error) {
+	if d.entry.folder == nil {
+		return nil, errNotDirectory
+	}
+	if !d.listed {
+		entries, err := fileSystem{d.fileService}.children(d.ctx, *d.entry.folder)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range entries {
+			d.children = append(d.children, child.info())
+		}
+		d.listed = true
+	}
+
+	if count <= 0 {
+		children := d.children
+		d.children = nil
+		return children, nil
+	}
+	if len(d.children) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(d.children) {
+		count = len(d.children)
+	}
+	children := d.children[:count]
+	d.children = d.children[count:]
+	return children, nil
+}
+
+func (d *davFile) Stat() (os.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *davFile) Write(p []byte) (int, error) {
+	return 0, errReadOnly
+}
+
+func (d *davFile) Close() error {
+	if d.body != nil {
+		return d.body.Close()
+	}
+	return nil
+}
+
+// davUpload is a file opened for writing. Content is buffered in a temporary
+// file, as the size has to be known to check the quota, and saved on Close.
+// A file copied from another davFile is copied in the file store instead.
+type davUpload struct {
+	ctx         context.Context
+	fileService *files.FileService
+	folderId    uuid.UUID
+	info        fileInfo
+	temp        *os.File
+	source      *davFile
+}
+
+func (u *davUpload) Write(p []byte) (int, error) {
+	if u.temp == nil {
+		temp, err := os.CreateTemp("", "file-fort-webdav-*")
+		if err != nil {
+			return 0, err
+		}
+		u.temp = temp
+	}
+	n, err := u.temp.Write(p)
+	u.info.size += int64(n)
+	return n, err
+}
+
+// ReadFrom is called by io.Copy, which the webdav package uses for both PUT
+// and COPY.
+func (u *davUpload) ReadFrom(r io.Reader) (int64, error) {
+	if source, ok := r.(*davFile); ok && source.entry.file != nil && u.temp == nil && u.source == nil {
+		u.source = source
+		u.info.size = source.info.size
+		return source.info.size, nil
+	}
+	return io.Copy(struct{ io.Writer }{u}, r)
+}
+
+func (u *davUpload) Stat() (os.FileInfo, error) {
+	// The webdav package reads the ETag after Close, which fills it in.
+	return &u.info, nil
+}
+
+func (u *davUpload) Close() error {
+	if u.temp != nil {
+		defer os.Remove(u.temp.Name())
+		defer u.temp.Close()
+	}
+
+	var saved domain.File
+	var err error
+	switch {
+	case u.source != nil:
+		saved, err = u.fileService.CopyFile(u.ctx, u.source.entry.file.ID, &u.folderId)
+		if err == nil && saved.FileName != u.info.name {
+			saved, err = u.fileService.RenameFile(u.ctx, saved.ID, u.folderId, u.info.name)
+		}
+	case u.temp != nil:
+		if _, err = u.temp.Seek(0, io.SeekStart); err == nil {
+			saved, err = u.fileService.PutFile(u.ctx, u.folderId, u.info.name, u.info.size, u.temp)
+		}
+	default:
+		saved, err = u.fileService.PutFile(u.ctx, u.folderId, u.info.name, 0, strings.NewReader(""))
+	}
+	if err != nil {
+		return err
+	}
+
+	u.info.modTime = time.Now()
+	u.info.etag = fileETag(saved)
+	return nil
+}
+
+func (u *davUpload) Read(p []byte) (int, error) {
+	return 0, errWriteOnly
+}
+
+func (u *davUpload) Seek(offset int64, whence int) (int64, error) {
+	return 0, errWriteOnly
+}
+
+func (u *davUpload) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, errNotDirectory
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/usecases/files"
+	"golang.org/x/net/webdav"
+)
+
+// fileSystem maps WebDAV paths onto the caller's folders and files. The root
+// is the home folder, which also lists the top level folders, and folders made
+// at the root become top level folders.
+type fileSystem struct {
+	fileService *files.FileService
+}
+
+var _ webdav.FileSystem = fileSystem{}
+
+// entry is a file or folder, under the name it has in a WebDAV path.
+type entry struct {
+	name   string
+	folder *domain.Folder
+	file   *domain.File
+}
+
+func (e entry) info() fileInfo {
+	if e.folder != nil {
+		return fileInfo{name: e.name, modTime: e.folder.UpdatedAt, isDir: true}
+	}
+	return fileInfo{
+		name:        e.name,
+		size:        e.file.FileSize,
+		modTime:     e.file.UpdatedAt,
+		contentType: e.file.ContentType,
+		etag:        fileETag(*e.file),
+	}
+}
+
+func (fs fileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	parent, base, err := fs.resolveParent(ctx, "mkdir", name)
+	if err != nil {
+		return err
+	}
+	if _, ok, err := fs.lookup(ctx, parent, base); err != nil {
+		return toFileSystemError("mkdir", name, err)
+	} else if ok {
+		return &os.PathError{Op: "mkdir", Path: name, Err: os.ErrExist}
+	}
+
+	_, err = fs.fileService.CreateFolder(ctx, base, parentId(parent))
+	return toFileSystemError("mkdir", name, err)
+}
+
+func (fs fileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		found, err := fs.resolve(ctx, "open", name)
+		if err != nil {
+			return nil, err
+		}
+		return &davFile{ctx: ctx, fileService: fs.fileService, entry: found, info: found.info()}, nil
+	}
+
+	parent, base, err := fs.resolveParent(ctx, "open", name)
+	if err != nil {
+		return nil, err
+	}
+	existing, ok, err := fs.lookup(ctx, parent, base)
+	if err != nil {
+		return nil, toFileSystemError("open", name, err)
+	}
+	switch {
+	case ok && existing.folder != nil:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrPermission}
+	case ok && flag&os.O_EXCL != 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrExist}
+	case !ok && flag&os.O_CREATE == 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return &davUpload{ctx: ctx, fileService: fs.fileService, folderId: parent.ID, info: fileInfo{name: base}}, nil
+}
+
+func (fs fileSystem) RemoveAll(ctx context.Context, name string) error {
+	found, err := fs.resolve(ctx, "remove", name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if found.file != nil {
+		err = fs.fileService.DeleteFile(ctx, found.file.ID)
+	} else {
+		err = fs.fileService.DeleteFolder(ctx, found.folder.ID)
+	}
+	return toFileSystemError("remove", name, err)
+}
+
+// Rename replaces a file already at newName, as os.Rename does. The webdav
+// package only renames onto an existing file when the request allows it to
+// be overwritten.
+func (fs fileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	found, err := fs.resolve(ctx, "rename", oldName)
+	if err != nil {
+		return err
+	}
+	parent, base, err := fs.resolveParent(ctx, "rename", newName)
+	if err != nil {
+		return err
+	}
+
+	if found.file != nil {
+		existing, resolveErr := fs.resolve(ctx, "rename", newName)
+		if resolveErr != nil && !os.IsNotExist(resolveErr) {
+			return resolveErr
+		}
+		if resolveErr == nil && existing.file != nil && existing.file.ID != found.file.ID {
+			if err := fs.fileService.DeleteFile(ctx, existing.file.ID); err != nil {
+				return toFileSystemError("rename", newName, err)
+			}
+		}
+		_, err = fs.fileService.RenameFile(ctx, found.file.ID, parent.ID, base)
+	} else {
+		_, err = fs.fileService.RenameFolder(ctx, found.folder.ID, parentId(parent), base)
+	}
+	return toFileSystemError("rename", oldName, err)
+}
+
+func (fs fileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	info, err := fs.stat(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (fs fileSystem) stat(ctx context.Context, name string) (fileInfo, error) {
+	found, err := fs.resolve(ctx, "stat", name)
+	if err != nil {
+		return fileInfo{}, err
+	}
+	return found.info(), nil
+}
+
+// resolve walks name down from the home folder.
+func (fs fileSystem) resolve(ctx context.Context, op, name string) (entry, error) {
+	home, err := fs.fileService.GetHomeFolder(ctx)
+	if err != nil {
+		return entry{}, toFileSystemError(op, name, err)
+	}
+
+	current := entry{name: "/", folder: &home}
+	for _, segment := range splitPath(name) {
+		if current.folder == nil {
+			return entry{}, &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+		}
+		next, ok, err := fs.lookup(ctx, *current.folder, segment)
+		if err != nil {
+			return entry{}, toFileSystemError(op, name, err)
+		}
+		if !ok {
+			return entry{}, &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// resolveParent resolves the folder that name would be created in, and
+// returns it with the last element of name.
+func (fs fileSystem) resolveParent(ctx context.Context, op, name string) (domain.Folder, string, error) {
+	segments := splitPath(name)
+	if len(segments) == 0 {
+		return domain.Folder{}, "", &os.PathError{Op: op, Path: name, Err: os.ErrPermission}
+	}
+
+	parent, err := fs.resolve(ctx, op, strings.Join(segments[:len(segments)-1], "/"))
+	if err != nil {
+		return domain.Folder{}, "", err
+	}
+	if parent.folder == nil {
+		return domain.Folder{}, "", &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+	return *parent.folder, segments[len(segments)-1], nil
+}
+
+func (fs fileSystem) lookup(ctx context.Context, folder domain.Folder, name string) (entry, bool, error) {
+	entries, err := fs.children(ctx, folder)
+	if err != nil {
+		return entry{}, false, err
+	}
+	for _, child := range entries {
+		if child.name == name {
+			return child, true, nil
+		}
+	}
+	return entry{}, false, nil
+}
+
+// children lists a folder by name. Names need not be unique in file-fort, so
+// a folder hides files of the same name, and of several files with one name
+// only the newest is listed.
+func (fs fileSystem) children(ctx context.Context, folder domain.Folder) ([]entry, error) {
+	folders, folderFiles, err := fs.fileService.GetFolderContents(ctx, folder.ID)
+	if err != nil {
+		return []entry{}, err
+	}
+
+	entries := []entry{}
+	indexes := map[string]int{}
+	for i := range folders {
+		if _, ok := indexes[folders[i].FolderName]; ok {
+			continue
+		}
+		indexes[folders[i].FolderName] = len(entries)
+		entries = append(entries, entry{name: folders[i].FolderName, folder: &folders[i]})
+	}
+	for i := range folderFiles {
+		file := &folderFiles[i]
+		index, ok := indexes[file.FileName]
+		if !ok {
+			indexes[file.FileName] = len(entries)
+			entries = append(entries, entry{name: file.FileName, file: file})
+			continue
+		}
+		if existing := entries[index].file; existing != nil && file.CreatedAt.After(existing.CreatedAt) {
+			entries[index].file = file
+		}
+	}
+	return entries, nil
+}
+
+func splitPath(name string) []string {
+	cleaned := strings.Trim(path.Clean("/"+name), "/")
+	if cleaned == "" {
+		return []string{}
+	}
+	return strings.Split(cleaned, "/")
+}
+
+// parentId is the parent a folder made in folder gets. Folders made at the
+// root are top level folders, like folders made through the API without a
+// parent.
+func parentId(folder domain.Folder) *uuid.UUID {
+	if folder.ID == folder.OwnerId {
+		return nil
+	}
+	return &folder.ID
+}
+
+// toFileSystemError turns the errors of the files service into the os errors
+// the webdav package maps to status codes.
+func toFileSystemError(op, name string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, infra.ErrFileNotFound), errors.Is(err, infra.ErrFolderNotFound):
+		return &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	case errors.Is(err, infra.ErrUserNotAuthorized),
+		errors.Is(err, files.ErrHomeFolder),
+		errors.Is(err, files.ErrFolderCycle):
+		return &os.PathError{Op: op, Path: name, Err: os.ErrPermission}
+	case errors.Is(err, files.ErrFileNameTaken):
+		return &os.PathError{Op: op, Path: name, Err: os.ErrExist}
+	default:
+		return err
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/services/auth"
+	"github.com/olad5/file-fort/internal/usecases/files"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+	"golang.org/x/net/webdav"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+// Prefix is the path the WebDAV tree is served under.
+const Prefix = "/webdav"
+
+// Methods are the request methods WebDAV adds to HTTP.
+var Methods = []string{"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK"}
+
+// WebDAVHandler serves each user's files as a WebDAV tree, with their home
+// folder at the root.
+type WebDAVHandler struct {
+	fileService files.FileService
+	locks       *lockSystems
+}
+
+func NewWebDAVHandler(fileService files.FileService) (*WebDAVHandler, error) {
+	if fileService == (files.FileService{}) {
+		return nil, errors.New("file service cannot be empty")
+	}
+
+	return &WebDAVHandler{fileService, newLockSystems()}, nil
+}
+
+func (h WebDAVHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		response.ErrorResponse(w, appErrors.ErrUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	fs := fileSystem{&h.fileService}
+	name := strings.TrimPrefix(r.URL.Path, Prefix)
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		// Setting the content type up front stops http.ServeContent from
+		// reading the start of the file to sniff it.
+		if info, err := fs.stat(ctx, name); err == nil && !info.IsDir() {
+			w.Header().Set("Content-Type", info.contentType)
+		}
+	case http.MethodPut:
+		size := r.ContentLength
+		if size < 0 {
+			// Some clients, such as the macOS Finder, stream uploads with
+			// their length in X-Expected-Entity-Length instead.
+			expected, err := strconv.ParseInt(r.Header.Get("X-Expected-Entity-Length"), 10, 64)
+			if err != nil || expected < 0 {
+				http.Error(w, http.StatusText(http.StatusLengthRequired), http.StatusLengthRequired)
+				return
+			}
+			size = expected
+		}
+		if size > files.MaxUploadSize {
+			http.Error(w, files.ErrFileTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		// The body is buffered on disk before it is saved, so it is held to
+		// the size checked here.
+		r.Body = http.MaxBytesReader(w, r.Body, size)
+
+		// The webdav package answers any failure to save a file with 405, so
+		// the quota is checked first to give clients a clear answer.
+		if size > 0 {
+			if info, err := fs.stat(ctx, name); err == nil && !info.IsDir() {
+				size -= info.Size()
+			}
+			canStore, err := h.fileService.CanStore(ctx, size)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if !canStore {
+				http.Error(w, "storage quota exceeded", http.StatusInsufficientStorage)
+				return
+			}
+		}
+	}
+
+	handler := &webdav.Handler{
+		Prefix:     Prefix,
+		FileSystem: fs,
+		LockSystem: h.locks.forUser(jwtClaims.ID),
+		Logger: func(r *http.Request, err error) {
+			if err != nil && !os.IsNotExist(err) && !errors.Is(err, webdav.ErrLocked) {
+				log.Printf("Error handling WebDAV %s %s: %v", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+	handler.ServeHTTP(w, r)
+}
+
+// maxLockDuration bounds how long a lock lasts without being refreshed,
+// including locks asked for with an infinite timeout. A user's lock system
+// that has not been used for that long holds no live locks and is dropped.
+const maxLockDuration = time.Hour
+
+// lockSystems keeps the locks of each user apart, since every user sees their
+// own tree under the same paths. Locks are held in memory, so they only
+// protect against clients of the same server instance.
+type lockSystems struct {
+	mu        sync.Mutex
+	byUser    map[uuid.UUID]*userLockSystem
+	lastSweep time.Time
+}
+
+type userLockSystem struct {
+	lockSystem webdav.LockSystem
+	lastUsed   time.Time
+}
+
+func newLockSystems() *lockSystems {
+	return &lockSystems{byUser: map[uuid.UUID]*userLockSystem{}, lastSweep: time.Now()}
+}
+
+func (l *lockSystems) forUser(userId uuid.UUID) webdav.LockSystem {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) > maxLockDuration {
+		for id, user := range l.byUser {
+			if now.Sub(user.lastUsed) > maxLockDuration {
+				delete(l.byUser, id)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	user, ok := l.byUser[userId]
+	if !ok {
+		user = &userLockSystem{lockSystem: boundedLockSystem{webdav.NewMemLS()}}
+		l.byUser[userId] = user
+	}
+	user.lastUsed = now
+	return user.lockSystem
+}
+
+// boundedLockSystem holds every lock to at most maxLockDuration.
+type boundedLockSystem struct {
+	webdav.LockSystem
+}
+
+func (b boundedLockSystem) Create(now time.Time, details webdav.LockDetails) (string, error) {
+	details.Duration = boundLockDuration(details.Duration)
+	return b.LockSystem.Create(now, details)
+}
+
+func (b boundedLockSystem) Refresh(now time.Time, token string, duration time.Duration) (webdav.LockDetails, error) {
+	return b.LockSystem.Refresh(now, token, boundLockDuration(duration))
+}
+
+func boundLockDuration(duration time.Duration) time.Duration {
+	if duration < 0 || duration > maxLockDuration {
+		return maxLockDuration
+	}
+	return duration
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/usecases/webhooks"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func (wh WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Body == nil {
+		response.ErrorResponse(w, appErrors.ErrMissingBody, http.StatusBadRequest)
+		return
+	}
+	type requestDTO struct {
+		Url    string             `json:"url"`
+		Events []domain.EventType `json:"events"`
+	}
+
+	var request requestDTO
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidJson, http.StatusBadRequest)
+		return
+	}
+	if request.Url == "" {
+		response.ErrorResponse(w, "url required", http.StatusBadRequest)
+		return
+	}
+
+	newWebhook, err := wh.webhookService.CreateWebhook(ctx, request.Url, request.Events)
+	if err != nil {
+		switch {
+		case errors.Is(err, webhooks.ErrInvalidWebhookUrl),
+			errors.Is(err, webhooks.ErrInvalidEventType),
+			errors.Is(err, webhooks.ErrMissingEventTypes),
+			errors.Is(err, webhooks.ErrTooManyWebhooks),
+			errors.Is(err, webhooks.ErrPrivateWebhookUrl),
+			errors.Is(err, webhooks.ErrUnresolvableHost):
+			response.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	data := ToResponseWebhook(newWebhook)
+	data["secret"] = newWebhook.Secret
+	response.SuccessResponse(w, "webhook created successfully", data)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/infra"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func (wh WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		response.ErrorResponse(w, "webhook id required", http.StatusBadRequest)
+		return
+	}
+
+	webhookId, err := uuid.Parse(id)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pageNumber, rowsPerPage := 1, 20
+	if pageQuery := r.URL.Query().Get("page"); pageQuery != "" {
+		pageNumber, err = strconv.Atoi(pageQuery)
+		if err != nil {
+			response.ErrorResponse(w, "page must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+	if rowQuery := r.URL.Query().Get("rows"); rowQuery != "" {
+		rowsPerPage, err = strconv.Atoi(rowQuery)
+		if err != nil {
+			response.ErrorResponse(w, "rows must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if pageNumber < 1 {
+		pageNumber = 1
+	}
+	if rowsPerPage < 1 || rowsPerPage > 100 {
+		rowsPerPage = 20
+	}
+
+	deliveries, err := wh.webhookService.GetDeliveries(ctx, webhookId, pageNumber, rowsPerPage)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrWebhookNotFound):
+			response.ErrorResponse(w, "webhook does not exist", http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrUserNotAuthorized):
+			response.ErrorResponse(w, "unauthorized to manage this webhook", http.StatusForbidden)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	results := []map[string]interface{}{}
+	for _, delivery := range deliveries {
+		results = append(results, ToResponseDelivery(delivery))
+	}
+
+	response.SuccessResponse(w, "webhook deliveries retrieved successfully",
+		map[string]interface{}{
+			"webhook_id":    webhookId,
+			"deliveries":    results,
+			"page":          pageNumber,
+			"rows_per_page": rowsPerPage,
+		})
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func (wh WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	webhooks, err := wh.webhookService.GetWebhooks(ctx)
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+		return
+	}
+
+	results := []map[string]interface{}{}
+	for _, webhook := range webhooks {
+		results = append(results, ToResponseWebhook(webhook))
+	}
+
+	response.SuccessResponse(w, "webhooks retrieved successfully",
+		map[string]interface{}{
+			"webhooks": results,
+		})
+}
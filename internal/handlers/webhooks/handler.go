@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/olad5/file-fort/internal/usecases/webhooks"
+)
+
+type WebhookHandler struct {
+	webhookService webhooks.WebhookService
+}
+
+func NewWebhookHandler(webhookService webhooks.WebhookService) (*WebhookHandler, error) {
+	if webhookService == (webhooks.WebhookService{}) {
+		return nil, errors.New("webhook service cannot be empty")
+	}
+
+	return &WebhookHandler{webhookService}, nil
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/olad5/file-fort/internal/domain"
+)
+
+func ToResponseWebhook(webhook domain.Webhook) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         webhook.ID,
+		"url":        webhook.Url,
+		"events":     webhook.Events,
+		"is_active":  webhook.IsActive,
+		"owner_id":   webhook.OwnerId,
+		"created_at": webhook.CreatedAt,
+		"updated_at": webhook.UpdatedAt,
+	}
+}
+
+func ToResponseDelivery(delivery domain.WebhookDelivery) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                 delivery.ID,
+		"webhook_id":         delivery.WebhookId,
+		"event_id":           delivery.EventId,
+		"event_type":         delivery.EventType,
+		"payload":            json.RawMessage(delivery.Payload),
+		"status":             delivery.Status,
+		"attempts":           delivery.Attempts,
+		"next_attempt_at":    delivery.NextAttemptAt,
+		"last_response_code": delivery.LastResponseCode,
+		"last_error":         delivery.LastError,
+		"delivered_at":       delivery.DeliveredAt,
+		"created_at":         delivery.CreatedAt,
+	}
+}
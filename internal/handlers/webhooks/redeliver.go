@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/infra"
+	appErrors "github.com/olad5/file-fort/pkg/errors"
+
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func (wh WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	webhookId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+	deliveryId, err := uuid.Parse(chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		response.ErrorResponse(w, appErrors.ErrInvalidID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := wh.webhookService.Redeliver(ctx, webhookId, deliveryId)
+	if err != nil {
+		switch {
+		case errors.Is(err, infra.ErrWebhookNotFound):
+			response.ErrorResponse(w, "webhook does not exist", http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrDeliveryNotFound):
+			response.ErrorResponse(w, "webhook delivery does not exist", http.StatusNotFound)
+			return
+		case errors.Is(err, infra.ErrUserNotAuthorized):
+			response.ErrorResponse(w, "unauthorized to manage this webhook", http.StatusForbidden)
+			return
+		default:
+			response.ErrorResponse(w, appErrors.ErrSomethingWentWrong, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.SuccessResponse(w, "webhook delivery queued successfully", ToResponseDelivery(delivery))
+}
@@ -2,16 +2,24 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/google/uuid"
 	"github.com/olad5/file-fort/config"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
 )
 
 type AwsFileStore struct {
@@ -51,10 +59,17 @@ func NewAwsFileStore(ctx context.Context, configurations *config.Configurations)
 	}, nil
 }
 
+// newObjectKey prefixes filename with a random id, so that every file gets
+// an object of its own even when files share a name. Deleting one file then
+// leaves the others intact.
+func newObjectKey(filename string) string {
+	return uuid.NewString() + "/" + filename
+}
+
 func (a *AwsFileStore) SaveToFileStore(ctx context.Context, filename string, file io.Reader) (string, error) {
 	uploader := s3manager.NewUploader(a.session)
 	bucket := a.Bucket
-	key := filename
+	key := newObjectKey(filename)
 	_, err := uploader.Upload(&s3manager.UploadInput{
 		Bucket: bucket,
 		Key:    aws.String(key),
@@ -67,6 +82,45 @@ func (a *AwsFileStore) SaveToFileStore(ctx context.Context, filename string, fil
 	return key, nil
 }
 
+func (a *AwsFileStore) SaveToFileStoreWithKey(ctx context.Context, key, contentType string, file io.Reader) error {
+	uploader := s3manager.NewUploader(a.session)
+	_, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      a.Bucket,
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+		Body:        file,
+	})
+	if err != nil {
+		return fmt.Errorf("Unable to upload %s to %s, %v", key, *a.Bucket, err)
+	}
+	return nil
+}
+
+// CopyFile copies the object at sourceKey within the bucket and returns the
+// key of the copy.
+func (a *AwsFileStore) CopyFile(ctx context.Context, sourceKey, filename string) (string, error) {
+	key := newObjectKey(filename)
+	_, err := a.Client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:     a.Bucket,
+		Key:        aws.String(key),
+		CopySource: aws.String(copySource(*a.Bucket, sourceKey)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("Unable to copy %s in %s, %v", sourceKey, *a.Bucket, err)
+	}
+	return key, nil
+}
+
+// copySource url-encodes the bucket and key of a copy, leaving the slashes
+// between path segments intact.
+func copySource(bucket, key string) string {
+	segments := strings.Split(bucket+"/"+key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
 func (a *AwsFileStore) GetDownloadUrl(ctx context.Context, key string) (string, error) {
 	downloadUrl, err := a.generatePreSignedUrl(ctx, key)
 	if err != nil {
@@ -89,6 +143,51 @@ func (a *AwsFileStore) generatePreSignedUrl(ctx context.Context, key string) (st
 	return urlStr, nil
 }
 
+func (a *AwsFileStore) GetFile(ctx context.Context, key string) (io.ReadCloser, error) {
+	output, err := a.Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: a.Bucket,
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting file from file store: %v", err)
+	}
+	return output.Body, nil
+}
+
+// GetFileInfo returns infra.ErrFileNotFound when there is no object at key.
+func (a *AwsFileStore) GetFileInfo(ctx context.Context, key string) (domain.StoredObject, error) {
+	output, err := a.Client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: a.Bucket,
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var requestErr awserr.RequestFailure
+		if errors.As(err, &requestErr) && requestErr.StatusCode() == http.StatusNotFound {
+			return domain.StoredObject{}, infra.ErrFileNotFound
+		}
+		return domain.StoredObject{}, fmt.Errorf("error getting file info from file store: %v", err)
+	}
+	return domain.StoredObject{
+		Key:          key,
+		Size:         aws.Int64Value(output.ContentLength),
+		LastModified: aws.TimeValue(output.LastModified),
+		ETag:         aws.StringValue(output.ETag),
+	}, nil
+}
+
+// GetFileRange reads length bytes of the object at key, starting at offset.
+func (a *AwsFileStore) GetFileRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	output, err := a.Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: a.Bucket,
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting file range from file store: %v", err)
+	}
+	return output.Body, nil
+}
+
 func (a *AwsFileStore) DeleteFile(ctx context.Context, key string) error {
 	_, err := a.Client.DeleteObject(&s3.DeleteObjectInput{
 		Bucket: a.Bucket,
@@ -100,6 +199,27 @@ func (a *AwsFileStore) DeleteFile(ctx context.Context, key string) error {
 	return nil
 }
 
+func (a *AwsFileStore) ListObjects(ctx context.Context) ([]domain.StoredObject, error) {
+	objects := []domain.StoredObject{}
+	err := a.Client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: a.Bucket,
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range page.Contents {
+			objects = append(objects, domain.StoredObject{
+				Key:          aws.StringValue(object.Key),
+				Size:         aws.Int64Value(object.Size),
+				LastModified: aws.TimeValue(object.LastModified),
+				ETag:         aws.StringValue(object.ETag),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing objects in file store: %v", err)
+	}
+	return objects, nil
+}
+
 func (a *AwsFileStore) Ping(ctx context.Context) error {
 	return nil
 }
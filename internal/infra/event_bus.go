@@ -0,0 +1,67 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+)
+
+type EventHandler func(ctx context.Context, event domain.Event) error
+
+type EventBus interface {
+	Publish(ctx context.Context, event domain.Event) error
+	// Subscribe registers handler under consumer. Every consumer receives each
+	// event at least once; an event is redelivered when handler returns an error.
+	Subscribe(ctx context.Context, consumer string, handler EventHandler) error
+}
+
+// NotificationBroker fans events out to every instance of the server, so that
+// clients connected to any of them are notified live. Unlike the EventBus,
+// delivery is best effort: events notified while nobody listens are dropped.
+type NotificationBroker interface {
+	// Notify passes an event on to the listeners of its owner.
+	Notify(ctx context.Context, event domain.Event) error
+	// Listen returns a channel of the events of a user, which is closed once
+	// ctx is done, or early when the listener falls too far behind.
+	Listen(ctx context.Context, userId uuid.UUID) (<-chan domain.Event, error)
+}
+
+type encodedEvent struct {
+	ID         uuid.UUID              `json:"id"`
+	Type       domain.EventType       `json:"type"`
+	OwnerId    uuid.UUID              `json:"owner_id"`
+	Data       map[string]interface{} `json:"data"`
+	OccurredAt time.Time              `json:"occurred_at"`
+}
+
+func EncodeEvent(event domain.Event) ([]byte, error) {
+	body, err := json.Marshal(encodedEvent{
+		ID:         event.ID,
+		Type:       event.Type,
+		OwnerId:    event.OwnerId,
+		Data:       event.Data,
+		OccurredAt: event.OccurredAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding event: %w", err)
+	}
+	return body, nil
+}
+
+func DecodeEvent(body []byte) (domain.Event, error) {
+	var e encodedEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return domain.Event{}, fmt.Errorf("error decoding event: %w", err)
+	}
+	return domain.Event{
+		ID:         e.ID,
+		Type:       e.Type,
+		OwnerId:    e.OwnerId,
+		Data:       e.Data,
+		OccurredAt: e.OccurredAt,
+	}, nil
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+// MemoryEventBus delivers events synchronously to subscribers in the same
+// process. Publish fails when any subscriber fails, so the outbox relay
+// retries the event and every subscriber sees it at least once.
+type MemoryEventBus struct {
+	mu       sync.RWMutex
+	handlers map[string]infra.EventHandler
+}
+
+func NewMemoryEventBus() *MemoryEventBus {
+	return &MemoryEventBus{handlers: map[string]infra.EventHandler{}}
+}
+
+func (m *MemoryEventBus) Publish(ctx context.Context, event domain.Event) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var errs []error
+	for consumer, handler := range m.handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("consumer %s: %w", consumer, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *MemoryEventBus) Subscribe(ctx context.Context, consumer string, handler infra.EventHandler) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.handlers[consumer]; exists {
+		return fmt.Errorf("consumer %s is already subscribed", consumer)
+	}
+	m.handlers[consumer] = handler
+	return nil
+}
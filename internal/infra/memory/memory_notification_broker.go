@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+)
+
+// listenerBuffer is how many events a listener can fall behind before it is
+// disconnected.
+const listenerBuffer = 64
+
+// MemoryNotificationBroker notifies the listeners in the same process.
+// A listener that does not keep up has its channel closed rather than
+// holding up everyone else; it can catch up from the change feed.
+type MemoryNotificationBroker struct {
+	mu        sync.Mutex
+	listeners map[uuid.UUID]map[chan domain.Event]struct{}
+}
+
+func NewMemoryNotificationBroker() *MemoryNotificationBroker {
+	return &MemoryNotificationBroker{listeners: map[uuid.UUID]map[chan domain.Event]struct{}{}}
+}
+
+func (m *MemoryNotificationBroker) Notify(ctx context.Context, event domain.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for listener := range m.listeners[event.OwnerId] {
+		select {
+		case listener <- event:
+		default:
+			m.remove(event.OwnerId, listener)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryNotificationBroker) Listen(ctx context.Context, userId uuid.UUID) (<-chan domain.Event, error) {
+	listener := make(chan domain.Event, listenerBuffer)
+
+	m.mu.Lock()
+	if m.listeners[userId] == nil {
+		m.listeners[userId] = map[chan domain.Event]struct{}{}
+	}
+	m.listeners[userId][listener] = struct{}{}
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.remove(userId, listener)
+	}()
+	return listener, nil
+}
+
+// remove closes a listener unless it has been removed already. It is called
+// with mu held.
+func (m *MemoryNotificationBroker) remove(userId uuid.UUID, listener chan domain.Event) {
+	if _, ok := m.listeners[userId][listener]; !ok {
+		return
+	}
+	delete(m.listeners[userId], listener)
+	if len(m.listeners[userId]) == 0 {
+		delete(m.listeners, userId)
+	}
+	close(listener)
+}
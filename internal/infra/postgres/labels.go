@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// SqlxMetadata maps the metadata JSONB column of files and folders.
+type SqlxMetadata map[string]string
+
+func (m SqlxMetadata) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (m *SqlxMetadata) Scan(src interface{}) error {
+	var data []byte
+	switch value := src.(type) {
+	case nil:
+		*m = SqlxMetadata{}
+		return nil
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		return fmt.Errorf("cannot scan %T into SqlxMetadata", src)
+	}
+	return json.Unmarshal(data, m)
+}
+
+func toSqlxTags(tags []string) pq.StringArray {
+	if tags == nil {
+		return pq.StringArray{}
+	}
+	return pq.StringArray(tags)
+}
+
+func toDomainTags(tags pq.StringArray) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return []string(tags)
+}
+
+func toDomainMetadata(metadata SqlxMetadata) map[string]string {
+	if metadata == nil {
+		return map[string]string{}
+	}
+	return map[string]string(metadata)
+}
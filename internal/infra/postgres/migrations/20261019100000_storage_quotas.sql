@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+CREATE TABLE role_storage_quotas(
+    role TEXT PRIMARY KEY,
+    quota_bytes BIGINT NOT NULL,
+    CHECK (role IN ('regular', 'admin')),
+    CHECK (quota_bytes >= 0)
+);
+
+INSERT INTO role_storage_quotas (role, quota_bytes) VALUES
+    ('regular', 5368709120),
+    ('admin', 53687091200);
+
+CREATE TABLE user_storage(
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    quota_bytes BIGINT,
+    used_bytes BIGINT NOT NULL DEFAULT 0,
+    "updated_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (quota_bytes IS NULL OR quota_bytes >= 0),
+    CHECK (used_bytes >= 0)
+);
+
+INSERT INTO user_storage (user_id, used_bytes)
+    SELECT owner_id, SUM(file_size) FROM files WHERE is_unsafe = false GROUP BY owner_id;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE user_storage;
+DROP TABLE role_storage_quotas;
+
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+CREATE TABLE outbox_events(
+    id UUID PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    owner_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP(3) NOT NULL,
+    available_at TIMESTAMP(3) NOT NULL,
+    published_at TIMESTAMP(3),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX outbox_events_unpublished_idx ON outbox_events (available_at) WHERE published_at IS NULL;
+
+CREATE TABLE processed_events(
+    consumer TEXT NOT NULL,
+    event_id UUID NOT NULL,
+    "processed_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (consumer, event_id)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE processed_events;
+DROP TABLE outbox_events;
+
+-- +goose StatementEnd
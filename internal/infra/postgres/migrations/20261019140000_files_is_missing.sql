@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+ALTER TABLE files ADD COLUMN is_missing BOOL NOT NULL DEFAULT 'f';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE files DROP COLUMN is_missing;
+
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE folders ADD COLUMN parent_id UUID REFERENCES folders(id);
+ALTER TABLE files ADD COLUMN content_type TEXT NOT NULL DEFAULT 'application/octet-stream';
+
+CREATE INDEX folders_parent_id_idx ON folders (parent_id);
+CREATE INDEX files_owner_id_idx ON files (owner_id, created_at);
+CREATE INDEX files_file_name_tsv_idx ON files USING GIN (to_tsvector('simple', file_name));
+CREATE INDEX files_file_name_trgm_idx ON files USING GIN (file_name gin_trgm_ops);
+CREATE INDEX folders_folder_name_tsv_idx ON folders USING GIN (to_tsvector('simple', folder_name));
+CREATE INDEX folders_folder_name_trgm_idx ON folders USING GIN (folder_name gin_trgm_ops);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX folders_folder_name_trgm_idx;
+DROP INDEX folders_folder_name_tsv_idx;
+DROP INDEX files_file_name_trgm_idx;
+DROP INDEX files_file_name_tsv_idx;
+DROP INDEX files_owner_id_idx;
+DROP INDEX folders_parent_id_idx;
+ALTER TABLE files DROP COLUMN content_type;
+ALTER TABLE folders DROP COLUMN parent_id;
+
+-- +goose StatementEnd
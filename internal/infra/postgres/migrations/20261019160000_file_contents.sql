@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+CREATE TABLE file_contents (
+  file_id UUID PRIMARY KEY REFERENCES files(id) ON DELETE CASCADE,
+  content TEXT NOT NULL,
+  content_tsv tsvector GENERATED ALWAYS AS (to_tsvector('english', content)) STORED,
+  "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX file_contents_content_tsv_idx ON file_contents USING GIN (content_tsv);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE file_contents;
+
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+CREATE INDEX files_folder_id_file_name_idx ON files (folder_id, file_name, id);
+CREATE INDEX files_folder_id_file_size_idx ON files (folder_id, file_size, id);
+CREATE INDEX files_folder_id_created_at_idx ON files (folder_id, created_at, id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX files_folder_id_created_at_idx;
+DROP INDEX files_folder_id_file_size_idx;
+DROP INDEX files_folder_id_file_name_idx;
+
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+CREATE TABLE file_thumbnails (
+  file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+  size TEXT NOT NULL,
+  file_store_key TEXT NOT NULL,
+  width INTEGER NOT NULL,
+  height INTEGER NOT NULL,
+  "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (file_id, size)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE file_thumbnails;
+
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+ALTER TABLE files ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE files ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE folders ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE folders ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX files_tags_idx ON files USING GIN (tags);
+CREATE INDEX folders_tags_idx ON folders USING GIN (tags);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX folders_tags_idx;
+DROP INDEX files_tags_idx;
+ALTER TABLE folders DROP COLUMN metadata;
+ALTER TABLE folders DROP COLUMN tags;
+ALTER TABLE files DROP COLUMN metadata;
+ALTER TABLE files DROP COLUMN tags;
+
+-- +goose StatementEnd
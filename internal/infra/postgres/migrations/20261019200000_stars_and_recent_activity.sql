@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+CREATE TABLE stars (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  file_id UUID REFERENCES files(id) ON DELETE CASCADE,
+  folder_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+  "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CHECK ((file_id IS NULL) <> (folder_id IS NULL))
+);
+
+CREATE UNIQUE INDEX stars_user_id_file_id_idx ON stars (user_id, file_id) WHERE file_id IS NOT NULL;
+CREATE UNIQUE INDEX stars_user_id_folder_id_idx ON stars (user_id, folder_id) WHERE folder_id IS NOT NULL;
+CREATE INDEX stars_user_id_created_at_idx ON stars (user_id, created_at DESC, id);
+
+CREATE TABLE recent_activity (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+  activity TEXT NOT NULL,
+  occurred_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, file_id)
+);
+
+CREATE INDEX recent_activity_user_id_occurred_at_idx ON recent_activity (user_id, occurred_at DESC, file_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE recent_activity;
+DROP TABLE stars;
+
+-- +goose StatementEnd
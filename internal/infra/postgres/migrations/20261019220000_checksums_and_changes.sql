@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Files uploaded before checksums were recorded keep an empty checksum.
+ALTER TABLE files ADD COLUMN checksum TEXT NOT NULL DEFAULT '';
+
+-- change_sequences holds the last sequence handed out to each owner. Taking
+-- the next one locks the owner's row until the transaction ends, so an
+-- owner's changes are committed in sequence order and a reader that has seen
+-- a sequence has seen every change before it.
+CREATE TABLE change_sequences (
+  owner_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  last_sequence BIGINT NOT NULL
+);
+
+CREATE TABLE changes (
+  owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  sequence BIGINT NOT NULL,
+  change_type TEXT NOT NULL,
+  target_type TEXT NOT NULL,
+  target_id UUID NOT NULL,
+  data JSONB NOT NULL DEFAULT '{}',
+  occurred_at TIMESTAMP(3) NOT NULL,
+  PRIMARY KEY (owner_id, sequence)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE changes;
+DROP TABLE change_sequences;
+ALTER TABLE files DROP COLUMN checksum;
+
+-- +goose StatementEnd
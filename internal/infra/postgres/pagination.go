@@ -0,0 +1,11 @@
+package postgres
+
+// pageTotal returns the total number of rows a paged query matches when the
+// page itself gives it away, that is when the page is not full and is either
+// the first page or not empty. Otherwise the rows have to be counted.
+func pageTotal(pageLength, limit, offset int) (int, bool) {
+	if pageLength < limit && (pageLength > 0 || offset == 0) {
+		return offset + pageLength, true
+	}
+	return 0, false
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+type PostgresAccessKeyRepository struct {
+	connection *sqlx.DB
+}
+
+func NewPostgresAccessKeyRepo(ctx context.Context, connection *sqlx.DB) (*PostgresAccessKeyRepository, error) {
+	if connection == nil {
+		return &PostgresAccessKeyRepository{}, fmt.Errorf("Failed to create PostgresAccessKeyRepository: connection is nil")
+	}
+	return &PostgresAccessKeyRepository{connection: connection}, nil
+}
+
+func (p *PostgresAccessKeyRepository) CreateAccessKey(ctx context.Context, accessKey domain.AccessKey) error {
+	const query = `
+    INSERT INTO access_keys
+      (id, user_id, secret_key, created_at)
+    VALUES
+      (:id, :user_id, :secret_key, :created_at)
+  `
+
+	_, err := conn(ctx, p.connection).NamedExecContext(ctx, query, toSqlxAccessKey(accessKey))
+	if err != nil {
+		return fmt.Errorf("error creating access key in the db: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresAccessKeyRepository) GetAccessKeyByAccessKeyId(ctx context.Context, accessKeyId string) (domain.AccessKey, error) {
+	var accessKey SqlxAccessKey
+	err := conn(ctx, p.connection).GetContext(ctx, &accessKey, "SELECT * FROM access_keys WHERE id=$1", accessKeyId)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return domain.AccessKey{}, infra.ErrAccessKeyNotFound
+		}
+		return domain.AccessKey{}, fmt.Errorf("error getting access key :%w", err)
+	}
+	return toDomainAccessKey(accessKey), nil
+}
+
+func (p *PostgresAccessKeyRepository) GetAccessKeysByUserId(ctx context.Context, userId uuid.UUID) ([]domain.AccessKey, error) {
+	var accessKeys []SqlxAccessKey
+	err := conn(ctx, p.connection).SelectContext(ctx, &accessKeys, "SELECT * FROM access_keys WHERE user_id=$1 ORDER BY created_at", userId)
+	if err != nil {
+		return []domain.AccessKey{}, fmt.Errorf("error getting access keys :%w", err)
+	}
+
+	result := []domain.AccessKey{}
+	for _, element := range accessKeys {
+		result = append(result, toDomainAccessKey(element))
+	}
+	return result, nil
+}
+
+func (p *PostgresAccessKeyRepository) DeleteAccessKey(ctx context.Context, accessKeyId string) error {
+	_, err := conn(ctx, p.connection).ExecContext(ctx, "DELETE FROM access_keys WHERE id=$1", accessKeyId)
+	if err != nil {
+		return fmt.Errorf("error deleting access key in the db: %w", err)
+	}
+	return nil
+}
+
+type SqlxAccessKey struct {
+	ID        string    `db:"id"`
+	UserId    uuid.UUID `db:"user_id"`
+	SecretKey string    `db:"secret_key"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func toDomainAccessKey(a SqlxAccessKey) domain.AccessKey {
+	return domain.AccessKey{
+		ID:        a.ID,
+		UserId:    a.UserId,
+		SecretKey: a.SecretKey,
+		CreatedAt: a.CreatedAt,
+	}
+}
+
+func toSqlxAccessKey(a domain.AccessKey) SqlxAccessKey {
+	return SqlxAccessKey{
+		ID:        a.ID,
+		UserId:    a.UserId,
+		SecretKey: a.SecretKey,
+		CreatedAt: a.CreatedAt,
+	}
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/olad5/file-fort/internal/domain"
+)
+
+type PostgresActivityRepository struct {
+	connection *sqlx.DB
+}
+
+func NewPostgresActivityRepo(ctx context.Context, connection *sqlx.DB) (*PostgresActivityRepository, error) {
+	if connection == nil {
+		return &PostgresActivityRepository{}, fmt.Errorf("Failed to create PostgresActivityRepository: connection is nil")
+	}
+	return &PostgresActivityRepository{connection: connection}, nil
+}
+
+func (p *PostgresActivityRepository) RecordActivity(ctx context.Context, userId, fileId uuid.UUID, activity domain.ActivityType) error {
+	const query = `
+    INSERT INTO recent_activity (user_id, file_id, activity, occurred_at)
+    VALUES ($1, $2, $3, $4)
+    ON CONFLICT (user_id, file_id) DO UPDATE SET activity = EXCLUDED.activity, occurred_at = EXCLUDED.occurred_at
+  `
+	_, err := conn(ctx, p.connection).ExecContext(ctx, query, userId, fileId, activity, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error recording activity in the db: %w", err)
+	}
+	return nil
+}
+
+// GetRecentFiles returns the files the user last acted on, most recent
+// first, along with the total number of such files.
+func (p *PostgresActivityRepository) GetRecentFiles(ctx context.Context, userId uuid.UUID, limit, offset int) ([]domain.RecentFile, int, error) {
+	const from = `
+    FROM recent_activity a JOIN files f ON f.id = a.file_id
+    WHERE a.user_id = $1 AND f.is_unsafe = false AND f.is_missing = false
+  `
+	const query = "SELECT f.*, a.activity, a.occurred_at" + from + "ORDER BY a.occurred_at DESC, a.file_id LIMIT $2 OFFSET $3"
+
+	db := conn(ctx, p.connection)
+	var rows []SqlxRecentFile
+	if err := db.SelectContext(ctx, &rows, query, userId, limit, offset); err != nil {
+		return []domain.RecentFile{}, 0, fmt.Errorf("error getting recent files :%w", err)
+	}
+
+	total, ok := pageTotal(len(rows), limit, offset)
+	if !ok {
+		if err := db.GetContext(ctx, &total, "SELECT COUNT(*)"+from, userId); err != nil {
+			return []domain.RecentFile{}, 0, fmt.Errorf("error counting recent files :%w", err)
+		}
+	}
+
+	result := []domain.RecentFile{}
+	for _, row := range rows {
+		result = append(result, domain.RecentFile{
+			File:       toDomainFile(row.SqlxFile),
+			Activity:   row.Activity,
+			OccurredAt: row.OccurredAt,
+		})
+	}
+	return result, total, nil
+}
+
+type SqlxRecentFile struct {
+	SqlxFile
+	Activity   domain.ActivityType `db:"activity"`
+	OccurredAt time.Time           `db:"occurred_at"`
+}
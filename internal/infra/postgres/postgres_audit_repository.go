@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+type PostgresAuditRepository struct {
+	connection *sqlx.DB
+}
+
+func NewPostgresAuditRepo(ctx context.Context, connection *sqlx.DB) (*PostgresAuditRepository, error) {
+	if connection == nil {
+		return &PostgresAuditRepository{}, fmt.Errorf("Failed to create PostgresAuditRepository: connection is nil")
+	}
+	return &PostgresAuditRepository{connection: connection}, nil
+}
+
+func (p *PostgresAuditRepository) SaveAuditEvent(ctx context.Context, event domain.AuditEvent) error {
+	const query = `
+    INSERT INTO audit_events
+      (id, actor_id, actor_email, action, target_type, target_id, ip_address, user_agent)
+    VALUES
+      (:id, :actor_id, :actor_email, :action, :target_type, :target_id, :ip_address, :user_agent)
+  `
+
+	_, err := conn(ctx, p.connection).NamedExecContext(ctx, query, toSqlxAuditEvent(event))
+	if err != nil {
+		return fmt.Errorf("error saving audit event in the db: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresAuditRepository) GetAuditEvents(ctx context.Context, filter infra.AuditEventFilter) ([]domain.AuditEvent, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ActorId != nil {
+		addCondition("actor_id = $%d", *filter.ActorId)
+	}
+	if filter.TargetType != "" {
+		addCondition("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetId != "" {
+		addCondition("target_id = $%d", filter.TargetId)
+	}
+	if filter.From != nil {
+		addCondition("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("created_at < $%d", *filter.To)
+	}
+
+	query := "SELECT * FROM audit_events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id"
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	var events []SqlxAuditEvent
+	err := conn(ctx, p.connection).SelectContext(ctx, &events, query, args...)
+	if err != nil {
+		return []domain.AuditEvent{}, fmt.Errorf("error getting audit events :%w", err)
+	}
+
+	result := []domain.AuditEvent{}
+	for _, element := range events {
+		result = append(result, toDomainAuditEvent(element))
+	}
+	return result, nil
+}
+
+type SqlxAuditEvent struct {
+	ID         uuid.UUID              `db:"id"`
+	ActorId    *uuid.UUID             `db:"actor_id"`
+	ActorEmail string                 `db:"actor_email"`
+	Action     domain.AuditAction     `db:"action"`
+	TargetType domain.AuditTargetType `db:"target_type"`
+	TargetId   string                 `db:"target_id"`
+	IpAddress  string                 `db:"ip_address"`
+	UserAgent  string                 `db:"user_agent"`
+	CreatedAt  time.Time              `db:"created_at"`
+}
+
+func toDomainAuditEvent(e SqlxAuditEvent) domain.AuditEvent {
+	return domain.AuditEvent{
+		ID:         e.ID,
+		ActorId:    e.ActorId,
+		ActorEmail: e.ActorEmail,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetId:   e.TargetId,
+		IpAddress:  e.IpAddress,
+		UserAgent:  e.UserAgent,
+		CreatedAt:  e.CreatedAt,
+	}
+}
+
+func toSqlxAuditEvent(e domain.AuditEvent) SqlxAuditEvent {
+	return SqlxAuditEvent{
+		ID:         e.ID,
+		ActorId:    e.ActorId,
+		ActorEmail: e.ActorEmail,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetId:   e.TargetId,
+		IpAddress:  e.IpAddress,
+		UserAgent:  e.UserAgent,
+		CreatedAt:  e.CreatedAt,
+	}
+}
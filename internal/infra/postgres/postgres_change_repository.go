@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/olad5/file-fort/internal/domain"
+)
+
+type PostgresChangeRepository struct {
+	connection *sqlx.DB
+}
+
+func NewPostgresChangeRepo(ctx context.Context, connection *sqlx.DB) (*PostgresChangeRepository, error) {
+	if connection == nil {
+		return &PostgresChangeRepository{}, fmt.Errorf("Failed to create PostgresChangeRepository: connection is nil")
+	}
+	return &PostgresChangeRepository{connection: connection}, nil
+}
+
+// SaveChange takes the owner's next sequence from change_sequences, which
+// holds the lock on the owner's row until the transaction ends. A second
+// writer waits for the first to commit, so sequences become visible in order.
+func (p *PostgresChangeRepository) SaveChange(ctx context.Context, change domain.Change) error {
+	data, err := json.Marshal(change.Data)
+	if err != nil {
+		return fmt.Errorf("error encoding change: %w", err)
+	}
+
+	const query = `
+    WITH next AS (
+      INSERT INTO change_sequences (owner_id, last_sequence) VALUES ($1, 1)
+      ON CONFLICT (owner_id) DO UPDATE SET last_sequence = change_sequences.last_sequence + 1
+      RETURNING last_sequence
+    )
+    INSERT INTO changes
+      (owner_id, sequence, change_type, target_type, target_id, data, occurred_at)
+    SELECT $1, last_sequence, $2, $3, $4, $5, $6 FROM next
+  `
+	_, err = conn(ctx, p.connection).ExecContext(ctx, query,
+		change.OwnerId, change.Type, change.TargetType, change.TargetId, string(data), change.OccurredAt.UTC())
+	if err != nil {
+		return fmt.Errorf("error saving change in the db: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresChangeRepository) GetChanges(ctx context.Context, ownerId uuid.UUID, after int64, limit int) ([]domain.Change, error) {
+	const query = `
+    SELECT * FROM changes
+    WHERE owner_id = $1 AND sequence > $2
+    ORDER BY sequence
+    LIMIT $3
+  `
+
+	var changes []SqlxChange
+	err := conn(ctx, p.connection).SelectContext(ctx, &changes, query, ownerId, after, limit)
+	if err != nil {
+		return []domain.Change{}, fmt.Errorf("error getting changes :%w", err)
+	}
+
+	result := []domain.Change{}
+	for _, element := range changes {
+		change, err := toDomainChange(element)
+		if err != nil {
+			return []domain.Change{}, err
+		}
+		result = append(result, change)
+	}
+	return result, nil
+}
+
+func (p *PostgresChangeRepository) GetLatestSequence(ctx context.Context, ownerId uuid.UUID) (int64, error) {
+	var sequence int64
+	const query = "SELECT COALESCE(MAX(last_sequence), 0) FROM change_sequences WHERE owner_id = $1"
+	err := conn(ctx, p.connection).GetContext(ctx, &sequence, query, ownerId)
+	if err != nil {
+		return 0, fmt.Errorf("error getting latest change sequence :%w", err)
+	}
+	return sequence, nil
+}
+
+type SqlxChange struct {
+	OwnerId    uuid.UUID `db:"owner_id"`
+	Sequence   int64     `db:"sequence"`
+	ChangeType string    `db:"change_type"`
+	TargetType string    `db:"target_type"`
+	TargetId   uuid.UUID `db:"target_id"`
+	Data       []byte    `db:"data"`
+	OccurredAt time.Time `db:"occurred_at"`
+}
+
+func toDomainChange(c SqlxChange) (domain.Change, error) {
+	data := map[string]interface{}{}
+	if err := json.Unmarshal(c.Data, &data); err != nil {
+		return domain.Change{}, fmt.Errorf("error decoding change: %w", err)
+	}
+	return domain.Change{
+		Sequence:   c.Sequence,
+		OwnerId:    c.OwnerId,
+		Type:       domain.ChangeType(c.ChangeType),
+		TargetType: domain.ChangeTarget(c.TargetType),
+		TargetId:   c.TargetId,
+		Data:       data,
+		OccurredAt: c.OccurredAt,
+	}, nil
+}
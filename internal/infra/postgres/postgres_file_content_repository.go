@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/olad5/file-fort/internal/domain"
+)
+
+type PostgresFileContentRepository struct {
+	connection *sqlx.DB
+}
+
+func NewPostgresFileContentRepo(ctx context.Context, connection *sqlx.DB) (*PostgresFileContentRepository, error) {
+	if connection == nil {
+		return &PostgresFileContentRepository{}, fmt.Errorf("Failed to create PostgresFileContentRepository: connection is nil")
+	}
+	return &PostgresFileContentRepository{connection: connection}, nil
+}
+
+// SaveFileContent replaces any content previously extracted from the file.
+func (p *PostgresFileContentRepository) SaveFileContent(ctx context.Context, content domain.FileContent) error {
+	const query = `
+    INSERT INTO file_contents
+      (file_id, content, created_at, updated_at)
+    VALUES
+      ($1, $2, $3, $3)
+    ON CONFLICT (file_id) DO UPDATE SET content = EXCLUDED.content, updated_at = EXCLUDED.updated_at
+  `
+	_, err := conn(ctx, p.connection).ExecContext(ctx, query, content.FileId, content.Content, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error saving file content in the db: %w", err)
+	}
+	return nil
+}
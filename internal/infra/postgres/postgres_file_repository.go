@@ -7,7 +7,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/olad5/file-fort/internal/domain"
 	"github.com/olad5/file-fort/internal/infra"
 )
@@ -27,12 +27,12 @@ func NewPostgresFileRepo(ctx context.Context, connection *sqlx.DB) (*PostgresFil
 func (p *PostgresFileRepository) SaveFile(ctx context.Context, file domain.File) error {
 	const query = `
     INSERT INTO files 
-      (id, file_name, owner_id, folder_id, file_store_key, file_size) 
+      (id, file_name, owner_id, folder_id, file_store_key, file_size, content_type, checksum, tags, metadata) 
     VALUES 
-      (:id, :file_name, :owner_id, :folder_id, :file_store_key, :file_size)
+      (:id, :file_name, :owner_id, :folder_id, :file_store_key, :file_size, :content_type, :checksum, :tags, :metadata)
   `
 
-	_, err := p.connection.NamedExec(query, toSqlxFile(file))
+	_, err := conn(ctx, p.connection).NamedExecContext(ctx, query, toSqlxFile(file))
 	if err != nil {
 		return fmt.Errorf("error saving file in the db: %w", err)
 	}
@@ -41,7 +41,7 @@ func (p *PostgresFileRepository) SaveFile(ctx context.Context, file domain.File)
 
 func (p *PostgresFileRepository) GetFileByFileId(ctx context.Context, fileId uuid.UUID) (domain.File, error) {
 	var file SqlxFile
-	err := p.connection.Get(&file, "SELECT * FROM files WHERE id=$1 AND is_unsafe=false", fileId)
+	err := conn(ctx, p.connection).GetContext(ctx, &file, "SELECT * FROM files WHERE id=$1 AND is_unsafe=false", fileId)
 	if err != nil {
 		if err == ErrRecordNotFound {
 			return domain.File{}, infra.ErrFileNotFound
@@ -52,17 +52,90 @@ func (p *PostgresFileRepository) GetFileByFileId(ctx context.Context, fileId uui
 	return toDomainFile(file), nil
 }
 
-func (p *PostgresFileRepository) GetFilesByFolderId(ctx context.Context, folderId uuid.UUID, pageNumber, rowsPerPage int) ([]domain.File, error) {
-	offset := (pageNumber - 1) * rowsPerPage
+func (p *PostgresFileRepository) GetFileByFileIdForUpdate(ctx context.Context, fileId uuid.UUID) (domain.File, error) {
+	var file SqlxFile
+	err := conn(ctx, p.connection).GetContext(ctx, &file, "SELECT * FROM files WHERE id=$1 AND is_unsafe=false FOR UPDATE", fileId)
+	if err != nil {
+		if err == ErrRecordNotFound {
+			return domain.File{}, infra.ErrFileNotFound
+		}
+		return domain.File{}, fmt.Errorf("error getting file :%w", err)
+	}
+
+	return toDomainFile(file), nil
+}
+
+var fileSortColumns = map[infra.FileSortField]string{
+	infra.SortByName:      "file_name",
+	infra.SortBySize:      "file_size",
+	infra.SortByCreatedAt: "created_at",
+}
+
+// GetFilesByFolderId orders files by the sort column and then by id, so that
+// files with equal sort values still have a stable position to resume from.
+func (p *PostgresFileRepository) GetFilesByFolderId(ctx context.Context, folderId uuid.UUID, q infra.FileListQuery) ([]domain.File, error) {
+	column, ok := fileSortColumns[q.SortBy]
+	if !ok {
+		return []domain.File{}, fmt.Errorf("error getting files: unknown sort field %q", q.SortBy)
+	}
+	comparison, direction := ">", "ASC"
+	if q.Descending {
+		comparison, direction = "<", "DESC"
+	}
+
+	args := []interface{}{folderId}
+	bind := func(arg interface{}) string {
+		args = append(args, arg)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	query := "SELECT * FROM files WHERE folder_id = $1 AND is_unsafe = false AND is_missing = false"
+	if len(q.Tags) > 0 {
+		query += " AND tags @> " + bind(pq.StringArray(q.Tags))
+	}
+	if q.After != nil {
+		var value interface{}
+		switch q.SortBy {
+		case infra.SortByName:
+			value = q.After.Name
+		case infra.SortBySize:
+			value = q.After.Size
+		default:
+			value = q.After.CreatedAt
+		}
+		query += fmt.Sprintf(" AND (%s, id) %s (%s, %s)", column, comparison, bind(value), bind(q.After.ID))
+	}
+	query += fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s LIMIT %[3]s", column, direction, bind(q.Limit))
 
 	var files []SqlxFile
+	err := conn(ctx, p.connection).SelectContext(ctx, &files, query, args...)
+	if err != nil {
+		return []domain.File{}, fmt.Errorf("error getting files :%w", err)
+	}
 
-	query := fmt.Sprintf(`
-    SELECT * FROM files WHERE folder_id =$1 AND is_unsafe=false
-    OFFSET %d ROWS FETCH NEXT %d ROWS ONLY
-	`, offset, rowsPerPage)
+	result := []domain.File{}
+	for _, element := range files {
+		result = append(result, toDomainFile(element))
+	}
 
-	err := p.connection.Select(&files, query, folderId)
+	return result, nil
+}
+
+// GetFilesByFolderIds returns the files in any of the folders, leaving out
+// files that are unsafe or missing from the file store.
+func (p *PostgresFileRepository) GetFilesByFolderIds(ctx context.Context, folderIds []uuid.UUID) ([]domain.File, error) {
+	ids := pq.StringArray{}
+	for _, id := range folderIds {
+		ids = append(ids, id.String())
+	}
+
+	const query = `
+    SELECT * FROM files
+    WHERE folder_id = ANY($1::uuid[]) AND is_unsafe = false AND is_missing = false
+    ORDER BY folder_id, file_name, id
+  `
+
+	var files []SqlxFile
+	err := conn(ctx, p.connection).SelectContext(ctx, &files, query, ids)
 	if err != nil {
 		return []domain.File{}, fmt.Errorf("error getting files :%w", err)
 	}
@@ -71,22 +144,168 @@ func (p *PostgresFileRepository) GetFilesByFolderId(ctx context.Context, folderI
 	for _, element := range files {
 		result = append(result, toDomainFile(element))
 	}
+	return result, nil
+}
+
+// GetFilePagesByFolderIds returns the same page of the files in each of the
+// folders, ordered by name within each folder and leaving out files that are
+// unsafe or missing from the file store.
+func (p *PostgresFileRepository) GetFilePagesByFolderIds(ctx context.Context, folderIds []uuid.UUID, pageNumber, rowsPerPage int) ([]domain.File, error) {
+	ids := pq.StringArray{}
+	for _, id := range folderIds {
+		ids = append(ids, id.String())
+	}
+
+	const query = `
+    SELECT files.* FROM files JOIN (
+      SELECT id, ROW_NUMBER() OVER (PARTITION BY folder_id ORDER BY file_name, id) AS position
+      FROM files
+      WHERE folder_id = ANY($1::uuid[]) AND is_unsafe = false AND is_missing = false
+    ) ranked ON ranked.id = files.id
+    WHERE ranked.position > $2 AND ranked.position <= $2 + $3
+    ORDER BY files.folder_id, ranked.position
+  `
+	offset := (pageNumber - 1) * rowsPerPage
 
+	var files []SqlxFile
+	err := conn(ctx, p.connection).SelectContext(ctx, &files, query, ids, offset, rowsPerPage)
+	if err != nil {
+		return []domain.File{}, fmt.Errorf("error getting files :%w", err)
+	}
+
+	result := []domain.File{}
+	for _, element := range files {
+		result = append(result, toDomainFile(element))
+	}
 	return result, nil
 }
 
+// CountFilesByFolderId counts the files in the folder that have every one of
+// tags, leaving out files that are unsafe or missing from the file store.
+func (p *PostgresFileRepository) CountFilesByFolderId(ctx context.Context, folderId uuid.UUID, tags []string) (int, error) {
+	var count int
+	const query = "SELECT COUNT(*) FROM files WHERE folder_id = $1 AND is_unsafe = false AND is_missing = false AND tags @> $2"
+	err := conn(ctx, p.connection).GetContext(ctx, &count, query, folderId, toSqlxTags(tags))
+	if err != nil {
+		return 0, fmt.Errorf("error counting files :%w", err)
+	}
+	return count, nil
+}
+
 func (p *PostgresFileRepository) MarkFileAsUnsafe(ctx context.Context, file domain.File) error {
 	file.UpdatedAt = time.Now()
 	file.IsUnsafe = true
 
 	const query = `UPDATE files SET is_unsafe=:is_unsafe, updated_at=:updated_at WHERE id=:id`
-	_, err := p.connection.NamedExec(query, toSqlxFile(file))
+	_, err := conn(ctx, p.connection).NamedExecContext(ctx, query, toSqlxFile(file))
 	if err != nil {
 		return fmt.Errorf("error marking file as unsafe in the db: %w", err)
 	}
 	return nil
 }
 
+// MarkFileAsMissing reports whether the file was marked, which it is not
+// when it is already missing or no longer exists.
+func (p *PostgresFileRepository) MarkFileAsMissing(ctx context.Context, file domain.File) (bool, error) {
+	const query = `UPDATE files SET is_missing=true, updated_at=$2 WHERE id=$1 AND is_missing=false`
+	result, err := conn(ctx, p.connection).ExecContext(ctx, query, file.ID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("error marking file as missing in the db: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error marking file as missing in the db: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// MarkFileAsFound reports whether the file was marked, which it is not when
+// it is no longer missing or no longer exists.
+func (p *PostgresFileRepository) MarkFileAsFound(ctx context.Context, file domain.File) (bool, error) {
+	const query = `UPDATE files SET is_missing=false, updated_at=$2 WHERE id=$1 AND is_missing=true`
+	result, err := conn(ctx, p.connection).ExecContext(ctx, query, file.ID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("error marking file as found in the db: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error marking file as found in the db: %w", err)
+	}
+	return rows == 1, nil
+}
+
+func (p *PostgresFileRepository) GetAllFiles(ctx context.Context) ([]domain.File, error) {
+	var files []SqlxFile
+	err := conn(ctx, p.connection).SelectContext(ctx, &files, "SELECT * FROM files ORDER BY created_at")
+	if err != nil {
+		return []domain.File{}, fmt.Errorf("error getting files :%w", err)
+	}
+
+	result := []domain.File{}
+	for _, element := range files {
+		result = append(result, toDomainFile(element))
+	}
+	return result, nil
+}
+
+func (p *PostgresFileRepository) DeleteFile(ctx context.Context, fileId uuid.UUID) error {
+	_, err := conn(ctx, p.connection).ExecContext(ctx, "DELETE FROM files WHERE id=$1", fileId)
+	if err != nil {
+		return fmt.Errorf("error deleting file in the db: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresFileRepository) MoveFile(ctx context.Context, fileId, folderId uuid.UUID) error {
+	const query = `UPDATE files SET folder_id=$2, updated_at=$3 WHERE id=$1`
+	_, err := conn(ctx, p.connection).ExecContext(ctx, query, fileId, folderId, time.Now())
+	if err != nil {
+		return fmt.Errorf("error moving file in the db: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresFileRepository) RenameFile(ctx context.Context, fileId, folderId uuid.UUID, fileName string) error {
+	const query = `UPDATE files SET folder_id=$2, file_name=$3, updated_at=$4 WHERE id=$1`
+	_, err := conn(ctx, p.connection).ExecContext(ctx, query, fileId, folderId, fileName, time.Now())
+	if err != nil {
+		return fmt.Errorf("error renaming file in the db: %w", err)
+	}
+	return nil
+}
+
+// DeleteFilesByFolderIds deletes every file in the folders, unsafe and
+// missing files included, and returns the files it deleted.
+func (p *PostgresFileRepository) DeleteFilesByFolderIds(ctx context.Context, folderIds []uuid.UUID) ([]domain.File, error) {
+	ids := pq.StringArray{}
+	for _, id := range folderIds {
+		ids = append(ids, id.String())
+	}
+
+	var files []SqlxFile
+	err := conn(ctx, p.connection).SelectContext(ctx, &files, "DELETE FROM files WHERE folder_id = ANY($1::uuid[]) RETURNING *", ids)
+	if err != nil {
+		return []domain.File{}, fmt.Errorf("error deleting files in the db: %w", err)
+	}
+
+	result := []domain.File{}
+	for _, element := range files {
+		result = append(result, toDomainFile(element))
+	}
+	return result, nil
+}
+
+func (p *PostgresFileRepository) UpdateFileLabels(ctx context.Context, fileId uuid.UUID, tags []string, metadata map[string]string) error {
+	const query = `UPDATE files SET tags=$2, metadata=$3, updated_at=$4 WHERE id=$1`
+	_, err := conn(ctx, p.connection).ExecContext(ctx, query, fileId, toSqlxTags(tags), SqlxMetadata(metadata), time.Now())
+	if err != nil {
+		return fmt.Errorf("error updating file labels in the db: %w", err)
+	}
+	return nil
+}
+
 func (p *PostgresFileRepository) Ping(ctx context.Context) error {
 	err := p.connection.Ping()
 	if err != nil {
@@ -97,15 +316,20 @@ func (p *PostgresFileRepository) Ping(ctx context.Context) error {
 }
 
 type SqlxFile struct {
-	ID           uuid.UUID `db:"id"`
-	FileName     string    `db:"file_name"`
-	OwnerId      uuid.UUID `db:"owner_id"`
-	FolderId     uuid.UUID `db:"folder_id"`
-	FileStoreKey string    `db:"file_store_key"`
-	FileSize     int64     `db:"file_size"`
-	IsUnsafe     bool      `db:"is_unsafe"`
-	CreatedAt    time.Time `db:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at"`
+	ID           uuid.UUID      `db:"id"`
+	FileName     string         `db:"file_name"`
+	OwnerId      uuid.UUID      `db:"owner_id"`
+	FolderId     uuid.UUID      `db:"folder_id"`
+	FileStoreKey string         `db:"file_store_key"`
+	FileSize     int64          `db:"file_size"`
+	ContentType  string         `db:"content_type"`
+	Checksum     string         `db:"checksum"`
+	Tags         pq.StringArray `db:"tags"`
+	Metadata     SqlxMetadata   `db:"metadata"`
+	IsUnsafe     bool           `db:"is_unsafe"`
+	IsMissing    bool           `db:"is_missing"`
+	CreatedAt    time.Time      `db:"created_at"`
+	UpdatedAt    time.Time      `db:"updated_at"`
 }
 
 func toDomainFile(f SqlxFile) domain.File {
@@ -116,7 +340,12 @@ func toDomainFile(f SqlxFile) domain.File {
 		FolderId:     f.FolderId,
 		FileStoreKey: f.FileStoreKey,
 		FileSize:     f.FileSize,
+		ContentType:  f.ContentType,
+		Checksum:     f.Checksum,
+		Tags:         toDomainTags(f.Tags),
+		Metadata:     toDomainMetadata(f.Metadata),
 		IsUnsafe:     f.IsUnsafe,
+		IsMissing:    f.IsMissing,
 		CreatedAt:    f.CreatedAt,
 		UpdatedAt:    f.UpdatedAt,
 	}
@@ -130,7 +359,12 @@ func toSqlxFile(f domain.File) SqlxFile {
 		FolderId:     f.FolderId,
 		FileStoreKey: f.FileStoreKey,
 		FileSize:     f.FileSize,
+		ContentType:  f.ContentType,
+		Checksum:     f.Checksum,
+		Tags:         toSqlxTags(f.Tags),
+		Metadata:     SqlxMetadata(f.Metadata),
 		IsUnsafe:     f.IsUnsafe,
+		IsMissing:    f.IsMissing,
 		CreatedAt:    f.CreatedAt,
 		UpdatedAt:    f.UpdatedAt,
 	}
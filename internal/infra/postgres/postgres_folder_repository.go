@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/olad5/file-fort/internal/domain"
 	"github.com/olad5/file-fort/internal/infra"
 )
@@ -25,12 +26,12 @@ func NewPostgresFolderRepo(ctx context.Context, connection *sqlx.DB) (*PostgresF
 func (p *PostgresFolderRepository) CreateFolder(ctx context.Context, folder domain.Folder) error {
 	const query = `
     INSERT INTO folders 
-      (id, folder_name, owner_id) 
+      (id, folder_name, owner_id, parent_id, tags, metadata) 
     VALUES 
-      (:id, :folder_name, :owner_id)
+      (:id, :folder_name, :owner_id, :parent_id, :tags, :metadata)
   `
 
-	_, err := p.connection.NamedExec(query, toSqlxFolder(folder))
+	_, err := conn(ctx, p.connection).NamedExecContext(ctx, query, toSqlxFolder(folder))
 	if err != nil {
 		return fmt.Errorf("error creating folder in the db: %w", err)
 	}
@@ -39,7 +40,7 @@ func (p *PostgresFolderRepository) CreateFolder(ctx context.Context, folder doma
 
 func (p *PostgresFolderRepository) GetFolderByFolderId(ctx context.Context, folderId uuid.UUID) (domain.Folder, error) {
 	var folder SqlxFolder
-	err := p.connection.Get(&folder, "SELECT * FROM folders WHERE id=$1", folderId)
+	err := conn(ctx, p.connection).GetContext(ctx, &folder, "SELECT * FROM folders WHERE id=$1", folderId)
 	if err != nil {
 		if err == ErrRecordNotFound {
 			return domain.Folder{}, infra.ErrFolderNotFound
@@ -50,6 +51,158 @@ func (p *PostgresFolderRepository) GetFolderByFolderId(ctx context.Context, fold
 	return toDomainFolder(folder), nil
 }
 
+func (p *PostgresFolderRepository) GetFolderByFolderIdForUpdate(ctx context.Context, folderId uuid.UUID) (domain.Folder, error) {
+	var folder SqlxFolder
+	err := conn(ctx, p.connection).GetContext(ctx, &folder, "SELECT * FROM folders WHERE id=$1 FOR UPDATE", folderId)
+	if err != nil {
+		if err == ErrRecordNotFound {
+			return domain.Folder{}, infra.ErrFolderNotFound
+		}
+		return domain.Folder{}, fmt.Errorf("error getting folder :%w", err)
+	}
+
+	return toDomainFolder(folder), nil
+}
+
+// GetFolderTree returns the folder, first, and every folder nested below it.
+// The tree is built with UNION rather than UNION ALL, so a cycle left in the
+// table ends the recursion instead of repeating forever.
+func (p *PostgresFolderRepository) GetFolderTree(ctx context.Context, folderId uuid.UUID) ([]domain.Folder, error) {
+	const query = `
+    WITH RECURSIVE tree AS (
+      SELECT * FROM folders WHERE id = $1
+      UNION
+      SELECT child.* FROM folders child JOIN tree ON child.parent_id = tree.id
+    )
+    SELECT * FROM tree ORDER BY id <> $1
+  `
+
+	var folders []SqlxFolder
+	err := conn(ctx, p.connection).SelectContext(ctx, &folders, query, folderId)
+	if err != nil {
+		return []domain.Folder{}, fmt.Errorf("error getting folder tree :%w", err)
+	}
+	if len(folders) == 0 {
+		return []domain.Folder{}, infra.ErrFolderNotFound
+	}
+
+	result := []domain.Folder{}
+	for _, element := range folders {
+		result = append(result, toDomainFolder(element))
+	}
+	return result, nil
+}
+
+// GetFoldersByParentId returns the owner's folders directly below parentId,
+// or the owner's top level folders when parentId is nil.
+func (p *PostgresFolderRepository) GetFoldersByParentId(ctx context.Context, ownerId uuid.UUID, parentId *uuid.UUID) ([]domain.Folder, error) {
+	const query = `
+    SELECT * FROM folders
+    WHERE owner_id = $1 AND parent_id IS NOT DISTINCT FROM $2
+    ORDER BY folder_name, id
+  `
+
+	var folders []SqlxFolder
+	err := conn(ctx, p.connection).SelectContext(ctx, &folders, query, ownerId, parentId)
+	if err != nil {
+		return []domain.Folder{}, fmt.Errorf("error getting folders :%w", err)
+	}
+
+	result := []domain.Folder{}
+	for _, element := range folders {
+		result = append(result, toDomainFolder(element))
+	}
+	return result, nil
+}
+
+func (p *PostgresFolderRepository) GetFoldersByFolderIds(ctx context.Context, folderIds []uuid.UUID) ([]domain.Folder, error) {
+	ids := pq.StringArray{}
+	for _, id := range folderIds {
+		ids = append(ids, id.String())
+	}
+
+	var folders []SqlxFolder
+	err := conn(ctx, p.connection).SelectContext(ctx, &folders, "SELECT * FROM folders WHERE id = ANY($1::uuid[])", ids)
+	if err != nil {
+		return []domain.Folder{}, fmt.Errorf("error getting folders :%w", err)
+	}
+
+	result := []domain.Folder{}
+	for _, element := range folders {
+		result = append(result, toDomainFolder(element))
+	}
+	return result, nil
+}
+
+// GetFoldersByParentIds returns the same page of the owner's folders directly
+// below each of parentIds, ordered by name within each parent. Top level
+// folders are returned as the page of the owner's home folder, whose id is
+// the owner's.
+func (p *PostgresFolderRepository) GetFoldersByParentIds(ctx context.Context, ownerId uuid.UUID, parentIds []uuid.UUID, pageNumber, rowsPerPage int) ([]domain.Folder, error) {
+	ids := pq.StringArray{}
+	for _, id := range parentIds {
+		ids = append(ids, id.String())
+	}
+
+	const query = `
+    SELECT folders.* FROM folders JOIN (
+      SELECT id, COALESCE(parent_id, owner_id) AS listed_in,
+        ROW_NUMBER() OVER (PARTITION BY COALESCE(parent_id, owner_id) ORDER BY folder_name, id) AS position
+      FROM folders
+      WHERE owner_id = $1 AND id <> owner_id AND COALESCE(parent_id, owner_id) = ANY($2::uuid[])
+    ) ranked ON ranked.id = folders.id
+    WHERE ranked.position > $3 AND ranked.position <= $3 + $4
+    ORDER BY ranked.listed_in, ranked.position
+  `
+	offset := (pageNumber - 1) * rowsPerPage
+
+	var folders []SqlxFolder
+	err := conn(ctx, p.connection).SelectContext(ctx, &folders, query, ownerId, ids, offset, rowsPerPage)
+	if err != nil {
+		return []domain.Folder{}, fmt.Errorf("error getting folders :%w", err)
+	}
+
+	result := []domain.Folder{}
+	for _, element := range folders {
+		result = append(result, toDomainFolder(element))
+	}
+	return result, nil
+}
+
+func (p *PostgresFolderRepository) RenameFolder(ctx context.Context, folderId uuid.UUID, parentId *uuid.UUID, folderName string) error {
+	const query = `UPDATE folders SET parent_id=$2, folder_name=$3, updated_at=$4 WHERE id=$1`
+	_, err := conn(ctx, p.connection).ExecContext(ctx, query, folderId, parentId, folderName, time.Now())
+	if err != nil {
+		return fmt.Errorf("error renaming folder in the db: %w", err)
+	}
+	return nil
+}
+
+// DeleteFolders deletes the folders in one statement, so a folder and the
+// folders nested below it can be deleted together. The folders must not
+// hold any files.
+func (p *PostgresFolderRepository) DeleteFolders(ctx context.Context, folderIds []uuid.UUID) error {
+	ids := pq.StringArray{}
+	for _, id := range folderIds {
+		ids = append(ids, id.String())
+	}
+
+	_, err := conn(ctx, p.connection).ExecContext(ctx, "DELETE FROM folders WHERE id = ANY($1::uuid[])", ids)
+	if err != nil {
+		return fmt.Errorf("error deleting folders in the db: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresFolderRepository) UpdateFolderLabels(ctx context.Context, folderId uuid.UUID, tags []string, metadata map[string]string) error {
+	const query = `UPDATE folders SET tags=$2, metadata=$3, updated_at=$4 WHERE id=$1`
+	_, err := conn(ctx, p.connection).ExecContext(ctx, query, folderId, toSqlxTags(tags), SqlxMetadata(metadata), time.Now())
+	if err != nil {
+		return fmt.Errorf("error updating folder labels in the db: %w", err)
+	}
+	return nil
+}
+
 func (p *PostgresFolderRepository) Ping(ctx context.Context) error {
 	err := p.connection.Ping()
 	if err != nil {
@@ -60,11 +213,14 @@ func (p *PostgresFolderRepository) Ping(ctx context.Context) error {
 }
 
 type SqlxFolder struct {
-	ID         uuid.UUID `db:"id"`
-	FolderName string    `db:"folder_name"`
-	OwnerId    uuid.UUID `db:"owner_id"`
-	CreatedAt  time.Time `db:"created_at"`
-	UpdatedAt  time.Time `db:"updated_at"`
+	ID         uuid.UUID      `db:"id"`
+	FolderName string         `db:"folder_name"`
+	OwnerId    uuid.UUID      `db:"owner_id"`
+	ParentId   *uuid.UUID     `db:"parent_id"`
+	Tags       pq.StringArray `db:"tags"`
+	Metadata   SqlxMetadata   `db:"metadata"`
+	CreatedAt  time.Time      `db:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at"`
 }
 
 func toDomainFolder(f SqlxFolder) domain.Folder {
@@ -72,6 +228,9 @@ func toDomainFolder(f SqlxFolder) domain.Folder {
 		ID:         f.ID,
 		FolderName: f.FolderName,
 		OwnerId:    f.OwnerId,
+		ParentId:   f.ParentId,
+		Tags:       toDomainTags(f.Tags),
+		Metadata:   toDomainMetadata(f.Metadata),
 		CreatedAt:  f.CreatedAt,
 		UpdatedAt:  f.UpdatedAt,
 	}
@@ -82,6 +241,9 @@ func toSqlxFolder(f domain.Folder) SqlxFolder {
 		ID:         f.ID,
 		FolderName: f.FolderName,
 		OwnerId:    f.OwnerId,
+		ParentId:   f.ParentId,
+		Tags:       toSqlxTags(f.Tags),
+		Metadata:   SqlxMetadata(f.Metadata),
 		CreatedAt:  f.CreatedAt,
 		UpdatedAt:  f.UpdatedAt,
 	}
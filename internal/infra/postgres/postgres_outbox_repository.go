@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+type PostgresOutboxRepository struct {
+	connection *sqlx.DB
+}
+
+func NewPostgresOutboxRepo(ctx context.Context, connection *sqlx.DB) (*PostgresOutboxRepository, error) {
+	if connection == nil {
+		return &PostgresOutboxRepository{}, fmt.Errorf("Failed to create PostgresOutboxRepository: connection is nil")
+	}
+	return &PostgresOutboxRepository{connection: connection}, nil
+}
+
+// SaveEvent must be called with the transaction that persists the change
+// the event describes, so that both are committed or neither is.
+func (p *PostgresOutboxRepository) SaveEvent(ctx context.Context, event domain.Event) error {
+	payload, err := infra.EncodeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	const query = `
+    INSERT INTO outbox_events
+      (id, event_type, owner_id, payload, occurred_at, available_at)
+    VALUES
+      ($1, $2, $3, $4, $5, $5)
+  `
+	_, err = conn(ctx, p.connection).ExecContext(ctx, query, event.ID, event.Type, event.OwnerId, string(payload), event.OccurredAt.UTC())
+	if err != nil {
+		return fmt.Errorf("error saving outbox event in the db: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresOutboxRepository) ClaimUnpublishedEvents(ctx context.Context, limit int, lease time.Duration) ([]domain.Event, error) {
+	const query = `
+    UPDATE outbox_events SET available_at = $2, attempts = attempts + 1
+    WHERE id IN (
+      SELECT id FROM outbox_events
+      WHERE published_at IS NULL AND available_at <= $3
+      ORDER BY occurred_at, id
+      LIMIT $1
+      FOR UPDATE SKIP LOCKED
+    )
+    RETURNING payload
+  `
+	now := time.Now().UTC()
+
+	var payloads []string
+	err := conn(ctx, p.connection).SelectContext(ctx, &payloads, query, limit, now.Add(lease), now)
+	if err != nil {
+		return []domain.Event{}, fmt.Errorf("error claiming outbox events :%w", err)
+	}
+
+	result := []domain.Event{}
+	for _, payload := range payloads {
+		event, err := infra.DecodeEvent([]byte(payload))
+		if err != nil {
+			return []domain.Event{}, err
+		}
+		result = append(result, event)
+	}
+	return result, nil
+}
+
+func (p *PostgresOutboxRepository) MarkEventPublished(ctx context.Context, eventId uuid.UUID) error {
+	const query = `UPDATE outbox_events SET published_at = $2, last_error = '' WHERE id = $1`
+	_, err := conn(ctx, p.connection).ExecContext(ctx, query, eventId, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error marking outbox event as published: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresOutboxRepository) RecordPublishFailure(ctx context.Context, eventId uuid.UUID, publishErr error) error {
+	const query = `UPDATE outbox_events SET last_error = $2 WHERE id = $1`
+	_, err := conn(ctx, p.connection).ExecContext(ctx, query, eventId, publishErr.Error())
+	if err != nil {
+		return fmt.Errorf("error recording outbox publish failure: %w", err)
+	}
+	return nil
+}
+
+type PostgresInboxRepository struct {
+	connection *sqlx.DB
+}
+
+func NewPostgresInboxRepo(ctx context.Context, connection *sqlx.DB) (*PostgresInboxRepository, error) {
+	if connection == nil {
+		return &PostgresInboxRepository{}, fmt.Errorf("Failed to create PostgresInboxRepository: connection is nil")
+	}
+	return &PostgresInboxRepository{connection: connection}, nil
+}
+
+func (p *PostgresInboxRepository) IsEventProcessed(ctx context.Context, consumer string, eventId uuid.UUID) (bool, error) {
+	const query = `SELECT EXISTS (SELECT 1 FROM processed_events WHERE consumer = $1 AND event_id = $2)`
+	var processed bool
+	err := conn(ctx, p.connection).GetContext(ctx, &processed, query, consumer, eventId)
+	if err != nil {
+		return false, fmt.Errorf("error checking whether event was processed: %w", err)
+	}
+	return processed, nil
+}
+
+func (p *PostgresInboxRepository) MarkEventProcessed(ctx context.Context, consumer string, eventId uuid.UUID) (bool, error) {
+	const query = `
+    INSERT INTO processed_events (consumer, event_id) VALUES ($1, $2)
+    ON CONFLICT (consumer, event_id) DO NOTHING
+  `
+	result, err := conn(ctx, p.connection).ExecContext(ctx, query, consumer, eventId)
+	if err != nil {
+		return false, fmt.Errorf("error marking event as processed: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error marking event as processed: %w", err)
+	}
+	return rows == 1, nil
+}
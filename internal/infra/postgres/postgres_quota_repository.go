@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+type PostgresQuotaRepository struct {
+	connection *sqlx.DB
+}
+
+func NewPostgresQuotaRepo(ctx context.Context, connection *sqlx.DB) (*PostgresQuotaRepository, error) {
+	if connection == nil {
+		return &PostgresQuotaRepository{}, fmt.Errorf("Failed to create PostgresQuotaRepository: connection is nil")
+	}
+	return &PostgresQuotaRepository{connection: connection}, nil
+}
+
+func (p *PostgresQuotaRepository) GetStorageUsage(ctx context.Context, userId uuid.UUID) (domain.StorageUsage, error) {
+	const query = `
+    SELECT
+      u.id AS user_id,
+      COALESCE(s.used_bytes, 0) AS used_bytes,
+      COALESCE(s.quota_bytes, r.quota_bytes) AS quota_bytes
+    FROM users u
+    LEFT JOIN user_storage s ON s.user_id = u.id
+    LEFT JOIN role_storage_quotas r ON r.role = u.role
+    WHERE u.id = $1
+  `
+
+	var usage SqlxStorageUsage
+	err := conn(ctx, p.connection).GetContext(ctx, &usage, query, userId)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return domain.StorageUsage{}, infra.ErrUserNotFound
+		}
+		return domain.StorageUsage{}, fmt.Errorf("error getting storage usage: %w", err)
+	}
+	return toDomainStorageUsage(usage), nil
+}
+
+func (p *PostgresQuotaRepository) ReserveStorage(ctx context.Context, userId uuid.UUID, bytes int64) error {
+	db := conn(ctx, p.connection)
+	if err := ensureUserStorageRow(ctx, db, userId); err != nil {
+		return err
+	}
+
+	const query = `
+    UPDATE user_storage s SET used_bytes = s.used_bytes + $2, updated_at = CURRENT_TIMESTAMP
+    FROM users u
+    LEFT JOIN role_storage_quotas r ON r.role = u.role
+    WHERE s.user_id = $1 AND u.id = s.user_id
+      AND (COALESCE(s.quota_bytes, r.quota_bytes) IS NULL OR s.used_bytes + $2 <= COALESCE(s.quota_bytes, r.quota_bytes))
+  `
+	result, err := db.ExecContext(ctx, query, userId, bytes)
+	if err != nil {
+		return fmt.Errorf("error reserving storage: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error reserving storage: %w", err)
+	}
+	if rows == 0 {
+		return infra.ErrQuotaExceeded
+	}
+	return nil
+}
+
+func (p *PostgresQuotaRepository) ReleaseStorage(ctx context.Context, userId uuid.UUID, bytes int64) error {
+	const query = `
+    UPDATE user_storage SET used_bytes = GREATEST(used_bytes - $2, 0), updated_at = CURRENT_TIMESTAMP
+    WHERE user_id = $1
+  `
+	_, err := conn(ctx, p.connection).ExecContext(ctx, query, userId, bytes)
+	if err != nil {
+		return fmt.Errorf("error releasing storage: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresQuotaRepository) SetUserQuota(ctx context.Context, userId uuid.UUID, quotaBytes *int64) error {
+	const query = `
+    INSERT INTO user_storage (user_id, quota_bytes) VALUES ($1, $2)
+    ON CONFLICT (user_id) DO UPDATE SET quota_bytes = EXCLUDED.quota_bytes, updated_at = CURRENT_TIMESTAMP
+  `
+	var quota sql.NullInt64
+	if quotaBytes != nil {
+		quota = sql.NullInt64{Int64: *quotaBytes, Valid: true}
+	}
+
+	_, err := conn(ctx, p.connection).ExecContext(ctx, query, userId, quota)
+	if err != nil {
+		return fmt.Errorf("error setting user quota: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresQuotaRepository) SetRoleQuota(ctx context.Context, role domain.Role, quotaBytes int64) error {
+	const query = `
+    INSERT INTO role_storage_quotas (role, quota_bytes) VALUES ($1, $2)
+    ON CONFLICT (role) DO UPDATE SET quota_bytes = EXCLUDED.quota_bytes
+  `
+	_, err := conn(ctx, p.connection).ExecContext(ctx, query, role, quotaBytes)
+	if err != nil {
+		return fmt.Errorf("error setting role quota: %w", err)
+	}
+	return nil
+}
+
+func ensureUserStorageRow(ctx context.Context, db executor, userId uuid.UUID) error {
+	const query = `INSERT INTO user_storage (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING`
+	_, err := db.ExecContext(ctx, query, userId)
+	if err != nil {
+		return fmt.Errorf("error initializing user storage: %w", err)
+	}
+	return nil
+}
+
+type SqlxStorageUsage struct {
+	UserId     uuid.UUID     `db:"user_id"`
+	UsedBytes  int64         `db:"used_bytes"`
+	QuotaBytes sql.NullInt64 `db:"quota_bytes"`
+}
+
+func toDomainStorageUsage(s SqlxStorageUsage) domain.StorageUsage {
+	usage := domain.StorageUsage{
+		UserId:    s.UserId,
+		UsedBytes: s.UsedBytes,
+	}
+	if s.QuotaBytes.Valid {
+		quota := s.QuotaBytes.Int64
+		usage.QuotaBytes = &quota
+	}
+	return usage
+}
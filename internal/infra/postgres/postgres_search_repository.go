@@ -0,0 +1,217 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+type PostgresSearchRepository struct {
+	connection *sqlx.DB
+}
+
+func NewPostgresSearchRepo(ctx context.Context, connection *sqlx.DB) (*PostgresSearchRepository, error) {
+	if connection == nil {
+		return &PostgresSearchRepository{}, fmt.Errorf("Failed to create PostgresSearchRepository: connection is nil")
+	}
+	return &PostgresSearchRepository{connection: connection}, nil
+}
+
+// Search ranks each match by full-text relevance plus trigram similarity of
+// the name, and full-text relevance of the extracted content, so that exact
+// word matches come first while partial and misspelt names are still found.
+func (p *PostgresSearchRepository) Search(ctx context.Context, q infra.SearchQuery) ([]domain.SearchResult, int, error) {
+	args := []interface{}{q.OwnerId, q.Text, "%" + escapeLike(q.Text) + "%"}
+	bind := func(arg interface{}) string {
+		args = append(args, arg)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	matches := func(column string) string {
+		return fmt.Sprintf(
+			"(to_tsvector('simple', %[1]s) @@ plainto_tsquery('simple', $2) OR %[1]s ILIKE $3 OR %[1]s %% $2)",
+			column,
+		)
+	}
+	rank := func(column string) string {
+		return fmt.Sprintf(
+			"ts_rank(to_tsvector('simple', %[1]s), plainto_tsquery('simple', $2)) + similarity(%[1]s, $2)",
+			column,
+		)
+	}
+	const contentMatches = "c.content_tsv @@ plainto_tsquery('english', $2)"
+	const contentRank = "COALESCE(ts_rank(c.content_tsv, plainto_tsquery('english', $2)), 0)"
+
+	// Text is empty when searching by tag alone, which matches everything
+	// with the tags and ranks it all as 0, so results come in name order.
+	fileConditions := []string{
+		"f.owner_id = $1", "f.is_unsafe = false", "f.is_missing = false",
+		"($2 = '' OR " + matches("f.file_name") + " OR " + contentMatches + ")",
+	}
+	folderConditions := []string{"d.owner_id = $1", "($2 = '' OR " + matches("d.folder_name") + ")"}
+	if len(q.Tags) > 0 {
+		tags := bind(pq.StringArray(q.Tags))
+		fileConditions = append(fileConditions, "f.tags @> "+tags)
+		folderConditions = append(folderConditions, "d.tags @> "+tags)
+	}
+	includeFolders := true
+
+	if q.ContentType != "" {
+		if strings.HasSuffix(q.ContentType, "/*") {
+			fileConditions = append(fileConditions, "f.content_type LIKE "+bind(escapeLike(strings.TrimSuffix(q.ContentType, "*"))+"%"))
+		} else {
+			fileConditions = append(fileConditions, "split_part(f.content_type, ';', 1) = "+bind(q.ContentType))
+		}
+		includeFolders = false
+	}
+	if q.MinSize != nil {
+		fileConditions = append(fileConditions, "f.file_size >= "+bind(*q.MinSize))
+		includeFolders = false
+	}
+	if q.MaxSize != nil {
+		fileConditions = append(fileConditions, "f.file_size <= "+bind(*q.MaxSize))
+		includeFolders = false
+	}
+	if q.From != nil {
+		from := bind(q.From.UTC())
+		fileConditions = append(fileConditions, "f.created_at >= "+from)
+		folderConditions = append(folderConditions, "d.created_at >= "+from)
+	}
+	if q.To != nil {
+		to := bind(q.To.UTC())
+		fileConditions = append(fileConditions, "f.created_at < "+to)
+		folderConditions = append(folderConditions, "d.created_at < "+to)
+	}
+
+	query := "WITH RECURSIVE"
+	if q.FolderId != nil {
+		query += fmt.Sprintf(`
+    subtree AS (
+      SELECT id FROM folders WHERE id = %s AND owner_id = $1
+      UNION ALL
+      SELECT child.id FROM folders child JOIN subtree ON child.parent_id = subtree.id
+    ),`, bind(*q.FolderId))
+		fileConditions = append(fileConditions, "f.folder_id IN (SELECT id FROM subtree)")
+		folderConditions = append(folderConditions, "d.parent_id IN (SELECT id FROM subtree)")
+	}
+
+	query += fmt.Sprintf(`
+    matches AS (
+      SELECT 'file' AS result_type, f.id, f.file_name AS name, f.owner_id, f.folder_id AS parent_id,
+        f.file_store_key, f.file_size, f.content_type, f.tags, f.metadata, f.created_at, f.updated_at, %s + %s AS rank
+      FROM files f LEFT JOIN file_contents c ON c.file_id = f.id
+      WHERE %s`,
+		rank("f.file_name"), contentRank, strings.Join(fileConditions, " AND "))
+	if includeFolders {
+		query += fmt.Sprintf(`
+      UNION ALL
+      SELECT 'folder', d.id, d.folder_name, d.owner_id, d.parent_id,
+        '', 0, '', d.tags, d.metadata, d.created_at, d.updated_at, %s
+      FROM folders d WHERE %s`,
+			rank("d.folder_name"), strings.Join(folderConditions, " AND "))
+	}
+	query += `
+    )`
+	countQuery, countArgs := query+" SELECT COUNT(*) FROM matches", append([]interface{}{}, args...)
+
+	// Snippets are only generated for the requested page, since ts_headline
+	// has to re-parse the whole document.
+	query += fmt.Sprintf(`,
+    page AS (
+      SELECT * FROM matches
+      ORDER BY rank DESC, name, id
+      LIMIT %s OFFSET %s
+    )
+    SELECT page.*,
+      CASE WHEN %s THEN ts_headline('english', c.content, plainto_tsquery('english', $2), %s) ELSE '' END AS snippet
+    FROM page LEFT JOIN file_contents c ON page.result_type = 'file' AND c.file_id = page.id
+    ORDER BY rank DESC, name, id`, bind(q.Limit), bind(q.Offset), contentMatches, bind(snippetOptions))
+
+	db := conn(ctx, p.connection)
+	var rows []SqlxSearchResult
+	if err := db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return []domain.SearchResult{}, 0, fmt.Errorf("error searching files and folders :%w", err)
+	}
+
+	total, ok := pageTotal(len(rows), q.Limit, q.Offset)
+	if !ok {
+		if err := db.GetContext(ctx, &total, countQuery, countArgs...); err != nil {
+			return []domain.SearchResult{}, 0, fmt.Errorf("error counting search results :%w", err)
+		}
+	}
+
+	result := []domain.SearchResult{}
+	for _, element := range rows {
+		result = append(result, toDomainSearchResult(element))
+	}
+	return result, total, nil
+}
+
+var snippetOptions = fmt.Sprintf(
+	`StartSel="%s", StopSel="%s", MaxWords=25, MinWords=10, MaxFragments=2, FragmentDelimiter=" ... "`,
+	domain.HighlightStart, domain.HighlightEnd,
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+type SqlxSearchResult struct {
+	ResultType   domain.SearchResultType `db:"result_type"`
+	ID           uuid.UUID               `db:"id"`
+	Name         string                  `db:"name"`
+	OwnerId      uuid.UUID               `db:"owner_id"`
+	ParentId     *uuid.UUID              `db:"parent_id"`
+	FileStoreKey string                  `db:"file_store_key"`
+	FileSize     int64                   `db:"file_size"`
+	ContentType  string                  `db:"content_type"`
+	Tags         pq.StringArray          `db:"tags"`
+	Metadata     SqlxMetadata            `db:"metadata"`
+	CreatedAt    time.Time               `db:"created_at"`
+	UpdatedAt    time.Time               `db:"updated_at"`
+	Rank         float64                 `db:"rank"`
+	Snippet      string                  `db:"snippet"`
+}
+
+func toDomainSearchResult(r SqlxSearchResult) domain.SearchResult {
+	result := domain.SearchResult{Type: r.ResultType, Rank: r.Rank, Snippet: r.Snippet}
+	if r.ResultType == domain.SearchResultFolder {
+		result.Folder = &domain.Folder{
+			ID:         r.ID,
+			FolderName: r.Name,
+			OwnerId:    r.OwnerId,
+			ParentId:   r.ParentId,
+			Tags:       toDomainTags(r.Tags),
+			Metadata:   toDomainMetadata(r.Metadata),
+			CreatedAt:  r.CreatedAt,
+			UpdatedAt:  r.UpdatedAt,
+		}
+		return result
+	}
+
+	file := &domain.File{
+		ID:           r.ID,
+		FileName:     r.Name,
+		OwnerId:      r.OwnerId,
+		FileStoreKey: r.FileStoreKey,
+		FileSize:     r.FileSize,
+		ContentType:  r.ContentType,
+		Tags:         toDomainTags(r.Tags),
+		Metadata:     toDomainMetadata(r.Metadata),
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+	}
+	if r.ParentId != nil {
+		file.FolderId = *r.ParentId
+	}
+	result.File = file
+	return result
+}
@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/olad5/file-fort/internal/domain"
+)
+
+type PostgresStarRepository struct {
+	connection *sqlx.DB
+}
+
+func NewPostgresStarRepo(ctx context.Context, connection *sqlx.DB) (*PostgresStarRepository, error) {
+	if connection == nil {
+		return &PostgresStarRepository{}, fmt.Errorf("Failed to create PostgresStarRepository: connection is nil")
+	}
+	return &PostgresStarRepository{connection: connection}, nil
+}
+
+// StarFile does nothing when the user has already starred the file.
+func (p *PostgresStarRepository) StarFile(ctx context.Context, userId, fileId uuid.UUID) error {
+	const query = `INSERT INTO stars (id, user_id, file_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+	_, err := conn(ctx, p.connection).ExecContext(ctx, query, uuid.New(), userId, fileId)
+	if err != nil {
+		return fmt.Errorf("error starring file in the db: %w", err)
+	}
+	return nil
+}
+
+// StarFolder does nothing when the user has already starred the folder.
+func (p *PostgresStarRepository) StarFolder(ctx context.Context, userId, folderId uuid.UUID) error {
+	const query = `INSERT INTO stars (id, user_id, folder_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+	_, err := conn(ctx, p.connection).ExecContext(ctx, query, uuid.New(), userId, folderId)
+	if err != nil {
+		return fmt.Errorf("error starring folder in the db: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresStarRepository) UnstarFile(ctx context.Context, userId, fileId uuid.UUID) error {
+	_, err := conn(ctx, p.connection).ExecContext(ctx, "DELETE FROM stars WHERE user_id=$1 AND file_id=$2", userId, fileId)
+	if err != nil {
+		return fmt.Errorf("error unstarring file in the db: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresStarRepository) UnstarFolder(ctx context.Context, userId, folderId uuid.UUID) error {
+	_, err := conn(ctx, p.connection).ExecContext(ctx, "DELETE FROM stars WHERE user_id=$1 AND folder_id=$2", userId, folderId)
+	if err != nil {
+		return fmt.Errorf("error unstarring folder in the db: %w", err)
+	}
+	return nil
+}
+
+// GetStars returns the user's stars, most recently starred first, along with
+// the total number of stars. Stars of unsafe or missing files are left out.
+func (p *PostgresStarRepository) GetStars(ctx context.Context, userId uuid.UUID, limit, offset int) ([]domain.Star, int, error) {
+	const from = `
+    FROM stars s LEFT JOIN files f ON f.id = s.file_id
+    WHERE s.user_id = $1 AND (s.file_id IS NULL OR (f.is_unsafe = false AND f.is_missing = false))
+  `
+	const query = "SELECT s.id, s.user_id, s.file_id, s.folder_id, s.created_at" + from + "ORDER BY s.created_at DESC, s.id LIMIT $2 OFFSET $3"
+
+	db := conn(ctx, p.connection)
+	var rows []SqlxStar
+	if err := db.SelectContext(ctx, &rows, query, userId, limit, offset); err != nil {
+		return []domain.Star{}, 0, fmt.Errorf("error getting stars :%w", err)
+	}
+
+	total, ok := pageTotal(len(rows), limit, offset)
+	if !ok {
+		if err := db.GetContext(ctx, &total, "SELECT COUNT(*)"+from, userId); err != nil {
+			return []domain.Star{}, 0, fmt.Errorf("error counting stars :%w", err)
+		}
+	}
+
+	fileIds, folderIds := pq.StringArray{}, pq.StringArray{}
+	for _, row := range rows {
+		if row.FileId != nil {
+			fileIds = append(fileIds, row.FileId.String())
+		}
+		if row.FolderId != nil {
+			folderIds = append(folderIds, row.FolderId.String())
+		}
+	}
+
+	var files []SqlxFile
+	if err := db.SelectContext(ctx, &files, "SELECT * FROM files WHERE id = ANY($1::uuid[])", fileIds); err != nil {
+		return []domain.Star{}, 0, fmt.Errorf("error getting starred files :%w", err)
+	}
+	filesById := map[uuid.UUID]domain.File{}
+	for _, element := range files {
+		filesById[element.ID] = toDomainFile(element)
+	}
+
+	var folders []SqlxFolder
+	if err := db.SelectContext(ctx, &folders, "SELECT * FROM folders WHERE id = ANY($1::uuid[])", folderIds); err != nil {
+		return []domain.Star{}, 0, fmt.Errorf("error getting starred folders :%w", err)
+	}
+	foldersById := map[uuid.UUID]domain.Folder{}
+	for _, element := range folders {
+		foldersById[element.ID] = toDomainFolder(element)
+	}
+
+	result := []domain.Star{}
+	for _, row := range rows {
+		star := domain.Star{ID: row.ID, UserId: row.UserId, CreatedAt: row.CreatedAt}
+		if row.FileId != nil {
+			file := filesById[*row.FileId]
+			star.File = &file
+		} else {
+			folder := foldersById[*row.FolderId]
+			star.Folder = &folder
+		}
+		result = append(result, star)
+	}
+	return result, total, nil
+}
+
+type SqlxStar struct {
+	ID        uuid.UUID  `db:"id"`
+	UserId    uuid.UUID  `db:"user_id"`
+	FileId    *uuid.UUID `db:"file_id"`
+	FolderId  *uuid.UUID `db:"folder_id"`
+	CreatedAt time.Time  `db:"created_at"`
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+type PostgresThumbnailRepository struct {
+	connection *sqlx.DB
+}
+
+func NewPostgresThumbnailRepo(ctx context.Context, connection *sqlx.DB) (*PostgresThumbnailRepository, error) {
+	if connection == nil {
+		return &PostgresThumbnailRepository{}, fmt.Errorf("Failed to create PostgresThumbnailRepository: connection is nil")
+	}
+	return &PostgresThumbnailRepository{connection: connection}, nil
+}
+
+func (p *PostgresThumbnailRepository) SaveThumbnail(ctx context.Context, thumbnail domain.Thumbnail) error {
+	thumbnail.CreatedAt = time.Now().UTC()
+
+	const query = `
+    INSERT INTO file_thumbnails
+      (file_id, size, file_store_key, width, height, created_at)
+    VALUES
+      (:file_id, :size, :file_store_key, :width, :height, :created_at)
+    ON CONFLICT (file_id, size) DO UPDATE SET
+      file_store_key = EXCLUDED.file_store_key, width = EXCLUDED.width,
+      height = EXCLUDED.height, created_at = EXCLUDED.created_at
+  `
+	_, err := conn(ctx, p.connection).NamedExecContext(ctx, query, toSqlxThumbnail(thumbnail))
+	if err != nil {
+		return fmt.Errorf("error saving thumbnail in the db: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresThumbnailRepository) GetThumbnail(ctx context.Context, fileId uuid.UUID, size domain.ThumbnailSize) (domain.Thumbnail, error) {
+	var thumbnail SqlxThumbnail
+	err := conn(ctx, p.connection).GetContext(ctx, &thumbnail, "SELECT * FROM file_thumbnails WHERE file_id=$1 AND size=$2", fileId, size)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return domain.Thumbnail{}, infra.ErrThumbnailNotFound
+		}
+		return domain.Thumbnail{}, fmt.Errorf("error getting thumbnail :%w", err)
+	}
+	return toDomainThumbnail(thumbnail), nil
+}
+
+func (p *PostgresThumbnailRepository) DeleteThumbnailsByFileId(ctx context.Context, fileId uuid.UUID) error {
+	_, err := conn(ctx, p.connection).ExecContext(ctx, "DELETE FROM file_thumbnails WHERE file_id=$1", fileId)
+	if err != nil {
+		return fmt.Errorf("error deleting thumbnails in the db: %w", err)
+	}
+	return nil
+}
+
+type SqlxThumbnail struct {
+	FileId       uuid.UUID            `db:"file_id"`
+	Size         domain.ThumbnailSize `db:"size"`
+	FileStoreKey string               `db:"file_store_key"`
+	Width        int                  `db:"width"`
+	Height       int                  `db:"height"`
+	CreatedAt    time.Time            `db:"created_at"`
+}
+
+func toDomainThumbnail(t SqlxThumbnail) domain.Thumbnail {
+	return domain.Thumbnail{
+		FileId:       t.FileId,
+		Size:         t.Size,
+		FileStoreKey: t.FileStoreKey,
+		Width:        t.Width,
+		Height:       t.Height,
+		CreatedAt:    t.CreatedAt,
+	}
+}
+
+func toSqlxThumbnail(t domain.Thumbnail) SqlxThumbnail {
+	return SqlxThumbnail{
+		FileId:       t.FileId,
+		Size:         t.Size,
+		FileStoreKey: t.FileStoreKey,
+		Width:        t.Width,
+		Height:       t.Height,
+		CreatedAt:    t.CreatedAt,
+	}
+}
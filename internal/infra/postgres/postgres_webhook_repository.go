@@ -0,0 +1,284 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+type PostgresWebhookRepository struct {
+	connection *sqlx.DB
+}
+
+func NewPostgresWebhookRepo(ctx context.Context, connection *sqlx.DB) (*PostgresWebhookRepository, error) {
+	if connection == nil {
+		return &PostgresWebhookRepository{}, fmt.Errorf("Failed to create PostgresWebhookRepository: connection is nil")
+	}
+	return &PostgresWebhookRepository{connection: connection}, nil
+}
+
+func (p *PostgresWebhookRepository) CreateWebhook(ctx context.Context, webhook domain.Webhook) error {
+	const query = `
+    INSERT INTO webhooks
+      (id, owner_id, url, secret, events, is_active)
+    VALUES
+      (:id, :owner_id, :url, :secret, :events, :is_active)
+  `
+
+	_, err := conn(ctx, p.connection).NamedExecContext(ctx, query, toSqlxWebhook(webhook))
+	if err != nil {
+		return fmt.Errorf("error creating webhook in the db: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresWebhookRepository) GetWebhookByWebhookId(ctx context.Context, webhookId uuid.UUID) (domain.Webhook, error) {
+	var webhook SqlxWebhook
+	err := conn(ctx, p.connection).GetContext(ctx, &webhook, "SELECT * FROM webhooks WHERE id=$1", webhookId)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return domain.Webhook{}, infra.ErrWebhookNotFound
+		}
+		return domain.Webhook{}, fmt.Errorf("error getting webhook :%w", err)
+	}
+	return toDomainWebhook(webhook), nil
+}
+
+func (p *PostgresWebhookRepository) GetWebhooksByOwnerId(ctx context.Context, ownerId uuid.UUID) ([]domain.Webhook, error) {
+	var webhooks []SqlxWebhook
+	err := conn(ctx, p.connection).SelectContext(ctx, &webhooks, "SELECT * FROM webhooks WHERE owner_id=$1 ORDER BY created_at", ownerId)
+	if err != nil {
+		return []domain.Webhook{}, fmt.Errorf("error getting webhooks :%w", err)
+	}
+
+	result := []domain.Webhook{}
+	for _, element := range webhooks {
+		result = append(result, toDomainWebhook(element))
+	}
+	return result, nil
+}
+
+func (p *PostgresWebhookRepository) GetActiveWebhooksForEvent(ctx context.Context, ownerId uuid.UUID, eventType domain.EventType) ([]domain.Webhook, error) {
+	const query = `SELECT * FROM webhooks WHERE owner_id=$1 AND is_active=true AND $2 = ANY(events)`
+
+	var webhooks []SqlxWebhook
+	err := conn(ctx, p.connection).SelectContext(ctx, &webhooks, query, ownerId, string(eventType))
+	if err != nil {
+		return []domain.Webhook{}, fmt.Errorf("error getting webhooks for event :%w", err)
+	}
+
+	result := []domain.Webhook{}
+	for _, element := range webhooks {
+		result = append(result, toDomainWebhook(element))
+	}
+	return result, nil
+}
+
+func (p *PostgresWebhookRepository) DeleteWebhook(ctx context.Context, webhookId uuid.UUID) error {
+	_, err := conn(ctx, p.connection).ExecContext(ctx, "DELETE FROM webhooks WHERE id=$1", webhookId)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook in the db: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresWebhookRepository) CreateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	const query = `
+    INSERT INTO webhook_deliveries
+      (id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at, updated_at)
+    VALUES
+      (:id, :webhook_id, :event_id, :event_type, :payload, :status, :attempts, :next_attempt_at, :created_at, :updated_at)
+  `
+
+	_, err := conn(ctx, p.connection).NamedExecContext(ctx, query, toSqlxWebhookDelivery(delivery))
+	if err != nil {
+		return fmt.Errorf("error creating webhook delivery in the db: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresWebhookRepository) GetDeliveryByDeliveryId(ctx context.Context, deliveryId uuid.UUID) (domain.WebhookDelivery, error) {
+	var delivery SqlxWebhookDelivery
+	err := conn(ctx, p.connection).GetContext(ctx, &delivery, "SELECT * FROM webhook_deliveries WHERE id=$1", deliveryId)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return domain.WebhookDelivery{}, infra.ErrDeliveryNotFound
+		}
+		return domain.WebhookDelivery{}, fmt.Errorf("error getting webhook delivery :%w", err)
+	}
+	return toDomainWebhookDelivery(delivery), nil
+}
+
+func (p *PostgresWebhookRepository) GetDeliveriesByWebhookId(ctx context.Context, webhookId uuid.UUID, pageNumber, rowsPerPage int) ([]domain.WebhookDelivery, error) {
+	const query = `
+    SELECT * FROM webhook_deliveries WHERE webhook_id=$1
+    ORDER BY created_at DESC, id
+    LIMIT $2 OFFSET $3
+  `
+	offset := (pageNumber - 1) * rowsPerPage
+
+	var deliveries []SqlxWebhookDelivery
+	err := conn(ctx, p.connection).SelectContext(ctx, &deliveries, query, webhookId, rowsPerPage, offset)
+	if err != nil {
+		return []domain.WebhookDelivery{}, fmt.Errorf("error getting webhook deliveries :%w", err)
+	}
+
+	result := []domain.WebhookDelivery{}
+	for _, element := range deliveries {
+		result = append(result, toDomainWebhookDelivery(element))
+	}
+	return result, nil
+}
+
+// ClaimDueDeliveries pushes the next attempt of up to limit due deliveries
+// forward by lease and returns them, so that concurrent workers never pick
+// up the same delivery while it is being sent.
+func (p *PostgresWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	const query = `
+    UPDATE webhook_deliveries SET next_attempt_at = $2, updated_at = $3
+    WHERE id IN (
+      SELECT id FROM webhook_deliveries
+      WHERE status = 'pending' AND next_attempt_at <= $3
+      ORDER BY next_attempt_at
+      LIMIT $1
+      FOR UPDATE SKIP LOCKED
+    )
+    RETURNING *
+  `
+	now := time.Now().UTC()
+
+	var deliveries []SqlxWebhookDelivery
+	err := conn(ctx, p.connection).SelectContext(ctx, &deliveries, query, limit, now.Add(lease), now)
+	if err != nil {
+		return []domain.WebhookDelivery{}, fmt.Errorf("error claiming webhook deliveries :%w", err)
+	}
+
+	result := []domain.WebhookDelivery{}
+	for _, element := range deliveries {
+		result = append(result, toDomainWebhookDelivery(element))
+	}
+	return result, nil
+}
+
+func (p *PostgresWebhookRepository) UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	delivery.UpdatedAt = time.Now().UTC()
+
+	const query = `
+    UPDATE webhook_deliveries SET
+      status=:status, attempts=:attempts, next_attempt_at=:next_attempt_at,
+      last_response_code=:last_response_code, last_error=:last_error,
+      delivered_at=:delivered_at, updated_at=:updated_at
+    WHERE id=:id
+  `
+	_, err := conn(ctx, p.connection).NamedExecContext(ctx, query, toSqlxWebhookDelivery(delivery))
+	if err != nil {
+		return fmt.Errorf("error updating webhook delivery in the db: %w", err)
+	}
+	return nil
+}
+
+type SqlxWebhook struct {
+	ID        uuid.UUID      `db:"id"`
+	OwnerId   uuid.UUID      `db:"owner_id"`
+	Url       string         `db:"url"`
+	Secret    string         `db:"secret"`
+	Events    pq.StringArray `db:"events"`
+	IsActive  bool           `db:"is_active"`
+	CreatedAt time.Time      `db:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at"`
+}
+
+func toDomainWebhook(w SqlxWebhook) domain.Webhook {
+	events := []domain.EventType{}
+	for _, event := range w.Events {
+		events = append(events, domain.EventType(event))
+	}
+
+	return domain.Webhook{
+		ID:        w.ID,
+		OwnerId:   w.OwnerId,
+		Url:       w.Url,
+		Secret:    w.Secret,
+		Events:    events,
+		IsActive:  w.IsActive,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+func toSqlxWebhook(w domain.Webhook) SqlxWebhook {
+	events := pq.StringArray{}
+	for _, event := range w.Events {
+		events = append(events, string(event))
+	}
+
+	return SqlxWebhook{
+		ID:        w.ID,
+		OwnerId:   w.OwnerId,
+		Url:       w.Url,
+		Secret:    w.Secret,
+		Events:    events,
+		IsActive:  w.IsActive,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+type SqlxWebhookDelivery struct {
+	ID               uuid.UUID             `db:"id"`
+	WebhookId        uuid.UUID             `db:"webhook_id"`
+	EventId          uuid.UUID             `db:"event_id"`
+	EventType        domain.EventType      `db:"event_type"`
+	Payload          string                `db:"payload"`
+	Status           domain.DeliveryStatus `db:"status"`
+	Attempts         int                   `db:"attempts"`
+	NextAttemptAt    time.Time             `db:"next_attempt_at"`
+	LastResponseCode int                   `db:"last_response_code"`
+	LastError        string                `db:"last_error"`
+	DeliveredAt      *time.Time            `db:"delivered_at"`
+	CreatedAt        time.Time             `db:"created_at"`
+	UpdatedAt        time.Time             `db:"updated_at"`
+}
+
+func toDomainWebhookDelivery(d SqlxWebhookDelivery) domain.WebhookDelivery {
+	return domain.WebhookDelivery{
+		ID:               d.ID,
+		WebhookId:        d.WebhookId,
+		EventId:          d.EventId,
+		EventType:        d.EventType,
+		Payload:          []byte(d.Payload),
+		Status:           d.Status,
+		Attempts:         d.Attempts,
+		NextAttemptAt:    d.NextAttemptAt,
+		LastResponseCode: d.LastResponseCode,
+		LastError:        d.LastError,
+		DeliveredAt:      d.DeliveredAt,
+		CreatedAt:        d.CreatedAt,
+		UpdatedAt:        d.UpdatedAt,
+	}
+}
+
+func toSqlxWebhookDelivery(d domain.WebhookDelivery) SqlxWebhookDelivery {
+	return SqlxWebhookDelivery{
+		ID:               d.ID,
+		WebhookId:        d.WebhookId,
+		EventId:          d.EventId,
+		EventType:        d.EventType,
+		Payload:          string(d.Payload),
+		Status:           d.Status,
+		Attempts:         d.Attempts,
+		NextAttemptAt:    d.NextAttemptAt,
+		LastResponseCode: d.LastResponseCode,
+		LastError:        d.LastError,
+		DeliveredAt:      d.DeliveredAt,
+		CreatedAt:        d.CreatedAt,
+		UpdatedAt:        d.UpdatedAt,
+	}
+}
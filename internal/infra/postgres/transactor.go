@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type txKey struct{}
+
+type PostgresTransactor struct {
+	connection *sqlx.DB
+}
+
+func NewPostgresTransactor(ctx context.Context, connection *sqlx.DB) (*PostgresTransactor, error) {
+	if connection == nil {
+		return &PostgresTransactor{}, fmt.Errorf("Failed to create PostgresTransactor: connection is nil")
+	}
+	return &PostgresTransactor{connection: connection}, nil
+}
+
+// WithinTransaction runs fn with a context carrying a database transaction.
+// Repositories called with that context take part in the transaction, which
+// is committed when fn returns nil and rolled back otherwise. Nested calls
+// reuse the outer transaction.
+func (p *PostgresTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := p.connection.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+
+	// A failed rollback is added to the error of fn rather than replacing it,
+	// so callers can still match what fn returned.
+	defer func() {
+		errTx := tx.Rollback()
+		if errTx == nil || errors.Is(errTx, sql.ErrTxDone) {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("rollback: %w", errTx)
+			return
+		}
+		err = fmt.Errorf("%w (rollback: %w)", err, errTx)
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+type executor interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+}
+
+// conn returns the transaction stored in ctx, falling back to connection.
+func conn(ctx context.Context, connection *sqlx.DB) executor {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return connection
+}
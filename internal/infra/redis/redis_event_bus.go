@@ -0,0 +1,225 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+const (
+	EventStream        = "file-fort-events"
+	eventField         = "event"
+	trimInterval       = time.Minute
+	readBatchSize      = 10
+	readBlockTimeout   = 5 * time.Second
+	redeliveryMinIdle  = 30 * time.Second
+	readErrorBackoff   = time.Second
+	busyGroupErrPrefix = "BUSYGROUP"
+	noSuchKeyErr       = "no such key"
+)
+
+// RedisEventBus publishes events to a Redis stream. Each consumer is a
+// consumer group, so every instance of a consumer shares the group's events
+// and an event is only acknowledged after the handler succeeds. Unacknowledged
+// events are reclaimed and retried once they have been idle for
+// redeliveryMinIdle. The stream is trimmed every trimInterval of the events
+// every group has acknowledged, so a consumer that lags never loses events.
+type RedisEventBus struct {
+	client       *redis.Client
+	stream       string
+	consumerName string
+}
+
+// NewRedisEventBus trims the stream until ctx is cancelled.
+func NewRedisEventBus(ctx context.Context, client *redis.Client, stream string) (*RedisEventBus, error) {
+	if client == nil {
+		return nil, fmt.Errorf("failed to initialize redis event bus, client is nil")
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "file-fort"
+	}
+
+	r := &RedisEventBus{
+		client:       client,
+		stream:       stream,
+		consumerName: hostname + "-" + uuid.NewString(),
+	}
+	go r.trimPeriodically(ctx)
+	return r, nil
+}
+
+func (r *RedisEventBus) Publish(ctx context.Context, event domain.Event) error {
+	body, err := infra.EncodeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	err = r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: r.stream,
+		Values: map[string]interface{}{eventField: string(body)},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("Error publishing event to stream: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisEventBus) Subscribe(ctx context.Context, consumer string, handler infra.EventHandler) error {
+	err := r.client.XGroupCreateMkStream(ctx, r.stream, consumer, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), busyGroupErrPrefix) {
+		return fmt.Errorf("Error creating consumer group %s: %w", consumer, err)
+	}
+
+	go r.consume(ctx, consumer, handler)
+	return nil
+}
+
+func (r *RedisEventBus) consume(ctx context.Context, consumer string, handler infra.EventHandler) {
+	for ctx.Err() == nil {
+		claimed, _, err := r.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   r.stream,
+			Group:    consumer,
+			MinIdle:  redeliveryMinIdle,
+			Start:    "0-0",
+			Count:    readBatchSize,
+			Consumer: r.consumerName,
+		}).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			r.backoff(ctx, consumer, err)
+			continue
+		}
+		r.handle(ctx, consumer, handler, claimed)
+
+		streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    consumer,
+			Consumer: r.consumerName,
+			Streams:  []string{r.stream, ">"},
+			Count:    readBatchSize,
+			Block:    readBlockTimeout,
+		}).Result()
+		if err != nil {
+			if !errors.Is(err, redis.Nil) {
+				r.backoff(ctx, consumer, err)
+			}
+			continue
+		}
+		for _, stream := range streams {
+			r.handle(ctx, consumer, handler, stream.Messages)
+		}
+	}
+}
+
+func (r *RedisEventBus) handle(ctx context.Context, consumer string, handler infra.EventHandler, messages []redis.XMessage) {
+	for _, message := range messages {
+		body, _ := message.Values[eventField].(string)
+		event, err := infra.DecodeEvent([]byte(body))
+		if err != nil {
+			log.Printf("Dropping malformed event %s for consumer %s: %v", message.ID, consumer, err)
+			r.ack(ctx, consumer, message.ID)
+			continue
+		}
+
+		if err := handler(ctx, event); err != nil {
+			log.Printf("Error handling event %s for consumer %s: %v", event.ID, consumer, err)
+			continue
+		}
+		r.ack(ctx, consumer, message.ID)
+	}
+}
+
+func (r *RedisEventBus) ack(ctx context.Context, consumer, messageId string) {
+	if err := r.client.XAck(ctx, r.stream, consumer, messageId).Err(); err != nil {
+		log.Printf("Error acknowledging event %s for consumer %s: %v", messageId, consumer, err)
+	}
+}
+
+func (r *RedisEventBus) backoff(ctx context.Context, consumer string, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	log.Printf("Error reading events for consumer %s: %v", consumer, err)
+	select {
+	case <-ctx.Done():
+	case <-time.After(readErrorBackoff):
+	}
+}
+
+func (r *RedisEventBus) trimPeriodically(ctx context.Context) {
+	ticker := time.NewTicker(trimInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := r.trim(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error trimming stream %s: %v", r.stream, err)
+		}
+	}
+}
+
+// trim removes the events that come before what any consumer group still
+// needs: its oldest unacknowledged event, or, when it has none, the last
+// event it was given. Nothing is removed while there are no groups, as a
+// group created later starts from the beginning of the stream.
+func (r *RedisEventBus) trim(ctx context.Context) error {
+	groups, err := r.client.XInfoGroups(ctx, r.stream).Result()
+	if err != nil && strings.Contains(err.Error(), noSuchKeyErr) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	var minId string
+	for _, group := range groups {
+		needed := group.LastDeliveredID
+		if group.Pending > 0 {
+			pending, err := r.client.XPending(ctx, r.stream, group.Name).Result()
+			if err != nil {
+				return err
+			}
+			if pending.Count > 0 {
+				needed = pending.Lower
+			}
+		}
+		if minId == "" || streamIdLess(needed, minId) {
+			minId = needed
+		}
+	}
+	return r.client.XTrimMinID(ctx, r.stream, minId).Err()
+}
+
+// streamIdLess compares two stream IDs, "<milliseconds>-<sequence>".
+func streamIdLess(a, b string) bool {
+	aMillis, aSequence := parseStreamId(a)
+	bMillis, bSequence := parseStreamId(b)
+	if aMillis != bMillis {
+		return aMillis < bMillis
+	}
+	return aSequence < bSequence
+}
+
+func parseStreamId(id string) (uint64, uint64) {
+	millis, sequence, _ := strings.Cut(id, "-")
+	m, _ := strconv.ParseUint(millis, 10, 64)
+	s, _ := strconv.ParseUint(sequence, 10, 64)
+	return m, s
+}
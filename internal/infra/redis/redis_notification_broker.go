@@ -0,0 +1,93 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/infra/memory"
+)
+
+const NotificationChannel = "file-fort-notifications"
+
+// RedisNotificationBroker publishes events to a Redis pub/sub channel that
+// every instance of the server subscribes to, and each instance passes them
+// on to its own listeners. All events go to every instance, which keeps the
+// subscription fixed no matter who is listening where.
+type RedisNotificationBroker struct {
+	client  *redis.Client
+	channel string
+	local   *memory.MemoryNotificationBroker
+}
+
+// NewRedisNotificationBroker subscribes to channel until ctx is cancelled.
+func NewRedisNotificationBroker(ctx context.Context, client *redis.Client, channel string) (*RedisNotificationBroker, error) {
+	if client == nil {
+		return nil, fmt.Errorf("failed to initialize redis notification broker, client is nil")
+	}
+
+	pubSub := client.Subscribe(ctx, channel)
+	// Waiting for the confirmation means no event published after this
+	// returns is missed.
+	if _, err := pubSub.Receive(ctx); err != nil {
+		pubSub.Close()
+		return nil, fmt.Errorf("Error subscribing to channel %s: %w", channel, err)
+	}
+
+	r := &RedisNotificationBroker{
+		client:  client,
+		channel: channel,
+		local:   memory.NewMemoryNotificationBroker(),
+	}
+	go r.receive(ctx, pubSub)
+	return r, nil
+}
+
+func (r *RedisNotificationBroker) Notify(ctx context.Context, event domain.Event) error {
+	body, err := infra.EncodeEvent(event)
+	if err != nil {
+		return err
+	}
+	if err := r.client.Publish(ctx, r.channel, string(body)).Err(); err != nil {
+		return fmt.Errorf("Error publishing notification: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisNotificationBroker) Listen(ctx context.Context, userId uuid.UUID) (<-chan domain.Event, error) {
+	return r.local.Listen(ctx, userId)
+}
+
+// receive passes the events published by every instance on to the listeners
+// of this one. The subscription reconnects by itself when the connection to
+// Redis drops; events published in the meantime are lost.
+func (r *RedisNotificationBroker) receive(ctx context.Context, pubSub *redis.PubSub) {
+	defer pubSub.Close()
+
+	for ctx.Err() == nil {
+		message, err := pubSub.ReceiveMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error receiving notifications: %v", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(readErrorBackoff):
+			}
+			continue
+		}
+
+		event, err := infra.DecodeEvent([]byte(message.Payload))
+		if err != nil {
+			log.Printf("Dropping malformed notification: %v", err)
+			continue
+		}
+		r.local.Notify(ctx, event)
+	}
+}
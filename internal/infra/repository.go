@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/olad5/file-fort/internal/domain"
@@ -14,8 +15,17 @@ var (
 	ErrFolderNotFound    = errors.New("folder not found")
 	ErrUserNotFound      = errors.New("user not found")
 	ErrUserNotAuthorized = errors.New("unauthorized")
+	ErrQuotaExceeded     = errors.New("storage quota exceeded")
+	ErrWebhookNotFound   = errors.New("webhook not found")
+	ErrDeliveryNotFound  = errors.New("webhook delivery not found")
+	ErrThumbnailNotFound = errors.New("thumbnail not found")
+	ErrAccessKeyNotFound = errors.New("access key not found")
 )
 
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type UserRepository interface {
 	CreateUser(ctx context.Context, user domain.User) error
 	GetUserByEmail(ctx context.Context, email string) (domain.User, error)
@@ -25,18 +35,202 @@ type UserRepository interface {
 type FileRepository interface {
 	SaveFile(ctx context.Context, file domain.File) error
 	MarkFileAsUnsafe(ctx context.Context, file domain.File) error
+	MarkFileAsMissing(ctx context.Context, file domain.File) (bool, error)
+	MarkFileAsFound(ctx context.Context, file domain.File) (bool, error)
+	DeleteFile(ctx context.Context, fileId uuid.UUID) error
+	MoveFile(ctx context.Context, fileId, folderId uuid.UUID) error
+	RenameFile(ctx context.Context, fileId, folderId uuid.UUID, fileName string) error
+	DeleteFilesByFolderIds(ctx context.Context, folderIds []uuid.UUID) ([]domain.File, error)
+	UpdateFileLabels(ctx context.Context, fileId uuid.UUID, tags []string, metadata map[string]string) error
+	GetAllFiles(ctx context.Context) ([]domain.File, error)
 	GetFileByFileId(ctx context.Context, fileId uuid.UUID) (domain.File, error)
-	GetFilesByFolderId(ctx context.Context, folderId uuid.UUID, pageNumber, rowsPerPage int) ([]domain.File, error)
+	// GetFileByFileIdForUpdate also locks the file's row until the
+	// surrounding transaction ends.
+	GetFileByFileIdForUpdate(ctx context.Context, fileId uuid.UUID) (domain.File, error)
+	GetFilesByFolderId(ctx context.Context, folderId uuid.UUID, query FileListQuery) ([]domain.File, error)
+	CountFilesByFolderId(ctx context.Context, folderId uuid.UUID, tags []string) (int, error)
+	GetFilesByFolderIds(ctx context.Context, folderIds []uuid.UUID) ([]domain.File, error)
+	GetFilePagesByFolderIds(ctx context.Context, folderIds []uuid.UUID, pageNumber, rowsPerPage int) ([]domain.File, error)
+}
+
+type FileSortField string
+
+const (
+	SortByName      FileSortField = "name"
+	SortBySize      FileSortField = "size"
+	SortByCreatedAt FileSortField = "created_at"
+)
+
+var FileSortFields = []FileSortField{SortByName, SortBySize, SortByCreatedAt}
+
+func (f FileSortField) IsValid() bool {
+	for _, field := range FileSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// FileCursor is the position of a file in a listing. Only the field being
+// sorted on and ID need to be set.
+type FileCursor struct {
+	Name      string
+	Size      int64
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// FileListQuery pages through files in keyset order. When After is set, only
+// files that sort after it are returned. When Tags is set, only files that
+// have every one of them are returned.
+type FileListQuery struct {
+	SortBy     FileSortField
+	Descending bool
+	After      *FileCursor
+	Tags       []string
+	Limit      int
 }
 
 type FolderRepository interface {
 	CreateFolder(ctx context.Context, folder domain.Folder) error
 	GetFolderByFolderId(ctx context.Context, folderId uuid.UUID) (domain.Folder, error)
+	// GetFolderByFolderIdForUpdate also locks the folder's row until the
+	// surrounding transaction ends.
+	GetFolderByFolderIdForUpdate(ctx context.Context, folderId uuid.UUID) (domain.Folder, error)
+	GetFolderTree(ctx context.Context, folderId uuid.UUID) ([]domain.Folder, error)
+	GetFoldersByParentId(ctx context.Context, ownerId uuid.UUID, parentId *uuid.UUID) ([]domain.Folder, error)
+	GetFoldersByFolderIds(ctx context.Context, folderIds []uuid.UUID) ([]domain.Folder, error)
+	GetFoldersByParentIds(ctx context.Context, ownerId uuid.UUID, parentIds []uuid.UUID, pageNumber, rowsPerPage int) ([]domain.Folder, error)
+	RenameFolder(ctx context.Context, folderId uuid.UUID, parentId *uuid.UUID, folderName string) error
+	DeleteFolders(ctx context.Context, folderIds []uuid.UUID) error
+	UpdateFolderLabels(ctx context.Context, folderId uuid.UUID, tags []string, metadata map[string]string) error
+}
+
+type QuotaRepository interface {
+	GetStorageUsage(ctx context.Context, userId uuid.UUID) (domain.StorageUsage, error)
+	ReserveStorage(ctx context.Context, userId uuid.UUID, bytes int64) error
+	ReleaseStorage(ctx context.Context, userId uuid.UUID, bytes int64) error
+	SetUserQuota(ctx context.Context, userId uuid.UUID, quotaBytes *int64) error
+	SetRoleQuota(ctx context.Context, role domain.Role, quotaBytes int64) error
+}
+
+type AuditEventFilter struct {
+	ActorId    *uuid.UUID
+	TargetType string
+	TargetId   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+type AuditRepository interface {
+	SaveAuditEvent(ctx context.Context, event domain.AuditEvent) error
+	GetAuditEvents(ctx context.Context, filter AuditEventFilter) ([]domain.AuditEvent, error)
+}
+
+// SearchQuery matches Text against the names of OwnerId's files and folders.
+// ContentType, MinSize and MaxSize only apply to files, so setting any of
+// them leaves folders out of the results. FolderId limits results to that
+// folder and everything nested below it. Tags limits results to files and
+// folders that have every one of them, and may be used without Text.
+type SearchQuery struct {
+	OwnerId     uuid.UUID
+	Text        string
+	Tags        []string
+	ContentType string
+	MinSize     *int64
+	MaxSize     *int64
+	From        *time.Time
+	To          *time.Time
+	FolderId    *uuid.UUID
+	Limit       int
+	Offset      int
+}
+
+type SearchRepository interface {
+	Search(ctx context.Context, query SearchQuery) ([]domain.SearchResult, int, error)
+}
+
+type FileContentRepository interface {
+	SaveFileContent(ctx context.Context, content domain.FileContent) error
+}
+
+type ThumbnailRepository interface {
+	SaveThumbnail(ctx context.Context, thumbnail domain.Thumbnail) error
+	GetThumbnail(ctx context.Context, fileId uuid.UUID, size domain.ThumbnailSize) (domain.Thumbnail, error)
+	DeleteThumbnailsByFileId(ctx context.Context, fileId uuid.UUID) error
+}
+
+type StarRepository interface {
+	StarFile(ctx context.Context, userId, fileId uuid.UUID) error
+	StarFolder(ctx context.Context, userId, folderId uuid.UUID) error
+	UnstarFile(ctx context.Context, userId, fileId uuid.UUID) error
+	UnstarFolder(ctx context.Context, userId, folderId uuid.UUID) error
+	GetStars(ctx context.Context, userId uuid.UUID, limit, offset int) ([]domain.Star, int, error)
+}
+
+type ActivityRepository interface {
+	// RecordActivity keeps only the latest activity of a user on each file.
+	RecordActivity(ctx context.Context, userId, fileId uuid.UUID, activity domain.ActivityType) error
+	GetRecentFiles(ctx context.Context, userId uuid.UUID, limit, offset int) ([]domain.RecentFile, int, error)
+}
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook domain.Webhook) error
+	GetWebhookByWebhookId(ctx context.Context, webhookId uuid.UUID) (domain.Webhook, error)
+	GetWebhooksByOwnerId(ctx context.Context, ownerId uuid.UUID) ([]domain.Webhook, error)
+	GetActiveWebhooksForEvent(ctx context.Context, ownerId uuid.UUID, eventType domain.EventType) ([]domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookId uuid.UUID) error
+	CreateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error
+	GetDeliveryByDeliveryId(ctx context.Context, deliveryId uuid.UUID) (domain.WebhookDelivery, error)
+	GetDeliveriesByWebhookId(ctx context.Context, webhookId uuid.UUID, pageNumber, rowsPerPage int) ([]domain.WebhookDelivery, error)
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error
+}
+
+type AccessKeyRepository interface {
+	CreateAccessKey(ctx context.Context, accessKey domain.AccessKey) error
+	GetAccessKeyByAccessKeyId(ctx context.Context, accessKeyId string) (domain.AccessKey, error)
+	GetAccessKeysByUserId(ctx context.Context, userId uuid.UUID) ([]domain.AccessKey, error)
+	DeleteAccessKey(ctx context.Context, accessKeyId string) error
+}
+
+type OutboxRepository interface {
+	SaveEvent(ctx context.Context, event domain.Event) error
+	ClaimUnpublishedEvents(ctx context.Context, limit int, lease time.Duration) ([]domain.Event, error)
+	MarkEventPublished(ctx context.Context, eventId uuid.UUID) error
+	RecordPublishFailure(ctx context.Context, eventId uuid.UUID, publishErr error) error
+}
+
+type ChangeRepository interface {
+	// SaveChange assigns the change the owner's next sequence. It must be
+	// called with the transaction that persists the change, after any other
+	// locks on the owner's rows have been taken.
+	SaveChange(ctx context.Context, change domain.Change) error
+	// GetChanges returns the owner's changes with a sequence above after,
+	// oldest first.
+	GetChanges(ctx context.Context, ownerId uuid.UUID, after int64, limit int) ([]domain.Change, error)
+	GetLatestSequence(ctx context.Context, ownerId uuid.UUID) (int64, error)
+}
+
+type InboxRepository interface {
+	IsEventProcessed(ctx context.Context, consumer string, eventId uuid.UUID) (bool, error)
+	// MarkEventProcessed reports false when consumer has already processed
+	// the event.
+	MarkEventProcessed(ctx context.Context, consumer string, eventId uuid.UUID) (bool, error)
 }
 
 type FileStore interface {
 	Ping(ctx context.Context) error
 	SaveToFileStore(ctx context.Context, filename string, file io.Reader) (string, error)
 	GetDownloadUrl(ctx context.Context, key string) (string, error)
+	CopyFile(ctx context.Context, sourceKey, filename string) (string, error)
+	SaveToFileStoreWithKey(ctx context.Context, key, contentType string, file io.Reader) error
+	GetFile(ctx context.Context, key string) (io.ReadCloser, error)
+	GetFileInfo(ctx context.Context, key string) (domain.StoredObject, error)
+	GetFileRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
 	DeleteFile(ctx context.Context, key string) error
+	ListObjects(ctx context.Context) ([]domain.StoredObject, error)
 }
@@ -0,0 +1,29 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/olad5/file-fort/internal/domain"
+)
+
+type RequestInfo struct {
+	IpAddress string
+	UserAgent string
+}
+
+type ctxKey int
+
+const requestInfoKey ctxKey = 1
+
+func Set(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey, info)
+}
+
+func Get(ctx context.Context) (RequestInfo, bool) {
+	v, ok := ctx.Value(requestInfoKey).(RequestInfo)
+	return v, ok
+}
+
+type AuditLogger interface {
+	Log(ctx context.Context, event domain.AuditEvent)
+}
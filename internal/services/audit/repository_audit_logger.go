@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/services/auth"
+)
+
+type RepositoryAuditLogger struct {
+	auditRepo infra.AuditRepository
+}
+
+func NewRepositoryAuditLogger(ctx context.Context, auditRepo infra.AuditRepository) (*RepositoryAuditLogger, error) {
+	if auditRepo == nil {
+		return nil, fmt.Errorf("failed to initialize audit logger, auditRepo is nil")
+	}
+	return &RepositoryAuditLogger{auditRepo}, nil
+}
+
+// Log records event, filling in the actor from the JWT claims and the client
+// details from the request info carried by ctx when the caller left them
+// empty. Failures are logged rather than returned so that auditing never
+// blocks the action being audited.
+func (r *RepositoryAuditLogger) Log(ctx context.Context, event domain.AuditEvent) {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	if jwtClaims, ok := auth.Get(ctx); ok {
+		if event.ActorId == nil {
+			actorId := jwtClaims.ID
+			event.ActorId = &actorId
+		}
+		if event.ActorEmail == "" {
+			event.ActorEmail = jwtClaims.Email
+		}
+	}
+
+	if requestInfo, ok := Get(ctx); ok {
+		if event.IpAddress == "" {
+			event.IpAddress = requestInfo.IpAddress
+		}
+		if event.UserAgent == "" {
+			event.UserAgent = requestInfo.UserAgent
+		}
+	}
+
+	if err := r.auditRepo.SaveAuditEvent(ctx, event); err != nil {
+		log.Printf("Error saving audit event %s for %s %s: %v", event.Action, event.TargetType, event.TargetId, err)
+	}
+}
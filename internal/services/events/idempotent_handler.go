@@ -0,0 +1,52 @@
+package events
+
+import (
+	"context"
+
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+// Idempotent wraps handler so that consumer processes each event ID once it
+// has succeeded. The handler runs outside any transaction, so that consumers
+// reading and writing the file store do not hold a database connection
+// meanwhile; the processed marker is written on its own once the handler
+// succeeds. A failed handler leaves the event unprocessed. An event
+// redelivered while its handler is still running, or before the marker is
+// written, is handled again, so handler must be safe to repeat.
+func Idempotent(consumer string, inboxRepo infra.InboxRepository, handler infra.EventHandler) infra.EventHandler {
+	return func(ctx context.Context, event domain.Event) error {
+		processed, err := inboxRepo.IsEventProcessed(ctx, consumer, event.ID)
+		if err != nil {
+			return err
+		}
+		if processed {
+			return nil
+		}
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+		_, err = inboxRepo.MarkEventProcessed(ctx, consumer, event.ID)
+		return err
+	}
+}
+
+// IdempotentInTransaction wraps handler so that consumer processes each event
+// ID exactly once. The processed marker is written in the same transaction as
+// the handler's own database writes, so a failed handler leaves the event
+// unprocessed. It is only for handlers that do nothing but write to the
+// database, since the transaction stays open for as long as handler runs.
+func IdempotentInTransaction(consumer string, inboxRepo infra.InboxRepository, transactor infra.Transactor, handler infra.EventHandler) infra.EventHandler {
+	return func(ctx context.Context, event domain.Event) error {
+		return transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			isNew, err := inboxRepo.MarkEventProcessed(ctx, consumer, event.ID)
+			if err != nil {
+				return err
+			}
+			if !isNew {
+				return nil
+			}
+			return handler(ctx, event)
+		})
+	}
+}
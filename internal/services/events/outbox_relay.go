@@ -0,0 +1,74 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+const (
+	relayPollInterval = time.Second
+	relayBatchSize    = 50
+	relayLease        = 30 * time.Second
+)
+
+// OutboxRelay publishes events committed to the outbox to the event bus. An
+// event is only marked published once the bus accepts it, so a crash between
+// the two publishes it again; consumers dedupe on the event ID.
+type OutboxRelay struct {
+	outboxRepo infra.OutboxRepository
+	eventBus   infra.EventBus
+}
+
+func NewOutboxRelay(ctx context.Context, outboxRepo infra.OutboxRepository, eventBus infra.EventBus) (*OutboxRelay, error) {
+	if outboxRepo == nil {
+		return nil, fmt.Errorf("failed to initialize outbox relay, outboxRepo is nil")
+	}
+	if eventBus == nil {
+		return nil, fmt.Errorf("failed to initialize outbox relay, eventBus is nil")
+	}
+	return &OutboxRelay{outboxRepo, eventBus}, nil
+}
+
+// Run relays events until ctx is cancelled.
+func (o *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relayPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := o.RelayPending(ctx); err != nil {
+			log.Printf("Error relaying outbox events: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (o *OutboxRelay) RelayPending(ctx context.Context) error {
+	events, err := o.outboxRepo.ClaimUnpublishedEvents(ctx, relayBatchSize, relayLease)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if err := o.eventBus.Publish(ctx, event); err != nil {
+			log.Printf("Error publishing event %s: %v", event.ID, err)
+			if err := o.outboxRepo.RecordPublishFailure(ctx, event.ID, err); err != nil {
+				log.Printf("Error recording publish failure for event %s: %v", event.ID, err)
+			}
+			continue
+		}
+
+		if err := o.outboxRepo.MarkEventPublished(ctx, event.ID); err != nil {
+			log.Printf("Error marking event %s as published: %v", event.ID, err)
+		}
+	}
+	return nil
+}
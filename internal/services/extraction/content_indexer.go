@@ -0,0 +1,83 @@
+package extraction
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+type ContentIndexer struct {
+	fileRepo    infra.FileRepository
+	fileStore   infra.FileStore
+	contentRepo infra.FileContentRepository
+}
+
+func NewContentIndexer(ctx context.Context, fileRepo infra.FileRepository, fileStore infra.FileStore, contentRepo infra.FileContentRepository) (*ContentIndexer, error) {
+	if fileRepo == nil {
+		return nil, fmt.Errorf("failed to initialize content indexer, fileRepo is nil")
+	}
+	if fileStore == nil {
+		return nil, fmt.Errorf("failed to initialize content indexer, fileStore is nil")
+	}
+	if contentRepo == nil {
+		return nil, fmt.Errorf("failed to initialize content indexer, contentRepo is nil")
+	}
+	return &ContentIndexer{fileRepo, fileStore, contentRepo}, nil
+}
+
+// HandleEvent extracts and stores the text of newly uploaded or copied files.
+// Files that cannot be extracted are skipped rather than retried, since
+// retrying will not make a malformed document readable.
+func (c *ContentIndexer) HandleEvent(ctx context.Context, event domain.Event) error {
+	if event.Type != domain.EventFileUploaded && event.Type != domain.EventFileCopied {
+		return nil
+	}
+
+	fileId, err := uuid.Parse(fmt.Sprint(event.Data["id"]))
+	if err != nil {
+		return fmt.Errorf("error parsing file id of event %s: %w", event.ID, err)
+	}
+	return c.IndexFile(ctx, fileId)
+}
+
+func (c *ContentIndexer) IndexFile(ctx context.Context, fileId uuid.UUID) error {
+	file, err := c.fileRepo.GetFileByFileId(ctx, fileId)
+	if err != nil {
+		if errors.Is(err, infra.ErrFileNotFound) {
+			return nil
+		}
+		return err
+	}
+	if file.IsUnsafe || file.IsMissing || !Supports(file.ContentType) || file.FileSize > MaxObjectSize {
+		return nil
+	}
+
+	body, err := c.fileStore.GetFile(ctx, file.FileStoreKey)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, MaxObjectSize))
+	if err != nil {
+		return fmt.Errorf("error reading file %s from file store: %w", file.ID, err)
+	}
+
+	text, err := Extract(file.ContentType, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		log.Printf("Skipping content indexing of file %s: %v", file.ID, err)
+		return nil
+	}
+
+	return c.contentRepo.SaveFileContent(ctx, domain.FileContent{
+		FileId:  file.ID,
+		Content: text,
+	})
+}
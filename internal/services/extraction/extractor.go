@@ -0,0 +1,215 @@
+package extraction
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	// MaxObjectSize is the largest file that is downloaded for extraction.
+	MaxObjectSize = 25 * 1024 * 1024
+	// MaxContentLength is the most extracted text kept per file, in bytes.
+	MaxContentLength = 512 * 1024
+)
+
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+type extractFunc func(r io.ReaderAt, size int64) (string, error)
+
+var (
+	ooxmlText = textElements{text: []string{"t"}, breaks: []string{"p", "si"}}
+	odfText   = textElements{text: []string{"p", "h"}, breaks: []string{"p", "h"}}
+)
+
+var extractors = map[string]extractFunc{
+	"application/pdf": extractPdf,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   zipExtractor(ooxmlText, "word/document.xml"),
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": zipExtractor(ooxmlText, "ppt/slides/slide*.xml"),
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         zipExtractor(ooxmlText, "xl/sharedStrings.xml"),
+	"application/vnd.oasis.opendocument.text":                                   zipExtractor(odfText, "content.xml"),
+	"application/vnd.oasis.opendocument.presentation":                           zipExtractor(odfText, "content.xml"),
+	"application/vnd.oasis.opendocument.spreadsheet":                            zipExtractor(odfText, "content.xml"),
+	"application/json": extractText,
+	"application/xml":  extractText,
+}
+
+func extractorFor(contentType string) (extractFunc, bool) {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if strings.HasPrefix(mediaType, "text/") {
+		return extractText, true
+	}
+	extract, ok := extractors[mediaType]
+	return extract, ok
+}
+
+func Supports(contentType string) bool {
+	_, ok := extractorFor(contentType)
+	return ok
+}
+
+// Extract returns the plain text of a document, with control characters
+// removed and truncated to MaxContentLength.
+func Extract(contentType string, r io.ReaderAt, size int64) (string, error) {
+	extract, ok := extractorFor(contentType)
+	if !ok {
+		return "", ErrUnsupportedContentType
+	}
+
+	text, err := extract(r, size)
+	if err != nil {
+		return "", fmt.Errorf("error extracting %s content: %w", contentType, err)
+	}
+	return normalize(text), nil
+}
+
+func extractText(r io.ReaderAt, size int64) (string, error) {
+	body, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func extractPdf(r io.ReaderAt, size int64) (text string, err error) {
+	// The pdf package panics on some malformed documents.
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("malformed pdf: %v", recovered)
+		}
+	}()
+
+	reader, err := pdf.NewReader(r, size)
+	if err != nil {
+		return "", err
+	}
+	plainText, err := reader.GetPlainText()
+	if err != nil {
+		return "", err
+	}
+	body, err := io.ReadAll(io.LimitReader(plainText, MaxContentLength))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// textElements names the XML elements, by local name, whose character data
+// is document text, and the elements that end a line.
+type textElements struct {
+	text   []string
+	breaks []string
+}
+
+func zipExtractor(elements textElements, pattern string) extractFunc {
+	return func(r io.ReaderAt, size int64) (string, error) {
+		archive, err := zip.NewReader(r, size)
+		if err != nil {
+			return "", err
+		}
+
+		parts := []*zip.File{}
+		for _, file := range archive.File {
+			if matched, _ := path.Match(pattern, file.Name); matched {
+				parts = append(parts, file)
+			}
+		}
+		sort.Slice(parts, func(i, j int) bool { return naturalLess(parts[i].Name, parts[j].Name) })
+
+		var text strings.Builder
+		for _, part := range parts {
+			if text.Len() >= MaxContentLength {
+				break
+			}
+			if err := extractXmlText(part, elements, &text); err != nil {
+				return "", err
+			}
+		}
+		return text.String(), nil
+	}
+}
+
+func extractXmlText(part *zip.File, elements textElements, text *strings.Builder) error {
+	body, err := part.Open()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	isText := setOf(elements.text)
+	isBreak := setOf(elements.breaks)
+
+	decoder := xml.NewDecoder(io.LimitReader(body, MaxObjectSize))
+	depth := 0
+	for text.Len() < MaxContentLength {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			if isText[element.Name.Local] {
+				depth++
+			}
+		case xml.EndElement:
+			if isText[element.Name.Local] && depth > 0 {
+				depth--
+			}
+			if isBreak[element.Name.Local] {
+				text.WriteByte('\n')
+			}
+		case xml.CharData:
+			if depth > 0 {
+				text.Write(element)
+			}
+		}
+	}
+	return nil
+}
+
+// naturalLess orders slide2.xml before slide10.xml.
+func naturalLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+func setOf(values []string) map[string]bool {
+	set := map[string]bool{}
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func normalize(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, text)
+
+	if len(text) > MaxContentLength {
+		text = text[:MaxContentLength]
+		for !utf8.ValidString(text) {
+			text = text[:len(text)-1]
+		}
+	}
+	return strings.TrimSpace(text)
+}
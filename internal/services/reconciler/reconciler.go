@@ -0,0 +1,269 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+// orphanGracePeriod keeps the reconciler away from objects whose upload has
+// reached the file store but whose row has not been committed yet.
+const orphanGracePeriod = time.Hour
+
+type Options struct {
+	DeleteOrphans bool
+	MarkMissing   bool
+	DeleteMissing bool
+}
+
+type Reconciler struct {
+	fileRepo   infra.FileRepository
+	quotaRepo  infra.QuotaRepository
+	outboxRepo infra.OutboxRepository
+	changeRepo infra.ChangeRepository
+	fileStore  infra.FileStore
+	transactor infra.Transactor
+}
+
+func NewReconciler(ctx context.Context, fileRepo infra.FileRepository, quotaRepo infra.QuotaRepository, outboxRepo infra.OutboxRepository, changeRepo infra.ChangeRepository, fileStore infra.FileStore, transactor infra.Transactor) (*Reconciler, error) {
+	if fileRepo == nil {
+		return nil, fmt.Errorf("failed to initialize reconciler, fileRepo is nil")
+	}
+	if quotaRepo == nil {
+		return nil, fmt.Errorf("failed to initialize reconciler, quotaRepo is nil")
+	}
+	if outboxRepo == nil {
+		return nil, fmt.Errorf("failed to initialize reconciler, outboxRepo is nil")
+	}
+	if changeRepo == nil {
+		return nil, fmt.Errorf("failed to initialize reconciler, changeRepo is nil")
+	}
+	if fileStore == nil {
+		return nil, fmt.Errorf("failed to initialize reconciler, fileStore is nil")
+	}
+	if transactor == nil {
+		return nil, fmt.Errorf("failed to initialize reconciler, transactor is nil")
+	}
+	return &Reconciler{fileRepo, quotaRepo, outboxRepo, changeRepo, fileStore, transactor}, nil
+}
+
+// Reconcile compares the keys in the file store against files.file_store_key.
+// Orphans are stored objects no file row points to, other than thumbnails of
+// existing files; missing files are rows other than unsafe ones
+// whose object is gone from the store. Both are reported and, depending on
+// options, cleaned up.
+func (r *Reconciler) Reconcile(ctx context.Context, options Options) (domain.ReconciliationReport, error) {
+	report := domain.ReconciliationReport{
+		StartedAt: time.Now(),
+		Orphans:   []domain.StoredObject{},
+		Missing:   []domain.File{},
+	}
+
+	// Files are listed before objects: every file committed by now already
+	// has its object stored, and objects uploaded in between are covered by
+	// orphanGracePeriod.
+	files, err := r.fileRepo.GetAllFiles(ctx)
+	if err != nil {
+		return report, err
+	}
+	objects, err := r.fileStore.ListObjects(ctx)
+	if err != nil {
+		return report, err
+	}
+	report.ObjectsScanned = len(objects)
+	report.FilesScanned = len(files)
+
+	storedKeys := map[string]bool{}
+	for _, object := range objects {
+		storedKeys[object.Key] = true
+	}
+	referencedKeys := map[string]bool{}
+	fileIds := map[uuid.UUID]bool{}
+	for _, file := range files {
+		referencedKeys[file.FileStoreKey] = true
+		fileIds[file.ID] = true
+	}
+
+	cutoff := report.StartedAt.Add(-orphanGracePeriod)
+	for _, object := range objects {
+		if referencedKeys[object.Key] || object.LastModified.After(cutoff) {
+			continue
+		}
+		if fileId, ok := domain.ThumbnailFileId(object.Key); ok && fileIds[fileId] {
+			continue
+		}
+		report.Orphans = append(report.Orphans, object)
+
+		if options.DeleteOrphans {
+			if err := r.fileStore.DeleteFile(ctx, object.Key); err != nil {
+				log.Printf("Error deleting orphaned object %s: %v", object.Key, err)
+				continue
+			}
+			report.DeletedOrphans++
+		}
+	}
+
+	// The files were listed without locking them, so another pass of the
+	// reconciler, on this instance or another, or the owner may have changed
+	// them since. Each file is only counted when restoring, deleting or
+	// marking it changed it, and only then is its quota or change feed
+	// touched.
+	for _, file := range files {
+		// Unsafe files have their object removed on purpose.
+		if file.IsUnsafe {
+			continue
+		}
+		if storedKeys[file.FileStoreKey] {
+			if options.MarkMissing && file.IsMissing {
+				restored, err := r.restoreMissingFile(ctx, file)
+				if err != nil {
+					log.Printf("Error restoring missing file %s: %v", file.ID, err)
+					continue
+				}
+				if restored {
+					report.RestoredMissing++
+				}
+			}
+			continue
+		}
+		report.Missing = append(report.Missing, file)
+
+		switch {
+		case options.DeleteMissing:
+			deleted, err := r.deleteMissingFile(ctx, file)
+			if err != nil {
+				log.Printf("Error deleting missing file %s: %v", file.ID, err)
+				continue
+			}
+			if deleted {
+				report.DeletedMissing++
+			}
+		case options.MarkMissing && !file.IsMissing:
+			marked, err := r.markMissingFile(ctx, file)
+			if err != nil {
+				log.Printf("Error marking file %s as missing: %v", file.ID, err)
+				continue
+			}
+			if marked {
+				report.MarkedMissing++
+			}
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// Run reconciles every interval until ctx is cancelled.
+func (r *Reconciler) Run(ctx context.Context, interval time.Duration, options Options) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		report, err := r.Reconcile(ctx, options)
+		if err != nil {
+			log.Printf("Error reconciling file store: %v", err)
+			continue
+		}
+		log.Printf("Reconciled file store: %d orphaned objects (%d deleted), %d missing objects (%d marked, %d deleted), %d restored",
+			len(report.Orphans), report.DeletedOrphans, len(report.Missing), report.MarkedMissing, report.DeletedMissing, report.RestoredMissing)
+	}
+}
+
+// deleteMissingFile deletes a file whose object is gone. It does nothing if
+// the file has been deleted or marked unsafe since it was listed.
+func (r *Reconciler) deleteMissingFile(ctx context.Context, file domain.File) (bool, error) {
+	deleted := false
+	err := r.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		file, err := r.fileRepo.GetFileByFileIdForUpdate(ctx, file.ID)
+		if errors.Is(err, infra.ErrFileNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := r.fileRepo.DeleteFile(ctx, file.ID); err != nil {
+			return err
+		}
+		// A file already marked missing released its quota at the time.
+		if !file.IsMissing {
+			if err := r.quotaRepo.ReleaseStorage(ctx, file.OwnerId, file.FileSize); err != nil {
+				return err
+			}
+		}
+		if err := r.outboxRepo.SaveEvent(ctx, domain.NewEvent(domain.EventFileDeleted, file.OwnerId, map[string]interface{}{
+			"id":        file.ID,
+			"file_name": file.FileName,
+			"file_size": file.FileSize,
+			"owner_id":  file.OwnerId,
+			"folder_id": file.FolderId,
+		})); err != nil {
+			return err
+		}
+		deleted = true
+		return r.changeRepo.SaveChange(ctx, missingFileChange(domain.ChangeDeleted, file))
+	})
+	return deleted && err == nil, err
+}
+
+// markMissingFile hides a file whose object is gone and gives its size back
+// to the owner's quota. Missing files are no longer listed, so the owner's
+// change feed reports it as deleted.
+func (r *Reconciler) markMissingFile(ctx context.Context, file domain.File) (bool, error) {
+	marked := false
+	err := r.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		marked, err = r.fileRepo.MarkFileAsMissing(ctx, file)
+		if err != nil || !marked {
+			return err
+		}
+		if err := r.quotaRepo.ReleaseStorage(ctx, file.OwnerId, file.FileSize); err != nil {
+			return err
+		}
+		return r.changeRepo.SaveChange(ctx, missingFileChange(domain.ChangeDeleted, file))
+	})
+	return marked && err == nil, err
+}
+
+// restoreMissingFile undoes markMissingFile once the file's object is back
+// in the store. It fails with infra.ErrQuotaExceeded, leaving the file
+// missing, if the owner no longer has room for it.
+func (r *Reconciler) restoreMissingFile(ctx context.Context, file domain.File) (bool, error) {
+	restored := false
+	err := r.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		restored, err = r.fileRepo.MarkFileAsFound(ctx, file)
+		if err != nil || !restored {
+			return err
+		}
+		if err := r.quotaRepo.ReserveStorage(ctx, file.OwnerId, file.FileSize); err != nil {
+			return err
+		}
+		return r.changeRepo.SaveChange(ctx, missingFileChange(domain.ChangeCreated, file))
+	})
+	return restored && err == nil, err
+}
+
+func missingFileChange(changeType domain.ChangeType, file domain.File) domain.Change {
+	return domain.NewChange(changeType, domain.ChangeTargetFile, file.ID, file.OwnerId, map[string]interface{}{
+		"id":        file.ID,
+		"file_name": file.FileName,
+		"file_size": file.FileSize,
+		"owner_id":  file.OwnerId,
+		"folder_id": file.FolderId,
+		"checksum":  file.Checksum,
+	})
+}
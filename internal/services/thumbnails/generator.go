@@ -0,0 +1,124 @@
+package thumbnails
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+type Generator struct {
+	fileRepo      infra.FileRepository
+	thumbnailRepo infra.ThumbnailRepository
+	fileStore     infra.FileStore
+}
+
+func NewGenerator(ctx context.Context, fileRepo infra.FileRepository, thumbnailRepo infra.ThumbnailRepository, fileStore infra.FileStore) (*Generator, error) {
+	if fileRepo == nil {
+		return nil, fmt.Errorf("failed to initialize thumbnail generator, fileRepo is nil")
+	}
+	if thumbnailRepo == nil {
+		return nil, fmt.Errorf("failed to initialize thumbnail generator, thumbnailRepo is nil")
+	}
+	if fileStore == nil {
+		return nil, fmt.Errorf("failed to initialize thumbnail generator, fileStore is nil")
+	}
+	return &Generator{fileRepo, thumbnailRepo, fileStore}, nil
+}
+
+// HandleEvent generates thumbnails for uploaded or copied images and removes
+// them once the file is deleted or marked unsafe.
+func (g *Generator) HandleEvent(ctx context.Context, event domain.Event) error {
+	switch event.Type {
+	case domain.EventFileUploaded, domain.EventFileCopied, domain.EventFileDeleted, domain.EventFileMarkedUnsafe:
+	default:
+		return nil
+	}
+
+	fileId, err := uuid.Parse(fmt.Sprint(event.Data["id"]))
+	if err != nil {
+		return fmt.Errorf("error parsing file id of event %s: %w", event.ID, err)
+	}
+
+	if event.Type == domain.EventFileUploaded || event.Type == domain.EventFileCopied {
+		return g.GenerateThumbnails(ctx, fileId)
+	}
+	return g.DeleteThumbnails(ctx, fileId)
+}
+
+// GenerateThumbnails stores a thumbnail of every size for the file. Images
+// that cannot be decoded are skipped rather than retried.
+func (g *Generator) GenerateThumbnails(ctx context.Context, fileId uuid.UUID) error {
+	file, err := g.fileRepo.GetFileByFileId(ctx, fileId)
+	if err != nil {
+		if errors.Is(err, infra.ErrFileNotFound) {
+			return nil
+		}
+		return err
+	}
+	if file.IsUnsafe || file.IsMissing || !Supports(file.ContentType) || file.FileSize > MaxObjectSize {
+		return nil
+	}
+
+	body, err := g.fileStore.GetFile(ctx, file.FileStoreKey)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, MaxObjectSize))
+	if err != nil {
+		return fmt.Errorf("error reading file %s from file store: %w", file.ID, err)
+	}
+
+	img, err := Decode(data)
+	if err != nil {
+		log.Printf("Skipping thumbnails of file %s: %v", file.ID, err)
+		return nil
+	}
+
+	for _, size := range domain.ThumbnailSizes {
+		var thumbnail bytes.Buffer
+		width, height, err := Resize(&thumbnail, img, size.MaxDimension())
+		if err != nil {
+			return err
+		}
+
+		key := domain.ThumbnailKey(file.ID, size)
+		if err := g.fileStore.SaveToFileStoreWithKey(ctx, key, "image/jpeg", &thumbnail); err != nil {
+			return err
+		}
+		err = g.thumbnailRepo.SaveThumbnail(ctx, domain.Thumbnail{
+			FileId:       file.ID,
+			Size:         size,
+			FileStoreKey: key,
+			Width:        width,
+			Height:       height,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteThumbnails removes every thumbnail of the file from the file store.
+// It does not need the file row, which is already gone once a file is
+// deleted.
+func (g *Generator) DeleteThumbnails(ctx context.Context, fileId uuid.UUID) error {
+	if err := g.thumbnailRepo.DeleteThumbnailsByFileId(ctx, fileId); err != nil {
+		return err
+	}
+	for _, size := range domain.ThumbnailSizes {
+		if err := g.fileStore.DeleteFile(ctx, domain.ThumbnailKey(fileId, size)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
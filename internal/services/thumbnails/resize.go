@@ -0,0 +1,87 @@
+package thumbnails
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// MaxObjectSize is the largest image that is downloaded for thumbnailing.
+	MaxObjectSize = 25 * 1024 * 1024
+	// maxPixels guards against small files that decode to huge images.
+	maxPixels   = 50 * 1000 * 1000
+	jpegQuality = 80
+)
+
+var ErrImageTooLarge = errors.New("image is too large to thumbnail")
+
+var supportedContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+func Supports(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return supportedContentTypes[mediaType]
+}
+
+// Decode reads an image, refusing any whose dimensions exceed maxPixels
+// before decoding it.
+func Decode(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error reading image: %w", err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image: %w", err)
+	}
+	return img, nil
+}
+
+// Resize scales img down so that its longer side is at most maxDimension,
+// keeping its aspect ratio, and writes it as a JPEG. Images that are already
+// small enough are not scaled up. Transparent areas become white.
+func Resize(w io.Writer, img image.Image, maxDimension int) (int, int, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxDimension || height > maxDimension {
+		if width >= height {
+			width, height = maxDimension, height*maxDimension/width
+		} else {
+			width, height = width*maxDimension/height, maxDimension
+		}
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(thumbnail, thumbnail.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, bounds, draw.Over, nil)
+
+	if err := jpeg.Encode(w, thumbnail, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return 0, 0, fmt.Errorf("error encoding thumbnail: %w", err)
+	}
+	return width, height, nil
+}
@@ -0,0 +1,75 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var ErrPrivateAddress = errors.New("url must not point to a loopback, link-local or private address")
+
+// IsPublicAddress reports whether ip may receive webhooks. Loopback,
+// link-local, private and unspecified addresses are refused, so that
+// webhooks cannot be used to reach the network the server runs in.
+func IsPublicAddress(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsPrivate() ||
+		ip.IsUnspecified() ||
+		ip.IsMulticast())
+}
+
+// CheckHost resolves host and returns ErrPrivateAddress when any of its
+// addresses is not public.
+func CheckHost(ctx context.Context, host string) error {
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("error resolving %s: %w", host, err)
+	}
+	for _, address := range addresses {
+		if !IsPublicAddress(address.IP) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// newDeliveryClient returns the client deliveries are sent with. Unless
+// allowPrivateHosts is set, the address is checked as each connection is
+// made, after the host has been resolved, so a host that resolved to a
+// public address when the webhook was created cannot later be pointed at a
+// private one, and neither can a redirect.
+func newDeliveryClient(allowPrivateHosts bool) *http.Client {
+	dialer := &net.Dialer{Timeout: deliveryTimeout}
+	if !allowPrivateHosts {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !IsPublicAddress(ip) {
+				return ErrPrivateAddress
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout: deliveryTimeout,
+		Transport: &http.Transport{
+			// Deliveries do not go through a proxy, since the proxy's address
+			// would be checked instead of the webhook's.
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   deliveryTimeout,
+			ResponseHeaderTimeout: deliveryTimeout,
+			IdleConnTimeout:       90 * time.Second,
+			MaxIdleConnsPerHost:   2,
+		},
+	}
+}
@@ -0,0 +1,166 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+const (
+	MaxDeliveryAttempts = 8
+	pollInterval        = 5 * time.Second
+	deliveryBatchSize   = 20
+	deliveryLease       = time.Minute
+	deliveryTimeout     = 10 * time.Second
+	baseRetryDelay      = 30 * time.Second
+	maxRetryDelay       = 6 * time.Hour
+	maxErrorLength      = 500
+)
+
+type DeliveryWorker struct {
+	webhookRepo infra.WebhookRepository
+	client      *http.Client
+}
+
+// NewDeliveryWorker returns a worker that refuses to deliver to loopback,
+// link-local and private addresses unless allowPrivateHosts is set.
+func NewDeliveryWorker(ctx context.Context, webhookRepo infra.WebhookRepository, allowPrivateHosts bool) (*DeliveryWorker, error) {
+	if webhookRepo == nil {
+		return nil, fmt.Errorf("failed to initialize webhook delivery worker, webhookRepo is nil")
+	}
+	return &DeliveryWorker{
+		webhookRepo: webhookRepo,
+		client:      newDeliveryClient(allowPrivateHosts),
+	}, nil
+}
+
+// Run sends due deliveries until ctx is cancelled.
+func (d *DeliveryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.DeliverDue(ctx); err != nil {
+			log.Printf("Error delivering webhooks: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *DeliveryWorker) DeliverDue(ctx context.Context) error {
+	deliveries, err := d.webhookRepo.ClaimDueDeliveries(ctx, deliveryBatchSize, deliveryLease)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if err := d.deliver(ctx, delivery); err != nil {
+			log.Printf("Error recording webhook delivery %s: %v", delivery.ID, err)
+		}
+	}
+	return nil
+}
+
+func (d *DeliveryWorker) deliver(ctx context.Context, delivery domain.WebhookDelivery) error {
+	webhook, err := d.webhookRepo.GetWebhookByWebhookId(ctx, delivery.WebhookId)
+	if err != nil {
+		return err
+	}
+
+	delivery.Attempts++
+	statusCode, sendErr := d.send(ctx, webhook, delivery)
+	delivery.LastResponseCode = statusCode
+
+	now := time.Now().UTC()
+	switch {
+	case sendErr == nil:
+		delivery.Status = domain.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= MaxDeliveryAttempts || !webhook.IsActive:
+		delivery.Status = domain.DeliveryFailed
+		delivery.LastError = truncate(sendErr.Error(), maxErrorLength)
+	default:
+		delivery.Status = domain.DeliveryPending
+		delivery.LastError = truncate(sendErr.Error(), maxErrorLength)
+		delivery.NextAttemptAt = now.Add(RetryDelay(delivery.Attempts))
+	}
+
+	return d.webhookRepo.UpdateDelivery(ctx, delivery)
+}
+
+func (d *DeliveryWorker) send(ctx context.Context, webhook domain.Webhook, delivery domain.WebhookDelivery) (int, error) {
+	if !webhook.IsActive {
+		return 0, fmt.Errorf("webhook is disabled")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("error creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "file-fort-webhooks")
+	req.Header.Set(EventHeader, string(delivery.EventType))
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error sending webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1024*64))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of "<timestamp>.<body>" keyed
+// with the webhook secret. Receivers recompute it to verify a delivery.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RetryDelay doubles the wait after every failed attempt, starting at
+// baseRetryDelay and capped at maxRetryDelay.
+func RetryDelay(attempts int) time.Duration {
+	delay := baseRetryDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
+
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	return s[:length]
+}
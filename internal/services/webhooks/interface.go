@@ -0,0 +1,8 @@
+package webhooks
+
+const (
+	SignatureHeader = "X-FileFort-Signature"
+	TimestampHeader = "X-FileFort-Timestamp"
+	EventHeader     = "X-FileFort-Event"
+	DeliveryHeader  = "X-FileFort-Delivery"
+)
@@ -0,0 +1,80 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+type RepositoryDispatcher struct {
+	webhookRepo infra.WebhookRepository
+}
+
+func NewRepositoryDispatcher(ctx context.Context, webhookRepo infra.WebhookRepository) (*RepositoryDispatcher, error) {
+	if webhookRepo == nil {
+		return nil, fmt.Errorf("failed to initialize webhook dispatcher, webhookRepo is nil")
+	}
+	return &RepositoryDispatcher{webhookRepo}, nil
+}
+
+// HandleEvent queues a delivery of event for every active webhook of the
+// event owner that subscribes to it. The deliveries are sent by a
+// DeliveryWorker.
+func (r *RepositoryDispatcher) HandleEvent(ctx context.Context, event domain.Event) error {
+	webhooks, err := r.webhookRepo.GetActiveWebhooksForEvent(ctx, event.OwnerId, event.Type)
+	if err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	payload, err := NewPayload(event)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, webhook := range webhooks {
+		delivery := domain.WebhookDelivery{
+			ID:            uuid.New(),
+			WebhookId:     webhook.ID,
+			EventId:       event.ID,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        domain.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if err := r.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewPayload(event domain.Event) ([]byte, error) {
+	type payload struct {
+		ID        uuid.UUID              `json:"id"`
+		Type      domain.EventType       `json:"type"`
+		CreatedAt time.Time              `json:"created_at"`
+		Data      map[string]interface{} `json:"data"`
+	}
+
+	body, err := json.Marshal(payload{
+		ID:        event.ID,
+		Type:      event.Type,
+		CreatedAt: event.OccurredAt,
+		Data:      event.Data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding webhook payload: %w", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,146 @@
+package accesskeys
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/services/audit"
+	"github.com/olad5/file-fort/internal/services/auth"
+)
+
+type AccessKeyService struct {
+	accessKeyRepo infra.AccessKeyRepository
+	userRepo      infra.UserRepository
+	auditLogger   audit.AuditLogger
+}
+
+var ErrTooManyAccessKeys = errors.New("access key limit reached")
+
+const (
+	maxAccessKeysPerUser = 10
+	accessKeyIdPrefix    = "FF"
+)
+
+func NewAccessKeyService(accessKeyRepo infra.AccessKeyRepository, userRepo infra.UserRepository, auditLogger audit.AuditLogger) (*AccessKeyService, error) {
+	if accessKeyRepo == nil {
+		return &AccessKeyService{}, errors.New("AccessKeyService failed to initialize, accessKeyRepo is nil")
+	}
+	if userRepo == nil {
+		return &AccessKeyService{}, errors.New("AccessKeyService failed to initialize, userRepo is nil")
+	}
+	if auditLogger == nil {
+		return &AccessKeyService{}, errors.New("AccessKeyService failed to initialize, auditLogger is nil")
+	}
+	return &AccessKeyService{accessKeyRepo, userRepo, auditLogger}, nil
+}
+
+// CreateAccessKey issues the caller a new access key. The secret is only
+// ever handed out in the response to this call.
+func (a *AccessKeyService) CreateAccessKey(ctx context.Context) (domain.AccessKey, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return domain.AccessKey{}, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	existingAccessKeys, err := a.accessKeyRepo.GetAccessKeysByUserId(ctx, jwtClaims.ID)
+	if err != nil {
+		return domain.AccessKey{}, err
+	}
+	if len(existingAccessKeys) >= maxAccessKeysPerUser {
+		return domain.AccessKey{}, ErrTooManyAccessKeys
+	}
+
+	accessKeyId, secretKey, err := generateKeyPair()
+	if err != nil {
+		return domain.AccessKey{}, err
+	}
+
+	newAccessKey := domain.AccessKey{
+		ID:        accessKeyId,
+		UserId:    jwtClaims.ID,
+		SecretKey: secretKey,
+		CreatedAt: time.Now(),
+	}
+	err = a.accessKeyRepo.CreateAccessKey(ctx, newAccessKey)
+	if err != nil {
+		return domain.AccessKey{}, err
+	}
+
+	a.auditLogger.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionAccessKeyCreated,
+		TargetType: domain.AuditTargetAccessKey,
+		TargetId:   newAccessKey.ID,
+	})
+	return newAccessKey, nil
+}
+
+func (a *AccessKeyService) GetAccessKeys(ctx context.Context) ([]domain.AccessKey, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return []domain.AccessKey{}, fmt.Errorf("error parsing JWTClaims")
+	}
+	return a.accessKeyRepo.GetAccessKeysByUserId(ctx, jwtClaims.ID)
+}
+
+func (a *AccessKeyService) DeleteAccessKey(ctx context.Context, accessKeyId string) error {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return fmt.Errorf("error parsing JWTClaims")
+	}
+
+	accessKey, err := a.accessKeyRepo.GetAccessKeyByAccessKeyId(ctx, accessKeyId)
+	if err != nil {
+		return err
+	}
+	if accessKey.UserId != jwtClaims.ID {
+		return infra.ErrUserNotAuthorized
+	}
+
+	err = a.accessKeyRepo.DeleteAccessKey(ctx, accessKey.ID)
+	if err != nil {
+		return err
+	}
+
+	a.auditLogger.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionAccessKeyDeleted,
+		TargetType: domain.AuditTargetAccessKey,
+		TargetId:   accessKey.ID,
+	})
+	return nil
+}
+
+// GetKeyOwner looks up an access key and the user it was issued to, for
+// checking the signature of a request made with it.
+func (a *AccessKeyService) GetKeyOwner(ctx context.Context, accessKeyId string) (domain.AccessKey, domain.User, error) {
+	accessKey, err := a.accessKeyRepo.GetAccessKeyByAccessKeyId(ctx, accessKeyId)
+	if err != nil {
+		return domain.AccessKey{}, domain.User{}, err
+	}
+	user, err := a.userRepo.GetUserByUserId(ctx, accessKey.UserId)
+	if err != nil {
+		return domain.AccessKey{}, domain.User{}, err
+	}
+	return accessKey, user, nil
+}
+
+// generateKeyPair makes an id and secret shaped like the ones S3 clients
+// expect: 20 upper case characters and 40 hex characters.
+func generateKeyPair() (string, string, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", fmt.Errorf("error generating access key id: %w", err)
+	}
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("error generating secret access key: %w", err)
+	}
+	accessKeyId := accessKeyIdPrefix + base32.StdEncoding.EncodeToString(id)[:18]
+	return accessKeyId, hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,103 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/services/auth"
+)
+
+type ActivityService struct {
+	starRepo     infra.StarRepository
+	activityRepo infra.ActivityRepository
+	fileRepo     infra.FileRepository
+	folderRepo   infra.FolderRepository
+}
+
+func NewActivityService(starRepo infra.StarRepository, activityRepo infra.ActivityRepository, fileRepo infra.FileRepository, folderRepo infra.FolderRepository) (*ActivityService, error) {
+	if starRepo == nil {
+		return &ActivityService{}, errors.New("ActivityService failed to initialize, starRepo is nil")
+	}
+	if activityRepo == nil {
+		return &ActivityService{}, errors.New("ActivityService failed to initialize, activityRepo is nil")
+	}
+	if fileRepo == nil {
+		return &ActivityService{}, errors.New("ActivityService failed to initialize, fileRepo is nil")
+	}
+	if folderRepo == nil {
+		return &ActivityService{}, errors.New("ActivityService failed to initialize, folderRepo is nil")
+	}
+	return &ActivityService{starRepo, activityRepo, fileRepo, folderRepo}, nil
+}
+
+func (a *ActivityService) StarFile(ctx context.Context, fileId uuid.UUID) error {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return fmt.Errorf("error parsing JWTClaims")
+	}
+
+	file, err := a.fileRepo.GetFileByFileId(ctx, fileId)
+	if err != nil {
+		return err
+	}
+	if file.OwnerId != jwtClaims.ID {
+		return infra.ErrUserNotAuthorized
+	}
+	return a.starRepo.StarFile(ctx, jwtClaims.ID, file.ID)
+}
+
+func (a *ActivityService) StarFolder(ctx context.Context, folderId uuid.UUID) error {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return fmt.Errorf("error parsing JWTClaims")
+	}
+
+	folder, err := a.folderRepo.GetFolderByFolderId(ctx, folderId)
+	if err != nil {
+		return err
+	}
+	if folder.OwnerId != jwtClaims.ID {
+		return infra.ErrUserNotAuthorized
+	}
+	return a.starRepo.StarFolder(ctx, jwtClaims.ID, folder.ID)
+}
+
+// UnstarFile only ever removes the caller's own star, so it needs no
+// ownership check and succeeds when there is nothing to remove.
+func (a *ActivityService) UnstarFile(ctx context.Context, fileId uuid.UUID) error {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return fmt.Errorf("error parsing JWTClaims")
+	}
+	return a.starRepo.UnstarFile(ctx, jwtClaims.ID, fileId)
+}
+
+// UnstarFolder only ever removes the caller's own star, so it needs no
+// ownership check and succeeds when there is nothing to remove.
+func (a *ActivityService) UnstarFolder(ctx context.Context, folderId uuid.UUID) error {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return fmt.Errorf("error parsing JWTClaims")
+	}
+	return a.starRepo.UnstarFolder(ctx, jwtClaims.ID, folderId)
+}
+
+func (a *ActivityService) GetStarred(ctx context.Context, pageNumber, rowsPerPage int) ([]domain.Star, int, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return []domain.Star{}, 0, fmt.Errorf("error parsing JWTClaims")
+	}
+	return a.starRepo.GetStars(ctx, jwtClaims.ID, rowsPerPage, (pageNumber-1)*rowsPerPage)
+}
+
+func (a *ActivityService) GetRecentFiles(ctx context.Context, pageNumber, rowsPerPage int) ([]domain.RecentFile, int, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return []domain.RecentFile{}, 0, fmt.Errorf("error parsing JWTClaims")
+	}
+	return a.activityRepo.GetRecentFiles(ctx, jwtClaims.ID, rowsPerPage, (pageNumber-1)*rowsPerPage)
+}
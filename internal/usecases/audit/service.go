@@ -0,0 +1,29 @@
+package audit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+type AuditService struct {
+	auditRepo infra.AuditRepository
+}
+
+var ErrInvalidTimeRange = errors.New("from must be before to")
+
+func NewAuditService(auditRepo infra.AuditRepository) (*AuditService, error) {
+	if auditRepo == nil {
+		return &AuditService{}, errors.New("AuditService failed to initialize, auditRepo is nil")
+	}
+	return &AuditService{auditRepo}, nil
+}
+
+func (a *AuditService) GetAuditEvents(ctx context.Context, filter infra.AuditEventFilter) ([]domain.AuditEvent, error) {
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return []domain.AuditEvent{}, ErrInvalidTimeRange
+	}
+	return a.auditRepo.GetAuditEvents(ctx, filter)
+}
@@ -0,0 +1,182 @@
+package files
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/services/auth"
+)
+
+// ArchiveEntry is a file or, when File is nil, an empty directory in a
+// folder archive.
+type ArchiveEntry struct {
+	Path string
+	File *domain.File
+}
+
+// GetFolderArchive lists the entries of an archive of the folder and every
+// folder below it. Paths are relative to the folder and unique within the
+// archive.
+func (f *FileService) GetFolderArchive(ctx context.Context, folderId uuid.UUID) (domain.Folder, []ArchiveEntry, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return domain.Folder{}, nil, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	folders, err := f.folderRepo.GetFolderTree(ctx, folderId)
+	if err != nil {
+		return domain.Folder{}, nil, err
+	}
+	root := folders[0]
+	if root.OwnerId != jwtClaims.ID {
+		return domain.Folder{}, nil, infra.ErrUserNotAuthorized
+	}
+
+	folderIds := []uuid.UUID{}
+	for _, folder := range folders {
+		folderIds = append(folderIds, folder.ID)
+	}
+	files, err := f.fileRepo.GetFilesByFolderIds(ctx, folderIds)
+	if err != nil {
+		return domain.Folder{}, nil, err
+	}
+
+	paths := newArchivePaths()
+	foldersById := map[uuid.UUID]domain.Folder{}
+	for _, folder := range folders {
+		foldersById[folder.ID] = folder
+	}
+	folderPaths := map[uuid.UUID]string{root.ID: ""}
+	var folderPath func(folder domain.Folder) string
+	folderPath = func(folder domain.Folder) string {
+		if folderPath, ok := folderPaths[folder.ID]; ok {
+			return folderPath
+		}
+		folderPaths[folder.ID] = paths.unique(folderPath(foldersById[*folder.ParentId]), folder.FolderName) + "/"
+		return folderPaths[folder.ID]
+	}
+	for _, folder := range folders[1:] {
+		folderPath(folder)
+	}
+
+	hasFiles := map[uuid.UUID]bool{}
+	entries := []ArchiveEntry{}
+	for i := range files {
+		file := files[i]
+		if file.OwnerId != root.OwnerId {
+			continue
+		}
+		hasFiles[file.FolderId] = true
+		entries = append(entries, ArchiveEntry{
+			Path: paths.unique(folderPaths[file.FolderId], file.FileName),
+			File: &file,
+		})
+	}
+	for _, folder := range folders[1:] {
+		if !hasFiles[folder.ID] {
+			entries = append(entries, ArchiveEntry{Path: folderPaths[folder.ID]})
+		}
+	}
+
+	f.auditLogger.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionFolderDownloaded,
+		TargetType: domain.AuditTargetFolder,
+		TargetId:   root.ID.String(),
+	})
+	return root, entries, nil
+}
+
+// WriteArchive streams a zip of the entries to w, reading one file at a time
+// from the file store.
+func (f *FileService) WriteArchive(ctx context.Context, entries []ArchiveEntry, w io.Writer) error {
+	archive := zip.NewWriter(w)
+	for _, entry := range entries {
+		if entry.File == nil {
+			if _, err := archive.Create(entry.Path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		header := &zip.FileHeader{
+			Name:     entry.Path,
+			Method:   compressionMethod(entry.File.ContentType),
+			Modified: entry.File.CreatedAt,
+		}
+		writer, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if err := f.copyFile(ctx, *entry.File, writer); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func (f *FileService) copyFile(ctx context.Context, file domain.File, w io.Writer) error {
+	body, err := f.fileStore.GetFile(ctx, file.FileStoreKey)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if _, err := io.Copy(w, body); err != nil {
+		return fmt.Errorf("error archiving file %s: %w", file.ID, err)
+	}
+	return nil
+}
+
+// compressionMethod stores files whose format is already compressed, since
+// deflating them again only costs time.
+func compressionMethod(contentType string) uint16 {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	switch {
+	case strings.HasPrefix(mediaType, "image/") && mediaType != "image/svg+xml" && mediaType != "image/bmp",
+		strings.HasPrefix(mediaType, "video/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		mediaType == "application/zip",
+		mediaType == "application/gzip",
+		mediaType == "application/pdf":
+		return zip.Store
+	}
+	return zip.Deflate
+}
+
+// archivePaths hands out archive paths, renaming "name.ext" to
+// "name (1).ext" and so on when a path is already taken.
+type archivePaths map[string]bool
+
+func newArchivePaths() archivePaths {
+	return archivePaths{}
+}
+
+func (a archivePaths) unique(dir, name string) string {
+	name = sanitizeArchiveName(name)
+	extension := path.Ext(name)
+	base := strings.TrimSuffix(name, extension)
+
+	candidate := dir + name
+	for i := 1; a[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s%s (%d)%s", dir, base, i, extension)
+	}
+	a[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// sanitizeArchiveName keeps a user supplied name from escaping its directory
+// when the archive is extracted.
+func sanitizeArchiveName(name string) string {
+	name = strings.NewReplacer("/", "_", `\`, "_", "\x00", "").Replace(strings.TrimSpace(name))
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+	return name
+}
@@ -0,0 +1,416 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/services/auth"
+)
+
+type BatchOperationType string
+
+const (
+	BatchMove   BatchOperationType = "move"
+	BatchDelete BatchOperationType = "delete"
+	BatchCopy   BatchOperationType = "copy"
+	BatchTag    BatchOperationType = "tag"
+)
+
+const (
+	MaxBatchOperations = 100
+	// batchConcurrency bounds the requests a batch makes to the file store at
+	// once.
+	batchConcurrency = 8
+)
+
+var (
+	ErrEmptyBatch           = errors.New("at least one operation required")
+	ErrTooManyOperations    = fmt.Errorf("a batch can have at most %d operations", MaxBatchOperations)
+	ErrUnsupportedOperation = errors.New("unsupported operation")
+	ErrTargetFolderRequired = errors.New("folder_id required")
+	ErrDuplicateFile        = errors.New("file already appears in this batch")
+	ErrAlreadyInFolder      = errors.New("file is already in this folder")
+)
+
+// BatchOperation is one item of a batch. FolderId is the target folder of a
+// move or copy, and Tags are the tags a tag operation adds.
+type BatchOperation struct {
+	Type     BatchOperationType
+	FileId   uuid.UUID
+	FolderId *uuid.UUID
+	Tags     []string
+}
+
+// BatchResult is the outcome of the operation at the same index of a batch.
+// File is the moved or tagged file, or the new copy.
+type BatchResult struct {
+	Operation BatchOperation
+	File      *domain.File
+	Err       error
+}
+
+// batchItem is an operation that has passed validation, along with the file
+// it applies to.
+type batchItem struct {
+	index  int
+	file   domain.File
+	folder domain.Folder
+}
+
+// ExecuteBatch runs every operation it can and reports the outcome of each.
+// Operations of the same type are grouped into a single transaction, so
+// either all moves, tags, copies or deletes that passed validation are saved,
+// or none of them are. Copies count against the quota as a group.
+func (f *FileService) ExecuteBatch(ctx context.Context, operations []BatchOperation) ([]BatchResult, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return nil, fmt.Errorf("error parsing JWTClaims")
+	}
+	if len(operations) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if len(operations) > MaxBatchOperations {
+		return nil, ErrTooManyOperations
+	}
+
+	results := make([]BatchResult, len(operations))
+	groups := map[BatchOperationType][]batchItem{}
+	seen := map[uuid.UUID]bool{}
+	folders := map[uuid.UUID]domain.Folder{}
+	for i, operation := range operations {
+		results[i].Operation = operation
+		item, err := f.prepareBatchItem(ctx, jwtClaims.ID, operation, folders)
+		if err == nil && seen[operation.FileId] {
+			err = ErrDuplicateFile
+		}
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		seen[operation.FileId] = true
+		item.index = i
+		groups[operation.Type] = append(groups[operation.Type], item)
+	}
+
+	f.moveFiles(ctx, groups[BatchMove], results)
+	f.tagFiles(ctx, groups[BatchTag], results)
+	f.copyFiles(ctx, jwtClaims.ID, groups[BatchCopy], results)
+	f.deleteFiles(ctx, groups[BatchDelete], results)
+	return results, nil
+}
+
+func (f *FileService) prepareBatchItem(ctx context.Context, userId uuid.UUID, operation BatchOperation, folders map[uuid.UUID]domain.Folder) (batchItem, error) {
+	switch operation.Type {
+	case BatchMove, BatchCopy, BatchDelete, BatchTag:
+	default:
+		return batchItem{}, ErrUnsupportedOperation
+	}
+
+	file, err := f.fileRepo.GetFileByFileId(ctx, operation.FileId)
+	if err != nil {
+		return batchItem{}, err
+	}
+	if file.OwnerId != userId {
+		return batchItem{}, infra.ErrUserNotAuthorized
+	}
+	if operation.Type == BatchDelete {
+		return batchItem{file: file}, nil
+	}
+	if operation.Type == BatchTag {
+		if len(operation.Tags) == 0 {
+			return batchItem{}, ErrTagsRequired
+		}
+		file.Tags, file.Metadata, err = applyLabelUpdate(file.Tags, file.Metadata, LabelUpdate{Tags: operation.Tags})
+		if err != nil {
+			return batchItem{}, err
+		}
+		return batchItem{file: file}, nil
+	}
+	if file.IsMissing {
+		return batchItem{}, infra.ErrFileNotFound
+	}
+
+	if operation.FolderId == nil {
+		return batchItem{}, ErrTargetFolderRequired
+	}
+	folder, ok := folders[*operation.FolderId]
+	if !ok {
+		folder, err = f.folderRepo.GetFolderByFolderId(ctx, *operation.FolderId)
+		if err != nil {
+			return batchItem{}, err
+		}
+		folders[folder.ID] = folder
+	}
+	if folder.OwnerId != userId {
+		return batchItem{}, infra.ErrUserNotAuthorized
+	}
+	if operation.Type == BatchMove && folder.ID == file.FolderId {
+		return batchItem{}, ErrAlreadyInFolder
+	}
+	return batchItem{file: file, folder: folder}, nil
+}
+
+func (f *FileService) moveFiles(ctx context.Context, items []batchItem, results []BatchResult) {
+	if len(items) == 0 {
+		return
+	}
+
+	err := f.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, item := range items {
+			if err := f.fileRepo.MoveFile(ctx, item.file.ID, item.folder.ID); err != nil {
+				return err
+			}
+			data := fileEventData(item.file)
+			data["folder_id"] = item.folder.ID
+			data["previous_folder_id"] = item.file.FolderId
+			if err := f.outboxRepo.SaveEvent(ctx, domain.NewEvent(domain.EventFileMoved, item.file.OwnerId, data)); err != nil {
+				return err
+			}
+			moved := item.file
+			moved.FolderId = item.folder.ID
+			if err := f.changeRepo.SaveChange(ctx, fileChange(domain.ChangeMoved, moved)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	for _, item := range items {
+		if err != nil {
+			results[item.index].Err = err
+			continue
+		}
+		moved := item.file
+		moved.FolderId = item.folder.ID
+		results[item.index].File = &moved
+		f.auditLogger.Log(ctx, domain.AuditEvent{
+			Action:     domain.AuditActionFileMoved,
+			TargetType: domain.AuditTargetFile,
+			TargetId:   moved.ID.String(),
+		})
+	}
+}
+
+// tagFiles applies the tags again to each file as it is locked, so that tags
+// added concurrently, by another batch or a label update, are kept. Files are
+// locked in order of their IDs, so that two batches cannot wait on each other.
+func (f *FileService) tagFiles(ctx context.Context, items []batchItem, results []BatchResult) {
+	if len(items) == 0 {
+		return
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return items[order[a]].file.ID.String() < items[order[b]].file.ID.String()
+	})
+
+	tagged := make([]domain.File, len(items))
+	err := f.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, i := range order {
+			file, err := f.fileRepo.GetFileByFileIdForUpdate(ctx, items[i].file.ID)
+			if err != nil {
+				return err
+			}
+			tags := results[items[i].index].Operation.Tags
+			file.Tags, file.Metadata, err = applyLabelUpdate(file.Tags, file.Metadata, LabelUpdate{Tags: tags})
+			if err != nil {
+				return err
+			}
+			if err := f.fileRepo.UpdateFileLabels(ctx, file.ID, file.Tags, file.Metadata); err != nil {
+				return err
+			}
+			if err := f.changeRepo.SaveChange(ctx, fileChange(domain.ChangeUpdated, file)); err != nil {
+				return err
+			}
+			tagged[i] = file
+		}
+		return nil
+	})
+
+	for i, item := range items {
+		if err != nil {
+			results[item.index].Err = err
+			continue
+		}
+		results[item.index].File = &tagged[i]
+		f.auditLogger.Log(ctx, domain.AuditEvent{
+			Action:     domain.AuditActionFileLabelsUpdated,
+			TargetType: domain.AuditTargetFile,
+			TargetId:   item.file.ID.String(),
+		})
+	}
+}
+
+// copyFiles copies the stored objects first, then saves the new files. When
+// saving fails the copied objects are removed again. Copies are only started
+// when the quota has room for all of them.
+func (f *FileService) copyFiles(ctx context.Context, userId uuid.UUID, items []batchItem, results []BatchResult) {
+	if len(items) == 0 {
+		return
+	}
+
+	var requiredSize int64
+	for _, item := range items {
+		requiredSize += item.file.FileSize
+	}
+	usage, err := f.quotaRepo.GetStorageUsage(ctx, userId)
+	if err == nil && !usage.CanStore(requiredSize) {
+		err = infra.ErrQuotaExceeded
+	}
+	if err != nil {
+		for _, item := range items {
+			results[item.index].Err = err
+		}
+		return
+	}
+
+	copies := make([]domain.File, len(items))
+	errs := forEachConcurrently(len(items), func(i int) error {
+		item := items[i]
+		key, err := f.fileStore.CopyFile(ctx, item.file.FileStoreKey, item.file.FileName)
+		if err != nil {
+			return err
+		}
+		copies[i] = domain.File{
+			ID:           uuid.New(),
+			OwnerId:      userId,
+			FileStoreKey: key,
+			FolderId:     item.folder.ID,
+			FileName:     item.file.FileName,
+			FileSize:     item.file.FileSize,
+			ContentType:  item.file.ContentType,
+			Checksum:     item.file.Checksum,
+			Tags:         item.file.Tags,
+			Metadata:     item.file.Metadata,
+		}
+		return nil
+	})
+
+	copied := []int{}
+	var totalSize int64
+	for i, item := range items {
+		if errs[i] != nil {
+			results[item.index].Err = errs[i]
+			continue
+		}
+		copied = append(copied, i)
+		totalSize += copies[i].FileSize
+	}
+	if len(copied) == 0 {
+		return
+	}
+
+	err = f.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := f.quotaRepo.ReserveStorage(ctx, userId, totalSize); err != nil {
+			return err
+		}
+		for _, i := range copied {
+			if err := f.fileRepo.SaveFile(ctx, copies[i]); err != nil {
+				return err
+			}
+			data := fileEventData(copies[i])
+			data["source_id"] = items[i].file.ID
+			if err := f.outboxRepo.SaveEvent(ctx, domain.NewEvent(domain.EventFileCopied, userId, data)); err != nil {
+				return err
+			}
+			if err := f.changeRepo.SaveChange(ctx, fileChange(domain.ChangeCreated, copies[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	for _, i := range copied {
+		index := items[i].index
+		if err != nil {
+			results[index].Err = err
+			if deleteErr := f.fileStore.DeleteFile(ctx, copies[i].FileStoreKey); deleteErr != nil {
+				log.Printf("Error deleting copy %s of file %s: %v", copies[i].FileStoreKey, items[i].file.ID, deleteErr)
+			}
+			continue
+		}
+		results[index].File = &copies[i]
+		f.auditLogger.Log(ctx, domain.AuditEvent{
+			Action:     domain.AuditActionFileCopied,
+			TargetType: domain.AuditTargetFile,
+			TargetId:   copies[i].ID.String(),
+		})
+	}
+}
+
+// deleteFiles removes the files from the database before the file store, as
+// DeleteFile does. Objects that fail to delete are left for the reconciler.
+func (f *FileService) deleteFiles(ctx context.Context, items []batchItem, results []BatchResult) {
+	if len(items) == 0 {
+		return
+	}
+
+	err := f.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, item := range items {
+			if err := f.releaseFileStorage(ctx, item.file.ID); err != nil {
+				return err
+			}
+			if err := f.fileRepo.DeleteFile(ctx, item.file.ID); err != nil {
+				return err
+			}
+			if err := f.outboxRepo.SaveEvent(ctx, domain.NewEvent(domain.EventFileDeleted, item.file.OwnerId, fileEventData(item.file))); err != nil {
+				return err
+			}
+			if err := f.changeRepo.SaveChange(ctx, fileChange(domain.ChangeDeleted, item.file)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for _, item := range items {
+			results[item.index].Err = err
+		}
+		return
+	}
+
+	for _, item := range items {
+		f.auditLogger.Log(ctx, domain.AuditEvent{
+			Action:     domain.AuditActionFileDeleted,
+			TargetType: domain.AuditTargetFile,
+			TargetId:   item.file.ID.String(),
+		})
+	}
+	errs := forEachConcurrently(len(items), func(i int) error {
+		return f.fileStore.DeleteFile(ctx, items[i].file.FileStoreKey)
+	})
+	for i, err := range errs {
+		if err != nil {
+			log.Printf("Error deleting file %s from file store: %v", items[i].file.ID, err)
+		}
+	}
+}
+
+// forEachConcurrently calls fn for 0 to n-1, at most batchConcurrency at a
+// time, and returns the error of each call.
+func forEachConcurrently(n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	semaphore := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
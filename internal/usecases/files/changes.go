@@ -0,0 +1,262 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/services/auth"
+)
+
+// ChangePage is the changes that follow a cursor. Cursor is where the next
+// request should carry on from, and HasMore reports whether there are
+// already more changes after it.
+type ChangePage struct {
+	Changes []domain.Change
+	Cursor  string
+	HasMore bool
+}
+
+// GetChanges returns up to limit of the caller's changes after cursor, or
+// from the start of the feed when cursor is empty. The cursor of an empty
+// page is the one it was asked for, so clients can keep polling with it.
+func (f *FileService) GetChanges(ctx context.Context, cursor string, limit int) (ChangePage, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return ChangePage{}, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	after, err := decodeChangeCursor(cursor)
+	if err != nil {
+		return ChangePage{}, err
+	}
+
+	changes, err := f.changeRepo.GetChanges(ctx, jwtClaims.ID, after, limit+1)
+	if err != nil {
+		return ChangePage{}, err
+	}
+
+	page := ChangePage{Changes: changes, Cursor: encodeChangeCursor(after)}
+	if len(changes) > limit {
+		page.Changes = changes[:limit]
+		page.HasMore = true
+	}
+	if len(page.Changes) > 0 {
+		page.Cursor = encodeChangeCursor(page.Changes[len(page.Changes)-1].Sequence)
+	}
+	return page, nil
+}
+
+// Waiters are woken by the notifications of the caller's events, which reach
+// every instance of the server. Not every change has an event, and
+// notifications can be dropped, so the change feed is also polled, less
+// often the longer the wait goes on.
+const (
+	minChangePollInterval = time.Second
+	maxChangePollInterval = 15 * time.Second
+)
+
+// WaitForChanges is GetChanges for clients that long-poll: when there are no
+// changes after cursor yet, it waits up to wait for one to be made before
+// returning an empty page. It also returns early when ctx is done, which is
+// how a client going away ends the wait.
+func (f *FileService) WaitForChanges(ctx context.Context, cursor string, limit int, wait time.Duration) (ChangePage, error) {
+	if wait <= 0 {
+		return f.GetChanges(ctx, cursor, limit)
+	}
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return ChangePage{}, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	// Listening starts before the feed is first read, so that a change made
+	// in between still ends the wait.
+	listenCtx, stopListening := context.WithCancel(ctx)
+	defer stopListening()
+	notifications, err := f.notificationBroker.Listen(listenCtx, jwtClaims.ID)
+	if err != nil {
+		log.Printf("Error listening for the changes of user %s: %v", jwtClaims.ID, err)
+		notifications = nil
+	}
+
+	page, err := f.GetChanges(ctx, cursor, limit)
+	if err != nil || len(page.Changes) > 0 {
+		return page, err
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	pollInterval := minChangePollInterval
+	poll := time.NewTimer(pollInterval)
+	defer poll.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return page, nil
+		case <-timer.C:
+			return page, nil
+		case _, ok := <-notifications:
+			if !ok {
+				// The listener fell behind; polling carries on alone.
+				notifications = nil
+				continue
+			}
+		case <-poll.C:
+			pollInterval *= 2
+			if pollInterval > maxChangePollInterval {
+				pollInterval = maxChangePollInterval
+			}
+			poll.Reset(pollInterval)
+		}
+
+		page, err = f.GetChanges(ctx, cursor, limit)
+		if err != nil || len(page.Changes) > 0 {
+			return page, err
+		}
+	}
+}
+
+// TreeFolder is a folder of a tree along with its path below the root of the
+// tree.
+type TreeFolder struct {
+	Folder domain.Folder
+	Path   string
+}
+
+// TreeFile is a file of a tree along with its path below the root of the
+// tree.
+type TreeFile struct {
+	File domain.File
+	Path string
+}
+
+// FolderTree is everything below a folder. Cursor is the position of the
+// change feed the tree was listed at; changes after it may or may not be
+// reflected in the tree.
+type FolderTree struct {
+	Folder  domain.Folder
+	Folders []TreeFolder
+	Files   []TreeFile
+	Cursor  string
+}
+
+// GetFolderTree lists every folder and file nested below a folder, with their
+// paths. Like GetFolderContents, the tree of the home folder also holds the
+// caller's top level folders, and is created if the caller has none yet.
+// Names are not unique within a folder, so two entries can share a path.
+func (f *FileService) GetFolderTree(ctx context.Context, folderId uuid.UUID) (FolderTree, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return FolderTree{}, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	var folder domain.Folder
+	var err error
+	if folderId == jwtClaims.ID {
+		folder, err = f.GetHomeFolder(ctx)
+	} else {
+		folder, err = f.GetFolder(ctx, folderId)
+	}
+	if err != nil {
+		return FolderTree{}, err
+	}
+
+	// The cursor is read before the tree, so that a change made while the
+	// tree is listed is always replayed to clients that carry on from it.
+	sequence, err := f.changeRepo.GetLatestSequence(ctx, folder.OwnerId)
+	if err != nil {
+		return FolderTree{}, err
+	}
+
+	folders, err := f.folderRepo.GetFolderTree(ctx, folder.ID)
+	if err != nil {
+		return FolderTree{}, err
+	}
+	if isHomeFolder(folder) {
+		topLevelFolders, err := f.folderRepo.GetFoldersByParentId(ctx, folder.OwnerId, nil)
+		if err != nil {
+			return FolderTree{}, err
+		}
+		for _, topLevelFolder := range topLevelFolders {
+			if isHomeFolder(topLevelFolder) {
+				continue
+			}
+			nestedFolders, err := f.folderRepo.GetFolderTree(ctx, topLevelFolder.ID)
+			if err != nil {
+				return FolderTree{}, err
+			}
+			folders = append(folders, nestedFolders...)
+		}
+	}
+
+	byId := map[uuid.UUID]domain.Folder{}
+	folderIds := []uuid.UUID{}
+	for _, nestedFolder := range folders {
+		byId[nestedFolder.ID] = nestedFolder
+		folderIds = append(folderIds, nestedFolder.ID)
+	}
+	paths := map[uuid.UUID]string{folder.ID: ""}
+	var folderPath func(domain.Folder) string
+	folderPath = func(nestedFolder domain.Folder) string {
+		if p, ok := paths[nestedFolder.ID]; ok {
+			return p
+		}
+		parentPath := ""
+		if nestedFolder.ParentId != nil {
+			if parent, ok := byId[*nestedFolder.ParentId]; ok {
+				parentPath = folderPath(parent)
+			}
+		}
+		paths[nestedFolder.ID] = path.Join(parentPath, nestedFolder.FolderName)
+		return paths[nestedFolder.ID]
+	}
+
+	tree := FolderTree{Folder: folder, Folders: []TreeFolder{}, Files: []TreeFile{}, Cursor: encodeChangeCursor(sequence)}
+	for _, nestedFolder := range folders {
+		if nestedFolder.ID == folder.ID {
+			continue
+		}
+		tree.Folders = append(tree.Folders, TreeFolder{Folder: nestedFolder, Path: folderPath(nestedFolder)})
+	}
+
+	files, err := f.fileRepo.GetFilesByFolderIds(ctx, folderIds)
+	if err != nil {
+		return FolderTree{}, err
+	}
+	for _, file := range files {
+		parent, ok := byId[file.FolderId]
+		if !ok {
+			continue
+		}
+		tree.Files = append(tree.Files, TreeFile{File: file, Path: path.Join(folderPath(parent), file.FileName)})
+	}
+	return tree, nil
+}
+
+func encodeChangeCursor(sequence int64) string {
+	return strconv.FormatInt(sequence, 10)
+}
+
+func decodeChangeCursor(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	sequence, err := strconv.ParseInt(token, 10, 64)
+	if err != nil || sequence < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return sequence, nil
+}
+
+func fileChange(changeType domain.ChangeType, file domain.File) domain.Change {
+	return domain.NewChange(changeType, domain.ChangeTargetFile, file.ID, file.OwnerId, fileEventData(file))
+}
+
+func folderChange(changeType domain.ChangeType, folder domain.Folder) domain.Change {
+	return domain.NewChange(changeType, domain.ChangeTargetFolder, folder.ID, folder.OwnerId, folderEventData(folder))
+}
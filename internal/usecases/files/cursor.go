@@ -0,0 +1,62 @@
+package files
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor is encoded into the opaque token handed to clients. It records the
+// sort it was issued for, so that it cannot be replayed against another.
+type cursor struct {
+	SortBy     infra.FileSortField `json:"s"`
+	Descending bool                `json:"d"`
+	Name       string              `json:"n,omitempty"`
+	Size       int64               `json:"z,omitempty"`
+	CreatedAt  time.Time           `json:"c"`
+	ID         uuid.UUID           `json:"i"`
+}
+
+func encodeCursor(query infra.FileListQuery, file domain.File) string {
+	c := cursor{SortBy: query.SortBy, Descending: query.Descending, ID: file.ID}
+	switch query.SortBy {
+	case infra.SortByName:
+		c.Name = file.FileName
+	case infra.SortBySize:
+		c.Size = file.FileSize
+	default:
+		c.CreatedAt = file.CreatedAt
+	}
+
+	body, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+func decodeCursor(query infra.FileListQuery, token string) (*infra.FileCursor, error) {
+	body, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(body, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.SortBy != query.SortBy || c.Descending != query.Descending || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &infra.FileCursor{
+		Name:      c.Name,
+		Size:      c.Size,
+		CreatedAt: c.CreatedAt,
+		ID:        c.ID,
+	}, nil
+}
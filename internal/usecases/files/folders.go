@@ -0,0 +1,305 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/services/auth"
+)
+
+var (
+	ErrHomeFolder    = errors.New("the home folder cannot be moved or deleted")
+	ErrFolderCycle   = errors.New("a folder cannot be moved into itself")
+	ErrFileNameTaken = errors.New("a file with this name already exists in the folder")
+)
+
+// GetHomeFolder returns the caller's home folder, which files uploaded
+// without a folder go into. It is created on first use.
+func (f *FileService) GetHomeFolder(ctx context.Context) (domain.Folder, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return domain.Folder{}, fmt.Errorf("error parsing JWTClaims")
+	}
+	return getDefaultFolder(ctx, f, jwtClaims.ID)
+}
+
+// GetFolderContents returns the folders and files directly inside a folder.
+// The home folder also lists the caller's top level folders, since they are
+// created next to it.
+func (f *FileService) GetFolderContents(ctx context.Context, folderId uuid.UUID) ([]domain.Folder, []domain.File, error) {
+	folder, err := f.GetFolder(ctx, folderId)
+	if err != nil {
+		return []domain.Folder{}, []domain.File{}, err
+	}
+
+	folders, err := f.folderRepo.GetFoldersByParentId(ctx, folder.OwnerId, &folder.ID)
+	if err != nil {
+		return []domain.Folder{}, []domain.File{}, err
+	}
+	if isHomeFolder(folder) {
+		topLevelFolders, err := f.folderRepo.GetFoldersByParentId(ctx, folder.OwnerId, nil)
+		if err != nil {
+			return []domain.Folder{}, []domain.File{}, err
+		}
+		for _, topLevelFolder := range topLevelFolders {
+			if !isHomeFolder(topLevelFolder) {
+				folders = append(folders, topLevelFolder)
+			}
+		}
+	}
+
+	files, err := f.fileRepo.GetFilesByFolderIds(ctx, []uuid.UUID{folder.ID})
+	if err != nil {
+		return []domain.Folder{}, []domain.File{}, err
+	}
+	return folders, files, nil
+}
+
+// RenameFile moves a file into folderId under a new name. It returns
+// ErrFileNameTaken when another file of that name is already in the folder;
+// the folder is locked while this is checked, so that two files cannot be
+// moved onto the same name.
+func (f *FileService) RenameFile(ctx context.Context, fileId, folderId uuid.UUID, fileName string) (domain.File, error) {
+	file, err := f.GetFile(ctx, fileId)
+	if err != nil {
+		return domain.File{}, err
+	}
+	folder, err := f.GetFolder(ctx, folderId)
+	if err != nil {
+		return domain.File{}, err
+	}
+
+	renamed := file
+	renamed.FolderId = folder.ID
+	renamed.FileName = fileName
+	err = f.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := f.folderRepo.GetFolderByFolderIdForUpdate(ctx, folder.ID); err != nil {
+			return err
+		}
+		existingFiles, err := f.fileRepo.GetFilesByFolderIds(ctx, []uuid.UUID{folder.ID})
+		if err != nil {
+			return err
+		}
+		for _, existingFile := range existingFiles {
+			if existingFile.FileName == fileName && existingFile.ID != file.ID {
+				return ErrFileNameTaken
+			}
+		}
+
+		if err := f.fileRepo.RenameFile(ctx, renamed.ID, renamed.FolderId, renamed.FileName); err != nil {
+			return err
+		}
+		data := fileEventData(renamed)
+		data["previous_folder_id"] = file.FolderId
+		data["previous_file_name"] = file.FileName
+		if err := f.outboxRepo.SaveEvent(ctx, domain.NewEvent(domain.EventFileMoved, renamed.OwnerId, data)); err != nil {
+			return err
+		}
+		return f.changeRepo.SaveChange(ctx, fileChange(domain.ChangeMoved, renamed))
+	})
+	if err != nil {
+		return domain.File{}, err
+	}
+
+	f.auditLogger.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionFileMoved,
+		TargetType: domain.AuditTargetFile,
+		TargetId:   renamed.ID.String(),
+	})
+	return renamed, nil
+}
+
+// RenameFolder moves a folder below parentId under a new name, or to the top
+// level when parentId is nil.
+func (f *FileService) RenameFolder(ctx context.Context, folderId uuid.UUID, parentId *uuid.UUID, folderName string) (domain.Folder, error) {
+	folder, err := f.GetFolder(ctx, folderId)
+	if err != nil {
+		return domain.Folder{}, err
+	}
+	if isHomeFolder(folder) {
+		return domain.Folder{}, ErrHomeFolder
+	}
+
+	if parentId != nil {
+		if _, err := f.GetFolder(ctx, *parentId); err != nil {
+			return domain.Folder{}, err
+		}
+		// The home folder is locked by the move below, so it has to exist.
+		if _, err := getDefaultFolder(ctx, f, folder.OwnerId); err != nil {
+			return domain.Folder{}, err
+		}
+	}
+
+	var renamed domain.Folder
+	err = f.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if parentId != nil {
+			locked, err := f.lockFoldersForMove(ctx, folder.OwnerId, folder.ID, *parentId)
+			if err != nil {
+				return err
+			}
+			folder = locked
+		}
+
+		renamed = folder
+		renamed.ParentId = parentId
+		renamed.FolderName = folderName
+		if err := f.folderRepo.RenameFolder(ctx, renamed.ID, renamed.ParentId, renamed.FolderName); err != nil {
+			return err
+		}
+		data := folderEventData(renamed)
+		data["previous_parent_id"] = folder.ParentId
+		data["previous_folder_name"] = folder.FolderName
+		if err := f.outboxRepo.SaveEvent(ctx, domain.NewEvent(domain.EventFolderMoved, renamed.OwnerId, data)); err != nil {
+			return err
+		}
+		return f.changeRepo.SaveChange(ctx, folderChange(domain.ChangeMoved, renamed))
+	})
+	if err != nil {
+		return domain.Folder{}, err
+	}
+
+	f.auditLogger.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionFolderMoved,
+		TargetType: domain.AuditTargetFolder,
+		TargetId:   renamed.ID.String(),
+	})
+	return renamed, nil
+}
+
+// lockFoldersForMove locks the folder being moved and its new parent, then
+// checks that the parent is not nested below the folder. The owner's home
+// folder is locked first, so that the moves of one user's folders are checked
+// one at a time: two moves checked side by side, such as A into B and B into
+// A, could each pass and together leave a cycle. It returns the moved folder
+// as locked.
+func (f *FileService) lockFoldersForMove(ctx context.Context, ownerId, folderId, parentId uuid.UUID) (domain.Folder, error) {
+	ids := []uuid.UUID{folderId, parentId}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+	ids = append([]uuid.UUID{ownerId}, ids...)
+
+	var folder domain.Folder
+	for _, id := range ids {
+		locked, err := f.folderRepo.GetFolderByFolderIdForUpdate(ctx, id)
+		if err != nil {
+			return domain.Folder{}, err
+		}
+		if locked.OwnerId != ownerId {
+			return domain.Folder{}, infra.ErrUserNotAuthorized
+		}
+		if locked.ID == folderId {
+			folder = locked
+		}
+	}
+
+	tree, err := f.folderRepo.GetFolderTree(ctx, folderId)
+	if err != nil {
+		return domain.Folder{}, err
+	}
+	for _, nestedFolder := range tree {
+		if nestedFolder.ID == parentId {
+			return domain.Folder{}, ErrFolderCycle
+		}
+	}
+	return folder, nil
+}
+
+// DeleteFolder deletes a folder along with every folder and file nested below
+// it. Like deleteFiles, objects that fail to delete from the file store are
+// left for the reconciler.
+func (f *FileService) DeleteFolder(ctx context.Context, folderId uuid.UUID) error {
+	folder, err := f.GetFolder(ctx, folderId)
+	if err != nil {
+		return err
+	}
+	if isHomeFolder(folder) {
+		return ErrHomeFolder
+	}
+
+	tree, err := f.folderRepo.GetFolderTree(ctx, folder.ID)
+	if err != nil {
+		return err
+	}
+	folderIds := []uuid.UUID{}
+	for _, nestedFolder := range tree {
+		folderIds = append(folderIds, nestedFolder.ID)
+	}
+
+	// Unsafe files have already had their object deleted and their storage
+	// released. Missing files have had their storage released by the
+	// reconciler.
+	storedFiles := []domain.File{}
+	err = f.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		deletedFiles, err := f.fileRepo.DeleteFilesByFolderIds(ctx, folderIds)
+		if err != nil {
+			return err
+		}
+		var releasedSize int64
+		for _, file := range deletedFiles {
+			if file.IsUnsafe {
+				continue
+			}
+			storedFiles = append(storedFiles, file)
+			if !file.IsMissing {
+				releasedSize += file.FileSize
+			}
+			if err := f.outboxRepo.SaveEvent(ctx, domain.NewEvent(domain.EventFileDeleted, file.OwnerId, fileEventData(file))); err != nil {
+				return err
+			}
+		}
+		if releasedSize > 0 {
+			if err := f.quotaRepo.ReleaseStorage(ctx, folder.OwnerId, releasedSize); err != nil {
+				return err
+			}
+		}
+		if err := f.folderRepo.DeleteFolders(ctx, folderIds); err != nil {
+			return err
+		}
+		if err := f.outboxRepo.SaveEvent(ctx, domain.NewEvent(domain.EventFolderDeleted, folder.OwnerId, folderEventData(folder))); err != nil {
+			return err
+		}
+
+		// The changes are saved last, as the quota row has to be locked
+		// before the change sequence.
+		for _, file := range storedFiles {
+			if err := f.changeRepo.SaveChange(ctx, fileChange(domain.ChangeDeleted, file)); err != nil {
+				return err
+			}
+		}
+		for _, nestedFolder := range tree {
+			if err := f.changeRepo.SaveChange(ctx, folderChange(domain.ChangeDeleted, nestedFolder)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	f.auditLogger.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionFolderDeleted,
+		TargetType: domain.AuditTargetFolder,
+		TargetId:   folder.ID.String(),
+	})
+	errs := forEachConcurrently(len(storedFiles), func(i int) error {
+		return f.fileStore.DeleteFile(ctx, storedFiles[i].FileStoreKey)
+	})
+	for i, err := range errs {
+		if err != nil {
+			log.Printf("Error deleting file %s from file store: %v", storedFiles[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// isHomeFolder reports whether folder is its owner's home folder, which
+// shares its id with the owner.
+func isHomeFolder(folder domain.Folder) bool {
+	return folder.ID == folder.OwnerId
+}
@@ -0,0 +1,197 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/services/auth"
+)
+
+var (
+	ErrInvalidTag             = fmt.Errorf("tags must be 1 to %d letters, digits, spaces or - _ . : characters", domain.MaxTagLength)
+	ErrInvalidMetadataKey     = fmt.Errorf("metadata keys must be 1 to %d letters, digits or - _ . characters", domain.MaxMetadataKeyLength)
+	ErrMetadataValueTooLong   = fmt.Errorf("metadata values must be at most %d characters", domain.MaxMetadataValueLength)
+	ErrTooManyMetadataEntries = fmt.Errorf("at most %d metadata entries are allowed", domain.MaxMetadataEntries)
+	ErrTagsRequired           = errors.New("tags required")
+)
+
+// LabelUpdate changes the tags and metadata of a file or folder. With
+// ReplaceTags, Tags replaces every tag; otherwise Tags are added and
+// RemoveTags removed. With ReplaceMetadata, Metadata replaces every entry;
+// otherwise it is merged in, and a nil value removes its key.
+type LabelUpdate struct {
+	ReplaceTags     bool
+	Tags            []string
+	RemoveTags      []string
+	ReplaceMetadata bool
+	Metadata        map[string]*string
+}
+
+func (f *FileService) GetFile(ctx context.Context, fileId uuid.UUID) (domain.File, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return domain.File{}, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	file, err := f.fileRepo.GetFileByFileId(ctx, fileId)
+	if err != nil {
+		return domain.File{}, err
+	}
+	if file.OwnerId != jwtClaims.ID {
+		return domain.File{}, infra.ErrUserNotAuthorized
+	}
+	return file, nil
+}
+
+func (f *FileService) GetFolder(ctx context.Context, folderId uuid.UUID) (domain.Folder, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return domain.Folder{}, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	folder, err := f.folderRepo.GetFolderByFolderId(ctx, folderId)
+	if err != nil {
+		return domain.Folder{}, err
+	}
+	if folder.OwnerId != jwtClaims.ID {
+		return domain.Folder{}, infra.ErrUserNotAuthorized
+	}
+	return folder, nil
+}
+
+// UpdateFileLabels locks the file while the update is applied, so that
+// concurrent updates of its labels are applied one after the other.
+func (f *FileService) UpdateFileLabels(ctx context.Context, fileId uuid.UUID, update LabelUpdate) (domain.File, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return domain.File{}, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	var updated domain.File
+	err := f.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		file, err := f.fileRepo.GetFileByFileIdForUpdate(ctx, fileId)
+		if err != nil {
+			return err
+		}
+		if file.OwnerId != jwtClaims.ID {
+			return infra.ErrUserNotAuthorized
+		}
+		file.Tags, file.Metadata, err = applyLabelUpdate(file.Tags, file.Metadata, update)
+		if err != nil {
+			return err
+		}
+		updated = file
+		if err := f.fileRepo.UpdateFileLabels(ctx, file.ID, file.Tags, file.Metadata); err != nil {
+			return err
+		}
+		return f.changeRepo.SaveChange(ctx, fileChange(domain.ChangeUpdated, file))
+	})
+	if err != nil {
+		return domain.File{}, err
+	}
+
+	f.auditLogger.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionFileLabelsUpdated,
+		TargetType: domain.AuditTargetFile,
+		TargetId:   updated.ID.String(),
+	})
+	return updated, nil
+}
+
+// UpdateFolderLabels locks the folder while the update is applied, like
+// UpdateFileLabels.
+func (f *FileService) UpdateFolderLabels(ctx context.Context, folderId uuid.UUID, update LabelUpdate) (domain.Folder, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return domain.Folder{}, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	var updated domain.Folder
+	err := f.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		folder, err := f.folderRepo.GetFolderByFolderIdForUpdate(ctx, folderId)
+		if err != nil {
+			return err
+		}
+		if folder.OwnerId != jwtClaims.ID {
+			return infra.ErrUserNotAuthorized
+		}
+		folder.Tags, folder.Metadata, err = applyLabelUpdate(folder.Tags, folder.Metadata, update)
+		if err != nil {
+			return err
+		}
+		updated = folder
+		if err := f.folderRepo.UpdateFolderLabels(ctx, folder.ID, folder.Tags, folder.Metadata); err != nil {
+			return err
+		}
+		return f.changeRepo.SaveChange(ctx, folderChange(domain.ChangeUpdated, folder))
+	})
+	if err != nil {
+		return domain.Folder{}, err
+	}
+
+	f.auditLogger.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionFolderLabelsUpdated,
+		TargetType: domain.AuditTargetFolder,
+		TargetId:   updated.ID.String(),
+	})
+	return updated, nil
+}
+
+// applyLabelUpdate returns the tags and metadata that result from update,
+// with tags normalized and sorted.
+func applyLabelUpdate(tags []string, metadata map[string]string, update LabelUpdate) ([]string, map[string]string, error) {
+	tagSet := map[string]bool{}
+	if !update.ReplaceTags {
+		for _, tag := range tags {
+			tagSet[tag] = true
+		}
+	}
+	for _, tag := range update.Tags {
+		tag = domain.NormalizeTag(tag)
+		if !domain.IsValidTag(tag) {
+			return nil, nil, ErrInvalidTag
+		}
+		tagSet[tag] = true
+	}
+	for _, tag := range update.RemoveTags {
+		delete(tagSet, domain.NormalizeTag(tag))
+	}
+	if len(tagSet) > domain.MaxTags {
+		return nil, nil, domain.ErrTooManyTags
+	}
+	newTags := []string{}
+	for tag := range tagSet {
+		newTags = append(newTags, tag)
+	}
+	sort.Strings(newTags)
+
+	newMetadata := map[string]string{}
+	if !update.ReplaceMetadata {
+		for key, value := range metadata {
+			newMetadata[key] = value
+		}
+	}
+	for key, value := range update.Metadata {
+		if !domain.IsValidMetadataKey(key) {
+			return nil, nil, ErrInvalidMetadataKey
+		}
+		if value == nil {
+			delete(newMetadata, key)
+			continue
+		}
+		if utf8.RuneCountInString(*value) > domain.MaxMetadataValueLength {
+			return nil, nil, ErrMetadataValueTooLong
+		}
+		newMetadata[key] = *value
+	}
+	if len(newMetadata) > domain.MaxMetadataEntries {
+		return nil, nil, ErrTooManyMetadataEntries
+	}
+	return newTags, newMetadata, nil
+}
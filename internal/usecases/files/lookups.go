@@ -0,0 +1,80 @@
+package files
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/services/auth"
+)
+
+// The lookups below load what a page of folders needs in one query, for
+// callers that would otherwise ask once per folder. Unlike GetFolder and
+// GetFolderContents, ids that do not exist or belong to someone else are
+// left out rather than failing the whole lookup.
+
+// GetFolders returns the caller's folders among folderIds, keyed by id.
+func (f *FileService) GetFolders(ctx context.Context, folderIds []uuid.UUID) (map[uuid.UUID]domain.Folder, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return nil, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	folders, err := f.folderRepo.GetFoldersByFolderIds(ctx, folderIds)
+	if err != nil {
+		return nil, err
+	}
+	result := map[uuid.UUID]domain.Folder{}
+	for _, folder := range folders {
+		if folder.OwnerId == jwtClaims.ID {
+			result[folder.ID] = folder
+		}
+	}
+	return result, nil
+}
+
+// GetSubfolders returns a page of the folders directly inside each of the
+// caller's folders, keyed by parent. Like GetFolderContents, the home folder
+// also holds the caller's top level folders.
+func (f *FileService) GetSubfolders(ctx context.Context, parentIds []uuid.UUID, pageNumber, rowsPerPage int) (map[uuid.UUID][]domain.Folder, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return nil, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	folders, err := f.folderRepo.GetFoldersByParentIds(ctx, jwtClaims.ID, parentIds, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, err
+	}
+	result := map[uuid.UUID][]domain.Folder{}
+	for _, folder := range folders {
+		parentId := folder.OwnerId
+		if folder.ParentId != nil {
+			parentId = *folder.ParentId
+		}
+		result[parentId] = append(result[parentId], folder)
+	}
+	return result, nil
+}
+
+// GetFilesInFolders returns a page of the files directly inside each of the
+// caller's folders, keyed by folder.
+func (f *FileService) GetFilesInFolders(ctx context.Context, folderIds []uuid.UUID, pageNumber, rowsPerPage int) (map[uuid.UUID][]domain.File, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return nil, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	files, err := f.fileRepo.GetFilePagesByFolderIds(ctx, folderIds, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, err
+	}
+	result := map[uuid.UUID][]domain.File{}
+	for _, file := range files {
+		if file.OwnerId == jwtClaims.ID {
+			result[file.FolderId] = append(result[file.FolderId], file)
+		}
+	}
+	return result, nil
+}
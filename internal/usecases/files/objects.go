@@ -0,0 +1,239 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+)
+
+// ErrInvalidObjectKey is returned for keys that do not name a file, such as
+// keys with empty path elements or a trailing slash.
+var ErrInvalidObjectKey = errors.New("object key must be a path to a file")
+
+// Object is a file addressed by its path below a bucket, for clients of the
+// S3 gateway. Buckets are the caller's top level folders, home included, and
+// the folders below a bucket are the prefixes of its keys.
+//
+// Names are not unique in file-fort, so the same rules as WebDAV apply: of
+// several sibling folders with one name, the one listed first wins, and of
+// several files with one name, the newest.
+type Object struct {
+	Key  string
+	File domain.File
+}
+
+// GetBuckets returns the caller's top level folders by name, with the home
+// folder first so that it is always the bucket called "home".
+func (f *FileService) GetBuckets(ctx context.Context) ([]domain.Folder, error) {
+	home, err := f.GetHomeFolder(ctx)
+	if err != nil {
+		return []domain.Folder{}, err
+	}
+	topLevelFolders, err := f.folderRepo.GetFoldersByParentId(ctx, home.OwnerId, nil)
+	if err != nil {
+		return []domain.Folder{}, err
+	}
+
+	buckets := []domain.Folder{home}
+	seen := map[string]bool{home.FolderName: true}
+	for _, folder := range topLevelFolders {
+		if !seen[folder.FolderName] {
+			seen[folder.FolderName] = true
+			buckets = append(buckets, folder)
+		}
+	}
+	return buckets, nil
+}
+
+// GetBucket returns the caller's bucket called name.
+func (f *FileService) GetBucket(ctx context.Context, name string) (domain.Folder, error) {
+	buckets, err := f.GetBuckets(ctx)
+	if err != nil {
+		return domain.Folder{}, err
+	}
+	for _, bucket := range buckets {
+		if bucket.FolderName == name {
+			return bucket, nil
+		}
+	}
+	return domain.Folder{}, infra.ErrFolderNotFound
+}
+
+// ListObjects returns every file below the bucket, sorted by key.
+func (f *FileService) ListObjects(ctx context.Context, bucket domain.Folder) ([]Object, error) {
+	if _, err := f.GetFolder(ctx, bucket.ID); err != nil {
+		return []Object{}, err
+	}
+	folders, err := f.folderRepo.GetFolderTree(ctx, bucket.ID)
+	if err != nil {
+		return []Object{}, err
+	}
+
+	// Folders are visited in the order siblings are looked up in, so the
+	// first of several with one name takes the prefix and the others, along
+	// with everything below them, are left out.
+	sort.Slice(folders[1:], func(i, j int) bool {
+		a, b := folders[1+i], folders[1+j]
+		if a.FolderName != b.FolderName {
+			return a.FolderName < b.FolderName
+		}
+		return a.ID.String() < b.ID.String()
+	})
+	prefixes := map[uuid.UUID]string{bucket.ID: ""}
+	taken := map[string]bool{}
+	for changed := true; changed; {
+		changed = false
+		for _, folder := range folders[1:] {
+			if _, ok := prefixes[folder.ID]; ok {
+				continue
+			}
+			parentPrefix, ok := prefixes[*folder.ParentId]
+			if !ok {
+				continue
+			}
+			prefix := parentPrefix + folder.FolderName + "/"
+			if !taken[prefix] {
+				taken[prefix] = true
+				prefixes[folder.ID] = prefix
+				changed = true
+			}
+		}
+	}
+
+	folderIds := []uuid.UUID{}
+	for folderId := range prefixes {
+		folderIds = append(folderIds, folderId)
+	}
+	folderFiles, err := f.fileRepo.GetFilesByFolderIds(ctx, folderIds)
+	if err != nil {
+		return []Object{}, err
+	}
+
+	indexes := map[string]int{}
+	objects := []Object{}
+	for _, file := range folderFiles {
+		key := prefixes[file.FolderId] + file.FileName
+		index, ok := indexes[key]
+		if !ok {
+			indexes[key] = len(objects)
+			objects = append(objects, Object{Key: key, File: file})
+			continue
+		}
+		if file.CreatedAt.After(objects[index].File.CreatedAt) {
+			objects[index].File = file
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+	return objects, nil
+}
+
+// GetObject returns the file at key in the bucket.
+func (f *FileService) GetObject(ctx context.Context, bucket domain.Folder, key string) (domain.File, error) {
+	folderNames, fileName, err := splitObjectKey(key)
+	if err != nil {
+		return domain.File{}, err
+	}
+	folder, err := f.findFolderPath(ctx, bucket, folderNames, false)
+	if errors.Is(err, infra.ErrFolderNotFound) {
+		return domain.File{}, infra.ErrFileNotFound
+	}
+	if err != nil {
+		return domain.File{}, err
+	}
+
+	folderFiles, err := f.fileRepo.GetFilesByFolderIds(ctx, []uuid.UUID{folder.ID})
+	if err != nil {
+		return domain.File{}, err
+	}
+	var found *domain.File
+	for i := range folderFiles {
+		if folderFiles[i].FileName == fileName && (found == nil || folderFiles[i].CreatedAt.After(found.CreatedAt)) {
+			found = &folderFiles[i]
+		}
+	}
+	if found == nil {
+		return domain.File{}, infra.ErrFileNotFound
+	}
+	return *found, nil
+}
+
+// PutObject saves a file at key in the bucket, creating any folders on the
+// way to it and replacing a file already there.
+func (f *FileService) PutObject(ctx context.Context, bucket domain.Folder, key string, size int64, file io.Reader) (domain.File, error) {
+	folderNames, fileName, err := splitObjectKey(key)
+	if err != nil {
+		return domain.File{}, err
+	}
+	folder, err := f.findFolderPath(ctx, bucket, folderNames, true)
+	if err != nil {
+		return domain.File{}, err
+	}
+	return f.PutFile(ctx, folder.ID, fileName, size, file)
+}
+
+// DeleteObject deletes the file at key in the bucket. Like S3, deleting a key
+// that does not exist succeeds.
+func (f *FileService) DeleteObject(ctx context.Context, bucket domain.Folder, key string) error {
+	file, err := f.GetObject(ctx, bucket, key)
+	if errors.Is(err, infra.ErrFileNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return f.DeleteFile(ctx, file.ID)
+}
+
+// findFolderPath walks folderNames down from the bucket. With create set,
+// missing folders are created instead of failing the walk.
+func (f *FileService) findFolderPath(ctx context.Context, bucket domain.Folder, folderNames []string, create bool) (domain.Folder, error) {
+	current, err := f.GetFolder(ctx, bucket.ID)
+	if err != nil {
+		return domain.Folder{}, err
+	}
+
+	for _, folderName := range folderNames {
+		children, err := f.folderRepo.GetFoldersByParentId(ctx, current.OwnerId, &current.ID)
+		if err != nil {
+			return domain.Folder{}, err
+		}
+		var next *domain.Folder
+		for i := range children {
+			if children[i].FolderName == folderName {
+				next = &children[i]
+				break
+			}
+		}
+		if next == nil {
+			if !create {
+				return domain.Folder{}, infra.ErrFolderNotFound
+			}
+			parentId := current.ID
+			created, err := f.CreateFolder(ctx, folderName, &parentId)
+			if err != nil {
+				return domain.Folder{}, err
+			}
+			next = &created
+		}
+		current = *next
+	}
+	return current, nil
+}
+
+func splitObjectKey(key string) ([]string, string, error) {
+	elements := strings.Split(key, "/")
+	for _, element := range elements {
+		if element == "" || element == "." || element == ".." {
+			return nil, "", ErrInvalidObjectKey
+		}
+	}
+	return elements[:len(elements)-1], elements[len(elements)-1], nil
+}
@@ -1,25 +1,51 @@
 package files
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"mime"
 	"mime/multipart"
+	"net/http"
+	"path/filepath"
 
 	"github.com/google/uuid"
 	"github.com/olad5/file-fort/internal/domain"
 	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/services/audit"
 	"github.com/olad5/file-fort/internal/services/auth"
 	appErrors "github.com/olad5/file-fort/pkg/errors"
 )
 
+// MaxUploadSize is the largest file that can be uploaded, whichever API it
+// is uploaded through.
+const MaxUploadSize = 1024 * 1024 * 200 // 1MB * 200
+
+var (
+	ErrFileTooLarge     = errors.New("file exceeds the maximum allowed size of 200MB")
+	ErrFileSizeMismatch = errors.New("file content does not match its declared size")
+)
+
 type FileService struct {
-	fileRepo   infra.FileRepository
-	fileStore  infra.FileStore
-	folderRepo infra.FolderRepository
+	fileRepo           infra.FileRepository
+	fileStore          infra.FileStore
+	folderRepo         infra.FolderRepository
+	quotaRepo          infra.QuotaRepository
+	transactor         infra.Transactor
+	auditLogger        audit.AuditLogger
+	outboxRepo         infra.OutboxRepository
+	thumbnailRepo      infra.ThumbnailRepository
+	activityRepo       infra.ActivityRepository
+	changeRepo         infra.ChangeRepository
+	notificationBroker infra.NotificationBroker
 }
 
-func NewFileService(fileRepo infra.FileRepository, folderRepo infra.FolderRepository, fileStore infra.FileStore) (*FileService, error) {
+func NewFileService(fileRepo infra.FileRepository, folderRepo infra.FolderRepository, quotaRepo infra.QuotaRepository, fileStore infra.FileStore, transactor infra.Transactor, auditLogger audit.AuditLogger, outboxRepo infra.OutboxRepository, thumbnailRepo infra.ThumbnailRepository, activityRepo infra.ActivityRepository, changeRepo infra.ChangeRepository, notificationBroker infra.NotificationBroker) (*FileService, error) {
 	if fileRepo == nil {
 		return &FileService{}, fmt.Errorf("FileService failed to initialize, fileRepo is nil")
 	}
@@ -29,17 +55,47 @@ func NewFileService(fileRepo infra.FileRepository, folderRepo infra.FolderReposi
 	if folderRepo == nil {
 		return &FileService{}, fmt.Errorf("FileService failed to initialize, folderRepo is nil")
 	}
-	return &FileService{fileRepo, fileStore, folderRepo}, nil
+	if quotaRepo == nil {
+		return &FileService{}, fmt.Errorf("FileService failed to initialize, quotaRepo is nil")
+	}
+	if transactor == nil {
+		return &FileService{}, fmt.Errorf("FileService failed to initialize, transactor is nil")
+	}
+	if auditLogger == nil {
+		return &FileService{}, fmt.Errorf("FileService failed to initialize, auditLogger is nil")
+	}
+	if outboxRepo == nil {
+		return &FileService{}, fmt.Errorf("FileService failed to initialize, outboxRepo is nil")
+	}
+	if thumbnailRepo == nil {
+		return &FileService{}, fmt.Errorf("FileService failed to initialize, thumbnailRepo is nil")
+	}
+	if activityRepo == nil {
+		return &FileService{}, fmt.Errorf("FileService failed to initialize, activityRepo is nil")
+	}
+	if changeRepo == nil {
+		return &FileService{}, fmt.Errorf("FileService failed to initialize, changeRepo is nil")
+	}
+	if notificationBroker == nil {
+		return &FileService{}, fmt.Errorf("FileService failed to initialize, notificationBroker is nil")
+	}
+	return &FileService{fileRepo, fileStore, folderRepo, quotaRepo, transactor, auditLogger, outboxRepo, thumbnailRepo, activityRepo, changeRepo, notificationBroker}, nil
 }
 
 func (f *FileService) UploadFile(ctx context.Context, file io.Reader, handler *multipart.FileHeader, folderId string) (domain.File, error) {
+	return f.SaveFile(ctx, folderId, handler.Filename, handler.Size, handler.Header.Get("Content-Type"), file)
+}
+
+// SaveFile saves a new file into the folder, or into the caller's home folder
+// when folderId is empty. size must be the exact length of file, and
+// contentType is detected from the name and content when empty.
+func (f *FileService) SaveFile(ctx context.Context, folderId, filename string, fileSize int64, contentType string, file io.Reader) (domain.File, error) {
 	jwtClaims, ok := auth.Get(ctx)
 	if !ok {
 		return domain.File{}, fmt.Errorf("error parsing JWTClaims")
 	}
 
 	userId := jwtClaims.ID
-	filename := handler.Filename
 
 	var folderIdInUUID uuid.UUID
 	var err error
@@ -66,23 +122,154 @@ func (f *FileService) UploadFile(ctx context.Context, file io.Reader, handler *m
 		}
 	}
 
-	fileStoreKey, err := f.fileStore.SaveToFileStore(ctx, filename, file)
+	return f.storeFile(ctx, userId, folderIdInUUID, filename, fileSize, contentType, file, nil)
+}
+
+// PutFile saves a file into the folder under filename, replacing any file of
+// that name already in the folder, so that clients which address files by
+// path can overwrite them. size must be the exact length of file.
+func (f *FileService) PutFile(ctx context.Context, folderId uuid.UUID, filename string, size int64, file io.Reader) (domain.File, error) {
+	folder, err := f.GetFolder(ctx, folderId)
+	if err != nil {
+		return domain.File{}, err
+	}
+
+	existingFiles, err := f.fileRepo.GetFilesByFolderIds(ctx, []uuid.UUID{folder.ID})
+	if err != nil {
+		return domain.File{}, err
+	}
+	replaced := []domain.File{}
+	for _, existingFile := range existingFiles {
+		if existingFile.FileName == filename {
+			replaced = append(replaced, existingFile)
+		}
+	}
+
+	return f.storeFile(ctx, folder.OwnerId, folder.ID, filename, size, "", file, replaced)
+}
+
+// CanStore reports whether the caller's quota has room for bytes more.
+func (f *FileService) CanStore(ctx context.Context, bytes int64) (bool, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return false, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	usage, err := f.quotaRepo.GetStorageUsage(ctx, jwtClaims.ID)
 	if err != nil {
+		return false, err
+	}
+	return usage.CanStore(bytes), nil
+}
+
+// storeFile saves a new file into the folder and deletes the replaced files in
+// the same transaction, so the quota only has to have room for the
+// difference.
+func (f *FileService) storeFile(ctx context.Context, userId, folderId uuid.UUID, filename string, fileSize int64, declaredType string, file io.Reader, replaced []domain.File) (domain.File, error) {
+	if fileSize > MaxUploadSize {
+		return domain.File{}, ErrFileTooLarge
+	}
+
+	var replacedSize int64
+	for _, replacedFile := range replaced {
+		replacedSize += replacedFile.FileSize
+	}
+
+	usage, err := f.quotaRepo.GetStorageUsage(ctx, userId)
+	if err != nil {
+		return domain.File{}, err
+	}
+	if !usage.CanStore(fileSize - replacedSize) {
+		return domain.File{}, infra.ErrQuotaExceeded
+	}
+
+	content := &sizedReader{reader: file, size: fileSize}
+	contentType, file, err := detectContentType(filename, declaredType, content)
+	if err != nil {
+		return domain.File{}, fmt.Errorf("unable to read file :%w", err)
+	}
+
+	hash := sha256.New()
+	fileStoreKey, err := f.fileStore.SaveToFileStore(ctx, filename, io.TeeReader(file, hash))
+	if err != nil {
+		// The error of the file store does not always wrap the error it read,
+		// which is the one callers can act on.
+		if content.err != nil {
+			return domain.File{}, fmt.Errorf("unable to read file :%w", content.err)
+		}
 		return domain.File{}, fmt.Errorf("unable to save to file Store :%w", err)
 	}
+	if content.read != fileSize {
+		if deleteErr := f.fileStore.DeleteFile(ctx, fileStoreKey); deleteErr != nil {
+			return domain.File{}, fmt.Errorf("%w: %v", ErrFileSizeMismatch, deleteErr)
+		}
+		return domain.File{}, ErrFileSizeMismatch
+	}
 
 	newFile := domain.File{
 		ID:           uuid.New(),
 		OwnerId:      userId,
 		FileStoreKey: fileStoreKey,
-		FolderId:     folderIdInUUID,
+		FolderId:     folderId,
 		FileName:     filename,
+		FileSize:     fileSize,
+		ContentType:  contentType,
+		Checksum:     hex.EncodeToString(hash.Sum(nil)),
 	}
 
-	err = f.fileRepo.SaveFile(ctx, newFile)
+	err = f.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, replacedFile := range replaced {
+			if err := f.releaseFileStorage(ctx, replacedFile.ID); err != nil {
+				return err
+			}
+			if err := f.fileRepo.DeleteFile(ctx, replacedFile.ID); err != nil {
+				return err
+			}
+			if err := f.outboxRepo.SaveEvent(ctx, domain.NewEvent(domain.EventFileDeleted, replacedFile.OwnerId, fileEventData(replacedFile))); err != nil {
+				return err
+			}
+		}
+		if err := f.quotaRepo.ReserveStorage(ctx, userId, newFile.FileSize); err != nil {
+			return err
+		}
+		if err := f.fileRepo.SaveFile(ctx, newFile); err != nil {
+			return err
+		}
+		if err := f.activityRepo.RecordActivity(ctx, userId, newFile.ID, domain.ActivityUploaded); err != nil {
+			return err
+		}
+		if err := f.outboxRepo.SaveEvent(ctx, domain.NewEvent(domain.EventFileUploaded, newFile.OwnerId, fileEventData(newFile))); err != nil {
+			return err
+		}
+		for _, replacedFile := range replaced {
+			if err := f.changeRepo.SaveChange(ctx, fileChange(domain.ChangeDeleted, replacedFile)); err != nil {
+				return err
+			}
+		}
+		return f.changeRepo.SaveChange(ctx, fileChange(domain.ChangeCreated, newFile))
+	})
 	if err != nil {
+		if deleteErr := f.fileStore.DeleteFile(ctx, fileStoreKey); deleteErr != nil {
+			return domain.File{}, fmt.Errorf("%w: %v", err, deleteErr)
+		}
 		return domain.File{}, err
 	}
+
+	for _, replacedFile := range replaced {
+		f.auditLogger.Log(ctx, domain.AuditEvent{
+			Action:     domain.AuditActionFileDeleted,
+			TargetType: domain.AuditTargetFile,
+			TargetId:   replacedFile.ID.String(),
+		})
+		if err := f.fileStore.DeleteFile(ctx, replacedFile.FileStoreKey); err != nil {
+			log.Printf("Error deleting file %s from file store: %v", replacedFile.ID, err)
+		}
+	}
+	f.auditLogger.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionFileUploaded,
+		TargetType: domain.AuditTargetFile,
+		TargetId:   newFile.ID.String(),
+	})
 	return newFile, nil
 }
 
@@ -101,15 +288,110 @@ func (f *FileService) DownloadFile(ctx context.Context, fileId uuid.UUID) (strin
 	if file.OwnerId != userId {
 		return "", infra.ErrUserNotAuthorized
 	}
+	if file.IsMissing {
+		return "", infra.ErrFileNotFound
+	}
 
 	fileUrl, err := f.fileStore.GetDownloadUrl(ctx, file.FileStoreKey)
 	if err != nil {
 		return "", err
 	}
+	f.recordDownload(ctx, file)
+
+	f.auditLogger.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionFileDownloadUrlIssued,
+		TargetType: domain.AuditTargetFile,
+		TargetId:   file.ID.String(),
+	})
 	return fileUrl, nil
 }
 
-func (f *FileService) CreateFolder(ctx context.Context, folderName string) (domain.Folder, error) {
+// StatFile returns the file and the stored object behind it, for serving the
+// file's content directly.
+func (f *FileService) StatFile(ctx context.Context, fileId uuid.UUID) (domain.File, domain.StoredObject, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return domain.File{}, domain.StoredObject{}, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	file, err := f.fileRepo.GetFileByFileId(ctx, fileId)
+	if err != nil {
+		return domain.File{}, domain.StoredObject{}, err
+	}
+	if file.OwnerId != jwtClaims.ID {
+		return domain.File{}, domain.StoredObject{}, infra.ErrUserNotAuthorized
+	}
+	if file.IsUnsafe || file.IsMissing {
+		return domain.File{}, domain.StoredObject{}, infra.ErrFileNotFound
+	}
+
+	object, err := f.fileStore.GetFileInfo(ctx, file.FileStoreKey)
+	if err != nil {
+		return domain.File{}, domain.StoredObject{}, err
+	}
+	return file, object, nil
+}
+
+// ReadFile reads length bytes of a file returned by StatFile, starting at
+// offset. Only reads from the start of the file are audited, so that clients
+// seeking through a file do not flood the audit log.
+func (f *FileService) ReadFile(ctx context.Context, file domain.File, offset, length int64) (io.ReadCloser, error) {
+	body, err := f.fileStore.GetFileRange(ctx, file.FileStoreKey, offset, length)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset == 0 {
+		f.recordDownload(ctx, file)
+		f.auditLogger.Log(ctx, domain.AuditEvent{
+			Action:     domain.AuditActionFileDownloaded,
+			TargetType: domain.AuditTargetFile,
+			TargetId:   file.ID.String(),
+		})
+	}
+	return body, nil
+}
+
+// recordDownload adds the file to the caller's recent files. A download is
+// not failed over it, since the file has already been handed out.
+func (f *FileService) recordDownload(ctx context.Context, file domain.File) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return
+	}
+	if err := f.activityRepo.RecordActivity(ctx, jwtClaims.ID, file.ID, domain.ActivityDownloaded); err != nil {
+		log.Printf("Error recording download of file %s: %v", file.ID, err)
+	}
+}
+
+// GetThumbnailUrl returns a download url for a thumbnail of the file.
+// Thumbnails are generated in the background after upload, so a file may not
+// have one yet.
+func (f *FileService) GetThumbnailUrl(ctx context.Context, fileId uuid.UUID, size domain.ThumbnailSize) (string, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return "", fmt.Errorf("error parsing JWTClaims")
+	}
+
+	file, err := f.fileRepo.GetFileByFileId(ctx, fileId)
+	if err != nil {
+		return "", err
+	}
+	if file.OwnerId != jwtClaims.ID {
+		return "", infra.ErrUserNotAuthorized
+	}
+	if file.IsUnsafe || file.IsMissing {
+		return "", infra.ErrFileNotFound
+	}
+
+	thumbnail, err := f.thumbnailRepo.GetThumbnail(ctx, file.ID, size)
+	if err != nil {
+		return "", err
+	}
+	return f.fileStore.GetDownloadUrl(ctx, thumbnail.FileStoreKey)
+}
+
+func (f *FileService) CreateFolder(ctx context.Context, folderName string, parentId *uuid.UUID) (domain.Folder, error) {
 	jwtClaims, ok := auth.Get(ctx)
 	if !ok {
 		return domain.Folder{}, fmt.Errorf("error parsing JWTClaims")
@@ -118,42 +400,108 @@ func (f *FileService) CreateFolder(ctx context.Context, folderName string) (doma
 	userId := jwtClaims.ID
 	var err error
 
+	if parentId != nil {
+		parentFolder, err := f.folderRepo.GetFolderByFolderId(ctx, *parentId)
+		if err != nil {
+			return domain.Folder{}, err
+		}
+		if parentFolder.OwnerId != userId {
+			return domain.Folder{}, infra.ErrUserNotAuthorized
+		}
+	}
+
 	newFolder := domain.Folder{
 		ID:         uuid.New(),
 		OwnerId:    userId,
+		ParentId:   parentId,
 		FolderName: folderName,
 	}
 
-	err = f.folderRepo.CreateFolder(ctx, newFolder)
+	err = f.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := f.folderRepo.CreateFolder(ctx, newFolder); err != nil {
+			return err
+		}
+		if err := f.outboxRepo.SaveEvent(ctx, domain.NewEvent(domain.EventFolderCreated, newFolder.OwnerId, folderEventData(newFolder))); err != nil {
+			return err
+		}
+		return f.changeRepo.SaveChange(ctx, folderChange(domain.ChangeCreated, newFolder))
+	})
 	if err != nil {
 		return domain.Folder{}, err
 	}
 
+	f.auditLogger.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionFolderCreated,
+		TargetType: domain.AuditTargetFolder,
+		TargetId:   newFolder.ID.String(),
+	})
 	return newFolder, nil
 }
 
-func (f *FileService) GetFilesByFolderId(ctx context.Context, folderId uuid.UUID, pageNumber, rowsPerPage int) ([]domain.File, error) {
+// FilePage is one page of a folder listing. NextCursor is empty on the last
+// page.
+type FilePage struct {
+	Folder     domain.Folder
+	Files      []domain.File
+	Total      int
+	NextCursor string
+}
+
+// GetFilesByFolderId returns the page of files that follows the one the
+// cursor was issued for, or the first page when cursor is empty. Only files
+// with every one of query.Tags are listed.
+func (f *FileService) GetFilesByFolderId(ctx context.Context, folderId uuid.UUID, query infra.FileListQuery, cursor string) (FilePage, error) {
 	jwtClaims, ok := auth.Get(ctx)
 	if !ok {
-		return []domain.File{}, fmt.Errorf("error parsing JWTClaims")
+		return FilePage{}, fmt.Errorf("error parsing JWTClaims")
 	}
 
 	userId := jwtClaims.ID
 	existingFolder, err := f.folderRepo.GetFolderByFolderId(ctx, folderId)
 	if err != nil {
-		return []domain.File{}, err
+		return FilePage{}, err
 	}
 
 	if existingFolder.OwnerId != userId {
-		return []domain.File{}, infra.ErrUserNotAuthorized
+		return FilePage{}, infra.ErrUserNotAuthorized
+	}
+
+	tags := []string{}
+	for _, tag := range query.Tags {
+		if tag = domain.NormalizeTag(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > domain.MaxTags {
+		return FilePage{}, domain.ErrTooManyTags
+	}
+	query.Tags = tags
+
+	if cursor != "" {
+		query.After, err = decodeCursor(query, cursor)
+		if err != nil {
+			return FilePage{}, err
+		}
 	}
 
-	files, err := f.fileRepo.GetFilesByFolderId(ctx, existingFolder.ID, pageNumber, rowsPerPage)
+	pageSize := query.Limit
+	query.Limit = pageSize + 1
+	files, err := f.fileRepo.GetFilesByFolderId(ctx, existingFolder.ID, query)
 	if err != nil {
-		return []domain.File{}, err
+		return FilePage{}, err
 	}
 
-	return files, nil
+	total, err := f.fileRepo.CountFilesByFolderId(ctx, existingFolder.ID, query.Tags)
+	if err != nil {
+		return FilePage{}, err
+	}
+
+	page := FilePage{Folder: existingFolder, Files: files, Total: total}
+	if len(files) > pageSize {
+		page.Files = files[:pageSize]
+		page.NextCursor = encodeCursor(query, page.Files[pageSize-1])
+	}
+	return page, nil
 }
 
 func getDefaultFolder(ctx context.Context, f *FileService, userId uuid.UUID) (domain.Folder, error) {
@@ -170,7 +518,15 @@ func getDefaultFolder(ctx context.Context, f *FileService, userId uuid.UUID) (do
 		FolderName: DefaultFolderName,
 	}
 
-	err = f.folderRepo.CreateFolder(ctx, newFolder)
+	err = f.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := f.folderRepo.CreateFolder(ctx, newFolder); err != nil {
+			return err
+		}
+		if err := f.outboxRepo.SaveEvent(ctx, domain.NewEvent(domain.EventFolderCreated, newFolder.OwnerId, folderEventData(newFolder))); err != nil {
+			return err
+		}
+		return f.changeRepo.SaveChange(ctx, folderChange(domain.ChangeCreated, newFolder))
+	})
 	if err != nil {
 		return domain.Folder{}, fmt.Errorf("error creating default folder: %w", err)
 	}
@@ -192,10 +548,189 @@ func (f *FileService) MarkFileAsUnsafe(ctx context.Context, fileId uuid.UUID) er
 		return err
 	}
 
-	err = f.fileRepo.MarkFileAsUnsafe(ctx, file)
+	err = f.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := f.releaseFileStorage(ctx, file.ID); err != nil {
+			return err
+		}
+		if err := f.fileRepo.MarkFileAsUnsafe(ctx, file); err != nil {
+			return err
+		}
+		if err := f.outboxRepo.SaveEvent(ctx, domain.NewEvent(domain.EventFileMarkedUnsafe, file.OwnerId, fileEventData(file))); err != nil {
+			return err
+		}
+		// Unsafe files are no longer listed, so to the owner it is gone.
+		return f.changeRepo.SaveChange(ctx, fileChange(domain.ChangeDeleted, file))
+	})
 	if err != nil {
 		return err
 	}
 
+	f.auditLogger.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionFileMarkedUnsafe,
+		TargetType: domain.AuditTargetFile,
+		TargetId:   file.ID.String(),
+	})
 	return nil
 }
+
+func (f *FileService) DeleteFile(ctx context.Context, fileId uuid.UUID) error {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return fmt.Errorf("error parsing JWTClaims")
+	}
+
+	file, err := f.fileRepo.GetFileByFileId(ctx, fileId)
+	if err != nil {
+		return err
+	}
+	if file.OwnerId != jwtClaims.ID {
+		return infra.ErrUserNotAuthorized
+	}
+
+	err = f.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := f.releaseFileStorage(ctx, file.ID); err != nil {
+			return err
+		}
+		if err := f.fileRepo.DeleteFile(ctx, file.ID); err != nil {
+			return err
+		}
+		if err := f.outboxRepo.SaveEvent(ctx, domain.NewEvent(domain.EventFileDeleted, file.OwnerId, fileEventData(file))); err != nil {
+			return err
+		}
+		return f.changeRepo.SaveChange(ctx, fileChange(domain.ChangeDeleted, file))
+	})
+	if err != nil {
+		return err
+	}
+
+	f.auditLogger.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionFileDeleted,
+		TargetType: domain.AuditTargetFile,
+		TargetId:   file.ID.String(),
+	})
+	return f.fileStore.DeleteFile(ctx, file.FileStoreKey)
+}
+
+// releaseFileStorage gives the size of a file that is about to be removed
+// back to its owner's quota. It must be called within a transaction. The file
+// is locked and read again, as the reconciler releases the size of a file
+// when it marks the file missing, and may have done so since it was first
+// read.
+func (f *FileService) releaseFileStorage(ctx context.Context, fileId uuid.UUID) error {
+	file, err := f.fileRepo.GetFileByFileIdForUpdate(ctx, fileId)
+	if err != nil {
+		return err
+	}
+	if file.IsMissing {
+		return nil
+	}
+	return f.quotaRepo.ReleaseStorage(ctx, file.OwnerId, file.FileSize)
+}
+
+// CopyFile copies a file into folderId, or into the file's own folder when
+// folderId is nil. The copy is a new file owned by the caller.
+func (f *FileService) CopyFile(ctx context.Context, fileId uuid.UUID, folderId *uuid.UUID) (domain.File, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return domain.File{}, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	if folderId == nil {
+		file, err := f.fileRepo.GetFileByFileId(ctx, fileId)
+		if err != nil {
+			return domain.File{}, err
+		}
+		folderId = &file.FolderId
+	}
+
+	operation := BatchOperation{Type: BatchCopy, FileId: fileId, FolderId: folderId}
+	item, err := f.prepareBatchItem(ctx, jwtClaims.ID, operation, map[uuid.UUID]domain.Folder{})
+	if err != nil {
+		return domain.File{}, err
+	}
+
+	results := []BatchResult{{Operation: operation}}
+	f.copyFiles(ctx, jwtClaims.ID, []batchItem{item}, results)
+	if results[0].Err != nil {
+		return domain.File{}, results[0].Err
+	}
+	return *results[0].File, nil
+}
+
+func fileEventData(file domain.File) map[string]interface{} {
+	return map[string]interface{}{
+		"id":        file.ID,
+		"file_name": file.FileName,
+		"file_size": file.FileSize,
+		"owner_id":  file.OwnerId,
+		"folder_id": file.FolderId,
+		"checksum":  file.Checksum,
+	}
+}
+
+// sizedReader counts the bytes read from the content of a file, and fails
+// once they go past the size the file was declared with or end short of it,
+// so that the quota checked against the declared size holds for what is
+// stored.
+type sizedReader struct {
+	reader io.Reader
+	size   int64
+	read   int64
+	err    error
+}
+
+func (s *sizedReader) Read(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	// Reading at most one byte past the size is enough to tell it was sent.
+	if limit := s.size - s.read + 1; int64(len(p)) > limit {
+		p = p[:limit]
+	}
+
+	n, err := s.reader.Read(p)
+	s.read += int64(n)
+	switch {
+	case s.read > s.size:
+		s.err = ErrFileSizeMismatch
+	case errors.Is(err, io.EOF) && s.read < s.size:
+		s.err = ErrFileSizeMismatch
+	case err != nil && !errors.Is(err, io.EOF):
+		s.err = err
+	}
+	if s.err != nil {
+		return n, s.err
+	}
+	return n, err
+}
+
+const sniffLength = 512
+
+// detectContentType prefers the type implied by the file extension, then the
+// type the client declared, and finally sniffs the first bytes of the file. The
+// returned reader must be used in place of file, since sniffing consumes it.
+func detectContentType(filename, declaredType string, file io.Reader) (string, io.Reader, error) {
+	if contentType := mime.TypeByExtension(filepath.Ext(filename)); contentType != "" {
+		return contentType, file, nil
+	}
+	if declaredType != "" && declaredType != "application/octet-stream" {
+		return declaredType, file, nil
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", nil, err
+	}
+	head = head[:n]
+	return http.DetectContentType(head), io.MultiReader(bytes.NewReader(head), file), nil
+}
+
+func folderEventData(folder domain.Folder) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          folder.ID,
+		"folder_name": folder.FolderName,
+		"owner_id":    folder.OwnerId,
+		"parent_id":   folder.ParentId,
+	}
+}
@@ -0,0 +1,32 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/services/auth"
+)
+
+type NotificationService struct {
+	broker infra.NotificationBroker
+}
+
+func NewNotificationService(broker infra.NotificationBroker) (*NotificationService, error) {
+	if broker == nil {
+		return &NotificationService{}, errors.New("NotificationService failed to initialize, broker is nil")
+	}
+	return &NotificationService{broker}, nil
+}
+
+// Listen returns the events of the caller's files and folders as they
+// happen, until ctx is done.
+func (n *NotificationService) Listen(ctx context.Context) (<-chan domain.Event, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return nil, fmt.Errorf("error parsing JWTClaims")
+	}
+	return n.broker.Listen(ctx, jwtClaims.ID)
+}
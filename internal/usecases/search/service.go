@@ -0,0 +1,82 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/services/auth"
+)
+
+const MaxQueryLength = 200
+
+var (
+	ErrEmptyQuery       = errors.New("q or tag required")
+	ErrQueryTooLong     = fmt.Errorf("q must be at most %d characters", MaxQueryLength)
+	ErrInvalidSizeRange = errors.New("min_size must not be greater than max_size")
+	ErrInvalidTimeRange = errors.New("from must be before to")
+)
+
+type SearchService struct {
+	searchRepo infra.SearchRepository
+	folderRepo infra.FolderRepository
+}
+
+func NewSearchService(searchRepo infra.SearchRepository, folderRepo infra.FolderRepository) (*SearchService, error) {
+	if searchRepo == nil {
+		return &SearchService{}, errors.New("SearchService failed to initialize, searchRepo is nil")
+	}
+	if folderRepo == nil {
+		return &SearchService{}, errors.New("SearchService failed to initialize, folderRepo is nil")
+	}
+	return &SearchService{searchRepo, folderRepo}, nil
+}
+
+// Search only ever returns the caller's own files and folders, whatever
+// OwnerId the query carries.
+func (s *SearchService) Search(ctx context.Context, query infra.SearchQuery) ([]domain.SearchResult, int, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return []domain.SearchResult{}, 0, fmt.Errorf("error parsing JWTClaims")
+	}
+	query.OwnerId = jwtClaims.ID
+
+	query.Text = strings.TrimSpace(query.Text)
+	tags := []string{}
+	for _, tag := range query.Tags {
+		if tag = domain.NormalizeTag(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	query.Tags = tags
+	if query.Text == "" && len(query.Tags) == 0 {
+		return []domain.SearchResult{}, 0, ErrEmptyQuery
+	}
+	if len(query.Tags) > domain.MaxTags {
+		return []domain.SearchResult{}, 0, domain.ErrTooManyTags
+	}
+	if len(query.Text) > MaxQueryLength {
+		return []domain.SearchResult{}, 0, ErrQueryTooLong
+	}
+	if query.MinSize != nil && query.MaxSize != nil && *query.MinSize > *query.MaxSize {
+		return []domain.SearchResult{}, 0, ErrInvalidSizeRange
+	}
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		return []domain.SearchResult{}, 0, ErrInvalidTimeRange
+	}
+
+	if query.FolderId != nil {
+		folder, err := s.folderRepo.GetFolderByFolderId(ctx, *query.FolderId)
+		if err != nil {
+			return []domain.SearchResult{}, 0, err
+		}
+		if folder.OwnerId != query.OwnerId {
+			return []domain.SearchResult{}, 0, infra.ErrUserNotAuthorized
+		}
+	}
+
+	return s.searchRepo.Search(ctx, query)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/olad5/file-fort/internal/services/audit"
 	"github.com/olad5/file-fort/internal/services/auth"
 	"golang.org/x/crypto/bcrypt"
 
@@ -15,23 +16,33 @@ import (
 
 type UserService struct {
 	userRepo    infra.UserRepository
+	quotaRepo   infra.QuotaRepository
 	authService auth.AuthService
+	auditLogger audit.AuditLogger
 }
 
 var (
 	ErrUserAlreadyExists = errors.New("email already exist")
 	ErrPasswordIncorrect = errors.New("invalid credentials")
 	ErrInvalidToken      = errors.New("invalid token")
+	ErrInvalidRole       = errors.New("invalid role")
+	ErrInvalidQuota      = errors.New("quota must not be negative")
 )
 
-func NewUserService(userRepo infra.UserRepository, authService auth.AuthService) (*UserService, error) {
+func NewUserService(userRepo infra.UserRepository, quotaRepo infra.QuotaRepository, authService auth.AuthService, auditLogger audit.AuditLogger) (*UserService, error) {
 	if userRepo == nil {
 		return &UserService{}, errors.New("UserService failed to initialize, userRepo is nil")
 	}
+	if quotaRepo == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, quotaRepo is nil")
+	}
 	if authService == nil {
 		return &UserService{}, errors.New("UserService failed to initialize, authService is nil")
 	}
-	return &UserService{userRepo, authService}, nil
+	if auditLogger == nil {
+		return &UserService{}, errors.New("UserService failed to initialize, auditLogger is nil")
+	}
+	return &UserService{userRepo, quotaRepo, authService, auditLogger}, nil
 }
 
 func (u *UserService) CreateUser(ctx context.Context, firstName, lastName, email, password string) (domain.User, error) {
@@ -58,26 +69,68 @@ func (u *UserService) CreateUser(ctx context.Context, firstName, lastName, email
 	if err != nil {
 		return domain.User{}, err
 	}
+
+	u.auditLogger.Log(ctx, domain.AuditEvent{
+		ActorId:    &newUser.ID,
+		ActorEmail: newUser.Email,
+		Action:     domain.AuditActionUserRegistered,
+		TargetType: domain.AuditTargetUser,
+		TargetId:   newUser.ID.String(),
+	})
 	return newUser, nil
 }
 
 func (u *UserService) LogUserIn(ctx context.Context, email, password string) (string, error) {
-	existingUser, err := u.userRepo.GetUserByEmail(ctx, email)
+	existingUser, err := u.Authenticate(ctx, email, password)
 	if err != nil {
 		return "", err
 	}
 
-	if isPasswordCorrect := comparePasswords(existingUser.Password, []byte(password)); !isPasswordCorrect {
-		return "", ErrPasswordIncorrect
-	}
-
 	accessToken, err := u.authService.GenerateJWT(ctx, existingUser)
 	if err != nil {
 		return "", err
 	}
+
+	u.auditLogger.Log(ctx, domain.AuditEvent{
+		ActorId:    &existingUser.ID,
+		ActorEmail: existingUser.Email,
+		Action:     domain.AuditActionLoginSucceeded,
+		TargetType: domain.AuditTargetUser,
+		TargetId:   existingUser.ID.String(),
+	})
 	return accessToken, nil
 }
 
+// Authenticate checks a user's email and password without issuing a token,
+// for clients that send their credentials with every request. Only failed
+// attempts are audited.
+func (u *UserService) Authenticate(ctx context.Context, email, password string) (domain.User, error) {
+	existingUser, err := u.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, infra.ErrUserNotFound) {
+			u.auditLogger.Log(ctx, domain.AuditEvent{
+				ActorEmail: email,
+				Action:     domain.AuditActionLoginFailed,
+				TargetType: domain.AuditTargetUser,
+				TargetId:   email,
+			})
+		}
+		return domain.User{}, err
+	}
+
+	if isPasswordCorrect := comparePasswords(existingUser.Password, []byte(password)); !isPasswordCorrect {
+		u.auditLogger.Log(ctx, domain.AuditEvent{
+			ActorId:    &existingUser.ID,
+			ActorEmail: existingUser.Email,
+			Action:     domain.AuditActionLoginFailed,
+			TargetType: domain.AuditTargetUser,
+			TargetId:   existingUser.ID.String(),
+		})
+		return domain.User{}, ErrPasswordIncorrect
+	}
+	return existingUser, nil
+}
+
 func (u *UserService) GetLoggedInUser(ctx context.Context) (domain.User, error) {
 	jwtClaims, ok := auth.Get(ctx)
 	if !ok {
@@ -92,6 +145,40 @@ func (u *UserService) GetLoggedInUser(ctx context.Context) (domain.User, error)
 	return existingUser, nil
 }
 
+func (u *UserService) GetStorageUsage(ctx context.Context) (domain.StorageUsage, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return domain.StorageUsage{}, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	return u.quotaRepo.GetStorageUsage(ctx, jwtClaims.ID)
+}
+
+func (u *UserService) SetUserQuota(ctx context.Context, userId uuid.UUID, quotaBytes *int64) (domain.StorageUsage, error) {
+	if quotaBytes != nil && *quotaBytes < 0 {
+		return domain.StorageUsage{}, ErrInvalidQuota
+	}
+
+	if _, err := u.userRepo.GetUserByUserId(ctx, userId); err != nil {
+		return domain.StorageUsage{}, err
+	}
+
+	if err := u.quotaRepo.SetUserQuota(ctx, userId, quotaBytes); err != nil {
+		return domain.StorageUsage{}, err
+	}
+	return u.quotaRepo.GetStorageUsage(ctx, userId)
+}
+
+func (u *UserService) SetRoleQuota(ctx context.Context, role domain.Role, quotaBytes int64) error {
+	if role != domain.RoleUser && role != domain.RoleAdmin {
+		return ErrInvalidRole
+	}
+	if quotaBytes < 0 {
+		return ErrInvalidQuota
+	}
+	return u.quotaRepo.SetRoleQuota(ctx, role, quotaBytes)
+}
+
 func hashAndSalt(plainPassword []byte) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword(plainPassword, bcrypt.MinCost)
 	if err != nil {
@@ -0,0 +1,196 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
+	"github.com/olad5/file-fort/internal/services/auth"
+	webhookDelivery "github.com/olad5/file-fort/internal/services/webhooks"
+)
+
+type WebhookService struct {
+	webhookRepo       infra.WebhookRepository
+	allowPrivateHosts bool
+}
+
+var (
+	ErrInvalidWebhookUrl = errors.New("url must be an absolute http or https url")
+	ErrInvalidEventType  = errors.New("unsupported event type")
+	ErrMissingEventTypes = errors.New("at least one event type is required")
+	ErrTooManyWebhooks   = errors.New("webhook limit reached")
+	ErrPrivateWebhookUrl = webhookDelivery.ErrPrivateAddress
+	ErrUnresolvableHost  = errors.New("url host could not be resolved")
+)
+
+const maxWebhooksPerUser = 20
+
+// NewWebhookService returns a service that refuses webhook urls whose host
+// resolves to a loopback, link-local or private address, unless
+// allowPrivateHosts is set.
+func NewWebhookService(webhookRepo infra.WebhookRepository, allowPrivateHosts bool) (*WebhookService, error) {
+	if webhookRepo == nil {
+		return &WebhookService{}, errors.New("WebhookService failed to initialize, webhookRepo is nil")
+	}
+	return &WebhookService{webhookRepo, allowPrivateHosts}, nil
+}
+
+func (w *WebhookService) CreateWebhook(ctx context.Context, webhookUrl string, events []domain.EventType) (domain.Webhook, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return domain.Webhook{}, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	parsedUrl, err := url.Parse(webhookUrl)
+	if err != nil || !parsedUrl.IsAbs() || (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || parsedUrl.Host == "" {
+		return domain.Webhook{}, ErrInvalidWebhookUrl
+	}
+	// The host is checked again when each delivery connects, as it may
+	// resolve to another address by then.
+	if !w.allowPrivateHosts {
+		err := webhookDelivery.CheckHost(ctx, parsedUrl.Hostname())
+		if errors.Is(err, webhookDelivery.ErrPrivateAddress) {
+			return domain.Webhook{}, ErrPrivateWebhookUrl
+		}
+		if err != nil {
+			return domain.Webhook{}, ErrUnresolvableHost
+		}
+	}
+
+	if len(events) == 0 {
+		return domain.Webhook{}, ErrMissingEventTypes
+	}
+	uniqueEvents := []domain.EventType{}
+	seen := map[domain.EventType]bool{}
+	for _, event := range events {
+		if !event.IsValid() {
+			return domain.Webhook{}, fmt.Errorf("%w: %s", ErrInvalidEventType, event)
+		}
+		if !seen[event] {
+			seen[event] = true
+			uniqueEvents = append(uniqueEvents, event)
+		}
+	}
+
+	existingWebhooks, err := w.webhookRepo.GetWebhooksByOwnerId(ctx, jwtClaims.ID)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	if len(existingWebhooks) >= maxWebhooksPerUser {
+		return domain.Webhook{}, ErrTooManyWebhooks
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+
+	now := time.Now()
+	newWebhook := domain.Webhook{
+		ID:        uuid.New(),
+		OwnerId:   jwtClaims.ID,
+		Url:       parsedUrl.String(),
+		Secret:    secret,
+		Events:    uniqueEvents,
+		IsActive:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err = w.webhookRepo.CreateWebhook(ctx, newWebhook)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	return newWebhook, nil
+}
+
+func (w *WebhookService) GetWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return []domain.Webhook{}, fmt.Errorf("error parsing JWTClaims")
+	}
+	return w.webhookRepo.GetWebhooksByOwnerId(ctx, jwtClaims.ID)
+}
+
+func (w *WebhookService) DeleteWebhook(ctx context.Context, webhookId uuid.UUID) error {
+	webhook, err := w.getOwnedWebhook(ctx, webhookId)
+	if err != nil {
+		return err
+	}
+	return w.webhookRepo.DeleteWebhook(ctx, webhook.ID)
+}
+
+func (w *WebhookService) GetDeliveries(ctx context.Context, webhookId uuid.UUID, pageNumber, rowsPerPage int) ([]domain.WebhookDelivery, error) {
+	webhook, err := w.getOwnedWebhook(ctx, webhookId)
+	if err != nil {
+		return []domain.WebhookDelivery{}, err
+	}
+	return w.webhookRepo.GetDeliveriesByWebhookId(ctx, webhook.ID, pageNumber, rowsPerPage)
+}
+
+// Redeliver queues a fresh delivery carrying the payload of an earlier one,
+// leaving the original delivery and its attempt history untouched.
+func (w *WebhookService) Redeliver(ctx context.Context, webhookId, deliveryId uuid.UUID) (domain.WebhookDelivery, error) {
+	webhook, err := w.getOwnedWebhook(ctx, webhookId)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	existingDelivery, err := w.webhookRepo.GetDeliveryByDeliveryId(ctx, deliveryId)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	if existingDelivery.WebhookId != webhook.ID {
+		return domain.WebhookDelivery{}, infra.ErrDeliveryNotFound
+	}
+
+	now := time.Now().UTC()
+	newDelivery := domain.WebhookDelivery{
+		ID:            uuid.New(),
+		WebhookId:     webhook.ID,
+		EventId:       existingDelivery.EventId,
+		EventType:     existingDelivery.EventType,
+		Payload:       existingDelivery.Payload,
+		Status:        domain.DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	err = w.webhookRepo.CreateDelivery(ctx, newDelivery)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	return newDelivery, nil
+}
+
+func (w *WebhookService) getOwnedWebhook(ctx context.Context, webhookId uuid.UUID) (domain.Webhook, error) {
+	jwtClaims, ok := auth.Get(ctx)
+	if !ok {
+		return domain.Webhook{}, fmt.Errorf("error parsing JWTClaims")
+	}
+
+	webhook, err := w.webhookRepo.GetWebhookByWebhookId(ctx, webhookId)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	if webhook.OwnerId != jwtClaims.ID {
+		return domain.Webhook{}, infra.ErrUserNotAuthorized
+	}
+	return webhook, nil
+}
+
+func generateSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("error generating webhook secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
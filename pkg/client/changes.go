@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetChanges returns the changes made to the user's files and folders after
+// cursor, oldest first. An empty cursor starts from the beginning of the
+// feed; the cursor of a FolderTree starts from when the tree was listed.
+func (c *Client) GetChanges(ctx context.Context, cursor string, limit int) (ChangePage, error) {
+	return c.WaitForChanges(ctx, cursor, limit, 0)
+}
+
+// WaitForChanges is GetChanges that long-polls: when there are no changes
+// after cursor yet, the server holds the request for up to wait, rounded to
+// the second and capped by the server, and answers as soon as there are. A
+// page with no changes means the wait ran out.
+func (c *Client) WaitForChanges(ctx context.Context, cursor string, limit int, wait time.Duration) (ChangePage, error) {
+	query := url.Values{}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if seconds := int(wait.Round(time.Second) / time.Second); seconds > 0 {
+		query.Set("wait", strconv.Itoa(seconds))
+	}
+
+	route := "/changes"
+	if len(query) > 0 {
+		route += "?" + query.Encode()
+	}
+	var page ChangePage
+	err := c.doJSON(ctx, http.MethodGet, route, nil, &page)
+	return page, err
+}
+
+// GetFolderTree lists every folder and file below a folder, with their paths.
+func (c *Client) GetFolderTree(ctx context.Context, folderId uuid.UUID) (FolderTree, error) {
+	var tree FolderTree
+	err := c.doJSON(ctx, http.MethodGet, "/folder/"+folderId.String()+"/tree", nil, &tree)
+	return tree, err
+}
@@ -0,0 +1,255 @@
+// Package client is a Go client for the REST API of a file-fort server.
+//
+//	c, err := client.NewClient("https://files.example.com")
+//	token, err := c.Login(ctx, client.LoginRequest{Email: email, Password: password})
+//	c.SetAccessToken(token.AccessToken)
+//	page, err := c.ListFiles(ctx, folderId, client.ListFilesOptions{})
+//
+// Errors returned by the server unwrap to one of the sentinel errors of this
+// package, so they can be checked with errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultBackoff    = 250 * time.Millisecond
+	maxBackoff        = 10 * time.Second
+)
+
+// Client sends requests to a file-fort server. It is safe for concurrent use,
+// as long as SetAccessToken is not called while requests are in flight.
+type Client struct {
+	baseUrl     string
+	accessToken string
+	httpClient  *http.Client
+	maxRetries  int
+	backoff     time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client requests are sent with, which is
+// http.DefaultClient by default.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAccessToken sets the access token requests are authenticated with.
+func WithAccessToken(accessToken string) Option {
+	return func(c *Client) {
+		c.accessToken = accessToken
+	}
+}
+
+// WithRetries sets how many times a failed request is retried, and how long
+// to wait before the first retry. The wait doubles with every retry. The
+// default is 3 retries, starting at 250ms.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+func NewClient(baseUrl string, options ...Option) (*Client, error) {
+	parsed, err := url.Parse(baseUrl)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("Failed to create Client: invalid base url %q", baseUrl)
+	}
+
+	c := &Client{
+		baseUrl:    strings.TrimRight(baseUrl, "/"),
+		httpClient: http.DefaultClient,
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	if c.httpClient == nil {
+		return nil, fmt.Errorf("Failed to create Client: http client is nil")
+	}
+	if c.maxRetries < 0 {
+		return nil, fmt.Errorf("Failed to create Client: retries cannot be negative")
+	}
+	return c, nil
+}
+
+// BaseUrl returns the url of the server, without a trailing slash.
+func (c *Client) BaseUrl() string {
+	return c.baseUrl
+}
+
+// SetAccessToken sets the access token requests are authenticated with,
+// typically the one returned by Login.
+func (c *Client) SetAccessToken(accessToken string) {
+	c.accessToken = accessToken
+}
+
+// doJSON sends body, if it is not nil, as JSON and decodes the data of the
+// response into data, if it is not nil.
+func (c *Client) doJSON(ctx context.Context, method, route string, body interface{}, data interface{}) error {
+	var encoded []byte
+	if body != nil {
+		var err error
+		encoded, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.send(ctx, func() (*http.Request, error) {
+		var reader io.Reader
+		if encoded != nil {
+			reader = bytes.NewReader(encoded)
+		}
+		req, err := c.newRequest(ctx, method, route, reader)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		return req, nil
+	}, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeResponse(resp, data)
+}
+
+func (c *Client) newRequest(ctx context.Context, method, route string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseUrl+route, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	}
+	return req, nil
+}
+
+// send sends the request built by newRequest, retrying it while the server
+// is unavailable. Requests whose body cannot be built again are only sent
+// once. Requests that are not idempotent are only retried when the server
+// says it did not handle them, never after a network error, so that they are
+// not applied twice.
+//
+// Responses with a status of 400 or above are returned as an error, and
+// their body closed.
+func (c *Client) send(ctx context.Context, newRequest func() (*http.Request, error), replayable bool) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.httpClient.Do(req)
+
+		canRetry := replayable && attempt < c.maxRetries
+		if err != nil {
+			if canRetry && isIdempotent(req.Method) && ctx.Err() == nil {
+				if err := c.wait(ctx, attempt, ""); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			return nil, err
+		}
+		if resp.StatusCode < http.StatusBadRequest {
+			return resp, nil
+		}
+
+		if canRetry && shouldRetry(req.Method, resp.StatusCode) {
+			retryAfter := resp.Header.Get("Retry-After")
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if err := c.wait(ctx, attempt, retryAfter); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err = decodeError(resp)
+		resp.Body.Close()
+		return nil, err
+	}
+}
+
+// wait sleeps before a retry, for as long as the server asked in Retry-After
+// or otherwise for the backoff of the attempt.
+func (c *Client) wait(ctx context.Context, attempt int, retryAfter string) error {
+	delay := c.backoff << attempt
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	}
+	if delay > maxBackoff || delay < 0 {
+		delay = maxBackoff
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// shouldRetry reports whether a request that failed with status is worth
+// sending again. 429 and 503 mean the server turned the request away, so any
+// request can be retried; 502 and 504 may come after the request was
+// handled, so only idempotent ones are.
+func shouldRetry(method string, status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return isIdempotent(method)
+	}
+	return false
+}
+
+func decodeResponse(resp *http.Response, data interface{}) error {
+	if data == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	var envelope Response[json.RawMessage]
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("error decoding response from %s: %w", resp.Request.URL.Path, err)
+	}
+	if err := json.Unmarshal(envelope.Data, data); err != nil {
+		return fmt.Errorf("error decoding response from %s: %w", resp.Request.URL.Path, err)
+	}
+	return nil
+}
+
+func decodeError(resp *http.Response) error {
+	var envelope ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil || envelope.Message == "" {
+		envelope.Message = http.StatusText(resp.StatusCode)
+	}
+	return &Error{StatusCode: resp.StatusCode, Message: envelope.Message}
+}
@@ -0,0 +1,449 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olad5/file-fort/pkg/client"
+	response "github.com/olad5/file-fort/pkg/utils"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, options ...client.Option) *client.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	options = append([]client.Option{client.WithRetries(3, time.Millisecond)}, options...)
+	c, err := client.NewClient(server.URL, options...)
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	return c
+}
+
+func TestNewClient(t *testing.T) {
+	t.Run(`Given a base url that is not an http url, when a client is created,
+    then an error should be returned.`,
+		func(t *testing.T) {
+			for _, baseUrl := range []string{"", "files.example.com", "ftp://files.example.com"} {
+				if _, err := client.NewClient(baseUrl); err == nil {
+					t.Errorf("expected an error for base url %q", baseUrl)
+				}
+			}
+		},
+	)
+}
+
+func TestLogin(t *testing.T) {
+	userId := uuid.New()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/login":
+			var request client.LoginRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				response.ErrorResponse(w, "Invalid JSON", http.StatusBadRequest)
+				return
+			}
+			if request.Email != "will@gmail.com" || request.Password != "passcode" {
+				response.ErrorResponse(w, "invalid credentials", http.StatusUnauthorized)
+				return
+			}
+			response.SuccessResponse(w, "user logged in successfully", map[string]interface{}{"access_token": "some-token"})
+		case "/users/me":
+			if r.Header.Get("Authorization") != "Bearer some-token" {
+				response.ErrorResponse(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			response.SuccessResponse(w, "user retrieved successfully", map[string]interface{}{
+				"id":         userId.String(),
+				"email":      "will@gmail.com",
+				"first_name": "will",
+				"last_name":  "hansen",
+				"role":       "user",
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	t.Run(`Given valid credentials, when the user logs in and fetches their profile
+    with the access token, then the profile of the user should be returned.`,
+		func(t *testing.T) {
+			login, err := c.Login(ctx, client.LoginRequest{Email: "will@gmail.com", Password: "passcode"})
+			if err != nil {
+				t.Fatalf("error logging in: %v", err)
+			}
+			c.SetAccessToken(login.AccessToken)
+
+			me, err := c.Me(ctx)
+			if err != nil {
+				t.Fatalf("error getting user: %v", err)
+			}
+			if me.ID != userId || me.Email != "will@gmail.com" {
+				t.Errorf("got user %+v", me)
+			}
+		},
+	)
+
+	t.Run(`Given invalid credentials, when the user logs in, then the error should
+    be ErrUnauthorized and carry the message of the server.`,
+		func(t *testing.T) {
+			_, err := c.Login(ctx, client.LoginRequest{Email: "will@gmail.com", Password: "wrong"})
+			if !errors.Is(err, client.ErrUnauthorized) {
+				t.Fatalf("expected ErrUnauthorized, got %v", err)
+			}
+			var apiErr *client.Error
+			if !errors.As(err, &apiErr) || apiErr.Message != "invalid credentials" {
+				t.Errorf("got error %#v", err)
+			}
+		},
+	)
+}
+
+func TestErrors(t *testing.T) {
+	statuses := map[int]error{
+		http.StatusBadRequest:            client.ErrBadRequest,
+		http.StatusForbidden:             client.ErrForbidden,
+		http.StatusNotFound:              client.ErrNotFound,
+		http.StatusRequestEntityTooLarge: client.ErrPayloadTooLarge,
+		http.StatusInternalServerError:   client.ErrServerError,
+		http.StatusTeapot:                client.ErrUnexpectedStatus,
+	}
+	for status, expected := range statuses {
+		status, expected := status, expected
+		t.Run(`Given the server responds with `+http.StatusText(status)+`, when a request
+    is made, then the error should match the sentinel error of the status.`,
+			func(t *testing.T) {
+				c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+					response.ErrorResponse(w, "something happened", status)
+				})
+				err := c.DeleteFile(context.Background(), uuid.New())
+				if !errors.Is(err, expected) {
+					t.Errorf("expected %v, got %v", expected, err)
+				}
+			},
+		)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run(`Given the server is unavailable for a while, when a GET request is made,
+    then it should be retried until it succeeds.`,
+		func(t *testing.T) {
+			var calls int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&calls, 1) < 3 {
+					response.ErrorResponse(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				response.SuccessResponse(w, "download url generated successfully", map[string]interface{}{"download_url": "https://store.example.com/file"})
+			})
+			downloadUrl, err := c.GetDownloadUrl(ctx, uuid.New())
+			if err != nil {
+				t.Fatalf("expected the request to succeed, got %v", err)
+			}
+			if downloadUrl != "https://store.example.com/file" || calls != 3 {
+				t.Errorf("got %q after %d calls", downloadUrl, calls)
+			}
+		},
+	)
+
+	t.Run(`Given the server keeps failing, when a request is made, then it should be
+    given up on after the configured number of retries.`,
+		func(t *testing.T) {
+			var calls int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				response.ErrorResponse(w, "unavailable", http.StatusServiceUnavailable)
+			})
+			_, err := c.GetDownloadUrl(ctx, uuid.New())
+			if !errors.Is(err, client.ErrServerError) {
+				t.Errorf("expected ErrServerError, got %v", err)
+			}
+			if calls != 4 {
+				t.Errorf("expected 4 calls, got %d", calls)
+			}
+		},
+	)
+
+	t.Run(`Given a gateway error, when a POST request is made, then it should not be
+    retried, since the server may have handled it.`,
+		func(t *testing.T) {
+			var calls int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				response.ErrorResponse(w, "bad gateway", http.StatusBadGateway)
+			})
+			_, err := c.CreateFolder(ctx, client.CreateFolderRequest{FolderName: "photos"})
+			if !errors.Is(err, client.ErrServerError) {
+				t.Errorf("expected ErrServerError, got %v", err)
+			}
+			if calls != 1 {
+				t.Errorf("expected 1 call, got %d", calls)
+			}
+		},
+	)
+
+	t.Run(`Given a client error, when a request is made, then it should not be
+    retried.`,
+		func(t *testing.T) {
+			var calls int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				response.ErrorResponse(w, "file does not exist", http.StatusNotFound)
+			})
+			_, err := c.GetDownloadUrl(ctx, uuid.New())
+			if !errors.Is(err, client.ErrNotFound) {
+				t.Errorf("expected ErrNotFound, got %v", err)
+			}
+			if calls != 1 {
+				t.Errorf("expected 1 call, got %d", calls)
+			}
+		},
+	)
+}
+
+func TestUploadFile(t *testing.T) {
+	ctx := context.Background()
+	folderId := uuid.New()
+	content := strings.Repeat("some file content\n", 10000)
+
+	upload := func(calls *int32, failFirst bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(calls, 1) == 1 && failFirst {
+				response.ErrorResponse(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+			if r.ContentLength != -1 {
+				response.ErrorResponse(w, "expected a streamed body", http.StatusBadRequest)
+				return
+			}
+			file, header, err := r.FormFile("file")
+			if err != nil {
+				response.ErrorResponse(w, "Error retrieving file, please try again", http.StatusBadRequest)
+				return
+			}
+			defer file.Close()
+			received, _ := io.ReadAll(file)
+			if string(received) != content || r.FormValue("folder_id") != folderId.String() {
+				response.ErrorResponse(w, "unexpected upload", http.StatusBadRequest)
+				return
+			}
+			response.SuccessResponse(w, "file uploaded successfully", map[string]interface{}{
+				"id":           uuid.New(),
+				"file_name":    header.Filename,
+				"file_size":    len(received),
+				"content_type": "text/plain",
+				"folder_id":    folderId,
+				"tags":         []string{},
+				"metadata":     map[string]string{},
+				"created_at":   time.Now(),
+				"updated_at":   time.Now(),
+			})
+		}
+	}
+
+	t.Run(`Given a file, when it is uploaded, then its content should be streamed to
+    the server and the uploaded file returned.`,
+		func(t *testing.T) {
+			var calls int32
+			c := newTestClient(t, upload(&calls, false))
+			file, err := c.UploadFile(ctx, client.UploadFileRequest{
+				FolderId: &folderId,
+				FileName: "notes/report.txt",
+				Content:  io.MultiReader(strings.NewReader(content)),
+			})
+			if err != nil {
+				t.Fatalf("error uploading file: %v", err)
+			}
+			if file.FileName != "report.txt" || file.FileSize != int64(len(content)) || file.FolderId != folderId {
+				t.Errorf("got file %+v", file)
+			}
+		},
+	)
+
+	t.Run(`Given content that can be seeked, when the server turns the upload away,
+    then it should be uploaded again from the start.`,
+		func(t *testing.T) {
+			var calls int32
+			c := newTestClient(t, upload(&calls, true))
+			_, err := c.UploadFile(ctx, client.UploadFileRequest{
+				FolderId: &folderId,
+				FileName: "report.txt",
+				Content:  bytes.NewReader([]byte(content)),
+			})
+			if err != nil {
+				t.Fatalf("error uploading file: %v", err)
+			}
+			if calls != 2 {
+				t.Errorf("expected 2 calls, got %d", calls)
+			}
+		},
+	)
+
+	t.Run(`Given content that cannot be seeked, when the server turns the upload
+    away, then it should not be retried.`,
+		func(t *testing.T) {
+			var calls int32
+			c := newTestClient(t, upload(&calls, true))
+			_, err := c.UploadFile(ctx, client.UploadFileRequest{
+				FolderId: &folderId,
+				FileName: "report.txt",
+				Content:  io.MultiReader(strings.NewReader(content)),
+			})
+			if !errors.Is(err, client.ErrTooManyRequests) {
+				t.Errorf("expected ErrTooManyRequests, got %v", err)
+			}
+			if calls != 1 {
+				t.Errorf("expected 1 call, got %d", calls)
+			}
+		},
+	)
+}
+
+func TestListAllFiles(t *testing.T) {
+	folderId := uuid.New()
+	pages := map[string][]string{
+		"":       {"a.txt", "b.txt"},
+		"page-2": {"c.txt"},
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/folder/"+folderId.String()+"/files" || r.URL.Query().Get("sort") != "name" {
+			http.NotFound(w, r)
+			return
+		}
+		cursor := r.URL.Query().Get("cursor")
+		var nextCursor interface{}
+		if cursor == "" {
+			nextCursor = "page-2"
+		}
+		files := []map[string]interface{}{}
+		for _, name := range pages[cursor] {
+			files = append(files, map[string]interface{}{"id": uuid.New(), "file_name": name, "folder_id": folderId})
+		}
+		response.SuccessResponse(w, "files retreived successfully", map[string]interface{}{
+			"folder_id":   folderId,
+			"files":       files,
+			"total":       3,
+			"next_cursor": nextCursor,
+		})
+	})
+
+	t.Run(`Given a folder with several pages of files, when all its files are listed,
+    then the cursor should be followed to the last page.`,
+		func(t *testing.T) {
+			files, err := c.ListAllFiles(context.Background(), folderId, client.ListFilesOptions{Sort: "name"})
+			if err != nil {
+				t.Fatalf("error listing files: %v", err)
+			}
+			names := []string{}
+			for _, file := range files {
+				names = append(names, file.FileName)
+			}
+			if strings.Join(names, ",") != "a.txt,b.txt,c.txt" {
+				t.Errorf("got files %v", names)
+			}
+		},
+	)
+}
+
+func TestDownloadFile(t *testing.T) {
+	content := "0123456789"
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="../digits.txt"`)
+		w.Header().Set("Content-Type", "text/plain")
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+	})
+	ctx := context.Background()
+	fileId := uuid.New()
+
+	t.Run(`Given a partially downloaded file, when the rest of it is downloaded, then
+    only the bytes from the offset should be returned, along with the size of the
+    whole file.`,
+		func(t *testing.T) {
+			download, err := c.DownloadFile(ctx, fileId, 4)
+			if err != nil {
+				t.Fatalf("error downloading file: %v", err)
+			}
+			defer download.Body.Close()
+			received, _ := io.ReadAll(download.Body)
+			if string(received) != "456789" || download.Offset != 4 || download.Size != 10 {
+				t.Errorf("got %q at offset %d of %d", received, download.Offset, download.Size)
+			}
+			if download.FileName != "digits.txt" {
+				t.Errorf("got file name %q", download.FileName)
+			}
+		},
+	)
+
+	t.Run(`Given a file, when it is stat'ed, then its name and size should be
+    returned.`,
+		func(t *testing.T) {
+			info, err := c.StatFile(ctx, fileId)
+			if err != nil {
+				t.Fatalf("error getting file info: %v", err)
+			}
+			if info.FileName != "digits.txt" || info.Size != 10 || info.ContentType != "text/plain" {
+				t.Errorf("got %+v", info)
+			}
+		},
+	)
+}
+
+func TestWaitForChanges(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/changes" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		changes := []map[string]interface{}{}
+		if query.Get("wait") == "" {
+			changes = append(changes, map[string]interface{}{"sequence": 8, "type": "created", "target_type": "file", "target_id": uuid.New()})
+		}
+		response.SuccessResponse(w, "changes retrieved successfully", map[string]interface{}{
+			"changes":  changes,
+			"cursor":   query.Get("cursor") + "-" + query.Get("wait"),
+			"has_more": false,
+		})
+	})
+
+	t.Run(`Given a wait, when changes are requested,
+    then it should be sent in whole seconds.`,
+		func(t *testing.T) {
+			page, err := c.WaitForChanges(context.Background(), "7", 10, 1500*time.Millisecond)
+			if err != nil {
+				t.Fatalf("error waiting for changes: %v", err)
+			}
+			if page.Cursor != "7-2" || len(page.Changes) != 0 {
+				t.Errorf("got page %+v", page)
+			}
+		},
+	)
+
+	t.Run(`Given no wait, when changes are requested,
+    then the request should not long-poll.`,
+		func(t *testing.T) {
+			page, err := c.GetChanges(context.Background(), "7", 10)
+			if err != nil {
+				t.Fatalf("error getting changes: %v", err)
+			}
+			if page.Cursor != "7-" || len(page.Changes) != 1 || page.Changes[0].Sequence != 8 {
+				t.Errorf("got page %+v", page)
+			}
+		},
+	)
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrBadRequest       = errors.New("bad request")
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrForbidden        = errors.New("forbidden")
+	ErrNotFound         = errors.New("not found")
+	ErrConflict         = errors.New("conflict")
+	ErrPayloadTooLarge  = errors.New("payload too large")
+	ErrTooManyRequests  = errors.New("too many requests")
+	ErrServerError      = errors.New("server error")
+	ErrUnexpectedStatus = errors.New("unexpected status")
+)
+
+// Error is an error response from the server. It unwraps to the sentinel
+// error of its status code, so that
+//
+//	errors.Is(err, client.ErrNotFound)
+//
+// reports whether the server answered 404, whatever its message was.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("file-fort: %s (%d)", e.Message, e.StatusCode)
+}
+
+func (e *Error) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrBadRequest
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case e.StatusCode == http.StatusRequestEntityTooLarge:
+		return ErrPayloadTooLarge
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrServerError
+	default:
+		return ErrUnexpectedStatus
+	}
+}
@@ -0,0 +1,252 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type UploadFileRequest struct {
+	// FolderId is the folder to upload into, the user's home folder when it
+	// is nil.
+	FolderId *uuid.UUID
+	FileName string
+	// Content is streamed to the server as it is read, so files of any size
+	// can be uploaded without holding them in memory. The upload is only
+	// retried when Content is also an io.Seeker.
+	Content io.Reader
+}
+
+// UploadFile uploads a file as a multipart form.
+func (c *Client) UploadFile(ctx context.Context, request UploadFileRequest) (File, error) {
+	if request.FileName == "" {
+		return File{}, fmt.Errorf("file name required")
+	}
+	if request.Content == nil {
+		return File{}, fmt.Errorf("file content required")
+	}
+
+	seeker, replayable := request.Content.(io.Seeker)
+	var start int64
+	if replayable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			replayable = false
+		}
+	}
+
+	attempt := 0
+	resp, err := c.send(ctx, func() (*http.Request, error) {
+		if attempt > 0 {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+		attempt++
+
+		body, writer := io.Pipe()
+		form := multipart.NewWriter(writer)
+		go func() {
+			writer.CloseWithError(writeUploadForm(form, request))
+		}()
+
+		req, err := c.newRequest(ctx, http.MethodPost, "/file", body)
+		if err != nil {
+			body.Close()
+			return nil, err
+		}
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("Accept", "application/json")
+		return req, nil
+	}, replayable)
+	if err != nil {
+		return File{}, err
+	}
+	defer resp.Body.Close()
+
+	var file File
+	err = decodeResponse(resp, &file)
+	return file, err
+}
+
+func writeUploadForm(form *multipart.Writer, request UploadFileRequest) error {
+	if request.FolderId != nil {
+		if err := form.WriteField("folder_id", request.FolderId.String()); err != nil {
+			return err
+		}
+	}
+	part, err := form.CreateFormFile("file", path.Base(request.FileName))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, request.Content); err != nil {
+		return err
+	}
+	return form.Close()
+}
+
+// ListFiles returns a page of the files in a folder. The home folder of a
+// user has the ID of the user.
+func (c *Client) ListFiles(ctx context.Context, folderId uuid.UUID, options ListFilesOptions) (FilePage, error) {
+	query := url.Values{}
+	if options.Cursor != "" {
+		query.Set("cursor", options.Cursor)
+	}
+	if options.Limit > 0 {
+		query.Set("limit", strconv.Itoa(options.Limit))
+	}
+	if options.Sort != "" {
+		query.Set("sort", options.Sort)
+	}
+	if options.Order != "" {
+		query.Set("order", options.Order)
+	}
+	for _, tag := range options.Tags {
+		query.Add("tag", tag)
+	}
+
+	route := "/folder/" + folderId.String() + "/files"
+	if len(query) > 0 {
+		route += "?" + query.Encode()
+	}
+	var page FilePage
+	err := c.doJSON(ctx, http.MethodGet, route, nil, &page)
+	return page, err
+}
+
+// ListAllFiles returns every file in a folder, following the cursor from page
+// to page. options.Cursor is where it starts.
+func (c *Client) ListAllFiles(ctx context.Context, folderId uuid.UUID, options ListFilesOptions) ([]File, error) {
+	files := []File{}
+	for {
+		page, err := c.ListFiles(ctx, folderId, options)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, page.Files...)
+		if page.NextCursor == "" {
+			return files, nil
+		}
+		options.Cursor = page.NextCursor
+	}
+}
+
+func (c *Client) CreateFolder(ctx context.Context, request CreateFolderRequest) (Folder, error) {
+	var folder Folder
+	err := c.doJSON(ctx, http.MethodPost, "/folder", request, &folder)
+	return folder, err
+}
+
+// GetDownloadUrl returns a link the file can be downloaded from without
+// authenticating, until it expires.
+func (c *Client) GetDownloadUrl(ctx context.Context, fileId uuid.UUID) (string, error) {
+	var data struct {
+		DownloadUrl string `json:"download_url"`
+	}
+	err := c.doJSON(ctx, http.MethodGet, "/file/"+fileId.String(), nil, &data)
+	return data.DownloadUrl, err
+}
+
+func (c *Client) DeleteFile(ctx context.Context, fileId uuid.UUID) error {
+	return c.doJSON(ctx, http.MethodDelete, "/file/"+fileId.String(), nil, nil)
+}
+
+// StatFile returns the name, type and size of the content of a file.
+func (c *Client) StatFile(ctx context.Context, fileId uuid.UUID) (FileInfo, error) {
+	resp, err := c.send(ctx, func() (*http.Request, error) {
+		return c.newRequest(ctx, http.MethodHead, "/file/"+fileId.String()+"/content", nil)
+	}, true)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	resp.Body.Close()
+	return toFileInfo(resp, fileId), nil
+}
+
+// DownloadFile streams the content of a file from offset onwards. A file's
+// content never changes once uploaded, so an interrupted download can be
+// carried on by asking for the rest of it.
+func (c *Client) DownloadFile(ctx context.Context, fileId uuid.UUID, offset int64) (Download, error) {
+	resp, err := c.send(ctx, func() (*http.Request, error) {
+		req, err := c.newRequest(ctx, http.MethodGet, "/file/"+fileId.String()+"/content", nil)
+		if err != nil {
+			return nil, err
+		}
+		if offset > 0 {
+			req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		}
+		return req, nil
+	}, true)
+	if err != nil {
+		return Download{}, err
+	}
+
+	download := Download{FileInfo: toFileInfo(resp, fileId), Body: resp.Body}
+	if resp.StatusCode == http.StatusPartialContent {
+		start, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok {
+			resp.Body.Close()
+			return Download{}, fmt.Errorf("invalid Content-Range %q", resp.Header.Get("Content-Range"))
+		}
+		download.Offset, download.Size = start, total
+	}
+	return download, nil
+}
+
+func toFileInfo(resp *http.Response, fileId uuid.UUID) FileInfo {
+	return FileInfo{
+		FileName:    attachmentName(resp.Header.Get("Content-Disposition"), fileId.String()),
+		ContentType: resp.Header.Get("Content-Type"),
+		Size:        resp.ContentLength,
+	}
+}
+
+// attachmentName returns the file name of a Content-Disposition header, or
+// fallback when it has none. Only the base name is kept, so it is safe to use
+// as a path.
+func attachmentName(header, fallback string) string {
+	_, params, err := mime.ParseMediaType(header)
+	if err != nil {
+		return fallback
+	}
+	name := path.Base(path.Clean("/" + strings.ReplaceAll(params["filename"], "\\", "/")))
+	if name == "/" || name == "." {
+		return fallback
+	}
+	return name
+}
+
+// parseContentRange parses a Content-Range header of the form
+// "bytes start-end/total".
+func parseContentRange(header string) (int64, int64, bool) {
+	rest, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, 0, false
+	}
+	span, size, ok := strings.Cut(rest, "/")
+	if !ok {
+		return 0, 0, false
+	}
+	first, _, ok := strings.Cut(span, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	total, err := strconv.ParseInt(size, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, total, true
+}
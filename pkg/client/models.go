@@ -0,0 +1,159 @@
+package client
+
+import (
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Response is the envelope every successful response is wrapped in.
+type Response[T any] struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Data    T      `json:"data"`
+}
+
+// ErrorResponse is the envelope every error response is wrapped in.
+type ErrorResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+type RegisterRequest struct {
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Password  string `json:"password"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type LoginResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+type User struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Role      string    `json:"role"`
+}
+
+type File struct {
+	ID          uuid.UUID `json:"id"`
+	FileName    string    `json:"file_name"`
+	FileSize    int64     `json:"file_size"`
+	ContentType string    `json:"content_type"`
+	// Checksum is the hex encoded SHA-256 of the content, or empty for files
+	// uploaded before the server recorded checksums.
+	Checksum  string            `json:"checksum"`
+	OwnerId   uuid.UUID         `json:"owner_id"`
+	FolderId  uuid.UUID         `json:"folder_id"`
+	Tags      []string          `json:"tags"`
+	Metadata  map[string]string `json:"metadata"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+type Folder struct {
+	ID         uuid.UUID         `json:"id"`
+	FolderName string            `json:"folder_name"`
+	OwnerId    uuid.UUID         `json:"owner_id"`
+	ParentId   *uuid.UUID        `json:"parent_id"`
+	Tags       []string          `json:"tags"`
+	Metadata   map[string]string `json:"metadata"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+type CreateFolderRequest struct {
+	FolderName string `json:"folder_name"`
+	// ParentId is the folder to create it in. The folder is created at the
+	// top level when it is nil.
+	ParentId *uuid.UUID `json:"parent_id,omitempty"`
+}
+
+// ListFilesOptions narrows and orders a page of files. The zero value asks
+// for the first page, in the server's default order.
+type ListFilesOptions struct {
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+	Limit  int
+	// Sort is one of name, size or created_at.
+	Sort string
+	// Order is asc or desc.
+	Order string
+	Tags  []string
+}
+
+type FilePage struct {
+	OwnerId  uuid.UUID `json:"owner_id"`
+	FolderId uuid.UUID `json:"folder_id"`
+	Files    []File    `json:"files"`
+	Total    int       `json:"total"`
+	// NextCursor is empty on the last page.
+	NextCursor string `json:"next_cursor"`
+	Limit      int    `json:"limit"`
+	Sort       string `json:"sort"`
+	Order      string `json:"order"`
+}
+
+// Change is an entry of the change feed: a file or folder of the user was
+// created, updated, moved or deleted. Data is the state of the target once
+// the change was made.
+type Change struct {
+	Sequence   int64                  `json:"sequence"`
+	Type       string                 `json:"type"`
+	TargetType string                 `json:"target_type"`
+	TargetId   uuid.UUID              `json:"target_id"`
+	Data       map[string]interface{} `json:"data"`
+	OccurredAt time.Time              `json:"occurred_at"`
+}
+
+type ChangePage struct {
+	Changes []Change `json:"changes"`
+	// Cursor is where the next request should carry on from.
+	Cursor  string `json:"cursor"`
+	HasMore bool   `json:"has_more"`
+}
+
+// TreeFolder is a folder of a FolderTree, with its path below the root.
+type TreeFolder struct {
+	Folder
+	Path string `json:"path"`
+}
+
+// TreeFile is a file of a FolderTree, with its path below the root.
+type TreeFile struct {
+	File
+	Path string `json:"path"`
+}
+
+// FolderTree is everything below a folder. Cursor is the position of the
+// change feed the tree was listed at, for GetChanges to carry on from.
+type FolderTree struct {
+	Folder  Folder       `json:"folder"`
+	Folders []TreeFolder `json:"folders"`
+	Files   []TreeFile   `json:"files"`
+	Cursor  string       `json:"cursor"`
+}
+
+// FileInfo describes the content of a file, as the server streams it.
+type FileInfo struct {
+	FileName    string
+	ContentType string
+	Size        int64
+}
+
+// Download is the content of a file, from Offset onwards. Body must be
+// closed.
+type Download struct {
+	FileInfo
+	Offset int64
+	Body   io.ReadCloser
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+func (c *Client) Register(ctx context.Context, request RegisterRequest) (User, error) {
+	var user User
+	err := c.doJSON(ctx, http.MethodPost, "/users", request, &user)
+	return user, err
+}
+
+// Login returns an access token for the user. It does not change the token
+// of the client; pass it to SetAccessToken for that.
+func (c *Client) Login(ctx context.Context, request LoginRequest) (LoginResponse, error) {
+	var login LoginResponse
+	err := c.doJSON(ctx, http.MethodPost, "/users/login", request, &login)
+	return login, err
+}
+
+// Me returns the user the client is logged in as. Its ID is also the ID of
+// the user's home folder.
+func (c *Client) Me(ctx context.Context) (User, error) {
+	var user User
+	err := c.doJSON(ctx, http.MethodGet, "/users/me", nil, &user)
+	return user, err
+}
@@ -0,0 +1,304 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: filefort/v1/folders.proto
+
+package filefortv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	FolderService_GetHomeFolder_FullMethodName      = "/filefort.v1.FolderService/GetHomeFolder"
+	FolderService_GetFolder_FullMethodName          = "/filefort.v1.FolderService/GetFolder"
+	FolderService_ListFolderContents_FullMethodName = "/filefort.v1.FolderService/ListFolderContents"
+	FolderService_CreateFolder_FullMethodName       = "/filefort.v1.FolderService/CreateFolder"
+	FolderService_MoveFolder_FullMethodName         = "/filefort.v1.FolderService/MoveFolder"
+	FolderService_DeleteFolder_FullMethodName       = "/filefort.v1.FolderService/DeleteFolder"
+)
+
+// FolderServiceClient is the client API for FolderService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type FolderServiceClient interface {
+	// GetHomeFolder returns the folder files uploaded without a folder go
+	// into. It is created on first use.
+	GetHomeFolder(ctx context.Context, in *GetHomeFolderRequest, opts ...grpc.CallOption) (*Folder, error)
+	GetFolder(ctx context.Context, in *GetFolderRequest, opts ...grpc.CallOption) (*Folder, error)
+	// ListFolderContents returns the folders and files directly inside a
+	// folder. The home folder also lists the top level folders.
+	ListFolderContents(ctx context.Context, in *ListFolderContentsRequest, opts ...grpc.CallOption) (*ListFolderContentsResponse, error)
+	CreateFolder(ctx context.Context, in *CreateFolderRequest, opts ...grpc.CallOption) (*Folder, error)
+	MoveFolder(ctx context.Context, in *MoveFolderRequest, opts ...grpc.CallOption) (*Folder, error)
+	// DeleteFolder deletes a folder along with everything in it.
+	DeleteFolder(ctx context.Context, in *DeleteFolderRequest, opts ...grpc.CallOption) (*DeleteFolderResponse, error)
+}
+
+type folderServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFolderServiceClient(cc grpc.ClientConnInterface) FolderServiceClient {
+	return &folderServiceClient{cc}
+}
+
+func (c *folderServiceClient) GetHomeFolder(ctx context.Context, in *GetHomeFolderRequest, opts ...grpc.CallOption) (*Folder, error) {
+	out := new(Folder)
+	err := c.cc.Invoke(ctx, FolderService_GetHomeFolder_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *folderServiceClient) GetFolder(ctx context.Context, in *GetFolderRequest, opts ...grpc.CallOption) (*Folder, error) {
+	out := new(Folder)
+	err := c.cc.Invoke(ctx, FolderService_GetFolder_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *folderServiceClient) ListFolderContents(ctx context.Context, in *ListFolderContentsRequest, opts ...grpc.CallOption) (*ListFolderContentsResponse, error) {
+	out := new(ListFolderContentsResponse)
+	err := c.cc.Invoke(ctx, FolderService_ListFolderContents_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *folderServiceClient) CreateFolder(ctx context.Context, in *CreateFolderRequest, opts ...grpc.CallOption) (*Folder, error) {
+	out := new(Folder)
+	err := c.cc.Invoke(ctx, FolderService_CreateFolder_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *folderServiceClient) MoveFolder(ctx context.Context, in *MoveFolderRequest, opts ...grpc.CallOption) (*Folder, error) {
+	out := new(Folder)
+	err := c.cc.Invoke(ctx, FolderService_MoveFolder_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *folderServiceClient) DeleteFolder(ctx context.Context, in *DeleteFolderRequest, opts ...grpc.CallOption) (*DeleteFolderResponse, error) {
+	out := new(DeleteFolderResponse)
+	err := c.cc.Invoke(ctx, FolderService_DeleteFolder_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FolderServiceServer is the server API for FolderService service.
+// All implementations must embed UnimplementedFolderServiceServer
+// for forward compatibility
+type FolderServiceServer interface {
+	// GetHomeFolder returns the folder files uploaded without a folder go
+	// into. It is created on first use.
+	GetHomeFolder(context.Context, *GetHomeFolderRequest) (*Folder, error)
+	GetFolder(context.Context, *GetFolderRequest) (*Folder, error)
+	// ListFolderContents returns the folders and files directly inside a
+	// folder. The home folder also lists the top level folders.
+	ListFolderContents(context.Context, *ListFolderContentsRequest) (*ListFolderContentsResponse, error)
+	CreateFolder(context.Context, *CreateFolderRequest) (*Folder, error)
+	MoveFolder(context.Context, *MoveFolderRequest) (*Folder, error)
+	// DeleteFolder deletes a folder along with everything in it.
+	DeleteFolder(context.Context, *DeleteFolderRequest) (*DeleteFolderResponse, error)
+	mustEmbedUnimplementedFolderServiceServer()
+}
+
+// UnimplementedFolderServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedFolderServiceServer struct {
+}
+
+func (UnimplementedFolderServiceServer) GetHomeFolder(context.Context, *GetHomeFolderRequest) (*Folder, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHomeFolder not implemented")
+}
+func (UnimplementedFolderServiceServer) GetFolder(context.Context, *GetFolderRequest) (*Folder, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFolder not implemented")
+}
+func (UnimplementedFolderServiceServer) ListFolderContents(context.Context, *ListFolderContentsRequest) (*ListFolderContentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFolderContents not implemented")
+}
+func (UnimplementedFolderServiceServer) CreateFolder(context.Context, *CreateFolderRequest) (*Folder, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateFolder not implemented")
+}
+func (UnimplementedFolderServiceServer) MoveFolder(context.Context, *MoveFolderRequest) (*Folder, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveFolder not implemented")
+}
+func (UnimplementedFolderServiceServer) DeleteFolder(context.Context, *DeleteFolderRequest) (*DeleteFolderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFolder not implemented")
+}
+func (UnimplementedFolderServiceServer) mustEmbedUnimplementedFolderServiceServer() {}
+
+// UnsafeFolderServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FolderServiceServer will
+// result in compilation errors.
+type UnsafeFolderServiceServer interface {
+	mustEmbedUnimplementedFolderServiceServer()
+}
+
+func RegisterFolderServiceServer(s grpc.ServiceRegistrar, srv FolderServiceServer) {
+	s.RegisterService(&FolderService_ServiceDesc, srv)
+}
+
+func _FolderService_GetHomeFolder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHomeFolderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FolderServiceServer).GetHomeFolder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FolderService_GetHomeFolder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FolderServiceServer).GetHomeFolder(ctx, req.(*GetHomeFolderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FolderService_GetFolder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFolderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FolderServiceServer).GetFolder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FolderService_GetFolder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FolderServiceServer).GetFolder(ctx, req.(*GetFolderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FolderService_ListFolderContents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFolderContentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FolderServiceServer).ListFolderContents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FolderService_ListFolderContents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FolderServiceServer).ListFolderContents(ctx, req.(*ListFolderContentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FolderService_CreateFolder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateFolderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FolderServiceServer).CreateFolder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FolderService_CreateFolder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FolderServiceServer).CreateFolder(ctx, req.(*CreateFolderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FolderService_MoveFolder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveFolderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FolderServiceServer).MoveFolder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FolderService_MoveFolder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FolderServiceServer).MoveFolder(ctx, req.(*MoveFolderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FolderService_DeleteFolder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteFolderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FolderServiceServer).DeleteFolder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FolderService_DeleteFolder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FolderServiceServer).DeleteFolder(ctx, req.(*DeleteFolderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FolderService_ServiceDesc is the grpc.ServiceDesc for FolderService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FolderService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "filefort.v1.FolderService",
+	HandlerType: (*FolderServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetHomeFolder",
+			Handler:    _FolderService_GetHomeFolder_Handler,
+		},
+		{
+			MethodName: "GetFolder",
+			Handler:    _FolderService_GetFolder_Handler,
+		},
+		{
+			MethodName: "ListFolderContents",
+			Handler:    _FolderService_ListFolderContents_Handler,
+		},
+		{
+			MethodName: "CreateFolder",
+			Handler:    _FolderService_CreateFolder_Handler,
+		},
+		{
+			MethodName: "MoveFolder",
+			Handler:    _FolderService_MoveFolder_Handler,
+		},
+		{
+			MethodName: "DeleteFolder",
+			Handler:    _FolderService_DeleteFolder_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "filefort/v1/folders.proto",
+}
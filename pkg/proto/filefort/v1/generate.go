@@ -0,0 +1,5 @@
+// Package filefortv1 holds the protobuf messages and gRPC services of the
+// gRPC API, and the clients of the services.
+package filefortv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative filefort/v1/users.proto filefort/v1/folders.proto filefort/v1/files.proto
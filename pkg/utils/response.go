@@ -32,3 +32,29 @@ func ErrorResponse(w http.ResponseWriter, message string, statusCode int) {
 		log.Printf("Error sending response: %v", err)
 	}
 }
+
+// FieldError is a single reason a request was rejected, In being where the
+// field was sent: the path, the query, a header or the body.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	In      string `json:"in"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorResponse is an ErrorResponse with an error per field, the
+// message being that of the first.
+func ValidationErrorResponse(w http.ResponseWriter, errors []FieldError) {
+	type ValidationErrorResponse struct {
+		Status  string       `json:"status"`
+		Message string       `json:"message"`
+		Errors  []FieldError `json:"errors"`
+	}
+	message := "invalid request"
+	if len(errors) > 0 {
+		message = errors[0].Message
+	}
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(ValidationErrorResponse{Status: "error", Message: message, Errors: errors}); err != nil {
+		log.Printf("Error sending response: %v", err)
+	}
+}
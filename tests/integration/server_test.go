@@ -4,47 +4,98 @@
 package integration
 
 import (
+	"archive/zip"
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	awsSDK "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/go-chi/chi/v5"
 
+	accessKeyHandlers "github.com/olad5/file-fort/internal/handlers/accesskeys"
+	activityHandlers "github.com/olad5/file-fort/internal/handlers/activity"
+	auditHandlers "github.com/olad5/file-fort/internal/handlers/audit"
 	fileHandlers "github.com/olad5/file-fort/internal/handlers/files"
+	graphqlHandlers "github.com/olad5/file-fort/internal/handlers/graphql"
+	grpcHandlers "github.com/olad5/file-fort/internal/handlers/grpc"
 	healthHandlers "github.com/olad5/file-fort/internal/handlers/health"
+	notificationHandlers "github.com/olad5/file-fort/internal/handlers/notifications"
+	openapiHandlers "github.com/olad5/file-fort/internal/handlers/openapi"
+	s3Handlers "github.com/olad5/file-fort/internal/handlers/s3"
+	searchHandlers "github.com/olad5/file-fort/internal/handlers/search"
 	userHandlers "github.com/olad5/file-fort/internal/handlers/users"
+	webdavHandlers "github.com/olad5/file-fort/internal/handlers/webdav"
+	webhookHandlers "github.com/olad5/file-fort/internal/handlers/webhooks"
+	accessKeyServices "github.com/olad5/file-fort/internal/usecases/accesskeys"
+	activityServices "github.com/olad5/file-fort/internal/usecases/activity"
+	auditServices "github.com/olad5/file-fort/internal/usecases/audit"
 	fileServices "github.com/olad5/file-fort/internal/usecases/files"
+	notificationServices "github.com/olad5/file-fort/internal/usecases/notifications"
+	searchServices "github.com/olad5/file-fort/internal/usecases/search"
+	webhookServices "github.com/olad5/file-fort/internal/usecases/webhooks"
 
+	"github.com/google/uuid"
 	"github.com/olad5/file-fort/config"
 	"github.com/olad5/file-fort/config/data"
 	"github.com/olad5/file-fort/internal/app/router"
+	"github.com/olad5/file-fort/internal/domain"
+	"github.com/olad5/file-fort/internal/infra"
 	"github.com/olad5/file-fort/internal/infra/aws"
+	"github.com/olad5/file-fort/internal/infra/memory"
 	"github.com/olad5/file-fort/internal/infra/postgres"
 	"github.com/olad5/file-fort/internal/infra/redis"
+	"github.com/olad5/file-fort/internal/services/audit"
 	"github.com/olad5/file-fort/internal/services/auth"
+	"github.com/olad5/file-fort/internal/services/events"
+	"github.com/olad5/file-fort/internal/services/extraction"
+	"github.com/olad5/file-fort/internal/services/reconciler"
+	"github.com/olad5/file-fort/internal/services/thumbnails"
+	"github.com/olad5/file-fort/internal/services/webhooks"
 	"github.com/olad5/file-fort/internal/usecases/users"
 	"github.com/olad5/file-fort/pkg/app/server"
+	filefortv1 "github.com/olad5/file-fort/pkg/proto/filefort/v1"
 	"github.com/olad5/file-fort/tests"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 var (
 	svr            *server.Server
+	grpcServer     *grpc.Server
 	userHandler    *userHandlers.UserHandler
 	configurations *config.Configurations
 	authService    auth.AuthService
+	webhookWorker  *webhooks.DeliveryWorker
+	webhookRepo    *postgres.PostgresWebhookRepository
+	outboxRelay    *events.OutboxRelay
+	eventBus       *memory.MemoryEventBus
+	fileStore      *aws.AwsFileStore
+	fileReconciler *reconciler.Reconciler
+	idempotent     func(consumer string, handler infra.EventHandler) infra.EventHandler
 )
 
 var (
@@ -84,7 +135,22 @@ func TestMain(m *testing.M) {
 		log.Fatal("Error Initializing Auth Service", err)
 	}
 
-	userService, err := users.NewUserService(userRepo, authService)
+	quotaRepo, err := postgres.NewPostgresQuotaRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Quota Repo", err)
+	}
+
+	auditRepo, err := postgres.NewPostgresAuditRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Audit Repo", err)
+	}
+
+	auditLogger, err := audit.NewRepositoryAuditLogger(ctx, auditRepo)
+	if err != nil {
+		log.Fatal("Error Initializing Audit Logger", err)
+	}
+
+	userService, err := users.NewUserService(userRepo, quotaRepo, authService, auditLogger)
 	if err != nil {
 		log.Fatal("Error dnitializing UserService")
 	}
@@ -108,12 +174,48 @@ func TestMain(m *testing.M) {
 		log.Fatal("Error creating s3 bucket for test", err)
 	}
 
-	fileStore, err := aws.NewAwsFileStore(ctx, configurations)
+	fileStore, err = aws.NewAwsFileStore(ctx, configurations)
 	if err != nil {
 		log.Fatal("Error Initializing AWS File store\n", err)
 	}
 
-	filesService, err := fileServices.NewFileService(fileRepo, folderRepo, fileStore)
+	webhookRepo, err = postgres.NewPostgresWebhookRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Webhook Repo", err)
+	}
+
+	transactor, err := postgres.NewPostgresTransactor(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Transactor", err)
+	}
+
+	outboxRepo, err := postgres.NewPostgresOutboxRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Outbox Repo", err)
+	}
+
+	inboxRepo, err := postgres.NewPostgresInboxRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Inbox Repo", err)
+	}
+
+	thumbnailRepo, err := postgres.NewPostgresThumbnailRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Thumbnail Repo", err)
+	}
+
+	activityRepo, err := postgres.NewPostgresActivityRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Activity Repo", err)
+	}
+
+	changeRepo, err := postgres.NewPostgresChangeRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Change Repo", err)
+	}
+
+	notificationBroker := memory.NewMemoryNotificationBroker()
+	filesService, err := fileServices.NewFileService(fileRepo, folderRepo, quotaRepo, fileStore, transactor, auditLogger, outboxRepo, thumbnailRepo, activityRepo, changeRepo, notificationBroker)
 	if err != nil {
 		log.Fatal("Error Initializing UserService")
 	}
@@ -128,9 +230,170 @@ func TestMain(m *testing.M) {
 		log.Fatal("failed to create the healthHandler: ", err)
 	}
 
-	appRouter := router.NewHttpRouter(*userHandler, *fileHandler, *healthHandler, authService)
+	auditService, err := auditServices.NewAuditService(auditRepo)
+	if err != nil {
+		log.Fatal("Error Initializing AuditService")
+	}
+
+	auditHandler, err := auditHandlers.NewAuditHandler(*auditService)
+	if err != nil {
+		log.Fatal("failed to create the auditHandler: ", err)
+	}
+
+	allowPrivateWebhookHosts := configurations.WebhookAllowPrivateHosts == "true"
+	webhookService, err := webhookServices.NewWebhookService(webhookRepo, allowPrivateWebhookHosts)
+	if err != nil {
+		log.Fatal("Error Initializing WebhookService")
+	}
+
+	webhookHandler, err := webhookHandlers.NewWebhookHandler(*webhookService)
+	if err != nil {
+		log.Fatal("failed to create the webhookHandler: ", err)
+	}
+
+	webhookWorker, err = webhooks.NewDeliveryWorker(ctx, webhookRepo, allowPrivateWebhookHosts)
+	if err != nil {
+		log.Fatal("Error Initializing Webhook Delivery Worker", err)
+	}
+
+	eventBus = memory.NewMemoryEventBus()
+	idempotent = func(consumer string, handler infra.EventHandler) infra.EventHandler {
+		return events.Idempotent(consumer, inboxRepo, handler)
+	}
+	webhookDispatcher, err := webhooks.NewRepositoryDispatcher(ctx, webhookRepo)
+	if err != nil {
+		log.Fatal("Error Initializing Webhook Dispatcher", err)
+	}
+
+	err = eventBus.Subscribe(ctx, "webhooks", events.IdempotentInTransaction("webhooks", inboxRepo, transactor, webhookDispatcher.HandleEvent))
+	if err != nil {
+		log.Fatal("Error Subscribing Webhook Dispatcher", err)
+	}
+
+	fileContentRepo, err := postgres.NewPostgresFileContentRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing File Content Repo", err)
+	}
+
+	contentIndexer, err := extraction.NewContentIndexer(ctx, fileRepo, fileStore, fileContentRepo)
+	if err != nil {
+		log.Fatal("Error Initializing Content Indexer", err)
+	}
+
+	err = eventBus.Subscribe(ctx, "content-indexer", events.Idempotent("content-indexer", inboxRepo, contentIndexer.HandleEvent))
+	if err != nil {
+		log.Fatal("Error Subscribing Content Indexer", err)
+	}
+
+	thumbnailGenerator, err := thumbnails.NewGenerator(ctx, fileRepo, thumbnailRepo, fileStore)
+	if err != nil {
+		log.Fatal("Error Initializing Thumbnail Generator", err)
+	}
+
+	err = eventBus.Subscribe(ctx, "thumbnails", events.Idempotent("thumbnails", inboxRepo, thumbnailGenerator.HandleEvent))
+	if err != nil {
+		log.Fatal("Error Subscribing Thumbnail Generator", err)
+	}
+
+	err = eventBus.Subscribe(ctx, "notifications", events.Idempotent("notifications", inboxRepo, notificationBroker.Notify))
+	if err != nil {
+		log.Fatal("Error Subscribing Notification Broker", err)
+	}
+
+	outboxRelay, err = events.NewOutboxRelay(ctx, outboxRepo, eventBus)
+	if err != nil {
+		log.Fatal("Error Initializing Outbox Relay", err)
+	}
+
+	fileReconciler, err = reconciler.NewReconciler(ctx, fileRepo, quotaRepo, outboxRepo, changeRepo, fileStore, transactor)
+	if err != nil {
+		log.Fatal("Error Initializing Reconciler", err)
+	}
+
+	searchRepo, err := postgres.NewPostgresSearchRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Search Repo", err)
+	}
+
+	searchService, err := searchServices.NewSearchService(searchRepo, folderRepo)
+	if err != nil {
+		log.Fatal("Error Initializing SearchService")
+	}
+
+	searchHandler, err := searchHandlers.NewSearchHandler(*searchService)
+	if err != nil {
+		log.Fatal("failed to create the searchHandler: ", err)
+	}
+
+	starRepo, err := postgres.NewPostgresStarRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Star Repo", err)
+	}
+
+	activityService, err := activityServices.NewActivityService(starRepo, activityRepo, fileRepo, folderRepo)
+	if err != nil {
+		log.Fatal("Error Initializing ActivityService")
+	}
+
+	activityHandler, err := activityHandlers.NewActivityHandler(*activityService)
+	if err != nil {
+		log.Fatal("failed to create the activityHandler: ", err)
+	}
+
+	webdavHandler, err := webdavHandlers.NewWebDAVHandler(*filesService)
+	if err != nil {
+		log.Fatal("failed to create the webdavHandler: ", err)
+	}
+
+	accessKeyRepo, err := postgres.NewPostgresAccessKeyRepo(ctx, postgresConnection)
+	if err != nil {
+		log.Fatal("Error Initializing Access Key Repo", err)
+	}
+
+	accessKeyService, err := accessKeyServices.NewAccessKeyService(accessKeyRepo, userRepo, auditLogger)
+	if err != nil {
+		log.Fatal("Error Initializing AccessKeyService")
+	}
+
+	accessKeyHandler, err := accessKeyHandlers.NewAccessKeyHandler(*accessKeyService)
+	if err != nil {
+		log.Fatal("failed to create the accessKeyHandler: ", err)
+	}
+
+	s3Handler, err := s3Handlers.NewS3Handler(*filesService, *accessKeyService)
+	if err != nil {
+		log.Fatal("failed to create the s3Handler: ", err)
+	}
+
+	notificationService, err := notificationServices.NewNotificationService(notificationBroker)
+	if err != nil {
+		log.Fatal("Error Initializing NotificationService")
+	}
+
+	notificationHandler, err := notificationHandlers.NewNotificationHandler(*notificationService)
+	if err != nil {
+		log.Fatal("failed to create the notificationHandler: ", err)
+	}
+
+	graphqlHandler, err := graphqlHandlers.NewGraphQLHandler(*filesService, *userService)
+	if err != nil {
+		log.Fatal("failed to create the graphqlHandler: ", err)
+	}
+
+	openapiHandler, err := openapiHandlers.NewOpenAPIHandler(ctx)
+	if err != nil {
+		log.Fatal("failed to create the openapiHandler: ", err)
+	}
+
+	appRouter := router.NewHttpRouter(*userHandler, *fileHandler, *healthHandler, *auditHandler, *webhookHandler, *searchHandler, *activityHandler, *webdavHandler, *accessKeyHandler, *s3Handler, *notificationHandler, *graphqlHandler, *openapiHandler, authService, userService)
 	svr = server.CreateNewServer(appRouter)
 
+	grpcHandler, err := grpcHandlers.NewGRPCHandler(*filesService, *userService)
+	if err != nil {
+		log.Fatal("failed to create the grpcHandler: ", err)
+	}
+	grpcServer = router.NewGrpcServer(*grpcHandler, authService)
+
 	exitVal := m.Run()
 	os.Exit(exitVal)
 }
@@ -601,104 +864,2792 @@ func TestMarkFileAsUnsafe(t *testing.T) {
 	)
 }
 
-func createFolder(t testing.TB, folderName, accessToken string) string {
-	t.Helper()
-	route := "/folder"
-
-	requestBody := []byte(fmt.Sprintf(`{
-      "folder_name": "%s"
-      }`, folderName))
-	req, _ := http.NewRequest(http.MethodPost, route, bytes.NewBuffer(requestBody))
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	response := tests.ExecuteRequest(req, svr)
-	responseBody := tests.ParseResponse(t, response)
-	data := responseBody["data"].(map[string]interface{})
-	folderId := data["id"].(string)
-	return folderId
-}
+func TestStorageQuota(t *testing.T) {
+	t.Run(`Given an authenticated user,
+      When they upload a file and request their storage usage,
+      Then the usage should include the size of the uploaded file.
+      `,
+		func(t *testing.T) {
+			email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
 
-func uploadFile(t testing.TB, fileSize int64, fileName, folderId, accessToken string) string {
-	t.Helper()
-	route := "/file"
-	fieldName := "file"
+			_ = createUser(t, "mike", "smith", email, password)
+			token := logUserIn(t, email, password)
+			_ = uploadFile(t, int64(1024), "someFile", "", token)
 
-	tempFile, fileCleanUp := openImageFile(t, fileName, fileSize)
+			req, _ := http.NewRequest(http.MethodGet, "/users/me/usage", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			if data["used_bytes"].(float64) <= 0 {
+				t.Errorf("got used_bytes: %v expected a positive value", data["used_bytes"])
+			}
+		},
+	)
 
-	defer fileCleanUp()
-	var requestBody bytes.Buffer
-	writer := multipart.NewWriter(&requestBody)
+	t.Run(`Given an admin has set a user's quota to zero,
+      When the user uploads a file,
+      Then the API should return a 413 response
+      And the storage usage should be unchanged.
+      `,
+		func(t *testing.T) {
+			email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
 
-	createFormFile(t, writer, tempFile, fieldName)
-	createFormField(t, writer, "folder_id", folderId)
-	writer.Close()
+			userId := createUser(t, "mike", "smith", email, password)
+			adminToken := logUserIn(t, adminEmail, adminPassword)
 
-	req, _ := http.NewRequest(http.MethodPost, route, &requestBody)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+			requestBody := []byte(`{"quota_bytes": 0}`)
+			req, _ := http.NewRequest(http.MethodPut, "/users/"+userId+"/quota", bytes.NewBuffer(requestBody))
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
 
-	req.Header.Set("Authorization", "Bearer "+accessToken)
+			token := logUserIn(t, email, password)
+			tempFile, fileCleanUp := openImageFile(t, "someFile", int64(1024))
+			defer fileCleanUp()
 
-	response := ExecuteRequestMultiPart(req, svr)
-	responseBody := tests.ParseResponse(t, response)
-	data := responseBody["data"].(map[string]interface{})
-	fileId := data["id"].(string)
+			var uploadBody bytes.Buffer
+			writer := multipart.NewWriter(&uploadBody)
+			createFormFile(t, writer, tempFile, "file")
+			writer.Close()
 
-	return fileId
+			req, _ = http.NewRequest(http.MethodPost, "/file", &uploadBody)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			req.Header.Set("Authorization", "Bearer "+token)
+			response = ExecuteRequestMultiPart(req, svr)
+			tests.AssertStatusCode(t, http.StatusRequestEntityTooLarge, response.Code)
+			message := tests.ParseResponse(t, response)["message"].(string)
+			tests.AssertResponseMessage(t, message, "storage quota exceeded")
+		},
+	)
 }
 
-func createUser(t testing.TB, firstName, lastName, email, password string) string {
-	t.Helper()
-	route := "/users"
-	requestBody := []byte(fmt.Sprintf(`{
-      "first_name": "%s",
-      "last_name": "%s",
-      "email": "%s",
-      "password": "%s"
-      }`, firstName, lastName, email, password))
-	req, _ := http.NewRequest(http.MethodPost, route, bytes.NewBuffer(requestBody))
-	response := tests.ExecuteRequest(req, svr)
-	data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
-	userId := data["id"].(string)
+func TestDeleteFile(t *testing.T) {
+	t.Run(`Given an authenticated user who owns a file,
+      When they delete the file,
+      Then the file should no longer be downloadable.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
 
-	return userId
-}
+			req, _ := http.NewRequest(http.MethodDelete, "/file/"+fileId, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			message := tests.ParseResponse(t, response)["message"].(string)
+			tests.AssertResponseMessage(t, message, "file deleted successfully")
 
-func getCurrentUser(t testing.TB, token string) map[string]interface{} {
-	t.Helper()
-	req, _ := http.NewRequest(http.MethodGet, "/users/me", nil)
-	req.Header.Set("Authorization", "Bearer "+token)
-	response := tests.ExecuteRequest(req, svr)
-	responseBody := tests.ParseResponse(t, response)
-	data := responseBody["data"].(map[string]interface{})
-	return data
+			_, err := getFileDownloadUrl(t, token, fileId)
+			if err == nil {
+				t.Errorf("expected file to be deleted")
+			}
+		},
+	)
 }
 
-func getFileDownloadUrl(t testing.TB, token, fileId string) (string, error) {
-	t.Helper()
-	route := "/file"
-	req, _ := http.NewRequest(http.MethodGet, route+"/"+fileId, nil)
-	req.Header.Set("Authorization", "Bearer "+token)
-	response := tests.ExecuteRequest(req, svr)
-	responseBody := tests.ParseResponse(t, response)
-	message := responseBody["message"].(string)
-	if message == "file does not exist" {
-		return "", fmt.Errorf("file does not exist")
-	}
-	data := responseBody["data"].(map[string]interface{})
+func TestAuditEvents(t *testing.T) {
+	t.Run(`Given a user has uploaded a file,
+      When an admin queries the audit log filtered by the file id,
+      Then the upload event should be returned with the user as actor.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			userId := getCurrentUser(t, token)["id"].(string)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
 
-	return data["download_url"].(string), nil
-}
+			adminToken := logUserIn(t, adminEmail, adminPassword)
+			req, _ := http.NewRequest(http.MethodGet, "/audit-events?target_type=file&target_id="+fileId, nil)
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			events := data["events"].([]interface{})
+			if len(events) != 1 {
+				t.Fatalf("got events length: %d expected: %d", len(events), 1)
+			}
+			event := events[0].(map[string]interface{})
+			tests.AssertResponseMessage(t, event["action"].(string), "file.uploaded")
+			tests.AssertResponseMessage(t, event["actor_id"].(string), userId)
+		},
+	)
 
-func logUserIn(t testing.TB, email, password string) string {
-	requestBody := []byte(fmt.Sprintf(`{
-      "email": "%s",
-      "password": "%s"
-      }`, email, password))
-	loginReq, _ := http.NewRequest(http.MethodPost, "/users/login", bytes.NewBuffer(requestBody))
-	loginResponse := tests.ExecuteRequest(loginReq, svr)
-	loginResponseBody := tests.ParseResponse(t, loginResponse)
-	loginData := loginResponseBody["data"].(map[string]interface{})
-	accessToken := loginData["access_token"]
+	t.Run(`Given a user is authenticated and is not an admin,
+      When they query the audit log,
+      Then they should get an unauthorized error message
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			req, _ := http.NewRequest(http.MethodGet, "/audit-events", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusUnauthorized, response.Code)
+		},
+	)
+
+	t.Run(`Given an admin requests an NDJSON export of the audit log,
+      Then each line of the response should be a JSON object.
+      `,
+		func(t *testing.T) {
+			adminToken := logUserIn(t, adminEmail, adminPassword)
+			req, _ := http.NewRequest(http.MethodGet, "/audit-events?format=ndjson", nil)
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			tests.AssertResponseMessage(t, response.Header().Get("Content-Type"), "application/x-ndjson")
+			for _, line := range strings.Split(strings.TrimSpace(response.Body.String()), "\n") {
+				if !strings.HasPrefix(line, "{") {
+					t.Errorf("got line: %q expected a JSON object", line)
+				}
+			}
+		},
+	)
+}
+
+func TestWebhooks(t *testing.T) {
+	t.Run(`Given a user has registered a webhook for file.uploaded,
+      When they upload a file and pending deliveries are sent,
+      Then the endpoint should receive the event signed with the webhook secret
+      And the delivery log should record the successful delivery.
+      `,
+		func(t *testing.T) {
+			email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
+			_ = createUser(t, "mike", "smith", email, password)
+			token := logUserIn(t, email, password)
+
+			received := make(chan *http.Request, 1)
+			receivedBody := make(chan []byte, 1)
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				received <- r
+				receivedBody <- body
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer receiver.Close()
+
+			requestBody := []byte(fmt.Sprintf(`{
+      "url": "%s",
+      "events": ["file.uploaded"]
+      }`, receiver.URL))
+			req, _ := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewBuffer(requestBody))
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			webhookId := data["id"].(string)
+			secret := data["secret"].(string)
+
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+
+			if err := outboxRelay.RelayPending(context.Background()); err != nil {
+				t.Fatalf("error relaying outbox events: %v", err)
+			}
+			if err := webhookWorker.DeliverDue(context.Background()); err != nil {
+				t.Fatalf("error delivering webhooks: %v", err)
+			}
+
+			request := <-received
+			body := <-receivedBody
+			tests.AssertResponseMessage(t, request.Header.Get(webhooks.EventHeader), "file.uploaded")
+			expectedSignature := "sha256=" + webhooks.Sign(secret, request.Header.Get(webhooks.TimestampHeader), body)
+			tests.AssertResponseMessage(t, request.Header.Get(webhooks.SignatureHeader), expectedSignature)
+			if !strings.Contains(string(body), fileId) {
+				t.Errorf("expected payload %s to contain file id %s", body, fileId)
+			}
+
+			req, _ = http.NewRequest(http.MethodGet, "/webhooks/"+webhookId+"/deliveries", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response = tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			data = tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			deliveries := data["deliveries"].([]interface{})
+			if len(deliveries) != 1 {
+				t.Fatalf("got deliveries length: %d expected: %d", len(deliveries), 1)
+			}
+			delivery := deliveries[0].(map[string]interface{})
+			tests.AssertResponseMessage(t, delivery["status"].(string), "succeeded")
+
+			req, _ = http.NewRequest(http.MethodPost, "/webhooks/"+webhookId+"/deliveries/"+delivery["id"].(string)+"/redeliver", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response = tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			data = tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			tests.AssertResponseMessage(t, data["status"].(string), "pending")
+		},
+	)
+
+	t.Run(`Given webhooks may not reach private hosts,
+      When a user registers a webhook for a loopback, link-local or private address,
+      Then the webhook should be rejected.
+      `,
+		func(t *testing.T) {
+			webhookService, err := webhookServices.NewWebhookService(webhookRepo, false)
+			if err != nil {
+				t.Fatalf("error creating webhook service: %v", err)
+			}
+			ctx := auth.Set(context.Background(), auth.JWTClaims{ID: uuid.New()})
+
+			for _, webhookUrl := range []string{"http://127.0.0.1:8080/hook", "http://localhost/hook", "http://169.254.169.254/latest/meta-data", "https://10.0.0.5/hook", "http://[::1]/hook"} {
+				_, err := webhookService.CreateWebhook(ctx, webhookUrl, []domain.EventType{domain.EventFileUploaded})
+				if !errors.Is(err, webhookServices.ErrPrivateWebhookUrl) {
+					t.Errorf("expected %s to be rejected, got %v", webhookUrl, err)
+				}
+			}
+		},
+	)
+
+	t.Run(`Given a webhook was registered for a host that now resolves to a private address,
+      When its pending deliveries are sent by a worker that does not allow private hosts,
+      Then the endpoint should not be called
+      And the delivery should record the error.
+      `,
+		func(t *testing.T) {
+			email := "webhookuser" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
+			_ = createUser(t, "webhook", "user", email, password)
+			token := logUserIn(t, email, password)
+
+			called := make(chan struct{}, 1)
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called <- struct{}{}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer receiver.Close()
+
+			requestBody := []byte(fmt.Sprintf(`{"url": "%s", "events": ["file.uploaded"]}`, receiver.URL))
+			req, _ := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewBuffer(requestBody))
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			webhookId := tests.ParseResponse(t, response)["data"].(map[string]interface{})["id"].(string)
+
+			_ = uploadFile(t, int64(1024), "someFile", "", token)
+			if err := outboxRelay.RelayPending(context.Background()); err != nil {
+				t.Fatalf("error relaying outbox events: %v", err)
+			}
+
+			strictWorker, err := webhooks.NewDeliveryWorker(context.Background(), webhookRepo, false)
+			if err != nil {
+				t.Fatalf("error creating delivery worker: %v", err)
+			}
+			if err := strictWorker.DeliverDue(context.Background()); err != nil {
+				t.Fatalf("error delivering webhooks: %v", err)
+			}
+
+			select {
+			case <-called:
+				t.Fatal("expected the private endpoint not to be called")
+			default:
+			}
+
+			req, _ = http.NewRequest(http.MethodGet, "/webhooks/"+webhookId+"/deliveries", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response = tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			deliveries := tests.ParseResponse(t, response)["data"].(map[string]interface{})["deliveries"].([]interface{})
+			if len(deliveries) != 1 {
+				t.Fatalf("got deliveries length: %d expected: %d", len(deliveries), 1)
+			}
+			delivery := deliveries[0].(map[string]interface{})
+			tests.AssertResponseMessage(t, delivery["status"].(string), "pending")
+			if !strings.Contains(delivery["last_error"].(string), webhooks.ErrPrivateAddress.Error()) {
+				t.Errorf("expected the delivery error to mention the private address, got %q", delivery["last_error"])
+			}
+		},
+	)
+
+	t.Run(`Given an authenticated user,
+      When they register a webhook for an unsupported event,
+      Then the API should return a validation error.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			requestBody := []byte(`{
+      "url": "https://example.com/hook",
+      "events": ["file.exploded"]
+      }`)
+			req, _ := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewBuffer(requestBody))
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusBadRequest, response.Code)
+		},
+	)
+}
+
+func TestOutbox(t *testing.T) {
+	t.Run(`Given a consumer subscribed to the event bus,
+      When a user creates a folder and the outbox is relayed,
+      Then the consumer should receive a folder.created event for the folder
+      And publishing the same event again should not reprocess it.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+
+			received := []domain.Event{}
+			consumer := "test-consumer-" + fmt.Sprint(tests.GenerateUniqueId())
+			handler := idempotent(consumer, func(ctx context.Context, event domain.Event) error {
+				received = append(received, event)
+				return nil
+			})
+			if err := eventBus.Subscribe(context.Background(), consumer, handler); err != nil {
+				t.Fatalf("error subscribing: %v", err)
+			}
+
+			folderId := createFolder(t, "some-new-folder", token)
+			if err := outboxRelay.RelayPending(context.Background()); err != nil {
+				t.Fatalf("error relaying outbox events: %v", err)
+			}
+
+			var folderEvent *domain.Event
+			for i := range received {
+				if received[i].Type == domain.EventFolderCreated && received[i].Data["id"] == folderId {
+					folderEvent = &received[i]
+				}
+			}
+			if folderEvent == nil {
+				t.Fatalf("expected a folder.created event for folder %s", folderId)
+			}
+
+			receivedCount := len(received)
+			if err := eventBus.Publish(context.Background(), *folderEvent); err != nil {
+				t.Fatalf("error publishing: %v", err)
+			}
+			if len(received) != receivedCount {
+				t.Errorf("got %d events after redelivery expected: %d", len(received), receivedCount)
+			}
+		},
+	)
+
+	t.Run(`Given an idempotent consumer whose handler fails the first time,
+      When the same event is delivered three times,
+      Then the handler should run again after the failure
+      And not after it has succeeded.
+      `,
+		func(t *testing.T) {
+			calls := 0
+			consumer := "test-consumer-" + fmt.Sprint(tests.GenerateUniqueId())
+			handler := idempotent(consumer, func(ctx context.Context, event domain.Event) error {
+				calls++
+				if calls == 1 {
+					return errors.New("temporary failure")
+				}
+				return nil
+			})
+
+			event := domain.NewEvent(domain.EventFolderCreated, uuid.New(), map[string]interface{}{})
+			if err := handler(context.Background(), event); err == nil {
+				t.Fatalf("expected the first delivery to fail")
+			}
+			if err := handler(context.Background(), event); err != nil {
+				t.Fatalf("expected the second delivery to succeed, got %v", err)
+			}
+			if err := handler(context.Background(), event); err != nil {
+				t.Fatalf("expected the third delivery to succeed, got %v", err)
+			}
+			if calls != 2 {
+				t.Errorf("got %d handler calls expected: %d", calls, 2)
+			}
+		},
+	)
+}
+
+func TestReconciler(t *testing.T) {
+	t.Run(`Given a file whose object has been removed from the file store,
+      When the reconciler runs with missing files marked,
+      Then the file should be reported as missing
+      And downloading it should return a not found error.
+      `,
+		func(t *testing.T) {
+			email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
+			_ = createUser(t, "mike", "smith", email, password)
+			token := logUserIn(t, email, password)
+
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+			req, _ := http.NewRequest(http.MethodGet, "/folder/"+getCurrentUser(t, token)["id"].(string)+"/files?page=1&rows=20", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			fileStoreKey := data["files"].([]interface{})[0].(map[string]interface{})["file_store_link"].(string)
+
+			if err := fileStore.DeleteFile(context.Background(), fileStoreKey); err != nil {
+				t.Fatalf("error deleting object: %v", err)
+			}
+
+			report, err := fileReconciler.Reconcile(context.Background(), reconciler.Options{MarkMissing: true})
+			if err != nil {
+				t.Fatalf("error reconciling: %v", err)
+			}
+
+			found := false
+			for _, file := range report.Missing {
+				if file.ID.String() == fileId {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected file %s to be reported missing", fileId)
+			}
+
+			_, err = getFileDownloadUrl(t, token, fileId)
+			if err == nil {
+				t.Errorf("expected missing file to not be downloadable")
+			}
+
+			response = tests.ExecuteRequest(req, svr)
+			data = tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			if files := data["files"].([]interface{}); len(files) != 0 {
+				t.Errorf("expected missing file to not be listed, got %d files", len(files))
+			}
+			if total := data["total"].(float64); total != 0 {
+				t.Errorf("expected missing file to not be counted, got total %v", total)
+			}
+		},
+	)
+
+	t.Run(`Given a file marked as missing,
+      When its object reappears in the file store and the reconciler runs again,
+      Then the file should no longer be missing
+      And downloading it should succeed.
+      `,
+		func(t *testing.T) {
+			email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
+			_ = createUser(t, "mike", "smith", email, password)
+			token := logUserIn(t, email, password)
+
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+			req, _ := http.NewRequest(http.MethodGet, "/folder/"+getCurrentUser(t, token)["id"].(string)+"/files?page=1&rows=20", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			fileStoreKey := data["files"].([]interface{})[0].(map[string]interface{})["file_store_link"].(string)
+
+			if err := fileStore.DeleteFile(context.Background(), fileStoreKey); err != nil {
+				t.Fatalf("error deleting object: %v", err)
+			}
+			if _, err := fileReconciler.Reconcile(context.Background(), reconciler.Options{MarkMissing: true}); err != nil {
+				t.Fatalf("error reconciling: %v", err)
+			}
+
+			content := bytes.NewReader(make([]byte, 1024))
+			if err := fileStore.SaveToFileStoreWithKey(context.Background(), fileStoreKey, "application/octet-stream", content); err != nil {
+				t.Fatalf("error restoring object: %v", err)
+			}
+			report, err := fileReconciler.Reconcile(context.Background(), reconciler.Options{MarkMissing: true})
+			if err != nil {
+				t.Fatalf("error reconciling: %v", err)
+			}
+
+			for _, file := range report.Missing {
+				if file.ID.String() == fileId {
+					t.Errorf("expected file %s to no longer be reported missing", fileId)
+				}
+			}
+			if report.RestoredMissing < 1 {
+				t.Errorf("expected at least one restored file, got %d", report.RestoredMissing)
+			}
+
+			if _, err := getFileDownloadUrl(t, token, fileId); err != nil {
+				t.Errorf("expected restored file to be downloadable, got %v", err)
+			}
+		},
+	)
+
+	t.Run(`Given a file that has been marked as unsafe,
+      When the reconciler runs,
+      Then the file should not be reported as missing.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+
+			adminToken := logUserIn(t, adminEmail, adminPassword)
+			req, _ := http.NewRequest(http.MethodPost, "/file"+"/"+fileId+"/mark-unsafe", nil)
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+
+			report, err := fileReconciler.Reconcile(context.Background(), reconciler.Options{})
+			if err != nil {
+				t.Fatalf("error reconciling: %v", err)
+			}
+			for _, file := range report.Missing {
+				if file.ID.String() == fileId {
+					t.Errorf("expected unsafe file %s to not be reported missing", fileId)
+				}
+			}
+		},
+	)
+
+	t.Run(`Given two files marked as missing,
+      When the owner deletes one and batch-deletes the other,
+      Then the storage usage should only have dropped once for each of them.
+      `,
+		func(t *testing.T) {
+			email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
+			_ = createUser(t, "mike", "smith", email, password)
+			token := logUserIn(t, email, password)
+
+			deletedId := uploadFile(t, int64(1024), "someFile", "", token)
+			batchDeletedId := uploadFile(t, int64(1024), "someFile", "", token)
+			_ = uploadFile(t, int64(1024), "someFile", "", token)
+
+			usedBytes := func() float64 {
+				t.Helper()
+				req, _ := http.NewRequest(http.MethodGet, "/users/me/usage", nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				response := tests.ExecuteRequest(req, svr)
+				tests.AssertStatusCode(t, http.StatusOK, response.Code)
+				return tests.ParseResponse(t, response)["data"].(map[string]interface{})["used_bytes"].(float64)
+			}
+			storedBytes := usedBytes()
+
+			req, _ := http.NewRequest(http.MethodGet, "/folder/"+getCurrentUser(t, token)["id"].(string)+"/files?page=1&rows=20", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			listed := tests.ParseResponse(t, response)["data"].(map[string]interface{})["files"].([]interface{})
+			var missingBytes float64
+			for _, element := range listed {
+				file := element.(map[string]interface{})
+				if file["id"] != deletedId && file["id"] != batchDeletedId {
+					continue
+				}
+				missingBytes += file["file_size"].(float64)
+				if err := fileStore.DeleteFile(context.Background(), file["file_store_link"].(string)); err != nil {
+					t.Fatalf("error deleting object: %v", err)
+				}
+			}
+			if _, err := fileReconciler.Reconcile(context.Background(), reconciler.Options{MarkMissing: true}); err != nil {
+				t.Fatalf("error reconciling: %v", err)
+			}
+			if got, expected := usedBytes(), storedBytes-missingBytes; got != expected {
+				t.Fatalf("got used_bytes: %v after marking files missing expected: %v", got, expected)
+			}
+
+			req, _ = http.NewRequest(http.MethodDelete, "/file/"+deletedId, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response = tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+
+			req, _ = http.NewRequest(http.MethodPost, "/files/batch", bytes.NewBufferString(fmt.Sprintf(`{"operations": [{"op": "delete", "file_id": "%s"}]}`, batchDeletedId)))
+			req.Header.Set("Authorization", "Bearer "+token)
+			response = tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			result := tests.ParseResponse(t, response)["data"].(map[string]interface{})["results"].([]interface{})[0].(map[string]interface{})
+			if int(result["status"].(float64)) != http.StatusOK {
+				t.Fatalf("expected the batch delete to succeed, got %v", result["message"])
+			}
+
+			if got, expected := usedBytes(), storedBytes-missingBytes; got != expected {
+				t.Errorf("got used_bytes: %v after deleting the missing files expected: %v", got, expected)
+			}
+		},
+	)
+
+	t.Run(`Given a file whose object has been removed from the file store,
+      When two reconciler passes mark missing files at the same time,
+      Then the storage usage should only drop once for the file.
+      `,
+		func(t *testing.T) {
+			email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
+			_ = createUser(t, "mike", "smith", email, password)
+			token := logUserIn(t, email, password)
+			_ = uploadFile(t, int64(1024), "someFile", "", token)
+
+			usedBytes := func() float64 {
+				t.Helper()
+				req, _ := http.NewRequest(http.MethodGet, "/users/me/usage", nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				response := tests.ExecuteRequest(req, svr)
+				tests.AssertStatusCode(t, http.StatusOK, response.Code)
+				return tests.ParseResponse(t, response)["data"].(map[string]interface{})["used_bytes"].(float64)
+			}
+			storedBytes := usedBytes()
+
+			req, _ := http.NewRequest(http.MethodGet, "/folder/"+getCurrentUser(t, token)["id"].(string)+"/files?page=1&rows=20", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			file := tests.ParseResponse(t, response)["data"].(map[string]interface{})["files"].([]interface{})[0].(map[string]interface{})
+			if err := fileStore.DeleteFile(context.Background(), file["file_store_link"].(string)); err != nil {
+				t.Fatalf("error deleting object: %v", err)
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := fileReconciler.Reconcile(context.Background(), reconciler.Options{MarkMissing: true}); err != nil {
+						t.Errorf("error reconciling: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got, expected := usedBytes(), storedBytes-file["file_size"].(float64); got != expected {
+				t.Errorf("got used_bytes: %v expected: %v", got, expected)
+			}
+		},
+	)
+}
+
+func TestSearch(t *testing.T) {
+	search := func(t testing.TB, token, query string) (int, map[string]interface{}) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "/search?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		response := tests.ExecuteRequest(req, svr)
+		return response.Code, tests.ParseResponse(t, response)
+	}
+	resultIds := func(body map[string]interface{}) []string {
+		ids := []string{}
+		for _, result := range body["data"].(map[string]interface{})["results"].([]interface{}) {
+			ids = append(ids, result.(map[string]interface{})["id"].(string))
+		}
+		return ids
+	}
+	contains := func(ids []string, id string) bool {
+		for _, element := range ids {
+			if element == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run(`Given a user with a file and a folder,
+      When they search by name,
+      Then only their matching file or folder should be returned.
+      `,
+		func(t *testing.T) {
+			email := "searchuser" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
+			_ = createUser(t, "search", "user", email, password)
+			token := logUserIn(t, email, password)
+
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+			folderId := createFolder(t, "Holiday Photos", token)
+
+			code, body := search(t, token, "q=wall")
+			tests.AssertStatusCode(t, http.StatusOK, code)
+			ids := resultIds(body)
+			if len(ids) != 1 || ids[0] != fileId {
+				t.Errorf("expected only file %s, got %v", fileId, ids)
+			}
+
+			code, body = search(t, token, "q=holiday")
+			tests.AssertStatusCode(t, http.StatusOK, code)
+			ids = resultIds(body)
+			if len(ids) != 1 || ids[0] != folderId {
+				t.Errorf("expected only folder %s, got %v", folderId, ids)
+			}
+
+			otherToken := logUserIn(t, userEmail, userPassword)
+			_, body = search(t, otherToken, "q=wall&rows=50")
+			if contains(resultIds(body), fileId) {
+				t.Errorf("expected another user's file to be excluded from results")
+			}
+		},
+	)
+
+	t.Run(`Given a user with files in a nested folder,
+      When they search with mime type and folder filters,
+      Then only files matching the filters should be returned.
+      `,
+		func(t *testing.T) {
+			email := "searchuser" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
+			_ = createUser(t, "search", "user", email, password)
+			token := logUserIn(t, email, password)
+
+			parentId := createFolder(t, "parent", token)
+			requestBody := []byte(fmt.Sprintf(`{"folder_name": "child", "parent_id": "%s"}`, parentId))
+			req, _ := http.NewRequest(http.MethodPost, "/folder", bytes.NewBuffer(requestBody))
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			childId := tests.ParseResponse(t, response)["data"].(map[string]interface{})["id"].(string)
+
+			nestedFileId := uploadFile(t, int64(1024), "someFile", childId, token)
+			homeFileId := uploadFile(t, int64(1024), "someFile", "", token)
+
+			_, body := search(t, token, "q=wall&folder_id="+parentId)
+			ids := resultIds(body)
+			if len(ids) != 1 || ids[0] != nestedFileId {
+				t.Errorf("expected only nested file %s, got %v", nestedFileId, ids)
+			}
+
+			_, body = search(t, token, "q=wall&mime=image/*")
+			ids = resultIds(body)
+			if !contains(ids, nestedFileId) || !contains(ids, homeFileId) {
+				t.Errorf("expected both images to match, got %v", ids)
+			}
+
+			_, body = search(t, token, "q=wall&mime=application/pdf")
+			if ids := resultIds(body); len(ids) != 0 {
+				t.Errorf("expected no pdf results, got %v", ids)
+			}
+		},
+	)
+
+	t.Run(`Given an authenticated user,
+      When they search without a query,
+      Then it should return a 400 error.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			code, body := search(t, token, "q=")
+			tests.AssertStatusCode(t, http.StatusBadRequest, code)
+			tests.AssertResponseMessage(t, body["message"].(string), "q or tag required")
+		},
+	)
+}
+
+func TestContentSearch(t *testing.T) {
+	t.Run(`Given a user has uploaded a text document,
+      When the upload event has been processed and they search for a word in the document,
+      Then the document should be returned with a highlighted snippet.
+      `,
+		func(t *testing.T) {
+			email := "contentsearch" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
+			_ = createUser(t, "content", "search", email, password)
+			token := logUserIn(t, email, password)
+
+			fileId := uploadTextFile(t, "notes.txt", "Plans for the lighthouse restoration are due in spring.", token)
+			if err := outboxRelay.RelayPending(context.Background()); err != nil {
+				t.Fatalf("error relaying outbox events: %v", err)
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, "/search?q=lighthouse", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+
+			data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			results := data["results"].([]interface{})
+			if len(results) != 1 {
+				t.Fatalf("expected 1 result, got %d", len(results))
+			}
+			result := results[0].(map[string]interface{})
+			if result["id"].(string) != fileId {
+				t.Errorf("expected file %s, got %s", fileId, result["id"])
+			}
+			if !strings.Contains(result["snippet"].(string), "<mark>lighthouse</mark>") {
+				t.Errorf("expected highlighted snippet, got %q", result["snippet"])
+			}
+		},
+	)
+}
+
+func TestFolderListingPagination(t *testing.T) {
+	listFiles := func(t testing.TB, token, folderId, query string) (int, map[string]interface{}) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "/folder/"+folderId+"/files?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		response := tests.ExecuteRequest(req, svr)
+		return response.Code, tests.ParseResponse(t, response)
+	}
+
+	t.Run(`Given a user with an empty folder,
+      When they list the folder,
+      Then it should return no files and no next cursor.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			folderId := createFolder(t, "empty-folder", token)
+
+			code, body := listFiles(t, token, folderId, "")
+			tests.AssertStatusCode(t, http.StatusOK, code)
+			data := body["data"].(map[string]interface{})
+			if files := data["files"].([]interface{}); len(files) != 0 {
+				t.Errorf("expected no files, got %d", len(files))
+			}
+			if data["total"].(float64) != 0 {
+				t.Errorf("expected total of 0, got %v", data["total"])
+			}
+			if data["next_cursor"] != nil {
+				t.Errorf("expected no next cursor, got %v", data["next_cursor"])
+			}
+		},
+	)
+
+	t.Run(`Given a user with more files in a folder than fit on a page,
+      When they follow the next cursor,
+      Then every file should be returned exactly once.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			folderId := createFolder(t, "paged-folder", token)
+			numberOfFiles := 3
+			for i := 0; i < numberOfFiles; i++ {
+				uploadFile(t, int64(1024), "someFile", folderId, token)
+			}
+
+			seen := map[string]bool{}
+			query := "sort=name&order=desc&limit=2"
+			for pages := 0; pages < numberOfFiles; pages++ {
+				code, body := listFiles(t, token, folderId, query)
+				tests.AssertStatusCode(t, http.StatusOK, code)
+				data := body["data"].(map[string]interface{})
+				if data["total"].(float64) != float64(numberOfFiles) {
+					t.Errorf("expected total of %d, got %v", numberOfFiles, data["total"])
+				}
+				for _, file := range data["files"].([]interface{}) {
+					id := file.(map[string]interface{})["id"].(string)
+					if seen[id] {
+						t.Errorf("file %s returned more than once", id)
+					}
+					seen[id] = true
+				}
+				if data["next_cursor"] == nil {
+					break
+				}
+				query = "sort=name&order=desc&limit=2&cursor=" + data["next_cursor"].(string)
+			}
+			if len(seen) != numberOfFiles {
+				t.Errorf("expected %d files, got %d", numberOfFiles, len(seen))
+			}
+		},
+	)
+
+	t.Run(`Given an authenticated user,
+      When they list a folder with an invalid cursor,
+      Then it should return a 400 error.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			folderId := createFolder(t, "cursor-folder", token)
+
+			code, body := listFiles(t, token, folderId, "cursor=not-a-cursor")
+			tests.AssertStatusCode(t, http.StatusBadRequest, code)
+			tests.AssertResponseMessage(t, body["message"].(string), "invalid cursor")
+		},
+	)
+}
+
+func TestThumbnails(t *testing.T) {
+	getThumbnail := func(t testing.TB, token, fileId, size string) (int, map[string]interface{}) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "/file/"+fileId+"/thumbnail?size="+size, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		response := tests.ExecuteRequest(req, svr)
+		return response.Code, tests.ParseResponse(t, response)
+	}
+
+	t.Run(`Given a user has uploaded an image,
+      When the upload event has been processed and they request a thumbnail,
+      Then a thumbnail url should be returned for every size.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+			if err := outboxRelay.RelayPending(context.Background()); err != nil {
+				t.Fatalf("error relaying outbox events: %v", err)
+			}
+
+			for _, size := range domain.ThumbnailSizes {
+				code, body := getThumbnail(t, token, fileId, string(size))
+				tests.AssertStatusCode(t, http.StatusOK, code)
+				data := body["data"].(map[string]interface{})
+				if data["thumbnail_url"].(string) == "" {
+					t.Errorf("expected a thumbnail url for size %s", size)
+				}
+			}
+		},
+	)
+
+	t.Run(`Given a user has uploaded a file that is not an image,
+      When they request a thumbnail,
+      Then it should return a 404 error.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			fileId := uploadTextFile(t, "notes.txt", "no pictures here", token)
+			if err := outboxRelay.RelayPending(context.Background()); err != nil {
+				t.Fatalf("error relaying outbox events: %v", err)
+			}
+
+			code, body := getThumbnail(t, token, fileId, "small")
+			tests.AssertStatusCode(t, http.StatusNotFound, code)
+			tests.AssertResponseMessage(t, body["message"].(string), "thumbnail is not available for this file")
+		},
+	)
+
+	t.Run(`Given a user has an image with thumbnails,
+      When they delete the image,
+      Then its thumbnails should be removed from the file store.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+			if err := outboxRelay.RelayPending(context.Background()); err != nil {
+				t.Fatalf("error relaying outbox events: %v", err)
+			}
+
+			req, _ := http.NewRequest(http.MethodDelete, "/file/"+fileId, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			if err := outboxRelay.RelayPending(context.Background()); err != nil {
+				t.Fatalf("error relaying outbox events: %v", err)
+			}
+
+			objects, err := fileStore.ListObjects(context.Background())
+			if err != nil {
+				t.Fatalf("error listing objects: %v", err)
+			}
+			for _, object := range objects {
+				if id, ok := domain.ThumbnailFileId(object.Key); ok && id.String() == fileId {
+					t.Errorf("expected thumbnail %s to be deleted", object.Key)
+				}
+			}
+		},
+	)
+
+	t.Run(`Given an authenticated user,
+      When they request a thumbnail of an unknown size,
+      Then it should return a 400 error.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			code, _ := getThumbnail(t, token, uuid.NewString(), "huge")
+			tests.AssertStatusCode(t, http.StatusBadRequest, code)
+		},
+	)
+}
+
+func TestFileContent(t *testing.T) {
+	getContent := func(t testing.TB, token, fileId string, headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "/file/"+fileId+"/content", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		return tests.ExecuteRequest(req, svr)
+	}
+
+	original, err := os.ReadFile("../data/wall.jpg")
+	if err != nil {
+		t.Fatal("Error reading file:", err)
+	}
+
+	t.Run(`Given a user has uploaded a file,
+      When they request its content,
+      Then the whole file should be streamed with its content type and name.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+
+			response := getContent(t, token, fileId, nil)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			if !bytes.Equal(response.Body.Bytes(), original) {
+				t.Errorf("expected streamed content to match the uploaded file")
+			}
+			tests.AssertResponseMessage(t, response.Header().Get("Content-Type"), "image/jpeg")
+			tests.AssertResponseMessage(t, response.Header().Get("Content-Disposition"), `attachment; filename=wall.jpg`)
+			if response.Header().Get("ETag") == "" {
+				t.Errorf("expected an ETag header")
+			}
+		},
+	)
+
+	t.Run(`Given a user has uploaded a file,
+      When they request a byte range of its content,
+      Then only that range should be returned with a 206 status.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+
+			response := getContent(t, token, fileId, map[string]string{"Range": "bytes=10-109"})
+			tests.AssertStatusCode(t, http.StatusPartialContent, response.Code)
+			if !bytes.Equal(response.Body.Bytes(), original[10:110]) {
+				t.Errorf("expected bytes 10-109 of the uploaded file")
+			}
+			tests.AssertResponseMessage(t, response.Header().Get("Content-Range"), fmt.Sprintf("bytes 10-109/%d", len(original)))
+
+			response = getContent(t, token, fileId, map[string]string{"Range": fmt.Sprintf("bytes=%d-", len(original))})
+			tests.AssertStatusCode(t, http.StatusRequestedRangeNotSatisfiable, response.Code)
+		},
+	)
+
+	t.Run(`Given a client has the current version of a file,
+      When it requests the content with the file's ETag in If-None-Match,
+      Then it should receive a 304 status without a body.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+
+			etag := getContent(t, token, fileId, nil).Header().Get("ETag")
+			response := getContent(t, token, fileId, map[string]string{"If-None-Match": etag})
+			tests.AssertStatusCode(t, http.StatusNotModified, response.Code)
+			if response.Body.Len() != 0 {
+				t.Errorf("expected an empty body, got %d bytes", response.Body.Len())
+			}
+		},
+	)
+}
+
+func TestFolderArchive(t *testing.T) {
+	getArchive := func(t testing.TB, token, folderId string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "/folder/"+folderId+"/archive", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return tests.ExecuteRequest(req, svr)
+	}
+
+	original, err := os.ReadFile("../data/wall.jpg")
+	if err != nil {
+		t.Fatal("Error reading file:", err)
+	}
+
+	t.Run(`Given a user has a folder with files and a subfolder,
+      When they download the folder as an archive,
+      Then the zip should contain every file under its folder path.
+      `,
+		func(t *testing.T) {
+			email := "archiveuser" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
+			_ = createUser(t, "archive", "user", email, password)
+			token := logUserIn(t, email, password)
+
+			parentId := createFolder(t, "photos", token)
+			requestBody := []byte(fmt.Sprintf(`{"folder_name": "child", "parent_id": "%s"}`, parentId))
+			req, _ := http.NewRequest(http.MethodPost, "/folder", bytes.NewBuffer(requestBody))
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			childId := tests.ParseResponse(t, response)["data"].(map[string]interface{})["id"].(string)
+
+			_ = uploadFile(t, int64(1024), "someFile", parentId, token)
+			_ = uploadFile(t, int64(1024), "someFile", parentId, token)
+			_ = uploadFile(t, int64(1024), "someFile", childId, token)
+
+			response = getArchive(t, token, parentId)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			tests.AssertResponseMessage(t, response.Header().Get("Content-Type"), "application/zip")
+			tests.AssertResponseMessage(t, response.Header().Get("Content-Disposition"), `attachment; filename=photos.zip`)
+
+			body := response.Body.Bytes()
+			archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+			if err != nil {
+				t.Fatal("Error reading archive:", err)
+			}
+			names := map[string]bool{}
+			for _, entry := range archive.File {
+				names[entry.Name] = true
+				content, err := entry.Open()
+				if err != nil {
+					t.Fatal("Error opening archive entry:", err)
+				}
+				data, err := io.ReadAll(content)
+				content.Close()
+				if err != nil {
+					t.Fatal("Error reading archive entry:", err)
+				}
+				if !bytes.Equal(data, original) {
+					t.Errorf("expected %s to match the uploaded file", entry.Name)
+				}
+			}
+			for _, name := range []string{"wall.jpg", "wall (1).jpg", "child/wall.jpg"} {
+				if !names[name] {
+					t.Errorf("expected archive to contain %s, got %v", name, names)
+				}
+			}
+			if len(names) != 3 {
+				t.Errorf("expected 3 archive entries, got %v", names)
+			}
+		},
+	)
+
+	t.Run(`Given a folder belongs to another user,
+      When a user tries to download it as an archive,
+      Then they should receive a 403 status.
+      `,
+		func(t *testing.T) {
+			email := "archiveowner" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
+			_ = createUser(t, "archive", "owner", email, password)
+			folderId := createFolder(t, "private", logUserIn(t, email, password))
+
+			response := getArchive(t, logUserIn(t, userEmail, userPassword), folderId)
+			tests.AssertStatusCode(t, http.StatusForbidden, response.Code)
+			tests.AssertResponseMessage(t, tests.ParseResponse(t, response)["message"].(string), "unauthorized to view this folder")
+		},
+	)
+
+	t.Run(`Given a folder does not exist,
+      When a user tries to download it as an archive,
+      Then they should receive a 404 status.
+      `,
+		func(t *testing.T) {
+			response := getArchive(t, logUserIn(t, userEmail, userPassword), uuid.NewString())
+			tests.AssertStatusCode(t, http.StatusNotFound, response.Code)
+		},
+	)
+}
+
+func TestBatchOperations(t *testing.T) {
+	runBatch := func(t testing.TB, token, requestBody string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, "/files/batch", bytes.NewBufferString(requestBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		return tests.ExecuteRequest(req, svr)
+	}
+
+	t.Run(`Given a user has several files,
+      When they move, copy and delete them in one batch,
+      Then each operation should report its own result.
+      `,
+		func(t *testing.T) {
+			email := "batchuser" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
+			_ = createUser(t, "batch", "user", email, password)
+			token := logUserIn(t, email, password)
+
+			otherToken := logUserIn(t, userEmail, userPassword)
+			otherFileId := uploadFile(t, int64(1024), "someFile", "", otherToken)
+
+			folderId := createFolder(t, "archive", token)
+			movedId := uploadFile(t, int64(1024), "someFile", "", token)
+			copiedId := uploadFile(t, int64(1024), "someFile", "", token)
+			deletedId := uploadFile(t, int64(1024), "someFile", "", token)
+
+			response := runBatch(t, token, fmt.Sprintf(`{"operations": [
+        {"op": "move", "file_id": "%[1]s", "folder_id": "%[5]s"},
+        {"op": "copy", "file_id": "%[2]s", "folder_id": "%[5]s"},
+        {"op": "delete", "file_id": "%[3]s"},
+        {"op": "delete", "file_id": "%[4]s"},
+        {"op": "rename", "file_id": "%[1]s"}
+      ]}`, movedId, copiedId, deletedId, otherFileId, folderId))
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+
+			data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			results := data["results"].([]interface{})
+			expectedStatuses := []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusForbidden, http.StatusBadRequest}
+			for i, expected := range expectedStatuses {
+				result := results[i].(map[string]interface{})
+				if int(result["status"].(float64)) != expected {
+					t.Errorf("expected operation %d to have status %d, got %v: %v", i, expected, result["status"], result["message"])
+				}
+			}
+			if data["succeeded"].(float64) != 3 || data["failed"].(float64) != 2 {
+				t.Errorf("expected 3 succeeded and 2 failed operations, got %v and %v", data["succeeded"], data["failed"])
+			}
+
+			copiedFile := results[1].(map[string]interface{})["file"].(map[string]interface{})
+			if copiedFile["id"] == copiedId || copiedFile["folder_id"] != folderId {
+				t.Errorf("expected a new file in folder %s, got %v", folderId, copiedFile)
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, "/folder/"+folderId+"/files", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response = tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			folderFiles := tests.ParseResponse(t, response)["data"].(map[string]interface{})["files"].([]interface{})
+			if len(folderFiles) != 2 {
+				t.Errorf("expected the moved file and the copy in the folder, got %d files", len(folderFiles))
+			}
+
+			_, err := getFileDownloadUrl(t, token, deletedId)
+			if err == nil {
+				t.Errorf("expected deleted file %s to be gone", deletedId)
+			}
+			if _, err := getFileDownloadUrl(t, token, copiedId); err != nil {
+				t.Errorf("expected the source of the copy to remain: %v", err)
+			}
+		},
+	)
+
+	t.Run(`Given a file is in a folder,
+      When it is moved into the same folder in a batch,
+      Then the operation should have a 400 status.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			folderId := createFolder(t, "unchanged", token)
+			fileId := uploadFile(t, int64(1024), "someFile", folderId, token)
+
+			response := runBatch(t, token, fmt.Sprintf(`{"operations": [{"op": "move", "file_id": "%s", "folder_id": "%s"}]}`, fileId, folderId))
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+
+			result := tests.ParseResponse(t, response)["data"].(map[string]interface{})["results"].([]interface{})[0].(map[string]interface{})
+			if int(result["status"].(float64)) != http.StatusBadRequest {
+				t.Fatalf("expected status %d, got %v: %v", http.StatusBadRequest, result["status"], result["message"])
+			}
+			tests.AssertResponseMessage(t, result["message"].(string), "file is already in this folder")
+		},
+	)
+
+	t.Run(`Given a file has been tagged,
+      When it is tagged again in a batch,
+      Then it should keep its earlier tags along with the new ones.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+
+			var tags []interface{}
+			for _, tag := range []string{"invoices", "paid"} {
+				response := runBatch(t, token, fmt.Sprintf(`{"operations": [{"op": "tag", "file_id": "%s", "tags": ["%s"]}]}`, fileId, tag))
+				tests.AssertStatusCode(t, http.StatusOK, response.Code)
+				result := tests.ParseResponse(t, response)["data"].(map[string]interface{})["results"].([]interface{})[0].(map[string]interface{})
+				if int(result["status"].(float64)) != http.StatusOK {
+					t.Fatalf("expected tag operation to succeed, got %v", result["message"])
+				}
+				tags = result["file"].(map[string]interface{})["tags"].([]interface{})
+			}
+			if len(tags) != 2 || tags[0] != "invoices" || tags[1] != "paid" {
+				t.Errorf("expected tags [invoices paid], got %v", tags)
+			}
+		},
+	)
+
+	t.Run(`Given a user sends an empty batch,
+      When the batch is processed,
+      Then they should receive a 400 status.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			response := runBatch(t, token, `{"operations": []}`)
+			tests.AssertStatusCode(t, http.StatusBadRequest, response.Code)
+			tests.AssertResponseMessage(t, tests.ParseResponse(t, response)["message"].(string), "at least one operation required")
+		},
+	)
+}
+
+func TestCopyFile(t *testing.T) {
+	copyFile := func(t testing.TB, token, fileId, requestBody string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, "/file/"+fileId+"/copy", bytes.NewBufferString(requestBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		return tests.ExecuteRequest(req, svr)
+	}
+	getContent := func(t testing.TB, token, fileId string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "/file/"+fileId+"/content", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return tests.ExecuteRequest(req, svr)
+	}
+
+	original, err := os.ReadFile("../data/wall.jpg")
+	if err != nil {
+		t.Fatal("Error reading file:", err)
+	}
+
+	t.Run(`Given a user has uploaded a file,
+      When they copy it into another folder and delete the original,
+      Then the copy should be a new file that still has the original content.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+			folderId := createFolder(t, "copies", token)
+
+			response := copyFile(t, token, fileId, fmt.Sprintf(`{"folder_id": "%s"}`, folderId))
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			copyId := data["id"].(string)
+			if copyId == fileId || data["folder_id"] != folderId || data["file_name"] != "wall.jpg" {
+				t.Errorf("expected a new file named wall.jpg in folder %s, got %v", folderId, data)
+			}
+
+			req, _ := http.NewRequest(http.MethodDelete, "/file/"+fileId, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			tests.AssertStatusCode(t, http.StatusOK, tests.ExecuteRequest(req, svr).Code)
+
+			response = getContent(t, token, copyId)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			if !bytes.Equal(response.Body.Bytes(), original) {
+				t.Errorf("expected the copy to keep the original content")
+			}
+		},
+	)
+
+	t.Run(`Given two files are uploaded with the same name,
+      When they are stored,
+      Then each should get its own file store key.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			folderId := createFolder(t, "duplicates", token)
+			_ = uploadFile(t, int64(1024), "someFile", folderId, token)
+			_ = uploadFile(t, int64(1024), "someFile", folderId, token)
+
+			req, _ := http.NewRequest(http.MethodGet, "/folder/"+folderId+"/files", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			files := tests.ParseResponse(t, response)["data"].(map[string]interface{})["files"].([]interface{})
+			if len(files) != 2 {
+				t.Fatalf("expected 2 files, got %d", len(files))
+			}
+			first := files[0].(map[string]interface{})["file_store_link"]
+			second := files[1].(map[string]interface{})["file_store_link"]
+			if first == second {
+				t.Errorf("expected distinct file store keys, both were %v", first)
+			}
+		},
+	)
+
+	t.Run(`Given a file belongs to another user,
+      When a user tries to copy it,
+      Then they should receive a 403 status.
+      `,
+		func(t *testing.T) {
+			email := "copyowner" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			password := "some-password"
+			_ = createUser(t, "copy", "owner", email, password)
+			fileId := uploadFile(t, int64(1024), "someFile", "", logUserIn(t, email, password))
+
+			response := copyFile(t, logUserIn(t, userEmail, userPassword), fileId, "")
+			tests.AssertStatusCode(t, http.StatusForbidden, response.Code)
+			tests.AssertResponseMessage(t, tests.ParseResponse(t, response)["message"].(string), "unauthorized to copy this file")
+		},
+	)
+}
+
+func TestLabels(t *testing.T) {
+	sendLabels := func(t testing.TB, method, route, token, requestBody string) *httptest.ResponseRecorder {
+		t.Helper()
+		var body io.Reader
+		if requestBody != "" {
+			body = bytes.NewBufferString(requestBody)
+		}
+		req, _ := http.NewRequest(method, route, body)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return tests.ExecuteRequest(req, svr)
+	}
+	responseTags := func(data map[string]interface{}) []string {
+		tags := []string{}
+		for _, tag := range data["tags"].([]interface{}) {
+			tags = append(tags, tag.(string))
+		}
+		return tags
+	}
+	newUser := func(t testing.TB) string {
+		t.Helper()
+		email := "labelsuser" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+		password := "some-password"
+		_ = createUser(t, "labels", "user", email, password)
+		return logUserIn(t, email, password)
+	}
+
+	t.Run(`Given a user has uploaded a file,
+      When they replace and then patch its tags and metadata,
+      Then the file should carry the normalized result.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+			route := "/file/" + fileId + "/labels"
+
+			response := sendLabels(t, http.MethodPut, route, token, `{"tags": ["Invoices", " 2024 "], "metadata": {"project": "apollo"}}`)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+
+			response = sendLabels(t, http.MethodGet, route, token, "")
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			tests.AssertResponseMessage(t, strings.Join(responseTags(data), ","), "2024,invoices")
+			tests.AssertResponseMessage(t, data["metadata"].(map[string]interface{})["project"].(string), "apollo")
+
+			response = sendLabels(t, http.MethodPatch, route, token,
+				`{"add_tags": ["paid"], "remove_tags": ["2024"], "metadata": {"project": null, "team": "finance"}}`)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			data = tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			tests.AssertResponseMessage(t, strings.Join(responseTags(data), ","), "invoices,paid")
+			metadata := data["metadata"].(map[string]interface{})
+			if len(metadata) != 1 || metadata["team"] != "finance" {
+				t.Errorf("expected only the team metadata entry, got %v", metadata)
+			}
+		},
+	)
+
+	t.Run(`Given a user has tagged one of two files in a folder,
+      When they list the folder or search by the tag,
+      Then only the tagged file should be returned.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			folderId := createFolder(t, "receipts", token)
+			taggedId := uploadFile(t, int64(1024), "someFile", folderId, token)
+			_ = uploadFile(t, int64(1024), "someFile", folderId, token)
+
+			response := sendLabels(t, http.MethodPost, "/files/batch", token,
+				fmt.Sprintf(`{"operations": [{"op": "tag", "file_id": "%s", "tags": ["Reimbursed"]}]}`, taggedId))
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			result := tests.ParseResponse(t, response)["data"].(map[string]interface{})["results"].([]interface{})[0].(map[string]interface{})
+			if result["status"].(float64) != http.StatusOK {
+				t.Fatalf("expected tag operation to succeed, got %v", result["message"])
+			}
+
+			response = sendLabels(t, http.MethodGet, "/folder/"+folderId+"/files?tag=reimbursed", token, "")
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			listed := data["files"].([]interface{})
+			if len(listed) != 1 || listed[0].(map[string]interface{})["id"] != taggedId || data["total"].(float64) != 1 {
+				t.Errorf("expected only file %s in the listing, got %v", taggedId, data)
+			}
+
+			response = sendLabels(t, http.MethodGet, "/search?tag=reimbursed", token, "")
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			results := tests.ParseResponse(t, response)["data"].(map[string]interface{})["results"].([]interface{})
+			if len(results) != 1 || results[0].(map[string]interface{})["id"] != taggedId {
+				t.Errorf("expected only file %s in the search results, got %v", taggedId, results)
+			}
+		},
+	)
+
+	t.Run(`Given a user labels a file,
+      When the tags are invalid or too many,
+      Then they should receive a 400 status.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+			route := "/file/" + fileId + "/labels"
+
+			response := sendLabels(t, http.MethodPut, route, token, `{"tags": ["a/b"]}`)
+			tests.AssertStatusCode(t, http.StatusBadRequest, response.Code)
+
+			tags := []string{}
+			for i := 0; i <= domain.MaxTags; i++ {
+				tags = append(tags, fmt.Sprintf(`"tag-%d"`, i))
+			}
+			response = sendLabels(t, http.MethodPut, route, token, `{"tags": [`+strings.Join(tags, ",")+`]}`)
+			tests.AssertStatusCode(t, http.StatusBadRequest, response.Code)
+		},
+	)
+
+	t.Run(`Given a folder belongs to another user,
+      When a user tries to label it,
+      Then they should receive a 403 status.
+      `,
+		func(t *testing.T) {
+			folderId := createFolder(t, "private", newUser(t))
+
+			response := sendLabels(t, http.MethodPut, "/folder/"+folderId+"/labels", newUser(t), `{"tags": ["mine"]}`)
+			tests.AssertStatusCode(t, http.StatusForbidden, response.Code)
+			tests.AssertResponseMessage(t, tests.ParseResponse(t, response)["message"].(string), "unauthorized to update this folder")
+		},
+	)
+
+	t.Run(`Given a user has uploaded a file,
+      When they add different tags to it concurrently,
+      Then the file should carry every one of the tags.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+			route := "/file/" + fileId + "/labels"
+
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					sendLabels(t, http.MethodPatch, route, token, fmt.Sprintf(`{"add_tags": ["tag-%d"]}`, i))
+				}(i)
+			}
+			wg.Wait()
+
+			response := sendLabels(t, http.MethodGet, route, token, "")
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			tests.AssertResponseMessage(t, strings.Join(responseTags(data), ","), "tag-0,tag-1,tag-2,tag-3,tag-4")
+		},
+	)
+}
+
+func TestStarredAndRecent(t *testing.T) {
+	sendRequest := func(t testing.TB, method, path, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return tests.ExecuteRequest(req, svr)
+	}
+	newUser := func(t testing.TB) string {
+		t.Helper()
+		email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+		password := "some-random-password"
+		_ = createUser(t, "mike", "smith", email, password)
+		return logUserIn(t, email, password)
+	}
+
+	t.Run(`Given a user has starred a file and a folder,
+      When they list their starred items and then unstar the file,
+      Then both should be listed first and only the folder afterwards.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+			folderId := createFolder(t, "favourites", token)
+
+			tests.AssertStatusCode(t, http.StatusOK, sendRequest(t, http.MethodPut, "/file/"+fileId+"/star", token).Code)
+			tests.AssertStatusCode(t, http.StatusOK, sendRequest(t, http.MethodPut, "/folder/"+folderId+"/star", token).Code)
+			tests.AssertStatusCode(t, http.StatusOK, sendRequest(t, http.MethodPut, "/file/"+fileId+"/star", token).Code)
+
+			response := sendRequest(t, http.MethodGet, "/users/me/starred", token)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			starred := data["starred"].([]interface{})
+			if len(starred) != 2 || data["total"] != float64(2) {
+				t.Fatalf("expected 2 starred items, got %v", data)
+			}
+
+			response = sendRequest(t, http.MethodGet, "/users/me/starred?page=3&rows=1", token)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			data = tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			if len(data["starred"].([]interface{})) != 0 || data["total"] != float64(2) {
+				t.Errorf("expected an empty page past the end with a total of 2, got %v", data)
+			}
+
+			tests.AssertStatusCode(t, http.StatusOK, sendRequest(t, http.MethodDelete, "/file/"+fileId+"/star", token).Code)
+
+			response = sendRequest(t, http.MethodGet, "/users/me/starred", token)
+			starred = tests.ParseResponse(t, response)["data"].(map[string]interface{})["starred"].([]interface{})
+			if len(starred) != 1 {
+				t.Fatalf("expected 1 starred item, got %d", len(starred))
+			}
+			item := starred[0].(map[string]interface{})
+			if item["type"] != "folder" || item["id"] != folderId {
+				t.Errorf("expected folder %s to stay starred, got %v", folderId, item)
+			}
+		},
+	)
+
+	t.Run(`Given a user has uploaded a file,
+      When they download it,
+      Then it should be listed first in their recent files as downloaded.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+
+			response := sendRequest(t, http.MethodGet, "/users/me/recent", token)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			recent := tests.ParseResponse(t, response)["data"].(map[string]interface{})["recent"].([]interface{})
+			if len(recent) != 1 || recent[0].(map[string]interface{})["activity"] != "uploaded" {
+				t.Fatalf("expected the upload to be the only recent file, got %v", recent)
+			}
+
+			_ = uploadFile(t, int64(1024), "someFile", "", token)
+			tests.AssertStatusCode(t, http.StatusOK, sendRequest(t, http.MethodGet, "/file/"+fileId, token).Code)
+
+			response = sendRequest(t, http.MethodGet, "/users/me/recent", token)
+			recent = tests.ParseResponse(t, response)["data"].(map[string]interface{})["recent"].([]interface{})
+			if len(recent) != 2 {
+				t.Fatalf("expected 2 recent files, got %d", len(recent))
+			}
+			item := recent[0].(map[string]interface{})
+			if item["id"] != fileId || item["activity"] != "downloaded" {
+				t.Errorf("expected file %s to be the latest download, got %v", fileId, item)
+			}
+		},
+	)
+
+	t.Run(`Given a file belongs to another user,
+      When a user tries to star it,
+      Then it should fail with 403.
+      `,
+		func(t *testing.T) {
+			ownerToken := logUserIn(t, userEmail, userPassword)
+			fileId := uploadFile(t, int64(1024), "someFile", "", ownerToken)
+
+			response := sendRequest(t, http.MethodPut, "/file/"+fileId+"/star", newUser(t))
+			tests.AssertStatusCode(t, http.StatusForbidden, response.Code)
+			tests.AssertResponseMessage(t, tests.ParseResponse(t, response)["message"].(string), "unauthorized to star this file")
+		},
+	)
+}
+
+func TestWebDAV(t *testing.T) {
+	email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+	password := "some-random-password"
+	_ = createUser(t, "mike", "smith", email, password)
+
+	sendRequest := func(t testing.TB, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.SetBasicAuth(email, password)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		return tests.ExecuteRequest(req, svr)
+	}
+
+	t.Run(`Given a user sends wrong Basic credentials,
+      When they access the WebDAV tree,
+      Then it should fail with 401 and a Basic challenge.
+      `,
+		func(t *testing.T) {
+			req, _ := http.NewRequest("PROPFIND", "/webdav/", nil)
+			req.SetBasicAuth(email, "wrong-password")
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusUnauthorized, response.Code)
+			if !strings.HasPrefix(response.Header().Get("WWW-Authenticate"), "Basic ") {
+				t.Errorf("expected a Basic challenge, got %q", response.Header().Get("WWW-Authenticate"))
+			}
+		},
+	)
+
+	t.Run(`Given a user has sent too many wrong Basic passwords,
+      When they access the WebDAV tree again,
+      Then it should fail with 429 even with the right password.
+      `,
+		func(t *testing.T) {
+			lockedEmail := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			_ = createUser(t, "mike", "smith", lockedEmail, password)
+
+			for i := 0; i < 10; i++ {
+				req, _ := http.NewRequest("PROPFIND", "/webdav/", nil)
+				req.SetBasicAuth(lockedEmail, "wrong-password")
+				tests.AssertStatusCode(t, http.StatusUnauthorized, tests.ExecuteRequest(req, svr).Code)
+			}
+
+			req, _ := http.NewRequest("PROPFIND", "/webdav/", nil)
+			req.SetBasicAuth(lockedEmail, password)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusTooManyRequests, response.Code)
+			if response.Header().Get("Retry-After") == "" {
+				t.Errorf("expected a Retry-After header")
+			}
+		},
+	)
+
+	t.Run(`Given a user has mounted their files over WebDAV,
+      When they make a folder, upload a file into it and read it back,
+      Then the file should be listed and have the uploaded content.
+      `,
+		func(t *testing.T) {
+			tests.AssertStatusCode(t, http.StatusCreated, sendRequest(t, "MKCOL", "/webdav/notes", "", nil).Code)
+			tests.AssertStatusCode(t, http.StatusCreated, sendRequest(t, http.MethodPut, "/webdav/notes/todo.txt", "buy milk", nil).Code)
+
+			response := sendRequest(t, "PROPFIND", "/webdav/notes/", "", map[string]string{"Depth": "1"})
+			tests.AssertStatusCode(t, http.StatusMultiStatus, response.Code)
+			if !strings.Contains(response.Body.String(), "<D:href>/webdav/notes/todo.txt</D:href>") {
+				t.Errorf("expected todo.txt to be listed, got %s", response.Body.String())
+			}
+
+			response = sendRequest(t, http.MethodGet, "/webdav/notes/todo.txt", "", nil)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			if response.Body.String() != "buy milk" {
+				t.Errorf("expected %q, got %q", "buy milk", response.Body.String())
+			}
+
+			tests.AssertStatusCode(t, http.StatusCreated, sendRequest(t, http.MethodPut, "/webdav/notes/todo.txt", "buy bread", nil).Code)
+			response = sendRequest(t, http.MethodGet, "/webdav/notes/todo.txt", "", nil)
+			if response.Body.String() != "buy bread" {
+				t.Errorf("expected the file to be overwritten, got %q", response.Body.String())
+			}
+		},
+	)
+
+	t.Run(`Given a user has a folder with a file over WebDAV,
+      When they copy the file, move the folder and then delete it,
+      Then each change should show up in the tree.
+      `,
+		func(t *testing.T) {
+			tests.AssertStatusCode(t, http.StatusCreated, sendRequest(t, "MKCOL", "/webdav/drafts", "", nil).Code)
+			tests.AssertStatusCode(t, http.StatusCreated, sendRequest(t, http.MethodPut, "/webdav/drafts/post.md", "# hello", nil).Code)
+
+			response := sendRequest(t, "COPY", "/webdav/drafts/post.md", "", map[string]string{"Destination": "/webdav/post-copy.md"})
+			tests.AssertStatusCode(t, http.StatusCreated, response.Code)
+			response = sendRequest(t, http.MethodGet, "/webdav/post-copy.md", "", nil)
+			if response.Body.String() != "# hello" {
+				t.Errorf("expected the copy to have the original content, got %q", response.Body.String())
+			}
+
+			response = sendRequest(t, "MOVE", "/webdav/drafts", "", map[string]string{"Destination": "/webdav/published"})
+			tests.AssertStatusCode(t, http.StatusCreated, response.Code)
+			tests.AssertStatusCode(t, http.StatusNotFound, sendRequest(t, http.MethodGet, "/webdav/drafts/post.md", "", nil).Code)
+			tests.AssertStatusCode(t, http.StatusOK, sendRequest(t, http.MethodGet, "/webdav/published/post.md", "", nil).Code)
+
+			tests.AssertStatusCode(t, http.StatusNoContent, sendRequest(t, http.MethodDelete, "/webdav/published", "", nil).Code)
+			tests.AssertStatusCode(t, http.StatusNotFound, sendRequest(t, "PROPFIND", "/webdav/published", "", map[string]string{"Depth": "0"}).Code)
+		},
+	)
+
+	t.Run(`Given a user has two files over WebDAV,
+      When they move one onto the other, first without and then with Overwrite,
+      Then the move should be refused, and then replace the other file.
+      `,
+		func(t *testing.T) {
+			tests.AssertStatusCode(t, http.StatusCreated, sendRequest(t, http.MethodPut, "/webdav/old.txt", "old", nil).Code)
+			tests.AssertStatusCode(t, http.StatusCreated, sendRequest(t, http.MethodPut, "/webdav/new.txt", "new", nil).Code)
+
+			response := sendRequest(t, "MOVE", "/webdav/new.txt", "", map[string]string{"Destination": "/webdav/old.txt", "Overwrite": "F"})
+			tests.AssertStatusCode(t, http.StatusPreconditionFailed, response.Code)
+
+			response = sendRequest(t, "MOVE", "/webdav/new.txt", "", map[string]string{"Destination": "/webdav/old.txt", "Overwrite": "T"})
+			tests.AssertStatusCode(t, http.StatusNoContent, response.Code)
+			response = sendRequest(t, http.MethodGet, "/webdav/old.txt", "", nil)
+			if response.Body.String() != "new" {
+				t.Errorf("expected the moved file's content, got %q", response.Body.String())
+			}
+
+			response = sendRequest(t, "PROPFIND", "/webdav/", "", map[string]string{"Depth": "1"})
+			if count := strings.Count(response.Body.String(), "<D:href>/webdav/old.txt</D:href>"); count != 1 {
+				t.Errorf("expected old.txt to be listed once, got %d", count)
+			}
+		},
+	)
+
+	t.Run(`Given a user has two folders over WebDAV,
+      When they move each folder into the other at the same time,
+      Then at most one move should succeed and the tree should still be listed.
+      `,
+		func(t *testing.T) {
+			tests.AssertStatusCode(t, http.StatusCreated, sendRequest(t, "MKCOL", "/webdav/left", "", nil).Code)
+			tests.AssertStatusCode(t, http.StatusCreated, sendRequest(t, "MKCOL", "/webdav/right", "", nil).Code)
+
+			moves := [][2]string{
+				{"/webdav/left", "/webdav/right/left"},
+				{"/webdav/right", "/webdav/left/right"},
+			}
+			codes := make([]int, len(moves))
+			var wg sync.WaitGroup
+			for i, move := range moves {
+				wg.Add(1)
+				go func(i int, source, destination string) {
+					defer wg.Done()
+					codes[i] = sendRequest(t, "MOVE", source, "", map[string]string{"Destination": destination}).Code
+				}(i, move[0], move[1])
+			}
+			wg.Wait()
+
+			if codes[0] == http.StatusCreated && codes[1] == http.StatusCreated {
+				t.Fatalf("expected at most one move to succeed, got %v", codes)
+			}
+			response := sendRequest(t, "PROPFIND", "/webdav/", "", map[string]string{"Depth": "1"})
+			tests.AssertStatusCode(t, http.StatusMultiStatus, response.Code)
+		},
+	)
+
+	t.Run(`Given a user has logged in,
+      When they use their access token as the Basic password,
+      Then the WebDAV tree should be served.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, email, password)
+			req, _ := http.NewRequest("PROPFIND", "/webdav/", nil)
+			req.Header.Set("Depth", "0")
+			req.SetBasicAuth(email, token)
+			tests.AssertStatusCode(t, http.StatusMultiStatus, tests.ExecuteRequest(req, svr).Code)
+		},
+	)
+
+	t.Run(`Given a user has mounted their files over WebDAV,
+      When they upload a file without saying how large it is, or over the upload limit,
+      Then the upload should be rejected before it is read.
+      `,
+		func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPut, "/webdav/streamed.txt", strings.NewReader("buy milk"))
+			req.SetBasicAuth(email, password)
+			req.ContentLength = -1
+			tests.AssertStatusCode(t, http.StatusLengthRequired, tests.ExecuteRequest(req, svr).Code)
+
+			req, _ = http.NewRequest(http.MethodPut, "/webdav/huge.bin", strings.NewReader("buy milk"))
+			req.SetBasicAuth(email, password)
+			req.ContentLength = fileServices.MaxUploadSize + 1
+			tests.AssertStatusCode(t, http.StatusRequestEntityTooLarge, tests.ExecuteRequest(req, svr).Code)
+
+			response := sendRequest(t, "PROPFIND", "/webdav/", "", map[string]string{"Depth": "1"})
+			if strings.Contains(response.Body.String(), "streamed.txt") || strings.Contains(response.Body.String(), "huge.bin") {
+				t.Errorf("expected the rejected uploads not to be saved, got %s", response.Body.String())
+			}
+		},
+	)
+}
+
+func TestS3Gateway(t *testing.T) {
+	email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+	password := "some-random-password"
+	_ = createUser(t, "mike", "smith", email, password)
+	token := logUserIn(t, email, password)
+
+	sendRequest := func(t testing.TB, method, route, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(method, route, nil)
+		req.Header.Set("Authorization", token)
+		return tests.ExecuteRequest(req, svr)
+	}
+
+	response := sendRequest(t, http.MethodPost, "/users/me/access-keys", token)
+	tests.AssertStatusCode(t, http.StatusOK, response.Code)
+	accessKey := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+	accessKeyId := accessKey["access_key_id"].(string)
+	secretAccessKey := accessKey["secret_access_key"].(string)
+
+	sendS3Request := func(t testing.TB, method, route, body, secret string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(method, "http://file-fort.test"+route, nil)
+		signer := v4.NewSigner(credentials.NewStaticCredentials(accessKeyId, secret, ""))
+		signer.DisableURIPathEscaping = true
+		req.Header.Set("X-Amz-Content-Sha256", fmt.Sprintf("%x", sha256.Sum256([]byte(body))))
+		if _, err := signer.Sign(req, strings.NewReader(body), "s3", "us-east-1", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		svr.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run(`Given a user has created an access key,
+      When they list their access keys,
+      Then the key should be listed without its secret.
+      `,
+		func(t *testing.T) {
+			response := sendRequest(t, http.MethodGet, "/users/me/access-keys", token)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			accessKeys := tests.ParseResponse(t, response)["data"].(map[string]interface{})["access_keys"].([]interface{})
+			if len(accessKeys) != 1 {
+				t.Fatalf("expected 1 access key, got %d", len(accessKeys))
+			}
+			listed := accessKeys[0].(map[string]interface{})
+			if listed["access_key_id"] != accessKeyId {
+				t.Errorf("expected access key %s, got %v", accessKeyId, listed["access_key_id"])
+			}
+			if _, ok := listed["secret_access_key"]; ok {
+				t.Errorf("expected the secret not to be listed")
+			}
+		},
+	)
+
+	t.Run(`Given a request signed with an access key,
+      When objects are put, listed, read and deleted in the home bucket,
+      Then they should be stored as files in the user's folders.
+      `,
+		func(t *testing.T) {
+			response := sendS3Request(t, http.MethodGet, "/s3/", "", secretAccessKey)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			if !strings.Contains(response.Body.String(), "<Name>home</Name>") {
+				t.Errorf("expected the home bucket to be listed, got %s", response.Body.String())
+			}
+
+			tests.AssertStatusCode(t, http.StatusOK, sendS3Request(t, http.MethodPut, "/s3/home/notes/todo.txt", "buy milk", secretAccessKey).Code)
+			tests.AssertStatusCode(t, http.StatusOK, sendS3Request(t, http.MethodPut, "/s3/home/readme.txt", "hello", secretAccessKey).Code)
+
+			response = sendS3Request(t, http.MethodGet, "/s3/home?delimiter=%2F&list-type=2", "", secretAccessKey)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			for _, expected := range []string{"<Key>readme.txt</Key>", "<Prefix>notes/</Prefix>"} {
+				if !strings.Contains(response.Body.String(), expected) {
+					t.Errorf("expected %s in the listing, got %s", expected, response.Body.String())
+				}
+			}
+
+			response = sendS3Request(t, http.MethodGet, "/s3/home/notes/todo.txt", "", secretAccessKey)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			tests.AssertResponseMessage(t, response.Body.String(), "buy milk")
+
+			tests.AssertStatusCode(t, http.StatusNoContent, sendS3Request(t, http.MethodDelete, "/s3/home/notes/todo.txt", "", secretAccessKey).Code)
+			response = sendS3Request(t, http.MethodGet, "/s3/home/notes/todo.txt", "", secretAccessKey)
+			tests.AssertStatusCode(t, http.StatusNotFound, response.Code)
+			if !strings.Contains(response.Body.String(), "<Code>NoSuchKey</Code>") {
+				t.Errorf("expected NoSuchKey, got %s", response.Body.String())
+			}
+		},
+	)
+
+	t.Run(`Given a request signed with the wrong secret,
+      When it is sent to the gateway,
+      Then it should fail with SignatureDoesNotMatch.
+      `,
+		func(t *testing.T) {
+			response := sendS3Request(t, http.MethodGet, "/s3/", "", "wrong-secret")
+			tests.AssertStatusCode(t, http.StatusForbidden, response.Code)
+			if !strings.Contains(response.Body.String(), "<Code>SignatureDoesNotMatch</Code>") {
+				t.Errorf("expected SignatureDoesNotMatch, got %s", response.Body.String())
+			}
+		},
+	)
+
+	t.Run(`Given a user has deleted their access key,
+      When a request signed with it is sent,
+      Then it should fail with InvalidAccessKeyId.
+      `,
+		func(t *testing.T) {
+			tests.AssertStatusCode(t, http.StatusForbidden, sendRequest(t, http.MethodDelete, "/users/me/access-keys/"+accessKeyId, logUserIn(t, userEmail, userPassword)).Code)
+			tests.AssertStatusCode(t, http.StatusOK, sendRequest(t, http.MethodDelete, "/users/me/access-keys/"+accessKeyId, token).Code)
+
+			response := sendS3Request(t, http.MethodGet, "/s3/", "", secretAccessKey)
+			tests.AssertStatusCode(t, http.StatusForbidden, response.Code)
+			if !strings.Contains(response.Body.String(), "<Code>InvalidAccessKeyId</Code>") {
+				t.Errorf("expected InvalidAccessKeyId, got %s", response.Body.String())
+			}
+		},
+	)
+}
+
+func TestChanges(t *testing.T) {
+	sendRequest := func(t testing.TB, method, path, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return tests.ExecuteRequest(req, svr)
+	}
+	newUser := func(t testing.TB) string {
+		t.Helper()
+		email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+		password := "some-random-password"
+		_ = createUser(t, "mike", "smith", email, password)
+		return logUserIn(t, email, password)
+	}
+	getChanges := func(t *testing.T, cursor, token string) map[string]interface{} {
+		t.Helper()
+		response := sendRequest(t, http.MethodGet, "/changes?cursor="+cursor, token)
+		tests.AssertStatusCode(t, http.StatusOK, response.Code)
+		return tests.ParseResponse(t, response)["data"].(map[string]interface{})
+	}
+
+	t.Run(`Given a user uploads and then deletes a file,
+      When they read the change feed from the start and then from its cursor,
+      Then the changes should be listed in order and nothing should follow them.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+			tests.AssertStatusCode(t, http.StatusOK, sendRequest(t, http.MethodDelete, "/file/"+fileId, token).Code)
+
+			data := getChanges(t, "", token)
+			changes := data["changes"].([]interface{})
+			fileChanges := []string{}
+			for _, element := range changes {
+				change := element.(map[string]interface{})
+				if change["target_type"] == "file" {
+					if change["target_id"] != fileId {
+						t.Errorf("expected a change to file %s, got %v", fileId, change)
+					}
+					fileChanges = append(fileChanges, change["type"].(string))
+				}
+			}
+			if len(fileChanges) != 2 || fileChanges[0] != "created" || fileChanges[1] != "deleted" {
+				t.Fatalf("expected the file to be created and then deleted, got %v", fileChanges)
+			}
+			if data["has_more"] != false {
+				t.Errorf("expected no more changes, got %v", data["has_more"])
+			}
+
+			cursor := data["cursor"].(string)
+			data = getChanges(t, cursor, token)
+			if len(data["changes"].([]interface{})) != 0 || data["cursor"] != cursor {
+				t.Errorf("expected no changes after cursor %s, got %v", cursor, data)
+			}
+		},
+	)
+
+	t.Run(`Given a user asks for changes,
+      When the cursor is not one the server issued,
+      Then it should fail with 400.
+      `,
+		func(t *testing.T) {
+			response := sendRequest(t, http.MethodGet, "/changes?cursor=not-a-cursor", newUser(t))
+			tests.AssertStatusCode(t, http.StatusBadRequest, response.Code)
+			tests.AssertResponseMessage(t, tests.ParseResponse(t, response)["message"].(string), "invalid cursor")
+		},
+	)
+
+	t.Run(`Given a user has read every change,
+      When they long-poll for more and none are made,
+      Then the request should be held for the wait and return no changes.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			_ = uploadFile(t, int64(1024), "someFile", "", token)
+			cursor := getChanges(t, "", token)["cursor"].(string)
+
+			start := time.Now()
+			response := sendRequest(t, http.MethodGet, "/changes?wait=1&cursor="+cursor, token)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			if elapsed := time.Since(start); elapsed < time.Second {
+				t.Errorf("expected the request to wait a second, it returned after %v", elapsed)
+			}
+			data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+			if len(data["changes"].([]interface{})) != 0 || data["cursor"] != cursor {
+				t.Errorf("expected no changes after cursor %s, got %v", cursor, data)
+			}
+		},
+	)
+
+	t.Run(`Given a user has changes they have not read,
+      When they long-poll for changes,
+      Then the changes should be returned without waiting.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			_ = uploadFile(t, int64(1024), "someFile", "", token)
+
+			start := time.Now()
+			response := sendRequest(t, http.MethodGet, "/changes?wait=30", token)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("expected the changes to be returned at once, it took %v", elapsed)
+			}
+			if changes := tests.ParseResponse(t, response)["data"].(map[string]interface{})["changes"].([]interface{}); len(changes) == 0 {
+				t.Errorf("expected changes to be returned")
+			}
+		},
+	)
+
+	t.Run(`Given a user long-polls for changes,
+      When the wait is not a number of seconds,
+      Then it should fail with 400.
+      `,
+		func(t *testing.T) {
+			response := sendRequest(t, http.MethodGet, "/changes?wait=soon", newUser(t))
+			tests.AssertStatusCode(t, http.StatusBadRequest, response.Code)
+			tests.AssertResponseMessage(t, tests.ParseResponse(t, response)["message"].(string), "wait must be a number")
+		},
+	)
+
+	t.Run(`Given a user has files in their home folder and in a nested folder,
+      When they list the tree of their home folder,
+      Then every file should be listed with its path and checksum.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			folderId := createFolder(t, "docs", token)
+			requestBody := []byte(fmt.Sprintf(`{"folder_name": "sub", "parent_id": "%s"}`, folderId))
+			req, _ := http.NewRequest(http.MethodPost, "/folder", bytes.NewBuffer(requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			subFolderId := tests.ParseResponse(t, response)["data"].(map[string]interface{})["id"].(string)
+
+			_ = uploadFile(t, int64(1024), "top.png", "", token)
+			_ = uploadFile(t, int64(1024), "nested.png", subFolderId, token)
+
+			userId := tests.ParseResponse(t, sendRequest(t, http.MethodGet, "/users/me", token))["data"].(map[string]interface{})["id"].(string)
+			data := getChanges(t, "", token)
+			response = sendRequest(t, http.MethodGet, "/folder/"+userId+"/tree", token)
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+			tree := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+
+			paths := map[string]bool{}
+			for _, element := range tree["folders"].([]interface{}) {
+				paths[element.(map[string]interface{})["path"].(string)] = true
+			}
+			for _, element := range tree["files"].([]interface{}) {
+				file := element.(map[string]interface{})
+				paths[file["path"].(string)] = true
+				if len(file["checksum"].(string)) != 64 {
+					t.Errorf("expected a sha256 checksum, got %q", file["checksum"])
+				}
+			}
+			for _, expected := range []string{"docs", "docs/sub", "top.png", "docs/sub/nested.png"} {
+				if !paths[expected] {
+					t.Errorf("expected %s in the tree, got %v", expected, paths)
+				}
+			}
+			if tree["cursor"] != data["cursor"] {
+				t.Errorf("expected the tree to be listed at cursor %v, got %v", data["cursor"], tree["cursor"])
+			}
+		},
+	)
+
+	t.Run(`Given a folder belongs to another user,
+      When a user lists its tree,
+      Then it should fail with 403.
+      `,
+		func(t *testing.T) {
+			folderId := createFolder(t, "private", newUser(t))
+
+			response := sendRequest(t, http.MethodGet, "/folder/"+folderId+"/tree", newUser(t))
+			tests.AssertStatusCode(t, http.StatusForbidden, response.Code)
+			tests.AssertResponseMessage(t, tests.ParseResponse(t, response)["message"].(string), "unauthorized to view this folder")
+		},
+	)
+}
+
+func TestEvents(t *testing.T) {
+	eventServer := httptest.NewServer(svr.Router)
+	defer eventServer.Close()
+
+	email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+	password := "some-random-password"
+	_ = createUser(t, "mike", "smith", email, password)
+	token := logUserIn(t, email, password)
+
+	t.Run(`Given a user is listening for events,
+      When they upload a file,
+      Then the upload should be sent to them as a server-sent event.
+      `,
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, eventServer.URL+"/events", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			stream, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("error opening the event stream: %v", err)
+			}
+			defer stream.Body.Close()
+			tests.AssertStatusCode(t, http.StatusOK, stream.StatusCode)
+			if contentType := stream.Header.Get("Content-Type"); contentType != "text/event-stream" {
+				t.Fatalf("expected an event stream, got %q", contentType)
+			}
+
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+			if err := outboxRelay.RelayPending(context.Background()); err != nil {
+				t.Fatalf("error relaying events: %v", err)
+			}
+
+			scanner := bufio.NewScanner(stream.Body)
+			eventType := ""
+			for scanner.Scan() {
+				line := scanner.Text()
+				if value, ok := strings.CutPrefix(line, "event: "); ok {
+					eventType = value
+				}
+				data, ok := strings.CutPrefix(line, "data: ")
+				if !ok || eventType != string(domain.EventFileUploaded) {
+					continue
+				}
+				var event map[string]interface{}
+				if err := json.Unmarshal([]byte(data), &event); err != nil {
+					t.Fatalf("error decoding event: %v", err)
+				}
+				if event["data"].(map[string]interface{})["id"] != fileId {
+					continue
+				}
+				return
+			}
+			t.Fatalf("expected an event for the upload of file %s, stream ended with %v", fileId, scanner.Err())
+		},
+	)
+
+	t.Run(`Given a user is not logged in,
+      When they listen for events,
+      Then it should fail with 401.
+      `,
+		func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/events", nil)
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusUnauthorized, response.Code)
+		},
+	)
+}
+
+func TestGraphQL(t *testing.T) {
+	newUser := func(t testing.TB) string {
+		t.Helper()
+		email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+		password := "some-random-password"
+		_ = createUser(t, "mike", "smith", email, password)
+		return logUserIn(t, email, password)
+	}
+	sendQuery := func(t testing.TB, query string, variables map[string]interface{}, token string) map[string]interface{} {
+		t.Helper()
+		requestBody, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+		req, _ := http.NewRequest(http.MethodPost, "/graphql", bytes.NewBuffer(requestBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		response := tests.ExecuteRequest(req, svr)
+		if response.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", response.Code, response.Body.String())
+		}
+		var result map[string]interface{}
+		if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
+			t.Fatalf("error decoding response: %v", err)
+		}
+		return result
+	}
+
+	t.Run(`Given a user has a folder with a file in it,
+      When they query their home folder with its folders and files,
+      Then the nested folder and file should be returned.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			folderId := createFolder(t, "docs", token)
+			fileId := uploadFile(t, int64(1024), "someFile", folderId, token)
+
+			result := sendQuery(t, `{
+        me {
+          home {
+            folders { id name parent { name } files { id name folder { id } } }
+          }
+        }
+      }`, nil, token)
+			if result["errors"] != nil {
+				t.Fatalf("expected no errors, got %v", result["errors"])
+			}
+			home := result["data"].(map[string]interface{})["me"].(map[string]interface{})["home"].(map[string]interface{})
+			folders := home["folders"].([]interface{})
+			if len(folders) != 1 {
+				t.Fatalf("expected 1 folder in home, got %v", folders)
+			}
+			folder := folders[0].(map[string]interface{})
+			if folder["id"] != folderId || folder["name"] != "docs" {
+				t.Errorf("expected folder docs, got %v", folder)
+			}
+			folderFiles := folder["files"].([]interface{})
+			if len(folderFiles) != 1 || folderFiles[0].(map[string]interface{})["id"] != fileId {
+				t.Fatalf("expected file %s in docs, got %v", fileId, folderFiles)
+			}
+			if folderFiles[0].(map[string]interface{})["folder"].(map[string]interface{})["id"] != folderId {
+				t.Errorf("expected the file to be in folder %s", folderId)
+			}
+		},
+	)
+
+	t.Run(`Given a user has a folder,
+      When they create a folder inside it and move a file into the new folder,
+      Then the file should be listed in the new folder.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			parentId := createFolder(t, "parent", token)
+			fileId := uploadFile(t, int64(1024), "someFile", "", token)
+
+			result := sendQuery(t, `mutation($parentId: ID) {
+        createFolder(name: "child", parentId: $parentId) { id parent { id } }
+      }`, map[string]interface{}{"parentId": parentId}, token)
+			if result["errors"] != nil {
+				t.Fatalf("expected no errors, got %v", result["errors"])
+			}
+			created := result["data"].(map[string]interface{})["createFolder"].(map[string]interface{})
+			if created["parent"].(map[string]interface{})["id"] != parentId {
+				t.Errorf("expected the folder to be created in %s, got %v", parentId, created)
+			}
+			childId := created["id"].(string)
+
+			result = sendQuery(t, `mutation($id: ID!, $folderId: ID) {
+        moveFile(id: $id, folderId: $folderId, name: "moved") { name folder { id } }
+      }`, map[string]interface{}{"id": fileId, "folderId": childId}, token)
+			if result["errors"] != nil {
+				t.Fatalf("expected no errors, got %v", result["errors"])
+			}
+			moved := result["data"].(map[string]interface{})["moveFile"].(map[string]interface{})
+			if moved["name"] != "moved" || moved["folder"].(map[string]interface{})["id"] != childId {
+				t.Errorf("expected the file to be moved into %s, got %v", childId, moved)
+			}
+		},
+	)
+
+	t.Run(`Given a user has two files in a folder,
+      When they move one file onto the other's name,
+      Then a name conflict error should be returned.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			folderId := createFolder(t, "docs", token)
+			_ = uploadFile(t, int64(1024), "first", folderId, token)
+			secondId := uploadFile(t, int64(1024), "second", folderId, token)
+
+			result := sendQuery(t, `mutation($id: ID!, $folderId: ID) {
+        moveFile(id: $id, folderId: $folderId, name: "first") { name }
+      }`, map[string]interface{}{"id": secondId, "folderId": folderId}, token)
+			errs, ok := result["errors"].([]interface{})
+			if !ok || len(errs) != 1 {
+				t.Fatalf("expected an error, got %v", result)
+			}
+			if message := errs[0].(map[string]interface{})["message"]; message != fileServices.ErrFileNameTaken.Error() {
+				t.Errorf("got error %v", message)
+			}
+		},
+	)
+
+	t.Run(`Given a folder belongs to another user,
+      When a user queries it,
+      Then an unauthorized error should be returned.
+      `,
+		func(t *testing.T) {
+			folderId := createFolder(t, "private", newUser(t))
+
+			result := sendQuery(t, `query($id: ID!) { folder(id: $id) { name } }`, map[string]interface{}{"id": folderId}, newUser(t))
+			errs, ok := result["errors"].([]interface{})
+			if !ok || len(errs) != 1 {
+				t.Fatalf("expected an error, got %v", result)
+			}
+			if message := errs[0].(map[string]interface{})["message"]; message != "unauthorized to access this file or folder" {
+				t.Errorf("got error %v", message)
+			}
+		},
+	)
+
+	t.Run(`Given a user has several folders,
+      When they query a page of their home folder's folders,
+      Then only the folders on that page should be returned, ordered by name.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			for _, name := range []string{"c", "a", "b"} {
+				_ = createFolder(t, name, token)
+			}
+
+			result := sendQuery(t, `{ me { home { folders(page: 2, rows: 2) { name } } } }`, nil, token)
+			if result["errors"] != nil {
+				t.Fatalf("expected no errors, got %v", result["errors"])
+			}
+			home := result["data"].(map[string]interface{})["me"].(map[string]interface{})["home"].(map[string]interface{})
+			folders := home["folders"].([]interface{})
+			if len(folders) != 1 || folders[0].(map[string]interface{})["name"] != "c" {
+				t.Errorf("expected only folder c on page 2, got %v", folders)
+			}
+		},
+	)
+
+	t.Run(`Given a user is logged in,
+      When they ask for more rows than a page allows,
+      Then the query should fail.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+
+			result := sendQuery(t, `{ me { home { files(rows: 101) { id } } } }`, nil, token)
+			if result["errors"] == nil {
+				t.Errorf("expected an error for too many rows, got %v", result["data"])
+			}
+		},
+	)
+
+	t.Run(`Given a user is not logged in,
+      When they send a query,
+      Then it should fail with 401.
+      `,
+		func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/graphql", bytes.NewBufferString(`{"query": "{ me { id } }"}`))
+			req.Header.Set("Content-Type", "application/json")
+			response := tests.ExecuteRequest(req, svr)
+			tests.AssertStatusCode(t, http.StatusUnauthorized, response.Code)
+		},
+	)
+}
+
+func TestGRPC(t *testing.T) {
+	newUser := func(t testing.TB) string {
+		t.Helper()
+		email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+		password := "some-random-password"
+		_ = createUser(t, "mike", "smith", email, password)
+		return logUserIn(t, email, password)
+	}
+	listener := bufconn.Listen(1024 * 1024)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("error dialing the gRPC server: %v", err)
+	}
+	defer conn.Close()
+	userClient := filefortv1.NewUserServiceClient(conn)
+	folderClient := filefortv1.NewFolderServiceClient(conn)
+	fileClient := filefortv1.NewFileServiceClient(conn)
+
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+	assertCode := func(t testing.TB, expected codes.Code, err error) {
+		t.Helper()
+		if status.Code(err) != expected {
+			t.Fatalf("expected code %s, got %v", expected, err)
+		}
+	}
+	upload := func(t testing.TB, ctx context.Context, info *filefortv1.UploadFileInfo, content []byte) (*filefortv1.File, error) {
+		t.Helper()
+		stream, err := fileClient.UploadFile(ctx)
+		if err != nil {
+			t.Fatalf("error opening upload stream: %v", err)
+		}
+		err = stream.Send(&filefortv1.UploadFileRequest{Data: &filefortv1.UploadFileRequest_Info{Info: info}})
+		if err != nil {
+			t.Fatalf("error sending file info: %v", err)
+		}
+		for len(content) > 0 {
+			n := 10000
+			if n > len(content) {
+				n = len(content)
+			}
+			err = stream.Send(&filefortv1.UploadFileRequest{Data: &filefortv1.UploadFileRequest_Chunk{Chunk: content[:n]}})
+			if err != nil {
+				break
+			}
+			content = content[n:]
+		}
+		return stream.CloseAndRecv()
+	}
+
+	t.Run(`Given a user is not logged in,
+      When they call a method other than CreateUser or Login,
+      Then the call should fail as unauthenticated.
+      `,
+		func(t *testing.T) {
+			_, err := userClient.GetLoggedInUser(context.Background(), &filefortv1.GetLoggedInUserRequest{})
+			assertCode(t, codes.Unauthenticated, err)
+
+			_, err = userClient.GetLoggedInUser(withToken("not-a-token"), &filefortv1.GetLoggedInUserRequest{})
+			assertCode(t, codes.Unauthenticated, err)
+		},
+	)
+
+	t.Run(`Given a user is registered over gRPC,
+      When they log in and get the logged in user,
+      Then they should be returned.
+      `,
+		func(t *testing.T) {
+			email := "mikesmith" + fmt.Sprint(tests.GenerateUniqueId()) + "@gmail.com"
+			created, err := userClient.CreateUser(context.Background(), &filefortv1.CreateUserRequest{
+				Email: email, FirstName: "mike", LastName: "smith", Password: "some-random-password",
+			})
+			if err != nil {
+				t.Fatalf("error creating user: %v", err)
+			}
+			login, err := userClient.Login(context.Background(), &filefortv1.LoginRequest{Email: email, Password: "some-random-password"})
+			if err != nil {
+				t.Fatalf("error logging in: %v", err)
+			}
+
+			user, err := userClient.GetLoggedInUser(withToken(login.AccessToken), &filefortv1.GetLoggedInUserRequest{})
+			if err != nil {
+				t.Fatalf("error getting the logged in user: %v", err)
+			}
+			if user.Id != created.Id || user.Email != email {
+				t.Errorf("expected user %s, got %v", created.Id, user)
+			}
+
+			_, err = userClient.Login(context.Background(), &filefortv1.LoginRequest{Email: email, Password: "wrong-password"})
+			assertCode(t, codes.Unauthenticated, err)
+		},
+	)
+
+	t.Run(`Given a user has a folder,
+      When they upload a file into it over a stream and download it,
+      Then the downloaded content should match the uploaded content.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			ctx := withToken(token)
+			folder, err := folderClient.CreateFolder(ctx, &filefortv1.CreateFolderRequest{Name: "docs"})
+			if err != nil {
+				t.Fatalf("error creating folder: %v", err)
+			}
+			content := bytes.Repeat([]byte("file-fort "), 20000)
+
+			uploaded, err := upload(t, ctx, &filefortv1.UploadFileInfo{Name: "notes.txt", FolderId: folder.Id, Size: int64(len(content))}, content)
+			if err != nil {
+				t.Fatalf("error uploading file: %v", err)
+			}
+			if uploaded.FolderId != folder.Id || uploaded.Size != int64(len(content)) {
+				t.Errorf("expected a file of %d bytes in %s, got %v", len(content), folder.Id, uploaded)
+			}
+			checksum := sha256.Sum256(content)
+			if uploaded.Checksum != fmt.Sprintf("%x", checksum) {
+				t.Errorf("expected checksum %x, got %s", checksum, uploaded.Checksum)
+			}
+
+			stream, err := fileClient.DownloadFile(ctx, &filefortv1.DownloadFileRequest{Id: uploaded.Id})
+			if err != nil {
+				t.Fatalf("error opening download stream: %v", err)
+			}
+			first, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("error receiving file info: %v", err)
+			}
+			if first.GetInfo().GetId() != uploaded.Id {
+				t.Fatalf("expected the info of file %s first, got %v", uploaded.Id, first)
+			}
+			var downloaded []byte
+			for {
+				response, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("error receiving file content: %v", err)
+				}
+				downloaded = append(downloaded, response.GetChunk()...)
+			}
+			if !bytes.Equal(downloaded, content) {
+				t.Errorf("expected %d downloaded bytes to match the upload, got %d", len(content), len(downloaded))
+			}
+
+			contents, err := folderClient.ListFolderContents(ctx, &filefortv1.ListFolderContentsRequest{Id: folder.Id})
+			if err != nil {
+				t.Fatalf("error listing folder: %v", err)
+			}
+			if len(contents.Files) != 1 || contents.Files[0].Id != uploaded.Id {
+				t.Errorf("expected file %s in the folder, got %v", uploaded.Id, contents.Files)
+			}
+		},
+	)
+
+	t.Run(`Given a user uploads a file over a stream,
+      When the declared size is over the upload limit,
+      Then the upload should be rejected before any content is read.
+      `,
+		func(t *testing.T) {
+			ctx := withToken(newUser(t))
+			_, err := upload(t, ctx, &filefortv1.UploadFileInfo{Name: "huge.bin", Size: fileServices.MaxUploadSize + 1}, []byte("x"))
+			assertCode(t, codes.InvalidArgument, err)
+		},
+	)
+
+	t.Run(`Given a user uploads a file over a stream,
+      When the stream holds fewer bytes than the declared size,
+      Then the upload should be rejected and nothing stored.
+      `,
+		func(t *testing.T) {
+			token := newUser(t)
+			ctx := withToken(token)
+
+			_, err := upload(t, ctx, &filefortv1.UploadFileInfo{Name: "short.txt", Size: 1000}, []byte("too short"))
+			assertCode(t, codes.InvalidArgument, err)
+
+			home, err := folderClient.GetHomeFolder(ctx, &filefortv1.GetHomeFolderRequest{})
+			if err != nil {
+				t.Fatalf("error getting home folder: %v", err)
+			}
+			contents, err := folderClient.ListFolderContents(ctx, &filefortv1.ListFolderContentsRequest{Id: home.Id})
+			if err != nil {
+				t.Fatalf("error listing home folder: %v", err)
+			}
+			if len(contents.Files) != 0 {
+				t.Errorf("expected no files in home, got %v", contents.Files)
+			}
+		},
+	)
+
+	t.Run(`Given a user has a file,
+      When another user gets, moves or downloads it,
+      Then the call should be denied.
+      `,
+		func(t *testing.T) {
+			ownerToken := newUser(t)
+			fileId := uploadFile(t, int64(1024), "someFile", "", ownerToken)
+			ctx := withToken(newUser(t))
+
+			_, err := fileClient.GetFile(ctx, &filefortv1.GetFileRequest{Id: fileId})
+			assertCode(t, codes.PermissionDenied, err)
+
+			name := "stolen"
+			_, err = fileClient.MoveFile(ctx, &filefortv1.MoveFileRequest{Id: fileId, Name: &name})
+			assertCode(t, codes.PermissionDenied, err)
+
+			stream, err := fileClient.DownloadFile(ctx, &filefortv1.DownloadFileRequest{Id: fileId})
+			if err != nil {
+				t.Fatalf("error opening download stream: %v", err)
+			}
+			_, err = stream.Recv()
+			assertCode(t, codes.PermissionDenied, err)
+
+			_, err = fileClient.GetFile(ctx, &filefortv1.GetFileRequest{Id: "not-an-id"})
+			assertCode(t, codes.InvalidArgument, err)
+		},
+	)
+}
+
+func TestOpenAPI(t *testing.T) {
+	sendRequest := func(t testing.TB, method, path, body, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return tests.ExecuteRequest(req, svr)
+	}
+	fieldErrors := func(t testing.TB, response *httptest.ResponseRecorder) []interface{} {
+		t.Helper()
+		errs, ok := tests.ParseResponse(t, response)["errors"].([]interface{})
+		if !ok || len(errs) == 0 {
+			t.Fatalf("expected field errors, got %s", response.Body.String())
+		}
+		return errs
+	}
+
+	t.Run(`Given the API is running,
+      When anyone requests the spec,
+      Then every route of the JSON API should be documented in it.
+      `,
+		func(t *testing.T) {
+			response := sendRequest(t, http.MethodGet, "/openapi.json", "", "")
+			tests.AssertStatusCode(t, http.StatusOK, response.Code)
+
+			var spec struct {
+				OpenAPI string                            `json:"openapi"`
+				Paths   map[string]map[string]interface{} `json:"paths"`
+			}
+			if err := json.Unmarshal(response.Body.Bytes(), &spec); err != nil {
+				t.Fatalf("error decoding the spec: %v", err)
+			}
+			tests.AssertResponseMessage(t, spec.OpenAPI, "3.0.3")
+
+			err := chi.Walk(svr.Router.(chi.Routes), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+				if strings.HasPrefix(route, webdavHandlers.Prefix) || strings.HasPrefix(route, s3Handlers.Prefix) {
+					return nil
+				}
+				if _, ok := spec.Paths[route][strings.ToLower(method)]; !ok {
+					t.Errorf("expected %s %s to be documented", method, route)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("error walking the routes: %v", err)
+			}
+		},
+	)
+
+	t.Run(`Given an authenticated user,
+      When they create a folder without a folder_name,
+      Then it should fail with 400 and an error for the field.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			response := sendRequest(t, http.MethodPost, "/folder", `{"parent_id": "not-a-uuid"}`, token)
+			tests.AssertStatusCode(t, http.StatusBadRequest, response.Code)
+
+			messages := map[string]string{}
+			for _, e := range fieldErrors(t, response) {
+				fieldError := e.(map[string]interface{})
+				tests.AssertResponseMessage(t, fieldError["in"].(string), "body")
+				messages[fieldError["field"].(string)] = fieldError["message"].(string)
+			}
+			tests.AssertResponseMessage(t, messages["folder_name"], "folder_name required")
+			tests.AssertResponseMessage(t, messages["parent_id"], "parent_id is not in its proper form")
+		},
+	)
+
+	t.Run(`Given an authenticated user,
+      When they list their recent files with a page that is not a number,
+      Then it should fail with 400 and an error for the query parameter.
+      `,
+		func(t *testing.T) {
+			token := logUserIn(t, userEmail, userPassword)
+			response := sendRequest(t, http.MethodGet, "/users/me/recent?page=abc", "", token)
+			tests.AssertStatusCode(t, http.StatusBadRequest, response.Code)
+
+			fieldError := fieldErrors(t, response)[0].(map[string]interface{})
+			tests.AssertResponseMessage(t, fieldError["field"].(string), "page")
+			tests.AssertResponseMessage(t, fieldError["in"].(string), "query")
+			tests.AssertResponseMessage(t, fieldError["message"].(string), "page must be a number")
+		},
+	)
+
+	t.Run(`Given a user who is not logged in,
+      When they send an invalid request to an authenticated route,
+      Then it should fail with 401 before the request is validated.
+      `,
+		func(t *testing.T) {
+			response := sendRequest(t, http.MethodPost, "/folder", `{}`, "")
+			tests.AssertStatusCode(t, http.StatusUnauthorized, response.Code)
+		},
+	)
+}
+
+func createFolder(t testing.TB, folderName, accessToken string) string {
+	t.Helper()
+	route := "/folder"
+
+	requestBody := []byte(fmt.Sprintf(`{
+      "folder_name": "%s"
+      }`, folderName))
+	req, _ := http.NewRequest(http.MethodPost, route, bytes.NewBuffer(requestBody))
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	response := tests.ExecuteRequest(req, svr)
+	responseBody := tests.ParseResponse(t, response)
+	data := responseBody["data"].(map[string]interface{})
+	folderId := data["id"].(string)
+	return folderId
+}
+
+func uploadFile(t testing.TB, fileSize int64, fileName, folderId, accessToken string) string {
+	t.Helper()
+	route := "/file"
+	fieldName := "file"
+
+	tempFile, fileCleanUp := openImageFile(t, fileName, fileSize)
+
+	defer fileCleanUp()
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
+
+	createFormFile(t, writer, tempFile, fieldName)
+	createFormField(t, writer, "folder_id", folderId)
+	writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, route, &requestBody)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	response := ExecuteRequestMultiPart(req, svr)
+	responseBody := tests.ParseResponse(t, response)
+	data := responseBody["data"].(map[string]interface{})
+	fileId := data["id"].(string)
+
+	return fileId
+}
+
+func createUser(t testing.TB, firstName, lastName, email, password string) string {
+	t.Helper()
+	route := "/users"
+	requestBody := []byte(fmt.Sprintf(`{
+      "first_name": "%s",
+      "last_name": "%s",
+      "email": "%s",
+      "password": "%s"
+      }`, firstName, lastName, email, password))
+	req, _ := http.NewRequest(http.MethodPost, route, bytes.NewBuffer(requestBody))
+	response := tests.ExecuteRequest(req, svr)
+	data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+	userId := data["id"].(string)
+
+	return userId
+}
+
+func getCurrentUser(t testing.TB, token string) map[string]interface{} {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, "/users/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	response := tests.ExecuteRequest(req, svr)
+	responseBody := tests.ParseResponse(t, response)
+	data := responseBody["data"].(map[string]interface{})
+	return data
+}
+
+func getFileDownloadUrl(t testing.TB, token, fileId string) (string, error) {
+	t.Helper()
+	route := "/file"
+	req, _ := http.NewRequest(http.MethodGet, route+"/"+fileId, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	response := tests.ExecuteRequest(req, svr)
+	responseBody := tests.ParseResponse(t, response)
+	message := responseBody["message"].(string)
+	if message == "file does not exist" {
+		return "", fmt.Errorf("file does not exist")
+	}
+	data := responseBody["data"].(map[string]interface{})
+
+	return data["download_url"].(string), nil
+}
+
+func logUserIn(t testing.TB, email, password string) string {
+	requestBody := []byte(fmt.Sprintf(`{
+      "email": "%s",
+      "password": "%s"
+      }`, email, password))
+	loginReq, _ := http.NewRequest(http.MethodPost, "/users/login", bytes.NewBuffer(requestBody))
+	loginResponse := tests.ExecuteRequest(loginReq, svr)
+	loginResponseBody := tests.ParseResponse(t, loginResponse)
+	loginData := loginResponseBody["data"].(map[string]interface{})
+	accessToken := loginData["access_token"]
 	token := accessToken.(string)
 	return token
 }
@@ -798,3 +3749,26 @@ func createS3BucketForTest(ctx context.Context, configurations *config.Configura
 
 	return nil
 }
+
+func uploadTextFile(t testing.TB, fileName, content, accessToken string) string {
+	t.Helper()
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
+
+	fileWriter, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatal("Error creating form file:", err)
+	}
+	if _, err := fileWriter.Write([]byte(content)); err != nil {
+		t.Fatal("Error writing file data to request:", err)
+	}
+	writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "/file", &requestBody)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	response := ExecuteRequestMultiPart(req, svr)
+	data := tests.ParseResponse(t, response)["data"].(map[string]interface{})
+	return data["id"].(string)
+}